@@ -0,0 +1,163 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// `POST /admin/api-keys` (an admin session, not an API key)
+func (c *Client) CreateAPIKey(ctx context.Context, key NewAPIKey) (*CreatedAPIKey, error) {
+	var created CreatedAPIKey
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/admin/api-keys", body: key}, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// `GET /admin/api-keys` (an admin session)
+func (c *Client) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	var keys []APIKey
+	err := c.do(ctx, request{method: http.MethodGet, path: "/admin/api-keys"}, &keys)
+	return keys, err
+}
+
+// `DELETE /admin/api-keys/{id}` (an admin session)
+func (c *Client) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: "/admin/api-keys/" + id.String()}, nil)
+}
+
+// `GET /admin/audit` (an admin session), most recent first
+func (c *Client) ListAuditEvents(ctx context.Context, filter AuditFilter) ([]AuditEvent, error) {
+	query := pageQuery(filter.Page)
+	if filter.ActorID != nil {
+		query.Set("actor_id", filter.ActorID.String())
+	}
+	for name, value := range map[string]string{
+		"action":      filter.Action,
+		"outcome":     string(filter.Outcome),
+		"target_type": filter.TargetType,
+		"target_id":   filter.TargetID,
+	} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	for name, value := range map[string]*time.Time{"since": filter.Since, "until": filter.Until} {
+		if value != nil {
+			query.Set(name, value.Format(time.RFC3339))
+		}
+	}
+
+	var events []AuditEvent
+	err := c.do(ctx, request{method: http.MethodGet, path: "/admin/audit", query: query}, &events)
+	return events, err
+}
+
+// `POST /admin/webhooks` (an admin session). the secret is only returned here
+func (c *Client) CreateWebhook(ctx context.Context, webhook NewWebhook) (*CreatedWebhook, error) {
+	var created CreatedWebhook
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/admin/webhooks", body: webhook}, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// `GET /admin/webhooks` (an admin session), most recent first
+func (c *Client) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	var webhooks []Webhook
+	err := c.do(ctx, request{method: http.MethodGet, path: "/admin/webhooks"}, &webhooks)
+	return webhooks, err
+}
+
+// `DELETE /admin/webhooks/{id}` (an admin session), with its deliveries
+func (c *Client) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: "/admin/webhooks/" + id.String()}, nil)
+}
+
+// `GET /admin/webhooks/{id}/deliveries` (an admin session), most recent first
+func (c *Client) ListWebhookDeliveries(ctx context.Context, id uuid.UUID, page Page) ([]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	err := c.do(ctx, request{method: http.MethodGet, path: "/admin/webhooks/" + id.String() + "/deliveries", query: pageQuery(page)}, &deliveries)
+	return deliveries, err
+}
+
+// `POST /admin/webhooks/{id}/deliveries/{deliveryId}/redeliver` (an admin
+// session): the event is queued again, as a new delivery
+func (c *Client) RedeliverWebhookDelivery(ctx context.Context, id, deliveryId uuid.UUID) (*WebhookDelivery, error) {
+	var redelivery WebhookDelivery
+	path := "/admin/webhooks/" + id.String() + "/deliveries/" + deliveryId.String() + "/redeliver"
+	if err := c.do(ctx, request{method: http.MethodPost, path: path}, &redelivery); err != nil {
+		return nil, err
+	}
+	return &redelivery, nil
+}
+
+// `POST /admin/import/movies` (movies:write). a failure half way is an
+// *ImportError with the report of what was inserted before it
+func (c *Client) ImportMovies(ctx context.Context, file io.Reader, format Format, dryRun bool) (*ImportReport, error) {
+	return c.importFile(ctx, "/admin/import/movies", file, format, dryRun)
+}
+
+// `POST /admin/import/reviews` (reviews:write)
+func (c *Client) ImportReviews(ctx context.Context, file io.Reader, format Format, dryRun bool) (*ImportReport, error) {
+	return c.importFile(ctx, "/admin/import/reviews", file, format, dryRun)
+}
+
+// `GET /admin/export/movies` (catalog:export): streams the file into w
+func (c *Client) ExportMovies(ctx context.Context, w io.Writer, format Format) error {
+	return c.exportFile(ctx, "/admin/export/movies", w, format)
+}
+
+// `GET /admin/export/reviews` (catalog:export): streams the file into w
+func (c *Client) ExportReviews(ctx context.Context, w io.Writer, format Format) error {
+	return c.exportFile(ctx, "/admin/export/reviews", w, format)
+}
+
+// `GET /openapi.json`: the OpenAPI document of the API
+func (c *Client) Spec(ctx context.Context) ([]byte, error) {
+	resp, err := c.send(ctx, request{method: http.MethodGet, path: "/openapi.json", anonymous: true})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return io.ReadAll(resp.Body)
+}
+
+// -------- helpers
+func (c *Client) importFile(ctx context.Context, path string, file io.Reader, format Format, dryRun bool) (*ImportReport, error) {
+	query := url.Values{"format": {string(format)}}
+	if dryRun {
+		query.Set("dry_run", "true")
+	}
+
+	var report ImportReport
+	err := c.do(ctx, request{method: http.MethodPost, path: path, query: query, stream: file, contentType: format.contentType()}, &report)
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (c *Client) exportFile(ctx context.Context, path string, w io.Writer, format Format) error {
+	resp, err := c.send(ctx, request{method: http.MethodGet, path: path, query: url.Values{"format": {string(format)}}})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+func (f Format) contentType() string {
+	if f == NDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv"
+}
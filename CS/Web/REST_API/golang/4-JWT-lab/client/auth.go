@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// `POST /auth/register`: the account, not logged in (call Login)
+func (c *Client) Register(ctx context.Context, username, password, email string) error {
+	return c.do(ctx, request{method: http.MethodPost, path: "/auth/register", body: credentials{username, password, email}, anonymous: true}, nil)
+}
+
+// `POST /auth/login`: the client keeps the tokens for the next calls.
+// with 2FA the session only has MFARequired + MFAToken: call LoginMFA
+func (c *Client) Login(ctx context.Context, username, password string) (*Session, error) {
+	return c.login(ctx, "/auth/login", credentials{Username: username, Password: password})
+}
+
+// `POST /auth/login/mfa`: second step of Login, with a TOTP or a recovery code
+func (c *Client) LoginMFA(ctx context.Context, mfaToken, code string) (*Session, error) {
+	return c.login(ctx, "/auth/login/mfa", mfaLogin{mfaToken, code})
+}
+
+// `POST /auth/refresh`: done automatically, for clients that want to force it
+func (c *Client) RefreshToken(ctx context.Context) error {
+	accessToken, _ := c.Tokens()
+	return c.refresh(ctx, accessToken)
+}
+
+// `POST /auth/logout`: revokes the refresh token and forgets the session
+// (the access token itself stays valid until it expires)
+func (c *Client) Logout(ctx context.Context) error {
+	_, refreshToken := c.Tokens()
+	if refreshToken == "" {
+		return nil
+	}
+
+	err := c.do(ctx, request{method: http.MethodPost, path: "/auth/logout", body: refreshBody{refreshToken}, anonymous: true}, nil)
+	c.SetTokens("", "")
+	return err
+}
+
+// `POST /auth/password/forgot`: same answer whether the email exists or not
+func (c *Client) ForgotPassword(ctx context.Context, email string) error {
+	body := struct {
+		Email string `json:"email"`
+	}{email}
+	return c.do(ctx, request{method: http.MethodPost, path: "/auth/password/forgot", body: body, anonymous: true}, nil)
+}
+
+// `POST /auth/password/reset`, token is the one of the emailed link
+func (c *Client) ResetPassword(ctx context.Context, token, password string) error {
+	body := struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}{token, password}
+	return c.do(ctx, request{method: http.MethodPost, path: "/auth/password/reset", body: body, anonymous: true}, nil)
+}
+
+// `POST /auth/2fa/enroll`: a new secret, enabled by ConfirmTOTP
+func (c *Client) EnrollTOTP(ctx context.Context) (*TOTPEnrollment, error) {
+	var enrollment TOTPEnrollment
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/auth/2fa/enroll"}, &enrollment); err != nil {
+		return nil, err
+	}
+	return &enrollment, nil
+}
+
+// `POST /auth/2fa/confirm`: the recovery codes, only shown once
+func (c *Client) ConfirmTOTP(ctx context.Context, code string) ([]string, error) {
+	var codes recoveryCodes
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/auth/2fa/confirm", body: totpCode{code}}, &codes); err != nil {
+		return nil, err
+	}
+	return codes.RecoveryCodes, nil
+}
+
+// `POST /auth/2fa/disable`
+func (c *Client) DisableTOTP(ctx context.Context, code string) error {
+	return c.do(ctx, request{method: http.MethodPost, path: "/auth/2fa/disable", body: totpCode{code}}, nil)
+}
+
+// -------- helpers
+func (c *Client) login(ctx context.Context, path string, body any) (*Session, error) {
+	var session Session
+	if err := c.do(ctx, request{method: http.MethodPost, path: path, body: body, anonymous: true}, &session); err != nil {
+		return nil, err
+	}
+
+	if !session.MFARequired {
+		c.SetTokens(session.AccessToken, session.RefreshToken)
+	}
+	return &session, nil
+}
@@ -0,0 +1,326 @@
+// Package client is a typed Go client for the movie API (see the OpenAPI
+// document at /openapi.json, every operation has a method here).
+//
+//	c := client.New("http://localhost:3000")
+//	if _, err := c.Login(ctx, "alice", "correct horse battery"); err != nil { ... }
+//	movie, err := c.CreateMovie(ctx, client.NewMovie{Title: "Heat"})
+//
+// after Login the client keeps the tokens: the access token is refreshed
+// (POST /auth/refresh) when it expires or when the API answers 401.
+// scripts use an API key instead: client.New(url, client.WithAPIKey(key)).
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	apiKey     string
+
+	// idempotent calls (GET, PUT, DELETE) are retried on network errors,
+	// 429 and 5xx, waiting baseDelay, 2*baseDelay, 4*baseDelay... (+ jitter)
+	maxRetries int
+	baseDelay  time.Duration
+
+	// the session, set by Login/LoginMFA/SetTokens. refreshMu makes
+	// concurrent calls wait for a single refresh instead of each spending
+	// the (single use) refresh token
+	mu           sync.Mutex
+	refreshMu    sync.Mutex
+	accessToken  string
+	refreshToken string
+}
+
+type Option func(*Client)
+
+// default value: a client with a 30s timeout
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.httpClient = httpClient }
+}
+
+// authenticates every call with X-API-Key instead of a session
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = key }
+}
+
+// default value: 3 retries from 200ms. 0 disables the retries
+func WithRetries(maxRetries int, baseDelay time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.baseDelay = baseDelay
+	}
+}
+
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		maxRetries: 3,
+		baseDelay:  200 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// SetTokens restores a session (e.g. tokens saved by another process)
+func (c *Client) SetTokens(accessToken, refreshToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.accessToken = accessToken
+	c.refreshToken = refreshToken
+}
+
+// Tokens is the current session, empty strings when logged out
+func (c *Client) Tokens() (accessToken, refreshToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.accessToken, c.refreshToken
+}
+
+// -------- requests
+
+// a call to the API. body is JSON (replayable) unless stream is set
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	body        any
+	stream      io.Reader
+	contentType string // of the stream
+	// no token: the auth routes themselves
+	anonymous bool
+	// the response is read for as long as ctx lives (a live stream): no client timeout
+	streaming bool
+}
+
+// do sends req and decodes a 2xx JSON answer into dest (when not nil)
+func (c *Client) do(ctx context.Context, req request, dest any) error {
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if dest == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(dest); err != nil {
+		return fmt.Errorf("decoding %s %s: %w", req.method, req.path, err)
+	}
+	return nil
+}
+
+// send is do without the decoding: the caller closes the body of the (2xx) response
+func (c *Client) send(ctx context.Context, req request) (*http.Response, error) {
+	var body []byte
+	if req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return nil, err
+		}
+	}
+
+	// an expired access token is refreshed before the call: a stream can't
+	// be replayed after a 401
+	if !req.anonymous && c.apiKey == "" {
+		if accessToken, _ := c.Tokens(); accessToken != "" && tokenExpired(accessToken) {
+			if err := c.refresh(ctx, accessToken); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	refreshed := false
+	for attempt := 0; ; attempt++ {
+		accessToken, _ := c.Tokens()
+		resp, err := c.attempt(ctx, req, body, accessToken)
+
+		// 401 with a session: the access token was revoked or expired in
+		// flight, refresh once and replay
+		if err == nil && resp.StatusCode == http.StatusUnauthorized && !refreshed && c.canRefresh(req) {
+			resp.Body.Close()
+			if err := c.refresh(ctx, accessToken); err != nil {
+				return nil, err
+			}
+			refreshed = true
+			continue
+		}
+
+		if attempt < c.maxRetries && retryable(req.method, resp, err) {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			if err := c.wait(ctx, attempt); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode >= 300 {
+			defer resp.Body.Close()
+			return nil, readError(resp)
+		}
+		return resp, nil
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, req request, body []byte, accessToken string) (*http.Response, error) {
+	target := c.baseURL + req.path
+	if len(req.query) > 0 {
+		target += "?" + req.query.Encode()
+	}
+
+	var reader io.Reader
+	if req.stream != nil {
+		reader = req.stream
+	} else if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case req.stream != nil:
+		httpReq.Header.Set("Content-Type", req.contentType)
+	case body != nil:
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+
+	if !req.anonymous {
+		if c.apiKey != "" {
+			httpReq.Header.Set("X-API-Key", c.apiKey)
+		} else if accessToken != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+accessToken)
+		}
+	}
+
+	httpClient := c.httpClient
+	if req.streaming {
+		withoutTimeout := *httpClient
+		withoutTimeout.Timeout = 0
+		httpClient = &withoutTimeout
+	}
+	return httpClient.Do(httpReq)
+}
+
+// -------- tokens
+
+func (c *Client) canRefresh(req request) bool {
+	_, refreshToken := c.Tokens()
+	return !req.anonymous && c.apiKey == "" && req.stream == nil && refreshToken != ""
+}
+
+// refresh swaps the refresh token for new tokens. stale is the access token
+// the caller saw: when another call already refreshed it, there's nothing to do
+func (c *Client) refresh(ctx context.Context, stale string) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	accessToken, refreshToken := c.Tokens()
+	if accessToken != stale {
+		return nil
+	}
+	if refreshToken == "" {
+		return ErrSessionExpired
+	}
+
+	var session Session
+	err := c.do(ctx, request{method: http.MethodPost, path: "/auth/refresh", body: refreshBody{refreshToken}, anonymous: true}, &session)
+	if err != nil {
+		// the refresh token is spent or revoked: log in again
+		if errors.Is(err, ErrBadRequest) {
+			c.SetTokens("", "")
+			return ErrSessionExpired
+		}
+		return err
+	}
+
+	c.SetTokens(session.AccessToken, session.RefreshToken)
+	return nil
+}
+
+// the exp claim, read without checking the signature (the server does that),
+// a few seconds early so the token doesn't expire on the way
+func tokenExpired(token string) bool {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return false
+	}
+
+	var claims struct {
+		ExpiresAt int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.ExpiresAt == 0 {
+		return false
+	}
+	return time.Now().Add(10 * time.Second).After(time.Unix(claims.ExpiresAt, 0))
+}
+
+// -------- retries
+
+func retryable(method string, resp *http.Response, err error) bool {
+	switch method {
+	case http.MethodGet, http.MethodPut, http.MethodDelete:
+	default:
+		// a POST may have been applied before the failure
+		return false
+	}
+
+	if err != nil {
+		// the caller gave up, don't insist
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// full jitter: a random wait up to baseDelay * 2^attempt
+func (c *Client) wait(ctx context.Context, attempt int) error {
+	delay := time.Duration(rand.Int64N(int64(c.baseDelay<<attempt) + 1))
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// -------- helpers
+
+func pageQuery(page Page) url.Values {
+	query := url.Values{}
+	if page.Limit > 0 {
+		query.Set("limit", fmt.Sprint(page.Limit))
+	}
+	if page.Offset > 0 {
+		query.Set("offset", fmt.Sprint(page.Offset))
+	}
+	return query
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// what went wrong, by status: errors.Is(err, client.ErrNotFound)...
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrServer       = errors.New("server error")
+
+	// the refresh token is spent, revoked or missing: Login again
+	ErrSessionExpired = errors.New("session expired, log in again")
+)
+
+// Error is a non-2xx answer of the API. Message is the body: the API
+// answers plain text, except for the JSON {"error": "..."} of the 404s
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("movie api: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("movie api: %d %s", e.StatusCode, e.Message)
+}
+
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrServer:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// ImportError is a failed import: the batches before the error are
+// inserted, Report says how far it went
+type ImportError struct {
+	Err    *Error
+	Report ImportReport
+}
+
+func (e *ImportError) Error() string {
+	return fmt.Sprintf("%v (%d rows inserted before the failure)", e.Err, e.Report.Inserted)
+}
+
+func (e *ImportError) Unwrap() error {
+	return e.Err
+}
+
+// -------- helpers
+func readError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	apiErr := &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		var body struct {
+			Error  string        `json:"error"`
+			Report *ImportReport `json:"report"`
+		}
+		if err := json.Unmarshal(data, &body); err == nil {
+			apiErr.Message = body.Error
+			if body.Report != nil {
+				return &ImportError{Err: apiErr, Report: *body.Report}
+			}
+		}
+	}
+
+	return apiErr
+}
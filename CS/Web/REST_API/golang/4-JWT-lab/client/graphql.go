@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// GraphQLError is one entry of the `errors` of a GraphQL answer. Code is
+// its extensions.code (UNAUTHENTICATED, FORBIDDEN, BAD_USER_INPUT,
+// NOT_FOUND, INTERNAL), errors.Is matches it like the REST statuses
+type GraphQLError struct {
+	Message    string         `json:"message"`
+	Path       []any          `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+func (e *GraphQLError) Code() string {
+	code, _ := e.Extensions["code"].(string)
+	return code
+}
+
+func (e *GraphQLError) Error() string {
+	return "movie api: graphql: " + e.Message
+}
+
+func (e *GraphQLError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.Code() == "BAD_USER_INPUT"
+	case ErrUnauthorized:
+		return e.Code() == "UNAUTHENTICATED"
+	case ErrForbidden:
+		return e.Code() == "FORBIDDEN"
+	case ErrNotFound:
+		return e.Code() == "NOT_FOUND"
+	case ErrServer:
+		return e.Code() == "INTERNAL"
+	}
+	return false
+}
+
+// GraphQLErrors are the errors of a GraphQL answer, the data of the fields
+// that didn't fail is still decoded
+type GraphQLErrors []*GraphQLError
+
+func (e GraphQLErrors) Error() string {
+	messages := make([]string, len(e))
+	for idx, err := range e {
+		messages[idx] = err.Message
+	}
+	return "movie api: graphql: " + strings.Join(messages, "; ")
+}
+
+// errors.Is/As see every error
+func (e GraphQLErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for idx, err := range e {
+		errs[idx] = err
+	}
+	return errs
+}
+
+// `POST /graphql`: runs query (variables can be nil) and decodes its data
+// into dest (when not nil). the GraphQL errors come back as GraphQLErrors
+func (c *Client) GraphQL(ctx context.Context, query string, variables map[string]any, dest any) error {
+	var answer struct {
+		Data   json.RawMessage `json:"data"`
+		Errors GraphQLErrors   `json:"errors"`
+	}
+	body := map[string]any{"query": query}
+	if variables != nil {
+		body["variables"] = variables
+	}
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/graphql", body: body}, &answer); err != nil {
+		return err
+	}
+
+	if dest != nil && len(answer.Data) > 0 && string(answer.Data) != "null" {
+		if err := json.Unmarshal(answer.Data, dest); err != nil {
+			return fmt.Errorf("decoding POST /graphql: %w", err)
+		}
+	}
+	if len(answer.Errors) > 0 {
+		return answer.Errors
+	}
+	return nil
+}
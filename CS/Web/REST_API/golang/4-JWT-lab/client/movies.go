@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// `GET /movies`
+func (c *Client) ListMovies(ctx context.Context, page Page) ([]MovieSummary, error) {
+	var movies []MovieSummary
+	err := c.do(ctx, request{method: http.MethodGet, path: "/movies", query: pageQuery(page)}, &movies)
+	return movies, err
+}
+
+// `GET /movies/popular`, most viewed first (10 without a limit)
+func (c *Client) ListPopularMovies(ctx context.Context, page Page) ([]PopularMovie, error) {
+	var movies []PopularMovie
+	err := c.do(ctx, request{method: http.MethodGet, path: "/movies/popular", query: pageQuery(page)}, &movies)
+	return movies, err
+}
+
+// `GET /movies/trending`, most active first (10 without a limit). an empty window is the last 24h
+func (c *Client) ListTrendingMovies(ctx context.Context, window TrendingWindow, page Page) ([]TrendingMovie, error) {
+	query := pageQuery(page)
+	if window != "" {
+		query.Set("window", string(window))
+	}
+
+	var movies []TrendingMovie
+	err := c.do(ctx, request{method: http.MethodGet, path: "/movies/trending", query: query}, &movies)
+	return movies, err
+}
+
+// `GET /movies/{id}` (counts a view)
+func (c *Client) GetMovie(ctx context.Context, id uuid.UUID) (*MovieSummary, error) {
+	var movie MovieSummary
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/movies/" + id.String()}, &movie); err != nil {
+		return nil, err
+	}
+	return &movie, nil
+}
+
+// `GET /movies/{id}/reviews`: the movie and its review aggregates
+func (c *Client) GetMovieWithReviews(ctx context.Context, id uuid.UUID) (*Movie, error) {
+	var movie Movie
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/movies/" + id.String() + "/reviews"}, &movie); err != nil {
+		return nil, err
+	}
+	return &movie, nil
+}
+
+// `POST /movies` (movies:write)
+func (c *Client) CreateMovie(ctx context.Context, movie NewMovie) (*Movie, error) {
+	var created Movie
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/movies", body: movie}, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// `PUT /movies/{id}` (movies:write)
+func (c *Client) UpdateMovieTitle(ctx context.Context, id uuid.UUID, title string) error {
+	return c.do(ctx, request{method: http.MethodPut, path: "/movies/" + id.String(), body: NewMovie{Title: title}}, nil)
+}
+
+// `DELETE /movies/{id}` (admins, or an API key with movies:delete)
+func (c *Client) DeleteMovie(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: "/movies/" + id.String()}, nil)
+}
@@ -0,0 +1,109 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// `GET /reviews`
+func (c *Client) ListReviews(ctx context.Context) ([]Review, error) {
+	var reviews []Review
+	err := c.do(ctx, request{method: http.MethodGet, path: "/reviews"}, &reviews)
+	return reviews, err
+}
+
+// `GET /reviews/{movie id}`
+func (c *Client) ListMovieReviews(ctx context.Context, movieId uuid.UUID, page Page) ([]Review, error) {
+	var reviews []Review
+	err := c.do(ctx, request{method: http.MethodGet, path: "/reviews/" + movieId.String(), query: pageQuery(page)}, &reviews)
+	return reviews, err
+}
+
+// `POST /reviews` (reviews:write)
+func (c *Client) CreateReview(ctx context.Context, review NewReview) (*Review, error) {
+	var created Review
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/reviews", body: review}, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// `GET /movies/{id}/reviews/stream`: the reviews of the movie posted after
+// lastEventId ("" for none), then the new ones as they come. read them with
+// Next, until ctx is done or Close
+func (c *Client) StreamMovieReviews(ctx context.Context, movieId uuid.UUID, lastEventId string) (*ReviewStream, error) {
+	query := url.Values{}
+	if lastEventId != "" {
+		query.Set("last_event_id", lastEventId)
+	}
+
+	resp, err := c.send(ctx, request{method: http.MethodGet, path: "/movies/" + movieId.String() + "/reviews/stream", query: query, anonymous: true, streaming: true})
+	if err != nil {
+		return nil, err
+	}
+	return &ReviewStream{body: resp.Body, scanner: bufio.NewScanner(resp.Body), LastEventID: lastEventId}, nil
+}
+
+// ReviewStream reads the Server-Sent Events of a review stream
+type ReviewStream struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+	// the id of the last review read: StreamMovieReviews resumes after it
+	LastEventID string
+}
+
+// Next blocks until the next review. io.EOF: the server ended the stream
+// (e.g. it shut down), reconnect with LastEventID
+func (s *ReviewStream) Next() (*FeedReview, error) {
+	var id, event string
+	var data []string
+	for s.scanner.Scan() {
+		line := s.scanner.Text()
+
+		// a blank line ends the event
+		if line == "" {
+			if event == "review" && len(data) > 0 {
+				var review Review
+				if err := json.Unmarshal([]byte(strings.Join(data, "\n")), &review); err != nil {
+					return nil, fmt.Errorf("decoding review %s: %w", id, err)
+				}
+				s.LastEventID = id
+				return &FeedReview{ID: id, Review: review}, nil
+			}
+			id, event, data = "", "", nil
+			continue
+		}
+		// a comment: the heartbeats
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			id = value
+		case "event":
+			event = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+
+	if err := s.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (s *ReviewStream) Close() error {
+	return s.body.Close()
+}
@@ -0,0 +1,263 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// the shapes of the OpenAPI document (components/schemas)
+
+// optional paging: a zero Limit means everything (or the route's default)
+type Page struct {
+	Limit  int
+	Offset int
+}
+
+// what the lists and GetMovie answer
+type MovieSummary struct {
+	ID       uuid.UUID `json:"id"`
+	Title    string    `json:"title"`
+	Director string    `json:"director"`
+	Year     int32     `json:"year"`
+}
+
+// a movie with its aggregates (CreateMovie, GetMovieWithReviews)
+type Movie struct {
+	MovieSummary
+	AverageRating float64 `json:"average_rating"`
+	ReviewsCount  int64   `json:"reviews_count"`
+	Views         int64   `json:"views"`
+}
+
+type PopularMovie struct {
+	MovieSummary
+	Views int64 `json:"views"`
+}
+
+type TrendingMovie struct {
+	MovieSummary
+	Score float64 `json:"score"`
+}
+
+// TrendingWindow is one of the windows of ListTrendingMovies
+type TrendingWindow string
+
+const (
+	LastHour TrendingWindow = "1h"
+	LastDay  TrendingWindow = "24h"
+	LastWeek TrendingWindow = "7d"
+)
+
+type NewMovie struct {
+	Title    string `json:"title"` // 1 to 40 characters
+	Director string `json:"director,omitempty"`
+	Year     int32  `json:"year,omitempty"`
+}
+
+type Review struct {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"user_name"`
+	Rating   int32     `json:"rating"`
+	Comment  *string   `json:"comment"`
+	MovieID  uuid.UUID `json:"movie_id"`
+}
+
+// a review of a movie's live stream, ID is its position in the stream
+type FeedReview struct {
+	ID     string `json:"id"`
+	Review Review `json:"review"`
+}
+
+// the author is the caller (for an API key: its owner)
+type NewReview struct {
+	Rating  int32     `json:"rating"` // 1 to 10
+	Comment *string   `json:"comment,omitempty"`
+	MovieID uuid.UUID `json:"movie_id"`
+}
+
+// Session is the answer of the logins and of a refresh. when the account
+// has 2FA, login only gives MFARequired + MFAToken: finish with LoginMFA
+type Session struct {
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	AccessToken  string    `json:"access_token,omitempty"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	MFARequired  bool      `json:"mfa_required,omitempty"`
+	MFAToken     string    `json:"mfa_token,omitempty"`
+}
+
+type TOTPEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type Permission string
+
+const (
+	PermMoviesWrite   Permission = "movies:write"
+	PermMoviesDelete  Permission = "movies:delete"
+	PermReviewsWrite  Permission = "reviews:write"
+	PermCatalogExport Permission = "catalog:export"
+)
+
+// APIKey never contains the key itself, only its prefix
+type APIKey struct {
+	ID         uuid.UUID    `json:"id"`
+	UserID     uuid.UUID    `json:"user_id"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	Scopes     []Permission `json:"scopes"`
+	CreatedAt  time.Time    `json:"created_at"`
+	ExpiresAt  *time.Time   `json:"expires_at"`
+	LastUsedAt *time.Time   `json:"last_used_at"`
+	RevokedAt  *time.Time   `json:"revoked_at"`
+}
+
+type NewAPIKey struct {
+	Name          string       `json:"name"`
+	Scopes        []Permission `json:"scopes"`
+	ExpiresInDays int          `json:"expires_in_days,omitempty"` // 0 = never
+}
+
+// returned once, at creation
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type AuditOutcome string
+
+const (
+	AuditSuccess AuditOutcome = "success"
+	AuditFailure AuditOutcome = "failure"
+	AuditDenied  AuditOutcome = "denied"
+)
+
+type AuditEvent struct {
+	ID         uuid.UUID    `json:"id"`
+	OccurredAt time.Time    `json:"occurred_at"`
+	ActorID    *uuid.UUID   `json:"actor_id,omitempty"`
+	ActorName  string       `json:"actor_name,omitempty"`
+	APIKeyID   *uuid.UUID   `json:"api_key_id,omitempty"`
+	Action     string       `json:"action"`
+	TargetType string       `json:"target_type,omitempty"`
+	TargetID   string       `json:"target_id,omitempty"`
+	IP         string       `json:"ip,omitempty"`
+	UserAgent  string       `json:"user_agent,omitempty"`
+	Outcome    AuditOutcome `json:"outcome"`
+	Detail     string       `json:"detail,omitempty"`
+}
+
+// AuditFilter: zero values mean "no filter"
+type AuditFilter struct {
+	ActorID    *uuid.UUID
+	Action     string // e.g. "movie.delete"
+	Outcome    AuditOutcome
+	TargetType string
+	TargetID   string
+	Since      *time.Time
+	Until      *time.Time
+	Page
+}
+
+// the events a webhook can subscribe to
+const (
+	EventMovieCreated  = "movie.created"
+	EventMovieUpdated  = "movie.updated"
+	EventMovieDeleted  = "movie.deleted"
+	EventReviewCreated = "review.created"
+)
+
+// Webhook never contains its secret
+type Webhook struct {
+	ID        uuid.UUID  `json:"id"`
+	URL       string     `json:"url"`
+	Events    []string   `json:"events"`
+	CreatedBy *uuid.UUID `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type NewWebhook struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+// returned once, at creation: the deliveries are signed with Secret
+type CreatedWebhook struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryDead      DeliveryStatus = "dead"
+)
+
+type WebhookDelivery struct {
+	ID             uuid.UUID       `json:"id"`
+	WebhookID      uuid.UUID       `json:"webhook_id"`
+	EventID        uuid.UUID       `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         DeliveryStatus  `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at"`
+	ResponseStatus int             `json:"response_status"` // 0: no answer
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// Format of the import/export files
+type Format string
+
+const (
+	CSV    Format = "csv"
+	NDJSON Format = "ndjson"
+)
+
+type ImportReport struct {
+	DryRun    bool          `json:"dry_run"`
+	Rows      int           `json:"rows"`
+	Valid     int           `json:"valid"`
+	Invalid   int           `json:"invalid"`
+	Skipped   int           `json:"skipped"`
+	Inserted  int           `json:"inserted"`
+	Errors    []ImportIssue `json:"errors"`
+	Truncated bool          `json:"errors_truncated,omitempty"`
+}
+
+// a rejected row of an import
+type ImportIssue struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// -------- request bodies
+type credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Email    string `json:"email,omitempty"`
+}
+
+type refreshBody struct {
+	Token string `json:"refresh_token"`
+}
+
+type mfaLogin struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+type totpCode struct {
+	Code string `json:"code"`
+}
+
+type recoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
@@ -1,24 +1,31 @@
 package main
 
 import (
-	"database/sql"
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
-	"github.com/go-chi/chi/v5"
-	chimw "github.com/go-chi/chi/v5/middleware"
 	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/grainme/movie-api/internal/auth"
 	"github.com/grainme/movie-api/internal/cache"
-	handlers "github.com/grainme/movie-api/internal/handler"
-	"github.com/grainme/movie-api/internal/middleware"
-	"github.com/grainme/movie-api/internal/repository/postgres"
+	"github.com/grainme/movie-api/internal/grpcserver"
+	"github.com/grainme/movie-api/internal/mailer"
+	"github.com/grainme/movie-api/internal/oidc"
+	"github.com/grainme/movie-api/internal/outbox"
+	"github.com/grainme/movie-api/internal/server"
 	"github.com/grainme/movie-api/internal/service"
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/grainme/movie-api/internal/storage"
 	"github.com/joho/godotenv"
+	"google.golang.org/grpc"
 )
 
 func main() {
@@ -30,78 +37,200 @@ func main() {
 	dbDSN := os.Getenv("DB_DSN")
 	redisAddr := os.Getenv("REDIS_ADDR")
 
-	// Migrations (tables creation)
-	log.Println("Running database migrations...")
-	m, err := migrate.New("file://db/migrations", dbDSN)
+	// password hashing cost (ARGON2_*), raising it upgrades old hashes on login
+	passwordParams, err := auth.PasswordParamsFromEnv()
 	if err != nil {
-		log.Fatalf("Migration initialization failed: %v", err)
+		log.Fatalf("Invalid password hashing params: %v", err)
 	}
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		// ErrNoChanges means DB is already up-to-date
-		log.Fatalf("Failed to apply migrations: %v", err)
+	auth.SetPasswordParams(passwordParams)
+
+	if breachList := os.Getenv("BREACHED_PASSWORDS_FILE"); breachList != "" {
+		count, err := auth.LoadBreachedPasswords(breachList)
+		if err != nil {
+			log.Fatalf("Unable to load breached passwords: %v", err)
+		}
+		log.Printf("Loaded %d breached passwords", count)
 	}
-	log.Println("Database migrations completed successfully.")
 
-	// setup postgresDB
-	db, err := sql.Open("pgx", dbDSN)
+	// setup the database: Postgres or SQLite, from the DB_DSN scheme (see storage.Open)
+	store, err := storage.Open(dbDSN)
 	if err != nil {
-		log.Fatalf("Unable to create connection pool: %v", err)
-		os.Exit(1)
+		log.Fatalf("Unable to open the database: %v", err)
+	}
+	defer store.Close()
+
+	// Migrations (tables creation): opt-in, `moviectl migrate up` otherwise.
+	// with several replicas starting at once, better run them once, before
+	// (an in-memory SQLite database needs them here: sqlite://:memory:)
+	if os.Getenv("MIGRATE_ON_START") == "true" {
+		log.Printf("Running database migrations (%s)...", store.Backend)
+		m, err := store.NewMigrate("")
+		if err != nil {
+			log.Fatalf("Migration initialization failed: %v", err)
+		}
+		if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+			// ErrNoChanges means DB is already up-to-date
+			log.Fatalf("Failed to apply migrations: %v", err)
+		}
+		log.Println("Database migrations completed successfully.")
+	}
+
+	// setup cache: Redis, or CACHE_BACKEND=memory for a single instance without Redis
+	// (sessions are then lost on restart and not shared between instances)
+	var movieCache cache.MovieCache
+	var lists cache.ListCache
+	var trending cache.TrendingStore
+	var sessions cache.SessionStore
+	var reviewFeed cache.ReviewFeed
+	if os.Getenv("CACHE_BACKEND") == "memory" {
+		log.Println("Using the in-memory cache")
+		movieCache = cache.NewMemoryMovieCache()
+		lists = cache.NewMemoryListCache()
+		trending = cache.NewMemoryTrendingStore()
+		sessions = cache.NewMemorySessionStore()
+		reviewFeed = cache.NewMemoryReviewFeed()
+	} else {
+		rdb, err := cache.NewRedisClient(redisAddr)
+		if err != nil {
+			log.Fatalf("Unable to connect to Redis: %v", err)
+			os.Exit(1)
+		}
+		defer rdb.Close()
+
+		if tracked, err := cache.TrackPendingViews(context.Background(), rdb); err != nil {
+			log.Printf("could not track the pending view counts: %v", err)
+		} else if tracked > 0 {
+			log.Printf("%d view counters were not pending, they will be flushed", tracked)
+		}
+
+		lists = cache.NewRedisListCache(rdb)
+		trending = cache.NewRedisTrendingStore(rdb)
+		sessions = cache.NewRedisSessionStore(rdb)
+		reviewFeed = cache.NewRedisReviewFeed(rdb)
+
+		// a local LRU in front of Redis for the movies (LOCAL_CACHE_SIZE=0 disables it)
+		localCacheSize, localCacheTTL, err := localCacheConfig()
+		if err != nil {
+			log.Fatalf("Invalid local cache config: %v", err)
+		}
+		if localCacheSize > 0 {
+			tieredCache := cache.NewTieredMovieCache(rdb, localCacheSize, localCacheTTL)
+			go tieredCache.Listen(context.Background())
+			movieCache = tieredCache
+		} else {
+			movieCache = cache.NewRedisMovieCache(rdb)
+		}
+	}
+
+	// setup mailer: MAILER_DIR set → one file per email, otherwise emails go to the logs
+	var mail mailer.Mailer = mailer.NewLogMailer()
+	if mailerDir := os.Getenv("MAILER_DIR"); mailerDir != "" {
+		mail, err = mailer.NewFileMailer(mailerDir)
+		if err != nil {
+			log.Fatalf("Unable to setup file mailer: %v", err)
+		}
 	}
-	defer db.Close()
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(25)
-	db.SetConnMaxLifetime(5 * time.Minute)
 
-	if err := db.Ping(); err != nil {
-		log.Fatalf("Unable to connect to the database: %v", err)
-		os.Exit(1)
+	// view counts: counted in the cache, flushed to the DB every VIEWS_FLUSH_INTERVAL
+	viewsFlushInterval := 30 * time.Second // default value
+	if value := os.Getenv("VIEWS_FLUSH_INTERVAL"); value != "" {
+		if viewsFlushInterval, err = time.ParseDuration(value); err != nil {
+			log.Fatalf("Invalid VIEWS_FLUSH_INTERVAL: %v", err)
+		}
 	}
+	viewFlusher := service.NewViewFlusher(store.Movies, movieCache, lists, viewsFlushInterval)
+	flusherCtx, stopFlusher := context.WithCancel(context.Background())
+	flusherDone := make(chan struct{})
+	go func() {
+		viewFlusher.Run(flusherCtx)
+		close(flusherDone)
+	}()
 
-	// setup redis
-	rdb, err := cache.NewRedisClient(redisAddr)
+	// domain events: written to the outbox by every replica, published to
+	// RabbitMQ by one of them. RABBITMQ_URL unset: nothing is published, the
+	// relay only prunes the outbox (the events are dropped after OUTBOX_RETENTION)
+	relayInterval, relayRetention, err := outboxRelayConfig()
 	if err != nil {
-		log.Fatalf("Unable to connect to Redis: %v", err)
-		os.Exit(1)
-	}
-	defer rdb.Close()
-
-	movieRepo := postgres.NewPostgresMovieRepository(db)
-	reviewRepo := postgres.NewPostgresReviewRepository(db)
-	userRepo := postgres.NewPostgresUserRepository(db)
-
-	movieService := service.NewMovieService(movieRepo, rdb)
-	reviewService := service.NewReviewService(reviewRepo, rdb)
-	userService := service.NewUserService(userRepo, rdb)
-
-	movieHandler := handlers.NewMovieHandler(movieService)
-	reviewHandler := handlers.NewReviewHandler(reviewService)
-	userHandler := handlers.NewUserHandler(userService)
-
-	r := chi.NewRouter()
-	r.Use(chimw.Logger)
-
-	// movie routes
-	r.Get("/movies", movieHandler.GetAllMovies)
-	r.Get("/movies/{id}", movieHandler.GetMovieById)
-	r.Group(func(r chi.Router) {
-		r.Use(middleware.Authenticate)
-		r.Post("/movies", movieHandler.AddMovie)
-		r.Put("/movies/{id}", movieHandler.UpdateMovieTitleById)
-		r.With(middleware.Authorize).Delete("/movies/{id}", movieHandler.DeleteById)
-	})
-	r.Get("/movies/{id}/reviews", movieHandler.GetMovieWithReviews)
-
-	// reviews routes
-	r.Get("/reviews", reviewHandler.GetAllReviews)
-	r.Post("/reviews", reviewHandler.AddReview)
-	r.Get("/reviews/{id}", reviewHandler.GetAllReviewsByMovieId)
-
-	// auth routes
-	r.Post("/auth/login", userHandler.Login)
-	r.Post("/auth/register", userHandler.Register)
-	r.Post("/auth/refresh", userHandler.RefreshToken)
-	r.Post("/auth/logout", userHandler.Logout)
+		log.Fatalf("Invalid outbox relay config: %v", err)
+	}
+	var publisher outbox.Publisher
+	if rabbitURL := os.Getenv("RABBITMQ_URL"); rabbitURL != "" {
+		exchange := os.Getenv("RABBITMQ_EXCHANGE")
+		if exchange == "" {
+			// default value
+			exchange = "movie_api.events"
+		}
+
+		rabbitPublisher := outbox.NewRabbitPublisher(rabbitURL, exchange)
+		defer rabbitPublisher.Close()
+		publisher = rabbitPublisher
+	} else {
+		log.Printf("RABBITMQ_URL not set: the domain events are not published, they are pruned after %s", relayRetention)
+	}
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	relayDone := make(chan struct{})
+	relay := outbox.NewRelay(store.Outbox, publisher, relayInterval, relayRetention)
+	go func() {
+		relay.Run(relayCtx)
+		close(relayDone)
+	}()
+
+	// outgoing webhooks: queued by the services, sent every WEBHOOK_DISPATCH_INTERVAL
+	webhookDispatchInterval := 5 * time.Second // default value
+	if value := os.Getenv("WEBHOOK_DISPATCH_INTERVAL"); value != "" {
+		if webhookDispatchInterval, err = time.ParseDuration(value); err != nil {
+			log.Fatalf("Invalid WEBHOOK_DISPATCH_INTERVAL: %v", err)
+		}
+	}
+	dispatcher := service.NewWebhookDispatcher(store.Webhooks, nil, webhookDispatchInterval, service.DefaultWebhookRetryPolicy)
+	dispatcherCtx, stopDispatcher := context.WithCancel(context.Background())
+	dispatcherDone := make(chan struct{})
+	go func() {
+		dispatcher.Run(dispatcherCtx)
+		close(dispatcherDone)
+	}()
+
+	// OIDC login is optional: only enabled when a provider is configured
+	var oidcProvider *oidc.Provider
+	if issuer := os.Getenv("OIDC_ISSUER"); issuer != "" {
+		oidcProvider, err = oidc.Discover(context.Background(), oidc.Config{
+			Issuer:       issuer,
+			ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+			ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+			RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+			Scopes:       []string{"email", "profile"},
+		}, nil)
+		if err != nil {
+			log.Fatalf("Unable to discover OIDC provider: %v", err)
+		}
+	}
+
+	cacheControl, err := cacheControlConfig()
+	if err != nil {
+		log.Fatalf("Invalid CACHE_CONTROL: %v", err)
+	}
+
+	// closed by httpServer.Shutdown: the live streams end instead of holding it up
+	streamsShutdown := make(chan struct{})
+	deps := server.Deps{
+		Movies:       store.Movies,
+		Reviews:      store.Reviews,
+		Users:        store.Users,
+		APIKeys:      store.APIKeys,
+		Audit:        store.Audit,
+		Webhooks:     store.Webhooks,
+		MovieCache:   movieCache,
+		Lists:        lists,
+		Trending:     trending,
+		Sessions:     sessions,
+		ReviewFeed:   reviewFeed,
+		Mailer:       mail,
+		OIDC:         oidcProvider,
+		Shutdown:     streamsShutdown,
+		CacheControl: cacheControl,
+		RequestLogs:  true,
+	}
+	r := server.NewRouter(deps)
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -109,8 +238,144 @@ func main() {
 		port = "3000"
 	}
 
-	log.Printf("Starting server on port %s", port)
-	if err := http.ListenAndServe(":"+port, r); err != nil {
-		log.Fatalf("Server failed to start: %v", err)
+	// SIGINT/SIGTERM: finish the requests in flight, then the last views flush
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	httpServer := &http.Server{Addr: ":" + port, Handler: r}
+	httpServer.RegisterOnShutdown(func() { close(streamsShutdown) })
+	go func() {
+		log.Printf("Starting server on port %s", port)
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Server failed to start: %v", err)
+		}
+	}()
+
+	// the gRPC API, same services on its own port (GRPC_PORT=off disables it)
+	grpcPort := os.Getenv("GRPC_PORT")
+	if grpcPort == "" {
+		// default value
+		grpcPort = "50051"
+	}
+	var grpcServer *grpc.Server
+	if grpcPort != "off" {
+		listener, err := net.Listen("tcp", ":"+grpcPort)
+		if err != nil {
+			log.Fatalf("Unable to listen on gRPC port %s: %v", grpcPort, err)
+		}
+		grpcServer = grpcserver.NewServer(deps)
+		go func() {
+			log.Printf("Starting gRPC server on port %s", grpcPort)
+			if err := grpcServer.Serve(listener); err != nil {
+				log.Fatalf("gRPC server failed: %v", err)
+			}
+		}()
 	}
+
+	<-ctx.Done()
+	log.Println("Shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server shutdown failed: %v", err)
+	}
+	if grpcServer != nil {
+		stopGRPC(shutdownCtx, grpcServer)
+	}
+
+	// after the server: the last requests' views are part of the last flush
+	stopFlusher()
+	<-flusherDone
+	// after the server too: the last requests' events go out
+	stopRelay()
+	<-relayDone
+	// the deliveries in flight are retried after their lease
+	stopDispatcher()
+	<-dispatcherDone
+}
+
+// GracefulStop waits for every call, and a WatchMovieReviews stream only ends
+// when its client leaves: past the deadline, cut them
+func stopGRPC(ctx context.Context, s *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.Stop()
+	}
+}
+
+// OUTBOX_RELAY_INTERVAL: how often the outbox is polled
+// OUTBOX_RETENTION: how long the published events are kept
+func outboxRelayConfig() (time.Duration, time.Duration, error) {
+	interval := time.Second // default value
+	if value := os.Getenv("OUTBOX_RELAY_INTERVAL"); value != "" {
+		var err error
+		if interval, err = time.ParseDuration(value); err != nil {
+			return 0, 0, fmt.Errorf("OUTBOX_RELAY_INTERVAL: %w", err)
+		}
+	}
+
+	retention := 7 * 24 * time.Hour // default value
+	if value := os.Getenv("OUTBOX_RETENTION"); value != "" {
+		var err error
+		if retention, err = time.ParseDuration(value); err != nil {
+			return 0, 0, fmt.Errorf("OUTBOX_RETENTION: %w", err)
+		}
+	}
+
+	return interval, retention, nil
+}
+
+// CACHE_CONTROL: the Cache-Control of some read routes, over
+// server.DefaultCacheControl, e.g. "/movies/{id}=public, max-age=60;/reviews="
+// (an empty value sends none)
+func cacheControlConfig() (map[string]string, error) {
+	cacheControl := map[string]string{}
+	value := os.Getenv("CACHE_CONTROL")
+	if value == "" {
+		return cacheControl, nil
+	}
+
+	for _, entry := range strings.Split(value, ";") {
+		pattern, header, found := strings.Cut(entry, "=")
+		pattern = strings.TrimSpace(pattern)
+		if !found {
+			return nil, fmt.Errorf("%q: expected <route>=<cache-control>", entry)
+		}
+		if _, ok := server.DefaultCacheControl[pattern]; !ok {
+			return nil, fmt.Errorf("%q is not a cacheable route", pattern)
+		}
+		cacheControl[pattern] = strings.TrimSpace(header)
+	}
+
+	return cacheControl, nil
+}
+
+// LOCAL_CACHE_SIZE: max movies kept in memory per replica
+// LOCAL_CACHE_TTL: max staleness when an invalidation message is lost
+func localCacheConfig() (int, time.Duration, error) {
+	size := 1000 // default value
+	if value := os.Getenv("LOCAL_CACHE_SIZE"); value != "" {
+		var err error
+		if size, err = strconv.Atoi(value); err != nil {
+			return 0, 0, fmt.Errorf("LOCAL_CACHE_SIZE: %w", err)
+		}
+	}
+
+	ttl := 30 * time.Second // default value
+	if value := os.Getenv("LOCAL_CACHE_TTL"); value != "" {
+		var err error
+		if ttl, err = time.ParseDuration(value); err != nil {
+			return 0, 0, fmt.Errorf("LOCAL_CACHE_TTL: %w", err)
+		}
+	}
+
+	return size, ttl, nil
 }
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/grainme/movie-api/internal/bulk"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/service"
+)
+
+func newBulkService() (*service.BulkService, func(), error) {
+	store, err := openStorage()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	auditLogger := service.NewAuditLogger(store.Audit)
+	bulkService := service.NewBulkService(store.Movies, store.Reviews, openLists(), auditLogger)
+	return bulkService, func() { store.Close() }, nil
+}
+
+func runImport(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	formatName := flags.String("format", "", "csv or ndjson (default: from the file extension, csv)")
+	dryRun := flags.Bool("dry-run", false, "only validate, write nothing")
+	if len(args) < 1 {
+		return errors.New(usage)
+	}
+	kind := args[0]
+	flags.Parse(args[1:])
+	if flags.NArg() != 1 {
+		return errors.New(usage)
+	}
+	path := flags.Arg(0)
+
+	format, err := formatFor(*formatName, path)
+	if err != nil {
+		return err
+	}
+
+	var input io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		input = file
+	}
+
+	bulkService, closeDB, err := newBulkService()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	var report domain.ImportReport
+	switch kind {
+	case "movies":
+		report, err = bulkService.ImportMovies(ctx, bulk.NewMovieReader(input, format), *dryRun)
+	case "reviews":
+		report, err = bulkService.ImportReviews(ctx, bulk.NewReviewReader(input, format), *dryRun)
+	default:
+		return fmt.Errorf("unknown import %q (movies or reviews)", kind)
+	}
+
+	// the report goes to stdout (JSON, easy to grep / jq) even on failure
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if encodeErr := encoder.Encode(report); encodeErr != nil {
+		return encodeErr
+	}
+	if err != nil {
+		return err
+	}
+	if report.Invalid > 0 {
+		return fmt.Errorf("%d invalid rows", report.Invalid)
+	}
+	return nil
+}
+
+func runExport(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	formatName := flags.String("format", "", "csv or ndjson (default: from the -o extension, csv)")
+	outPath := flags.String("o", "", "output file (default: stdout)")
+	if len(args) < 1 {
+		return errors.New(usage)
+	}
+	kind := args[0]
+	flags.Parse(args[1:])
+
+	format, err := formatFor(*formatName, *outPath)
+	if err != nil {
+		return err
+	}
+
+	var output io.Writer = os.Stdout
+	if *outPath != "" {
+		file, err := os.Create(*outPath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		output = file
+	}
+
+	bulkService, closeDB, err := newBulkService()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	var count int
+	switch kind {
+	case "movies":
+		count, err = bulkService.ExportMovies(ctx, bulk.NewMovieWriter(output, format))
+	case "reviews":
+		count, err = bulkService.ExportReviews(ctx, bulk.NewReviewWriter(output, format))
+	default:
+		return fmt.Errorf("unknown export %q (movies or reviews)", kind)
+	}
+	if err != nil {
+		return err
+	}
+
+	// stderr: stdout may be the export itself
+	log.Printf("exported %d %s", count, kind)
+	return nil
+}
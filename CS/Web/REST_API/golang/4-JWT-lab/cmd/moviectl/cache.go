@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/grainme/movie-api/internal/cache"
+)
+
+// moviectl cache flush
+func runCache(ctx context.Context, args []string) error {
+	if len(args) != 1 || args[0] != "flush" {
+		return errors.New(usage)
+	}
+
+	rdb, err := openRedis()
+	if err != nil {
+		return err
+	}
+	defer rdb.Close()
+
+	deleted, err := cache.FlushCache(ctx, rdb)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("deleted %d cached keys (sessions, view counts and trending kept)", deleted)
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/grainme/movie-api/internal/auth"
+)
+
+// moviectl rotate-keys [-drop-previous]
+//
+// the signing secret lives in the API's environment, moviectl can't change it:
+// it prints the new values, the operator puts them where the API reads them
+// (.env, the secret manager) and restarts every replica.
+func runRotateKeys(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("rotate-keys", flag.ExitOnError)
+	dropPrevious := flags.Bool("drop-previous", false, "the old secret leaked: reject the tokens it signed right away")
+	flags.Parse(args)
+	if flags.NArg() != 0 {
+		return errors.New(usage)
+	}
+
+	current := os.Getenv("JWT_SECRET")
+	if current == "" {
+		return errors.New("JWT_SECRET is not set")
+	}
+
+	// 256 bits, the size of the HS256 hash
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return err
+	}
+	secret := base64.RawURLEncoding.EncodeToString(buf)
+
+	// stdout: the only place the new secret is ever shown
+	fmt.Fprintf(out, "JWT_SECRET=%s\n", secret)
+	if *dropPrevious {
+		fmt.Fprintln(out, "JWT_PREVIOUS_SECRET=")
+		log.Printf("new signing key %s: every access token issued before is rejected, the clients refresh them (the refresh tokens are not signed, they stay valid)", auth.SigningKeyID(secret))
+		return nil
+	}
+
+	fmt.Fprintf(out, "JWT_PREVIOUS_SECRET=%s\n", current)
+	log.Printf("new signing key %s, the tokens of %s stay valid until they expire (15 minutes): then JWT_PREVIOUS_SECRET can go", auth.SigningKeyID(secret), auth.SigningKeyID(current))
+	return nil
+}
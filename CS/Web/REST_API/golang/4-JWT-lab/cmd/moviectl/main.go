@@ -0,0 +1,123 @@
+// moviectl: admin tasks run next to the API, straight on the database
+//
+//	moviectl migrate up [N] | down [-all] [N] | to VERSION | version | force VERSION
+//	moviectl seed
+//	moviectl user create -username NAME [-email EMAIL] [-admin] [-password-stdin]
+//	moviectl sessions purge [-user NAME]
+//	moviectl cache flush
+//	moviectl rotate-keys [-drop-previous]
+//	moviectl import movies|reviews [-format csv|ndjson] [-dry-run] FILE
+//	moviectl export movies|reviews [-format csv|ndjson] [-o FILE]
+//
+// same configuration as the API (.env, DB_DSN, REDIS_ADDR, CACHE_BACKEND...).
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/grainme/movie-api/internal/bulk"
+	"github.com/grainme/movie-api/internal/cache"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/storage"
+	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
+)
+
+const usage = `usage:
+  moviectl migrate up [N] | down [-all] [N] | to VERSION | version | force VERSION
+  moviectl seed
+  moviectl user create -username NAME [-email EMAIL] [-admin] [-password-stdin]
+  moviectl sessions purge [-user NAME]
+  moviectl cache flush
+  moviectl rotate-keys [-drop-previous]                                (prints the new JWT_SECRET)
+  moviectl import movies|reviews [-format csv|ndjson] [-dry-run] FILE  (FILE "-" is stdin)
+  moviectl export movies|reviews [-format csv|ndjson] [-o FILE]        (stdout by default)`
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("moviectl: ")
+
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+
+	// the .env is optional here, the environment is enough
+	_ = godotenv.Load()
+
+	// the audit log shows where the changes came from
+	ctx := context.WithValue(context.Background(), "request_meta", domain.RequestMeta{UserAgent: "moviectl"})
+
+	var err error
+	switch os.Args[1] {
+	case "migrate":
+		err = runMigrate(os.Args[2:])
+	case "seed":
+		err = runSeed(ctx)
+	case "user":
+		err = runUser(ctx, os.Args[2:])
+	case "sessions":
+		err = runSessions(ctx, os.Args[2:])
+	case "cache":
+		err = runCache(ctx, os.Args[2:])
+	case "rotate-keys":
+		err = runRotateKeys(os.Args[2:], os.Stdout)
+	case "import":
+		err = runImport(ctx, os.Args[2:])
+	case "export":
+		err = runExport(ctx, os.Args[2:])
+	default:
+		log.Fatal(usage)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// the API's database, Postgres or SQLite (see storage.Open)
+func openStorage() (*storage.Storage, error) {
+	dbDSN := os.Getenv("DB_DSN")
+	if dbDSN == "" {
+		return nil, fmt.Errorf("DB_DSN is not set")
+	}
+
+	return storage.Open(dbDSN)
+}
+
+// the API's Redis. with CACHE_BACKEND=memory the cache and the sessions live
+// inside the API process, moviectl can't reach them
+func openRedis() (*redis.Client, error) {
+	if os.Getenv("CACHE_BACKEND") == "memory" {
+		return nil, errors.New("CACHE_BACKEND=memory: the cache lives in the API process, restart it instead")
+	}
+	return cache.NewRedisClient(os.Getenv("REDIS_ADDR"))
+}
+
+// the API's list cache, so its cached lists are invalidated after an import.
+// without Redis the API's lists expire on their own.
+func openLists() cache.ListCache {
+	rdb, err := openRedis()
+	if err != nil {
+		log.Printf("warning: %v, the API may serve stale lists for a few minutes", err)
+		return cache.NewMemoryListCache()
+	}
+	return cache.NewRedisListCache(rdb)
+}
+
+// -format, otherwise the file extension, otherwise csv
+func formatFor(name string, path string) (bulk.Format, error) {
+	if name != "" {
+		return bulk.ParseFormat(name)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ndjson", ".jsonl":
+		return bulk.NDJSON, nil
+	}
+	return bulk.CSV, nil
+}
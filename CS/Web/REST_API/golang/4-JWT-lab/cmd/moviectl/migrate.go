@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/golang-migrate/migrate/v4"
+)
+
+// moviectl migrate up [N] | down [-all] [N] | to VERSION | version | force VERSION
+func runMigrate(args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	// default value: the backend's migrations (db/migrations or db/sqlite/migrations),
+	// run from the project root like the API
+	path := flags.String("path", "", "migrations directory")
+	all := flags.Bool("all", false, "down: revert every migration")
+	if len(args) < 1 {
+		return errors.New(usage)
+	}
+	command := args[0]
+	flags.Parse(args[1:])
+
+	store, err := openStorage()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	m, err := store.NewMigrate(*path)
+	if err != nil {
+		return fmt.Errorf("migration initialization failed: %w", err)
+	}
+	defer m.Close()
+
+	switch command {
+	case "up":
+		steps, err := optionalSteps(flags)
+		if err != nil {
+			return err
+		}
+		if steps == 0 {
+			err = m.Up()
+		} else {
+			err = m.Steps(steps)
+		}
+		if err := migrationResult(err); err != nil {
+			return err
+		}
+	case "down":
+		steps, err := optionalSteps(flags)
+		if err != nil {
+			return err
+		}
+		if *all {
+			err = m.Down()
+		} else {
+			// one at a time by default, reverting everything drops every table
+			err = m.Steps(-max(steps, 1))
+		}
+		if err := migrationResult(err); err != nil {
+			return err
+		}
+	case "to":
+		version, err := requiredVersion(flags)
+		if err != nil {
+			return err
+		}
+		if version < 0 {
+			return errors.New("to: the version must be positive")
+		}
+		if err := migrationResult(m.Migrate(uint(version))); err != nil {
+			return err
+		}
+	case "force":
+		// after a failed migration (dirty): set the version by hand, once the
+		// database is fixed. -1 means no migration applied
+		version, err := requiredVersion(flags)
+		if err != nil {
+			return err
+		}
+		if err := m.Force(version); err != nil {
+			return err
+		}
+	case "version":
+	default:
+		return fmt.Errorf("unknown migrate command %q", command)
+	}
+
+	return printVersion(m)
+}
+
+// ErrNoChange: the database is already there, not an error
+func migrationResult(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		log.Println("no change")
+		return nil
+	}
+	return err
+}
+
+func printVersion(m *migrate.Migrate) error {
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		fmt.Println("no migration applied")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if dirty {
+		fmt.Printf("version %d (dirty: fix the database, then `moviectl migrate force %d`)\n", version, version)
+		return nil
+	}
+	fmt.Printf("version %d\n", version)
+	return nil
+}
+
+func optionalSteps(flags *flag.FlagSet) (int, error) {
+	if flags.NArg() == 0 {
+		return 0, nil
+	}
+	steps, err := strconv.Atoi(flags.Arg(0))
+	if err != nil || steps < 1 {
+		return 0, fmt.Errorf("invalid number of steps %q", flags.Arg(0))
+	}
+	return steps, nil
+}
+
+func requiredVersion(flags *flag.FlagSet) (int, error) {
+	if flags.NArg() != 1 {
+		return 0, errors.New(usage)
+	}
+	version, err := strconv.Atoi(flags.Arg(0))
+	if err != nil {
+		return 0, fmt.Errorf("invalid version %q", flags.Arg(0))
+	}
+	return version, nil
+}
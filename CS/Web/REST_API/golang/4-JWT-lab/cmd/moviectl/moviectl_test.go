@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/apitest"
+	"github.com/grainme/movie-api/internal/auth"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/storage"
+)
+
+func TestMain(m *testing.M) {
+	apitest.Main(m)
+}
+
+// the tests run from cmd/moviectl, the migrations are at the project root
+const testMigrations = "../../db/sqlite/migrations"
+
+// a migrated SQLite file as DB_DSN, and no Redis
+func useTestDB(t *testing.T) {
+	t.Helper()
+	t.Setenv("DB_DSN", "sqlite://"+filepath.Join(t.TempDir(), "movies.db"))
+	t.Setenv("CACHE_BACKEND", "memory")
+
+	if err := runMigrate([]string{"up", "-path", testMigrations}); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+}
+
+// what the commands left in the database
+func openTestDB(t *testing.T) *storage.Storage {
+	t.Helper()
+	store, err := openStorage()
+	if err != nil {
+		t.Fatalf("open storage: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func migrationVersion(t *testing.T) uint {
+	t.Helper()
+	m, err := openTestDB(t).NewMigrate(testMigrations)
+	if err != nil {
+		t.Fatalf("new migrate: %v", err)
+	}
+	defer m.Close()
+	version, dirty, err := m.Version()
+	if err != nil || dirty {
+		t.Fatalf("version = %d (dirty: %t), %v", version, dirty, err)
+	}
+	return version
+}
+
+func TestMigrate(t *testing.T) {
+	useTestDB(t)
+	latest := migrationVersion(t)
+
+	if err := runMigrate([]string{"down", "-path", testMigrations, "2"}); err != nil {
+		t.Fatalf("migrate down 2: %v", err)
+	}
+	if version := migrationVersion(t); version != latest-2 {
+		t.Fatalf("version after down 2 = %d, want %d", version, latest-2)
+	}
+
+	// up again: no change is not an error
+	for range 2 {
+		if err := runMigrate([]string{"up", "-path", testMigrations}); err != nil {
+			t.Fatalf("migrate up: %v", err)
+		}
+	}
+	if version := migrationVersion(t); version != latest {
+		t.Fatalf("version after up = %d, want %d", version, latest)
+	}
+
+	if err := runMigrate([]string{"to", "-path", testMigrations, "--", "-1"}); err == nil {
+		t.Fatal("migrate to -1: expected an error")
+	}
+	if err := runMigrate([]string{"sideways", "-path", testMigrations}); err == nil {
+		t.Fatal("unknown migrate command: expected an error")
+	}
+}
+
+func TestSeed(t *testing.T) {
+	useTestDB(t)
+	ctx := context.Background()
+
+	// twice: the ids are fixed, the second run inserts nothing
+	for range 2 {
+		if err := runSeed(ctx); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+
+	store := openTestDB(t)
+	movies, err := store.Movies.GetMoviesAfter(ctx, uuid.Nil, 100)
+	if err != nil || len(movies) != bytes.Count(seedMovies, []byte("\n"))-1 {
+		t.Fatalf("%d movies seeded, %v, want one per row of seed/movies.csv", len(movies), err)
+	}
+	reviews, err := store.Reviews.GetReviewsAfter(ctx, uuid.Nil, 100)
+	if err != nil || len(reviews) != bytes.Count(seedReviews, []byte("\n"))-1 {
+		t.Fatalf("%d reviews seeded, %v, want one per row of seed/reviews.csv", len(reviews), err)
+	}
+}
+
+func TestUserCreate(t *testing.T) {
+	useTestDB(t)
+	ctx := context.Background()
+	t.Setenv("MOVIECTL_PASSWORD", apitest.Password)
+
+	if err := runUser(ctx, []string{"create", "-username", "root", "-admin"}); err != nil {
+		t.Fatalf("user create: %v", err)
+	}
+	if err := runUser(ctx, []string{"create", "-username", "alice", "-email", "alice@example.com"}); err != nil {
+		t.Fatalf("user create: %v", err)
+	}
+	// taken: nothing changes
+	if err := runUser(ctx, []string{"create", "-username", "alice", "-admin"}); err == nil {
+		t.Fatal("duplicate user: expected an error")
+	}
+
+	store := openTestDB(t)
+	for username, role := range map[string]domain.Role{"root": domain.Admin, "alice": domain.Regular} {
+		user, err := store.Users.FindUserByName(ctx, username)
+		if err != nil || user.Role != role {
+			t.Fatalf("%s = %+v, %v, want a %s", username, user, err, role)
+		}
+		if match, _ := auth.ComparePassword(apitest.Password, user.PasswordHash); !match {
+			t.Fatalf("%s: the password is not MOVIECTL_PASSWORD", username)
+		}
+	}
+
+	if err := runUser(ctx, []string{"create"}); err == nil {
+		t.Fatal("user create without -username: expected an error")
+	}
+}
+
+func TestImportExport(t *testing.T) {
+	useTestDB(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	input := filepath.Join(dir, "movies.ndjson")
+	movies := `{"id":"6f1c1a2e-0000-4000-8000-000000000001","title":"Heat","director":"Michael Mann","year":1995}` + "\n" +
+		`{"id":"6f1c1a2e-0000-4000-8000-000000000002","title":"Thief","director":"Michael Mann","year":1981}` + "\n"
+	if err := os.WriteFile(input, []byte(movies+`{"title":""}`+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// the invalid row fails the command, the valid ones are imported
+	if err := runImport(ctx, []string{"movies", input}); err == nil || !strings.Contains(err.Error(), "1 invalid rows") {
+		t.Fatalf("import = %v, want 1 invalid row", err)
+	}
+
+	output := filepath.Join(dir, "export.ndjson")
+	if err := runExport(ctx, []string{"movies", "-o", output}); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	exported, err := os.ReadFile(output)
+	if err != nil || string(exported) != movies {
+		t.Fatalf("export = %q, %v, want %q", exported, err, movies)
+	}
+
+	if err := runImport(ctx, []string{"directors", input}); err == nil {
+		t.Fatal("unknown import: expected an error")
+	}
+	if err := runExport(ctx, []string{"movies", "-format", "xml", "-o", output}); err == nil {
+		t.Fatal("unknown format: expected an error")
+	}
+}
+
+func TestRotateKeys(t *testing.T) {
+	t.Setenv("JWT_SECRET", "old-secret")
+	t.Setenv("JWT_PREVIOUS_SECRET", "")
+	userId := uuid.New()
+	oldToken, err := auth.GenerateAccessToken(userId, domain.Regular)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := runRotateKeys(nil, &out); err != nil {
+		t.Fatalf("rotate keys: %v", err)
+	}
+	env := parseEnv(t, out.String())
+	if env["JWT_PREVIOUS_SECRET"] != "old-secret" || len(env["JWT_SECRET"]) < 40 {
+		t.Fatalf("rotate keys printed %q", out.String())
+	}
+
+	// the API restarted with the new values
+	t.Setenv("JWT_SECRET", env["JWT_SECRET"])
+	t.Setenv("JWT_PREVIOUS_SECRET", env["JWT_PREVIOUS_SECRET"])
+	newToken, err := auth.GenerateAccessToken(userId, domain.Regular)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, token := range map[string]string{"old": oldToken, "new": newToken} {
+		if claims, err := auth.ValidateAccessToken(token); err != nil || claims.Subject != userId.String() {
+			t.Fatalf("%s token = %+v, %v, want it valid", name, claims, err)
+		}
+	}
+
+	// after a leak: the old tokens are rejected at once
+	out.Reset()
+	if err := runRotateKeys([]string{"-drop-previous"}, &out); err != nil {
+		t.Fatalf("rotate keys: %v", err)
+	}
+	env = parseEnv(t, out.String())
+	t.Setenv("JWT_SECRET", env["JWT_SECRET"])
+	t.Setenv("JWT_PREVIOUS_SECRET", env["JWT_PREVIOUS_SECRET"])
+	if _, err := auth.ValidateAccessToken(newToken); err == nil {
+		t.Fatal("token of a dropped secret accepted")
+	}
+
+	t.Setenv("JWT_SECRET", "")
+	if err := runRotateKeys(nil, &out); err == nil {
+		t.Fatal("rotate keys without JWT_SECRET: expected an error")
+	}
+}
+
+func TestRedisCommandsWithMemoryCache(t *testing.T) {
+	useTestDB(t)
+	ctx := context.Background()
+
+	// CACHE_BACKEND=memory: the cache lives in the API process
+	if err := runCache(ctx, []string{"flush"}); err == nil || !strings.Contains(err.Error(), "CACHE_BACKEND=memory") {
+		t.Fatalf("cache flush = %v, want the CACHE_BACKEND error", err)
+	}
+	if err := runSessions(ctx, []string{"purge"}); err == nil || !strings.Contains(err.Error(), "CACHE_BACKEND=memory") {
+		t.Fatalf("sessions purge = %v, want the CACHE_BACKEND error", err)
+	}
+	if err := runCache(ctx, []string{"drop"}); err == nil || err.Error() != usage {
+		t.Fatalf("cache drop = %v, want the usage", err)
+	}
+}
+
+// KEY=value lines
+func parseEnv(t *testing.T, out string) map[string]string {
+	t.Helper()
+	env := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			t.Fatalf("not a KEY=value line: %q", line)
+		}
+		env[key] = value
+	}
+	return env
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/grainme/movie-api/internal/auth"
+	"github.com/grainme/movie-api/internal/cache"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/mailer"
+	"github.com/grainme/movie-api/internal/service"
+)
+
+// the user service needs sessions only for the sessions command, the others
+// get an in-memory store nobody reads
+func newUserService(sessions cache.SessionStore) (*service.UserService, func(), error) {
+	// same password policy as the API
+	passwordParams, err := auth.PasswordParamsFromEnv()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid password hashing params: %w", err)
+	}
+	auth.SetPasswordParams(passwordParams)
+	if breachList := os.Getenv("BREACHED_PASSWORDS_FILE"); breachList != "" {
+		if _, err := auth.LoadBreachedPasswords(breachList); err != nil {
+			return nil, nil, fmt.Errorf("unable to load breached passwords: %w", err)
+		}
+	}
+
+	store, err := openStorage()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	auditLogger := service.NewAuditLogger(store.Audit)
+	userService := service.NewUserService(store.Users, sessions, mailer.NewLogMailer(), auditLogger)
+	return userService, func() { store.Close() }, nil
+}
+
+// moviectl user create -username NAME [-email EMAIL] [-admin] [-password-stdin]
+func runUser(ctx context.Context, args []string) error {
+	if len(args) < 1 || args[0] != "create" {
+		return errors.New(usage)
+	}
+
+	flags := flag.NewFlagSet("user create", flag.ExitOnError)
+	username := flags.String("username", "", "username (required)")
+	email := flags.String("email", "", "email, for password recovery")
+	admin := flags.Bool("admin", false, "create an admin")
+	passwordStdin := flags.Bool("password-stdin", false, "read the password from the first line of stdin")
+	flags.Parse(args[1:])
+	if *username == "" {
+		return errors.New("-username is required")
+	}
+
+	// stdin, or MOVIECTL_PASSWORD, or a generated one (printed once)
+	password := os.Getenv("MOVIECTL_PASSWORD")
+	generated := false
+	if *passwordStdin {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("reading the password: %w", err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+	if password == "" {
+		var err error
+		if password, err = generatePassword(); err != nil {
+			return err
+		}
+		generated = true
+	}
+
+	role := domain.Regular
+	if *admin {
+		role = domain.Admin
+	}
+
+	userService, closeDB, err := newUserService(cache.NewMemorySessionStore())
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	user, err := userService.CreateUser(ctx, domain.CreateUserRequest{
+		Username: *username,
+		Password: password,
+		Email:    *email,
+	}, role)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("created %s user %s (%s)", user.Role, user.Username, user.ID)
+	if generated {
+		// stdout: the only place it's ever shown
+		fmt.Println(password)
+	}
+	return nil
+}
+
+// 144 random bits
+func generatePassword() (string, error) {
+	buf := make([]byte, 18)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// moviectl sessions purge [-user NAME]
+func runSessions(ctx context.Context, args []string) error {
+	if len(args) < 1 || args[0] != "purge" {
+		return errors.New(usage)
+	}
+
+	flags := flag.NewFlagSet("sessions purge", flag.ExitOnError)
+	username := flags.String("user", "", "only this user's sessions (default: everyone)")
+	flags.Parse(args[1:])
+
+	rdb, err := openRedis()
+	if err != nil {
+		return err
+	}
+	defer rdb.Close()
+
+	userService, closeDB, err := newUserService(cache.NewRedisSessionStore(rdb))
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	deleted, err := userService.PurgeSessions(ctx, *username)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("deleted %d session keys, the access tokens already issued stay valid until they expire", deleted)
+	return nil
+}
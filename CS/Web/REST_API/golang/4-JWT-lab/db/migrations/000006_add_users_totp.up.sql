@@ -0,0 +1,13 @@
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS totp_secret TEXT,
+ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- recovery codes are single-use, we keep the used ones (used_at) for auditing
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+  id UUID PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  code_hash TEXT NOT NULL,
+  used_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS user_recovery_codes_user_index ON user_recovery_codes (user_id);
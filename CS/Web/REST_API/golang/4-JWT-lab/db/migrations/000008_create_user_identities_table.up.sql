@@ -0,0 +1,12 @@
+-- accounts at external OpenID Connect providers linked to local users
+-- (provider = issuer URL, subject = the provider's stable user id)
+CREATE TABLE IF NOT EXISTS user_identities (
+  provider TEXT NOT NULL,
+  subject TEXT NOT NULL,
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  email TEXT,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (provider, subject)
+);
+
+CREATE INDEX IF NOT EXISTS user_identities_user_index ON user_identities (user_id);
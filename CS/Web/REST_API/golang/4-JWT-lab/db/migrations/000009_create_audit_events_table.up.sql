@@ -0,0 +1,35 @@
+-- security audit trail: who did what, to what, from where, and did it work.
+-- no foreign keys on purpose: events must outlive the users/movies they mention.
+CREATE TABLE IF NOT EXISTS audit_events (
+  id UUID PRIMARY KEY,
+  occurred_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  actor_id UUID,
+  actor_name TEXT,
+  api_key_id UUID,
+  action TEXT NOT NULL,
+  target_type TEXT,
+  target_id TEXT,
+  ip TEXT,
+  user_agent TEXT,
+  outcome TEXT NOT NULL CHECK (outcome IN ('success', 'failure', 'denied')),
+  detail TEXT
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_occurred_at_index ON audit_events (occurred_at DESC);
+CREATE INDEX IF NOT EXISTS audit_events_actor_index ON audit_events (actor_id, occurred_at DESC);
+CREATE INDEX IF NOT EXISTS audit_events_action_index ON audit_events (action, occurred_at DESC);
+
+-- append-only: even the application user can't rewrite history
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS TRIGGER AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_no_update_delete
+BEFORE UPDATE OR DELETE ON audit_events
+FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+CREATE TRIGGER audit_events_no_truncate
+BEFORE TRUNCATE ON audit_events
+FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS movies_views_index;
+
+ALTER TABLE movies
+DROP COLUMN IF EXISTS views;
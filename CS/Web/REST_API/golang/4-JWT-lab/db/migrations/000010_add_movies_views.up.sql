@@ -0,0 +1,5 @@
+-- views are counted in the cache and flushed here in batches (see ViewFlusher)
+ALTER TABLE movies
+ADD COLUMN IF NOT EXISTS views BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS movies_views_index ON movies (views DESC, id);
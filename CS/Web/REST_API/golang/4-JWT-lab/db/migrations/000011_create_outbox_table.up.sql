@@ -0,0 +1,29 @@
+-- transactional outbox: the domain events, written in the same transaction as
+-- the change, published to RabbitMQ by the relay (see internal/outbox).
+-- sequence is the publishing order (per aggregate: an aggregate waits for its
+-- oldest event). published rows are kept a while, then pruned by the relay.
+-- sequence is the insert order, not the commit order: a transaction can commit
+-- after one with a higher sequence. the relay reads every unpublished row (no
+-- "sequence > last" cursor), so a late commit is published late, never lost;
+-- the events of one aggregate are serialized by its row lock (see
+-- internal/outbox). unpublished events are pruned too when there is no broker.
+CREATE TABLE IF NOT EXISTS outbox (
+  sequence BIGSERIAL PRIMARY KEY,
+  id UUID NOT NULL UNIQUE,
+  event_type TEXT NOT NULL,
+  aggregate_type TEXT NOT NULL,
+  aggregate_id UUID NOT NULL,
+  payload JSONB NOT NULL,
+  occurred_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  published_at TIMESTAMP,
+  attempts INT NOT NULL DEFAULT 0,
+  last_error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS outbox_unpublished_index ON outbox (sequence)
+WHERE
+  published_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS outbox_published_at_index ON outbox (published_at)
+WHERE
+  published_at IS NOT NULL;
@@ -0,0 +1,35 @@
+-- outgoing webhooks: the partners' subscriptions, and one delivery per event
+-- and webhook.
+-- secret: the HMAC-SHA256 key of the signatures. kept in clear, unlike the
+-- api keys: signing needs it. only shown once at creation
+CREATE TABLE IF NOT EXISTS webhooks (
+  id UUID PRIMARY KEY,
+  url TEXT NOT NULL,
+  events TEXT[] NOT NULL DEFAULT '{}',
+  secret TEXT NOT NULL,
+  created_by UUID REFERENCES users (id) ON DELETE SET NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- the deliveries are the retry queue and the history at once: pending ones
+-- wait for next_attempt_at, dead ones gave up (redelivered by hand)
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+  id UUID PRIMARY KEY,
+  webhook_id UUID NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+  event_id UUID NOT NULL,
+  event_type TEXT NOT NULL,
+  payload JSONB NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'dead')),
+  attempts INT NOT NULL DEFAULT 0,
+  next_attempt_at TIMESTAMP NOT NULL,
+  last_attempt_at TIMESTAMP,
+  response_status INT NOT NULL DEFAULT 0,
+  last_error TEXT,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_index ON webhook_deliveries (next_attempt_at)
+WHERE
+  status = 'pending';
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_index ON webhook_deliveries (webhook_id, created_at DESC);
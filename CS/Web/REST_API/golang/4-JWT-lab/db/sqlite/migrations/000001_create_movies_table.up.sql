@@ -0,0 +1,8 @@
+-- same schema as db/migrations (Postgres), in SQLite types:
+-- uuids are TEXT (lowercase, 36 chars: sorted as text they keep the Postgres uuid order)
+CREATE TABLE IF NOT EXISTS movies (
+  id TEXT PRIMARY KEY,
+  title TEXT NOT NULL,
+  director TEXT NOT NULL,
+  year INTEGER NOT NULL
+);
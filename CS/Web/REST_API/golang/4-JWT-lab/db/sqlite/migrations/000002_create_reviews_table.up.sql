@@ -0,0 +1,12 @@
+-- reviews table: id, movie_id (FOREIGN KEY), user_name, rating (1-10), comment
+-- (foreign keys are only enforced with PRAGMA foreign_keys = ON, see storage.Open)
+CREATE TABLE IF NOT EXISTS reviews (
+  id TEXT PRIMARY KEY,
+  user_name TEXT NOT NULL,
+  rating INTEGER NOT NULL CHECK (
+    rating >= 1
+    AND rating <= 10
+  ),
+  comment TEXT,
+  movie_id TEXT NOT NULL REFERENCES movies (id)
+);
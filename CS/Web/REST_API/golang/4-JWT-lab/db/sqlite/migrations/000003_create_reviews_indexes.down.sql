@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS movie_review_index;
+
+DROP INDEX IF EXISTS review_index;
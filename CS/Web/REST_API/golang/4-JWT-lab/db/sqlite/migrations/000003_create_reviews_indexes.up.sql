@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS movie_review_index ON REVIEWS (movie_id);
+
+CREATE INDEX IF NOT EXISTS review_index ON REVIEWS (rating);
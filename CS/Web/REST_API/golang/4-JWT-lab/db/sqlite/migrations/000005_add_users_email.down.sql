@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS users_email_index;
+
+ALTER TABLE users
+DROP COLUMN email;
@@ -0,0 +1,6 @@
+-- SQLite can't add a UNIQUE column: the column, then a unique index
+-- (NULLs are distinct, several users can have no email)
+ALTER TABLE users
+ADD COLUMN email TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_index ON users (email);
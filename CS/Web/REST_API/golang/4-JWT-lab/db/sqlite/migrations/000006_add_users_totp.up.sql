@@ -0,0 +1,16 @@
+-- one column per ALTER TABLE in SQLite. booleans are 0/1
+ALTER TABLE users
+ADD COLUMN totp_secret TEXT;
+
+ALTER TABLE users
+ADD COLUMN totp_enabled BOOLEAN NOT NULL DEFAULT 0;
+
+-- recovery codes are single-use, we keep the used ones (used_at) for auditing
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+  id TEXT PRIMARY KEY,
+  user_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  code_hash TEXT NOT NULL,
+  used_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS user_recovery_codes_user_index ON user_recovery_codes (user_id);
@@ -0,0 +1,34 @@
+-- security audit trail: who did what, to what, from where, and did it work.
+-- no foreign keys on purpose: events must outlive the users/movies they mention.
+CREATE TABLE IF NOT EXISTS audit_events (
+  id TEXT PRIMARY KEY,
+  occurred_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  actor_id TEXT,
+  actor_name TEXT,
+  api_key_id TEXT,
+  action TEXT NOT NULL,
+  target_type TEXT,
+  target_id TEXT,
+  ip TEXT,
+  user_agent TEXT,
+  outcome TEXT NOT NULL CHECK (outcome IN ('success', 'failure', 'denied')),
+  detail TEXT
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_occurred_at_index ON audit_events (occurred_at DESC);
+CREATE INDEX IF NOT EXISTS audit_events_actor_index ON audit_events (actor_id, occurred_at DESC);
+CREATE INDEX IF NOT EXISTS audit_events_action_index ON audit_events (action, occurred_at DESC);
+
+-- append-only: even the application can't rewrite history
+-- (no TRUNCATE in SQLite, a DELETE without WHERE fires the trigger too)
+CREATE TRIGGER IF NOT EXISTS audit_events_no_update
+BEFORE UPDATE ON audit_events
+BEGIN
+  SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS audit_events_no_delete
+BEFORE DELETE ON audit_events
+BEGIN
+  SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
@@ -0,0 +1,26 @@
+-- transactional outbox: the domain events, written in the same transaction as
+-- the change, published to RabbitMQ by the relay (see internal/outbox).
+-- sequence is the publishing order (per aggregate: an aggregate waits for its
+-- oldest event). published rows are kept a while, then pruned by the relay.
+-- one writer at a time: the sequence is also the commit order here.
+-- unpublished events are pruned too when there is no broker.
+CREATE TABLE IF NOT EXISTS outbox (
+  sequence INTEGER PRIMARY KEY AUTOINCREMENT,
+  id TEXT NOT NULL UNIQUE,
+  event_type TEXT NOT NULL,
+  aggregate_type TEXT NOT NULL,
+  aggregate_id TEXT NOT NULL,
+  payload TEXT NOT NULL,
+  occurred_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  published_at TIMESTAMP,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  last_error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS outbox_unpublished_index ON outbox (sequence)
+WHERE
+  published_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS outbox_published_at_index ON outbox (published_at)
+WHERE
+  published_at IS NOT NULL;
@@ -0,0 +1,36 @@
+-- outgoing webhooks: the partners' subscriptions, and one delivery per event
+-- and webhook.
+-- secret: the HMAC-SHA256 key of the signatures. kept in clear, unlike the
+-- api keys: signing needs it. only shown once at creation
+-- events: no arrays in SQLite, a JSON array of strings
+CREATE TABLE IF NOT EXISTS webhooks (
+  id TEXT PRIMARY KEY,
+  url TEXT NOT NULL,
+  events TEXT NOT NULL DEFAULT '[]',
+  secret TEXT NOT NULL,
+  created_by TEXT REFERENCES users (id) ON DELETE SET NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- the deliveries are the retry queue and the history at once: pending ones
+-- wait for next_attempt_at, dead ones gave up (redelivered by hand)
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+  id TEXT PRIMARY KEY,
+  webhook_id TEXT NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+  event_id TEXT NOT NULL,
+  event_type TEXT NOT NULL,
+  payload TEXT NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'dead')),
+  attempts INTEGER NOT NULL DEFAULT 0,
+  next_attempt_at TIMESTAMP NOT NULL,
+  last_attempt_at TIMESTAMP,
+  response_status INTEGER NOT NULL DEFAULT 0,
+  last_error TEXT,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_index ON webhook_deliveries (next_attempt_at)
+WHERE
+  status = 'pending';
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_index ON webhook_deliveries (webhook_id, created_at DESC);
@@ -0,0 +1,28 @@
+// Package apitest is the setup shared by the tests that run the API (REST,
+// gRPC, GraphQL, the client, moviectl)
+package apitest
+
+import (
+	"io"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/alexedwards/argon2id"
+	"github.com/grainme/movie-api/internal/auth"
+)
+
+// Password passes the password policy
+const Password = "correct horse battery"
+
+// Main is the TestMain of these packages:
+//   - the handlers log every failure, the test output is enough
+//   - the production argon2 cost makes every register/login take ~100ms
+//   - the access tokens need a secret
+func Main(m *testing.M) {
+	log.SetOutput(io.Discard)
+	auth.SetPasswordParams(&argon2id.Params{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+	os.Setenv("JWT_SECRET", "test-secret")
+
+	os.Exit(m.Run())
+}
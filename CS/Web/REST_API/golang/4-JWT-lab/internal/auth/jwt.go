@@ -1,8 +1,12 @@
 package auth
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"os"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -13,6 +17,49 @@ import (
 type Claims struct {
 	jwt.RegisteredClaims
 	Role string `json:"role"`
+	// true when the session passed the second factor (TOTP/recovery code)
+	MFA bool `json:"mfa,omitempty"`
+
+	// only set for API key principals (X-API-Key), never in a JWT
+	APIKeyID string              `json:"-"`
+	Scopes   []domain.Permission `json:"-"`
+}
+
+// users can do what their role allows, API keys only what they were scoped to
+func (c *Claims) HasPermission(p domain.Permission) bool {
+	if c.APIKeyID != "" {
+		return slices.Contains(c.Scopes, p)
+	}
+
+	return domain.Role(c.Role).Can(p)
+}
+
+// the refusals of Authorize, their text is the reason given to the caller
+var (
+	ErrAdminOnly         = errors.New("admin only")
+	ErrMFARequired       = errors.New("two-factor authentication required")
+	ErrMissingPermission = errors.New("missing permission")
+)
+
+// Authorize is the access check of every API (the REST middlewares, gRPC,
+// GraphQL): admin asks for an admin role, and its second factor with
+// REQUIRE_ADMIN_MFA=true; permission ("" for none) is checked with
+// HasPermission.
+func (c *Claims) Authorize(permission domain.Permission, admin bool) error {
+	if admin {
+		if c.Role != string(domain.Admin) {
+			return ErrAdminOnly
+		}
+		// API keys have no second factor, they are scoped instead
+		if os.Getenv("REQUIRE_ADMIN_MFA") == "true" && !c.MFA && c.APIKeyID == "" {
+			return ErrMFARequired
+		}
+	}
+	if permission != "" && !c.HasPermission(permission) {
+		return fmt.Errorf("%w %s", ErrMissingPermission, permission)
+	}
+
+	return nil
 }
 
 func GenerateRefreshToken() uuid.UUID {
@@ -26,6 +73,16 @@ func GenerateRefreshToken() uuid.UUID {
 // - userId UUID, role string as params
 // code design question? - it does not matter? - don't give a function more than it needs?
 func GenerateAccessToken(userId uuid.UUID, role domain.Role) (string, error) {
+	return generateAccessToken(userId, role, false)
+}
+
+// same token, but it says "this user also proved the second factor"
+// (middleware.Authorize can require it for admins)
+func GenerateMFAAccessToken(userId uuid.UUID, role domain.Role) (string, error) {
+	return generateAccessToken(userId, role, true)
+}
+
+func generateAccessToken(userId uuid.UUID, role domain.Role, mfa bool) (string, error) {
 	claims := Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
 			Subject:   userId.String(), // who the token is about?
@@ -33,10 +90,13 @@ func GenerateAccessToken(userId uuid.UUID, role domain.Role) (string, error) {
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 		Role: string(role),
+		MFA:  mfa,
 	}
 	secretKey := os.Getenv("JWT_SECRET")
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	// which secret signed it, see ValidateAccessToken
+	token.Header["kid"] = SigningKeyID(secretKey)
 
 	// This creates the signature using `HMAC(header + payload, secretKey)` and appends it:
 	// eyJhbGc...header.eyJ1c2Vy...payload.SflKxw...signature
@@ -50,10 +110,24 @@ func GenerateAccessToken(userId uuid.UUID, role domain.Role) (string, error) {
 	return tokenString, nil
 }
 
+// SigningKeyID names a secret without giving it away: the start of its sha256
+func SigningKeyID(secretKey string) string {
+	sum := sha256.Sum256([]byte(secretKey))
+	return hex.EncodeToString(sum[:8])
+}
+
+// key rotation (moviectl rotate-keys): JWT_SECRET signs the new tokens,
+// JWT_PREVIOUS_SECRET (optional) still verifies the ones signed before the
+// rotation, until they expire. the kid header says which one to use, the
+// tokens without a kid are checked with JWT_SECRET.
 func ValidateAccessToken(tokenString string) (*Claims, error) {
 	secretKey := os.Getenv("JWT_SECRET")
+	previousKey := os.Getenv("JWT_PREVIOUS_SECRET")
 
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(t *jwt.Token) (any, error) {
+		if kid, _ := t.Header["kid"].(string); previousKey != "" && kid == SigningKeyID(previousKey) {
+			return []byte(previousKey), nil
+		}
 		return []byte(secretKey), nil
 	})
 	if err != nil {
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+func TestAuthorize(t *testing.T) {
+	regular := &Claims{Role: string(domain.Regular)}
+	admin := &Claims{Role: string(domain.Admin)}
+	mfaAdmin := &Claims{Role: string(domain.Admin), MFA: true}
+	// an admin's key, scoped to the writes
+	apiKey := &Claims{Role: string(domain.Admin), APIKeyID: "key", Scopes: []domain.Permission{domain.PermMoviesWrite}}
+
+	tests := []struct {
+		name       string
+		claims     *Claims
+		permission domain.Permission
+		admin      bool
+		requireMFA bool
+		want       error
+	}{
+		{"regular writes", regular, domain.PermMoviesWrite, false, false, nil},
+		{"regular deletes", regular, domain.PermMoviesDelete, false, false, ErrMissingPermission},
+		{"regular on an admin route", regular, "", true, false, ErrAdminOnly},
+		{"admin deletes", admin, domain.PermMoviesDelete, true, false, nil},
+		{"admin without mfa", admin, domain.PermMoviesDelete, true, true, ErrMFARequired},
+		{"admin with mfa", mfaAdmin, domain.PermMoviesDelete, true, true, nil},
+		// no second factor for the keys, the scopes instead
+		{"api key in scope", apiKey, domain.PermMoviesWrite, true, true, nil},
+		{"api key out of scope", apiKey, domain.PermMoviesDelete, true, true, ErrMissingPermission},
+		{"mfa only for admin routes", admin, domain.PermMoviesWrite, false, true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.requireMFA {
+				t.Setenv("REQUIRE_ADMIN_MFA", "true")
+			}
+			err := tt.claims.Authorize(tt.permission, tt.admin)
+			if !errors.Is(err, tt.want) || (tt.want == nil) != (err == nil) {
+				t.Fatalf("Authorize(%q, %t) = %v, want %v", tt.permission, tt.admin, err, tt.want)
+			}
+		})
+	}
+
+	if err := regular.Authorize(domain.PermMoviesDelete, false); err.Error() != "missing permission movies:delete" {
+		t.Fatalf("reason = %q, want it to name the permission", err)
+	}
+}
@@ -1,9 +1,69 @@
 package auth
 
-import "github.com/alexedwards/argon2id"
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/alexedwards/argon2id"
+)
+
+// RFC 9106 "second recommended option": t=3, m=64MiB, p=4.
+// we don't use argon2id.DefaultParams: its parallelism is runtime.NumCPU(),
+// so two replicas with different CPU counts would disagree on what "outdated" is.
+var passwordParams = &argon2id.Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 4,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// SetPasswordParams changes the cost for new hashes. existing hashes keep
+// working (the params are stored in the hash itself) and get upgraded
+// on the next successful login, see NeedsRehash.
+func SetPasswordParams(params *argon2id.Params) {
+	passwordParams = params
+}
+
+// ARGON2_MEMORY (KiB), ARGON2_ITERATIONS, ARGON2_PARALLELISM
+// unset variables keep the current value
+func PasswordParamsFromEnv() (*argon2id.Params, error) {
+	params := *passwordParams
+
+	if v := os.Getenv("ARGON2_MEMORY"); v != "" {
+		memory, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ARGON2_MEMORY: %w", err)
+		}
+		params.Memory = uint32(memory)
+	}
+
+	if v := os.Getenv("ARGON2_ITERATIONS"); v != "" {
+		iterations, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ARGON2_ITERATIONS: %w", err)
+		}
+		params.Iterations = uint32(iterations)
+	}
+
+	if v := os.Getenv("ARGON2_PARALLELISM"); v != "" {
+		parallelism, err := strconv.ParseUint(v, 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ARGON2_PARALLELISM: %w", err)
+		}
+		params.Parallelism = uint8(parallelism)
+	}
+
+	if params.Memory < 8*uint32(params.Parallelism) || params.Iterations < 1 || params.Parallelism < 1 {
+		return nil, fmt.Errorf("invalid argon2 params: m=%d t=%d p=%d", params.Memory, params.Iterations, params.Parallelism)
+	}
+
+	return &params, nil
+}
 
 func HashPassword(plainPassword string) (string, error) {
-	hash, err := argon2id.CreateHash(plainPassword, argon2id.DefaultParams)
+	hash, err := argon2id.CreateHash(plainPassword, passwordParams)
 	return hash, err
 }
 
@@ -11,3 +71,20 @@ func ComparePassword(plainPassword string, hashedPassword string) (bool, error)
 	match, err := argon2id.ComparePasswordAndHash(plainPassword, hashedPassword)
 	return match, err
 }
+
+// NeedsRehash reads the params out of "$argon2id$v=19$m=65536,t=1,p=4$SALT$HASH"
+// and tells if they differ from the current ones.
+// the only moment we can rehash is right after a successful login:
+// it's the only time we see the plain password.
+func NeedsRehash(hashedPassword string) bool {
+	params, salt, key, err := argon2id.DecodeHash(hashedPassword)
+	if err != nil {
+		return true
+	}
+
+	return params.Memory != passwordParams.Memory ||
+		params.Iterations != passwordParams.Iterations ||
+		params.Parallelism != passwordParams.Parallelism ||
+		uint32(len(salt)) != passwordParams.SaltLength ||
+		uint32(len(key)) != passwordParams.KeyLength
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+// NIST SP 800-63B: length + "not a known breached password" beats
+// composition rules (one uppercase, one digit...) that just give "Password1!"
+const (
+	minPasswordLength = 8
+	// argon2 doesn't care, but hashing a 1MB "password" is a cheap DoS
+	maxPasswordLength = 128
+)
+
+// always rejected, even without a breach list file
+var breachedPasswords = map[string]struct{}{
+	"password": {}, "password1": {}, "password123": {}, "12345678": {},
+	"123456789": {}, "1234567890": {}, "qwertyuiop": {}, "qwerty123": {},
+	"iloveyou": {}, "sunshine": {}, "princess": {}, "football": {},
+	"baseball": {}, "welcome1": {}, "admin123": {}, "letmein1": {},
+	"11111111": {}, "abc12345": {}, "passw0rd": {}, "trustno1": {},
+}
+
+// LoadBreachedPasswords adds a breach list (one password per line,
+// e.g. a top-N list from a known leak) to the built-in one.
+func LoadBreachedPasswords(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	count := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		breachedPasswords[strings.ToLower(line)] = struct{}{}
+		count++
+	}
+
+	return count, scanner.Err()
+}
+
+// PasswordTooLong is checked on login, before the hash comparison: no
+// account can have such a password, and argon2 would still hash it all
+func PasswordTooLong(password string) bool {
+	return utf8.RuneCountInString(password) > maxPasswordLength
+}
+
+// ValidatePasswordStrength is checked on register and on password reset.
+// errors wrap domain.ErrWeakPassword / domain.ErrBreachedPassword.
+func ValidatePasswordStrength(password, username string) error {
+	length := utf8.RuneCountInString(password)
+	if length < minPasswordLength {
+		return fmt.Errorf("%w: at least %d characters", domain.ErrWeakPassword, minPasswordLength)
+	}
+	if length > maxPasswordLength {
+		return fmt.Errorf("%w: at most %d characters", domain.ErrWeakPassword, maxPasswordLength)
+	}
+
+	lower := strings.ToLower(password)
+	if username != "" && strings.Contains(lower, strings.ToLower(username)) {
+		return fmt.Errorf("%w: must not contain the username", domain.ErrWeakPassword)
+	}
+
+	// "aaaaaaaa"
+	if strings.Count(password, string([]rune(password)[0])) == length {
+		return fmt.Errorf("%w: must not be a single repeated character", domain.ErrWeakPassword)
+	}
+
+	if _, found := breachedPasswords[lower]; found {
+		return domain.ErrBreachedPassword
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+func TestValidatePasswordStrength(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		username string
+		want     error
+	}{
+		{"ok", "correct horse battery", "alice", nil},
+		{"too short", "short", "", domain.ErrWeakPassword},
+		{"shortest ok", "zq8#kW2m", "", nil},
+		// runes, not bytes: 8 accented letters are 16 bytes
+		{"short in runes", "ééééééé", "", domain.ErrWeakPassword},
+		{"longest ok", strings.Repeat("ab", maxPasswordLength/2), "", nil},
+		{"too long", strings.Repeat("ab", maxPasswordLength/2) + "c", "", domain.ErrWeakPassword},
+		{"contains the username", "my name is Alice!", "alice", domain.ErrWeakPassword},
+		{"repeated character", "aaaaaaaaaa", "", domain.ErrWeakPassword},
+		{"breached", "Password123", "", domain.ErrBreachedPassword},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePasswordStrength(tt.password, tt.username)
+			if tt.want == nil && err != nil {
+				t.Fatalf("ValidatePasswordStrength(%q) = %v, want nil", tt.password, err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("ValidatePasswordStrength(%q) = %v, want %v", tt.password, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPasswordTooLong(t *testing.T) {
+	if PasswordTooLong(strings.Repeat("é", maxPasswordLength)) {
+		t.Fatalf("%d runes should be accepted", maxPasswordLength)
+	}
+	if !PasswordTooLong(strings.Repeat("a", maxPasswordLength+1)) {
+		t.Fatalf("%d runes should be rejected", maxPasswordLength+1)
+	}
+}
+
+func TestLoadBreachedPasswords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(path, []byte("Hunter2Hunter2\n\n  dragonfly99  \n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		delete(breachedPasswords, "hunter2hunter2")
+		delete(breachedPasswords, "dragonfly99")
+	})
+
+	count, err := LoadBreachedPasswords(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("loaded %d passwords, want 2", count)
+	}
+
+	// case insensitive, like the built-in list
+	for _, password := range []string{"hunter2hunter2", "DRAGONFLY99"} {
+		if err := ValidatePasswordStrength(password, ""); !errors.Is(err, domain.ErrBreachedPassword) {
+			t.Fatalf("ValidatePasswordStrength(%q) = %v, want %v", password, err, domain.ErrBreachedPassword)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// webhook secrets look like "whsec_<random>", easy to spot like the api keys
+const webhookSecretPrefix = "whsec_"
+
+func GenerateWebhookSecret() (string, error) {
+	secret, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	return webhookSecretPrefix + secret, nil
+}
+
+// SignWebhook is the X-Webhook-Signature of a delivery: "sha256=" and the hex
+// HMAC-SHA256 of "<timestamp>.<body>". the timestamp is signed too, so a
+// receiver can refuse old (replayed) deliveries.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature is the receiver's side of SignWebhook (constant time)
+func VerifyWebhookSignature(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(SignWebhook(secret, timestamp, body)), []byte(signature))
+}
@@ -0,0 +1,185 @@
+package bulk
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+func TestRoundTrip(t *testing.T) {
+	comment := "great, \"really\"\nsecond line"
+	movies := []domain.Movie{
+		{ID: uuid.New(), Title: "Heat", Director: "Michael Mann", Year: 1995},
+		{ID: uuid.New(), Title: "Ronin, the movie"},
+	}
+	reviews := []domain.Review{
+		{ID: uuid.New(), MovieID: movies[0].ID, Username: "alice", Rating: 5, Comment: &comment},
+		{ID: uuid.New(), MovieID: movies[1].ID, Username: "bob", Rating: 3},
+	}
+
+	for _, format := range []Format{CSV, NDJSON} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			writer := NewMovieWriter(&buf, format)
+			for _, movie := range movies {
+				if err := writer.Write(movie); err != nil {
+					t.Fatalf("write movie: %v", err)
+				}
+			}
+			if err := writer.Flush(); err != nil {
+				t.Fatalf("flush: %v", err)
+			}
+			if got := readAll(t, NewMovieReader(&buf, format)); !reflect.DeepEqual(got, movies) {
+				t.Fatalf("movies = %+v, want %+v", got, movies)
+			}
+
+			buf.Reset()
+			reviewWriter := NewReviewWriter(&buf, format)
+			for _, review := range reviews {
+				if err := reviewWriter.Write(review); err != nil {
+					t.Fatalf("write review: %v", err)
+				}
+			}
+			if err := reviewWriter.Flush(); err != nil {
+				t.Fatalf("flush: %v", err)
+			}
+			if got := readAll(t, NewReviewReader(&buf, format)); !reflect.DeepEqual(got, reviews) {
+				t.Fatalf("reviews = %+v, want %+v", got, reviews)
+			}
+		})
+	}
+}
+
+func TestEmptyExport(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewMovieWriter(&buf, CSV).Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	// still a valid file: the header
+	if buf.String() != "id,title,director,year\n" {
+		t.Fatalf("empty csv = %q", buf.String())
+	}
+	if got := readAll(t, NewMovieReader(&buf, CSV)); len(got) != 0 {
+		t.Fatalf("movies = %+v, want none", got)
+	}
+}
+
+func TestRowErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		// the line of each row error, 0 for a record read
+		lines []int
+	}{
+		{
+			name: "csv",
+			// the columns in any order, a quoted field over two lines
+			input: "year,title\n" +
+				"1995,Heat\n" +
+				"soon,Thief\n" +
+				"2004,\"Collateral\nthe movie\"\n" +
+				"not a year,Ronin\n" +
+				"1998,Ronin\n",
+			lines: []int{0, 3, 0, 6, 0},
+		},
+		{
+			name:  "csv missing columns",
+			input: "title,year\nHeat\n",
+			lines: []int{0},
+		},
+		{
+			name: "ndjson",
+			// blank lines are skipped but still counted
+			input: `{"title":"Heat","year":1995}` + "\n" +
+				"\n" +
+				`{"title":"Thief","year":"soon"}` + "\n" +
+				"not json\n" +
+				`{"title":"Ronin"}`,
+			lines: []int{0, 3, 4, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format := CSV
+			if strings.HasPrefix(tt.name, "ndjson") {
+				format = NDJSON
+			}
+			reader := NewMovieReader(strings.NewReader(tt.input), format)
+
+			var lines []int
+			for {
+				_, err := reader.Next()
+				if err == io.EOF {
+					break
+				}
+				var rowErr *RowError
+				if err != nil && !errors.As(err, &rowErr) {
+					t.Fatalf("fatal error on a bad row: %v", err)
+				}
+				line := 0
+				if rowErr != nil {
+					line = rowErr.Line
+					if reader.Line() != line {
+						t.Fatalf("Line() = %d, the row error says %d", reader.Line(), line)
+					}
+				}
+				lines = append(lines, line)
+			}
+			if !reflect.DeepEqual(lines, tt.lines) {
+				t.Fatalf("rows = %v, want %v", lines, tt.lines)
+			}
+		})
+	}
+}
+
+func TestBadHeader(t *testing.T) {
+	// reviews need movie_id and rating
+	reader := NewReviewReader(strings.NewReader("movie_id,user_name\n"+uuid.NewString()+",alice\n"), CSV)
+	_, err := reader.Next()
+	var rowErr *RowError
+	if err == nil || err == io.EOF || errors.As(err, &rowErr) {
+		t.Fatalf("next = %v, want a fatal header error", err)
+	}
+	if !strings.Contains(err.Error(), `"rating"`) {
+		t.Fatalf("error = %q, want it to name the missing column", err)
+	}
+}
+
+func TestFormats(t *testing.T) {
+	for contentType, want := range map[string]Format{
+		"text/csv; charset=utf-8": CSV,
+		"application/x-ndjson":    NDJSON,
+		"application/ndjson":      NDJSON,
+		"application/json":        "",
+		"":                        "",
+	} {
+		if got := FormatFromContentType(contentType); got != want {
+			t.Errorf("FormatFromContentType(%q) = %q, want %q", contentType, got, want)
+		}
+	}
+	if _, err := ParseFormat("xml"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("ParseFormat(xml) = %v, want %v", err, ErrUnknownFormat)
+	}
+}
+
+func readAll[T any](t *testing.T, reader *Reader[T]) []T {
+	t.Helper()
+	var records []T
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			return records
+		}
+		if err != nil {
+			t.Fatalf("next, line %d: %v", reader.Line(), err)
+		}
+		records = append(records, record)
+	}
+}
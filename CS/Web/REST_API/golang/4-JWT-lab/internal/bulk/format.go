@@ -0,0 +1,58 @@
+package bulk
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+)
+
+// Format of an import or export stream
+type Format string
+
+const (
+	CSV    Format = "csv"    // a header row, then one row per record
+	NDJSON Format = "ndjson" // one JSON object per line
+)
+
+var ErrUnknownFormat = errors.New("unknown format, expected csv or ndjson")
+
+func ParseFormat(name string) (Format, error) {
+	switch Format(name) {
+	case CSV, NDJSON:
+		return Format(name), nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// FormatFromContentType: "text/csv" or "application/x-ndjson", "" otherwise
+func FormatFromContentType(contentType string) Format {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv":
+		return CSV
+	case "application/x-ndjson", "application/ndjson":
+		return NDJSON
+	}
+	return ""
+}
+
+func (f Format) ContentType() string {
+	if f == CSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+// RowError is one row that can't be decoded: the reader goes on with the next one
+type RowError struct {
+	Line int
+	Err  error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
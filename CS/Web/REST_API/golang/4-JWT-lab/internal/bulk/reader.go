@@ -0,0 +1,135 @@
+package bulk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// longest NDJSON line we accept
+const maxLineSize = 1 << 20
+
+// a record type: its CSV columns and how it converts to CSV and JSON
+type schema[T any] struct {
+	columns  []string
+	required []string
+	toRow    func(T) []string
+	fromRow  func(row map[string]string) (T, error)
+	toJSON   func(T) any
+	fromJSON func(data []byte) (T, error)
+}
+
+// Reader streams records from a CSV or NDJSON input, one at a time
+type Reader[T any] struct {
+	schema schema[T]
+	format Format
+
+	csv    *csv.Reader
+	header []string
+
+	lines *bufio.Scanner
+	line  int
+}
+
+func newReader[T any](r io.Reader, format Format, s schema[T]) *Reader[T] {
+	reader := &Reader[T]{schema: s, format: format}
+	if format == CSV {
+		reader.csv = csv.NewReader(r)
+		// missing columns are empty, extra ones are ignored
+		reader.csv.FieldsPerRecord = -1
+		reader.csv.ReuseRecord = true
+	} else {
+		reader.lines = bufio.NewScanner(r)
+		reader.lines.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	}
+	return reader
+}
+
+// Next returns the next record, a *RowError for a row that can't be decoded
+// (the next call goes on with the following row), or io.EOF at the end.
+// any other error is fatal (bad header, broken input).
+func (r *Reader[T]) Next() (T, error) {
+	if r.format == CSV {
+		return r.nextCSV()
+	}
+	return r.nextNDJSON()
+}
+
+// Line is the line of the last record returned
+func (r *Reader[T]) Line() int {
+	return r.line
+}
+
+func (r *Reader[T]) nextCSV() (T, error) {
+	var zero T
+
+	if r.header == nil {
+		header, err := r.csv.Read()
+		if err == io.EOF {
+			return zero, io.EOF
+		}
+		if err != nil {
+			return zero, fmt.Errorf("invalid header: %w", err)
+		}
+		r.header = slices.Clone(header)
+		for _, column := range r.schema.required {
+			if !slices.Contains(r.header, column) {
+				return zero, fmt.Errorf("invalid header: missing column %q", column)
+			}
+		}
+	}
+
+	record, err := r.csv.Read()
+	if err == io.EOF {
+		return zero, io.EOF
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		r.line = parseErr.StartLine
+		return zero, &RowError{Line: r.line, Err: parseErr.Err}
+	}
+	if err != nil {
+		return zero, err
+	}
+	r.line, _ = r.csv.FieldPos(0)
+
+	row := make(map[string]string, len(r.header))
+	for idx, column := range r.header {
+		if idx < len(record) {
+			row[column] = record[idx]
+		}
+	}
+
+	value, err := r.schema.fromRow(row)
+	if err != nil {
+		return zero, &RowError{Line: r.line, Err: err}
+	}
+	return value, nil
+}
+
+func (r *Reader[T]) nextNDJSON() (T, error) {
+	var zero T
+
+	for r.lines.Scan() {
+		r.line++
+		data := bytes.TrimSpace(r.lines.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		value, err := r.schema.fromJSON(data)
+		if err != nil {
+			return zero, &RowError{Line: r.line, Err: err}
+		}
+		return value, nil
+	}
+
+	if err := r.lines.Err(); err != nil {
+		return zero, fmt.Errorf("line %d: %w", r.line+1, err)
+	}
+	return zero, io.EOF
+}
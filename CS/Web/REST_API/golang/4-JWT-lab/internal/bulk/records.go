@@ -0,0 +1,130 @@
+package bulk
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+// ---------- movies
+// csv: id,title,director,year (id is optional on import: a new one is generated)
+
+type movieJSON struct {
+	ID       uuid.UUID `json:"id"`
+	Title    string    `json:"title"`
+	Director string    `json:"director"`
+	Year     int32     `json:"year"`
+}
+
+var movieSchema = schema[domain.Movie]{
+	columns:  []string{"id", "title", "director", "year"},
+	required: []string{"title"},
+	toRow: func(movie domain.Movie) []string {
+		return []string{movie.ID.String(), movie.Title, movie.Director, strconv.Itoa(int(movie.Year))}
+	},
+	fromRow: func(row map[string]string) (domain.Movie, error) {
+		movie := domain.Movie{
+			Title:    row["title"],
+			Director: row["director"],
+		}
+
+		var err error
+		if movie.ID, err = parseOptionalUUID(row["id"]); err != nil {
+			return domain.Movie{}, fmt.Errorf("invalid id: %w", err)
+		}
+		if year := row["year"]; year != "" {
+			value, err := strconv.ParseInt(year, 10, 32)
+			if err != nil {
+				return domain.Movie{}, fmt.Errorf("invalid year %q", year)
+			}
+			movie.Year = int32(value)
+		}
+
+		return movie, nil
+	},
+	toJSON: func(movie domain.Movie) any {
+		return movieJSON{ID: movie.ID, Title: movie.Title, Director: movie.Director, Year: movie.Year}
+	},
+	fromJSON: func(data []byte) (domain.Movie, error) {
+		var movie movieJSON
+		if err := json.Unmarshal(data, &movie); err != nil {
+			return domain.Movie{}, err
+		}
+		return domain.Movie{ID: movie.ID, Title: movie.Title, Director: movie.Director, Year: movie.Year}, nil
+	},
+}
+
+func NewMovieReader(r io.Reader, format Format) *Reader[domain.Movie] {
+	return newReader(r, format, movieSchema)
+}
+
+func NewMovieWriter(w io.Writer, format Format) *Writer[domain.Movie] {
+	return newWriter(w, format, movieSchema)
+}
+
+// ---------- reviews
+// csv: id,movie_id,user_name,rating,comment (an empty comment is no comment)
+
+var reviewSchema = schema[domain.Review]{
+	columns:  []string{"id", "movie_id", "user_name", "rating", "comment"},
+	required: []string{"movie_id", "rating"},
+	toRow: func(review domain.Review) []string {
+		comment := ""
+		if review.Comment != nil {
+			comment = *review.Comment
+		}
+		return []string{review.ID.String(), review.MovieID.String(), review.Username, strconv.Itoa(int(review.Rating)), comment}
+	},
+	fromRow: func(row map[string]string) (domain.Review, error) {
+		review := domain.Review{
+			Username: row["user_name"],
+		}
+
+		var err error
+		if review.ID, err = parseOptionalUUID(row["id"]); err != nil {
+			return domain.Review{}, fmt.Errorf("invalid id: %w", err)
+		}
+		if review.MovieID, err = uuid.Parse(row["movie_id"]); err != nil {
+			return domain.Review{}, fmt.Errorf("invalid movie_id: %w", err)
+		}
+		rating, err := strconv.ParseInt(row["rating"], 10, 32)
+		if err != nil {
+			return domain.Review{}, fmt.Errorf("invalid rating %q", row["rating"])
+		}
+		review.Rating = int32(rating)
+		if comment := row["comment"]; comment != "" {
+			review.Comment = &comment
+		}
+
+		return review, nil
+	},
+	// domain.Review already has the right JSON shape
+	toJSON: func(review domain.Review) any {
+		return review
+	},
+	fromJSON: func(data []byte) (domain.Review, error) {
+		var review domain.Review
+		err := json.Unmarshal(data, &review)
+		return review, err
+	},
+}
+
+func NewReviewReader(r io.Reader, format Format) *Reader[domain.Review] {
+	return newReader(r, format, reviewSchema)
+}
+
+func NewReviewWriter(w io.Writer, format Format) *Writer[domain.Review] {
+	return newWriter(w, format, reviewSchema)
+}
+
+// "" is uuid.Nil
+func parseOptionalUUID(value string) (uuid.UUID, error) {
+	if value == "" {
+		return uuid.Nil, nil
+	}
+	return uuid.Parse(value)
+}
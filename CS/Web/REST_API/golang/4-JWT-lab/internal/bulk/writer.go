@@ -0,0 +1,63 @@
+package bulk
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+)
+
+// Writer streams records as CSV or NDJSON, call Flush at the end
+type Writer[T any] struct {
+	schema schema[T]
+	format Format
+
+	csv         *csv.Writer
+	wroteHeader bool
+
+	buf  *bufio.Writer
+	json *json.Encoder
+}
+
+func newWriter[T any](w io.Writer, format Format, s schema[T]) *Writer[T] {
+	writer := &Writer[T]{schema: s, format: format}
+	if format == CSV {
+		writer.csv = csv.NewWriter(w)
+	} else {
+		writer.buf = bufio.NewWriter(w)
+		writer.json = json.NewEncoder(writer.buf)
+	}
+	return writer
+}
+
+func (w *Writer[T]) Write(record T) error {
+	if w.format == CSV {
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+		return w.csv.Write(w.schema.toRow(record))
+	}
+
+	// Encode adds the newline
+	return w.json.Encode(w.schema.toJSON(record))
+}
+
+// Flush writes what is buffered (and the header of an empty CSV)
+func (w *Writer[T]) Flush() error {
+	if w.format == CSV {
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+		w.csv.Flush()
+		return w.csv.Error()
+	}
+	return w.buf.Flush()
+}
+
+func (w *Writer[T]) writeHeader() error {
+	if w.wroteHeader {
+		return nil
+	}
+	w.wroteHeader = true
+	return w.csv.Write(w.schema.columns)
+}
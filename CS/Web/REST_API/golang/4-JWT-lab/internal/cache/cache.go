@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+// the services only see these interfaces: Redis in production,
+// the in-memory versions (memory_cache.go) for tests and local runs.
+// every "get" returns a nil value (and no error) on a miss.
+
+type MovieCache interface {
+	// a hit can be a negative entry (Movie == nil): the movie doesn't exist
+	GetMovie(ctx context.Context, id uuid.UUID) (*CachedMovie, error)
+	// kept until entry.ExpiresAt
+	SetMovie(ctx context.Context, id uuid.UUID, entry CachedMovie) error
+	DelMovie(ctx context.Context, id uuid.UUID) error
+	// views are counted here first, and flushed to the DB in batches
+	IncrementViewCount(ctx context.Context, id uuid.UUID) (int64, error)
+	// takes (and resets) the pending view counts of at most max movies,
+	// an empty map when there's nothing left to flush
+	DrainViewCounts(ctx context.Context, max int) (map[uuid.UUID]int64, error)
+	// puts back counts that could not be flushed
+	RestoreViewCounts(ctx context.Context, counts map[uuid.UUID]int64) error
+}
+
+// ListCache caches lists and aggregates (JSON) under versioned keys.
+// every tag has a version number that goes into the key of the entries that
+// depend on it: invalidating a tag bumps its version, the entries built with
+// the old version are never read again and just expire.
+// no need to know which keys belong to a tag, and a list computed while an
+// invalidation happens can't overwrite the fresh one (it's stored under the old version).
+type ListCache interface {
+	// returns nil data on a miss, and the versioned key to give to SetList
+	// once the value is computed
+	GetList(ctx context.Context, key string, tags []string) (data []byte, versionedKey string, err error)
+	SetList(ctx context.Context, versionedKey string, data []byte) error
+	InvalidateTags(ctx context.Context, tags ...string) error
+}
+
+// TrendingStore scores the movies by recent activity (views, reviews) in
+// time buckets, see trending.go
+type TrendingStore interface {
+	// adds score to the movie in the current bucket
+	RecordActivity(ctx context.Context, id uuid.UUID, score float64) error
+	// highest scores first over the last window (at most MaxTrendingWindow),
+	// older buckets weigh less
+	TopMovies(ctx context.Context, window time.Duration, page domain.Page) ([]TrendingEntry, error)
+}
+
+// SessionStore holds the short-lived auth state: refresh tokens, password
+// reset tokens, 2FA challenges, used TOTP steps and OIDC login state.
+type SessionStore interface {
+	GetUserByRefreshToken(ctx context.Context, refreshToken uuid.UUID) (*UserCache, error)
+	SetUserByRefreshToken(ctx context.Context, refreshToken uuid.UUID, user UserCache) error
+	DelUserByRefreshToken(ctx context.Context, refreshToken uuid.UUID) error
+
+	SetPasswordResetToken(ctx context.Context, tokenHash string, userId uuid.UUID) error
+	ConsumePasswordResetToken(ctx context.Context, tokenHash string) (uuid.UUID, error)
+
+	SetMFAChallenge(ctx context.Context, tokenHash string, user UserCache) error
+	GetMFAChallenge(ctx context.Context, tokenHash string) (*UserCache, error)
+	DelMFAChallenge(ctx context.Context, tokenHash string) error
+	IncrementMFAChallengeAttempts(ctx context.Context, tokenHash string) (int64, error)
+	GetMFAAttempts(ctx context.Context, userId uuid.UUID) (int64, error)
+	IncrementMFAAttempts(ctx context.Context, userId uuid.UUID) (int64, error)
+	ResetMFAAttempts(ctx context.Context, userId uuid.UUID) error
+	MarkTOTPStepUsed(ctx context.Context, userId uuid.UUID, step int64) (bool, error)
+
+	SetOIDCState(ctx context.Context, state string, oidcState OIDCState) error
+	ConsumeOIDCState(ctx context.Context, state string) (*OIDCState, error)
+
+	// logs out everyone (nil) or one user, returns how many keys were deleted
+	PurgeSessions(ctx context.Context, userId *uuid.UUID) (int, error)
+}
+
+// ReviewFeed fans the new reviews out to whoever watches a movie (gRPC
+// WatchMovieReviews, the SSE and WebSocket streams). the last reviews of each
+// movie are kept in a backlog (reviewBacklogSize, for reviewBacklogTTL) so a
+// watcher that reconnects gets what it missed; a watcher too slow to keep up
+// is dropped rather than skipped over, it reconnects and catches up.
+type ReviewFeed interface {
+	PublishReview(ctx context.Context, review domain.Review) error
+	// the reviews of the movie published after lastEventId (from the backlog,
+	// "" for none) then the ones published from now on, the channel is closed
+	// once ctx is done, or early when the watcher can't get every review (too
+	// slow, the subscription lost): the caller ends the stream, the client
+	// resumes from its last event id. an id that was never handed out is
+	// ErrInvalidEventID
+	SubscribeReviews(ctx context.Context, movieId uuid.UUID, lastEventId string) (<-chan domain.FeedReview, error)
+}
@@ -2,18 +2,86 @@ package cache
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
 )
 
 const (
-	moviePrefix        = "movie:"
-	viewsMoviePrefix   = "views:movie:"
-	refreshTokenPrefix = "refresh_token:"
+	moviePrefix          = "movie:"
+	viewsMoviePrefix     = "views:movie:"
+	refreshTokenPrefix   = "refresh_token:"
+	passwordResetPrefix  = "password_reset:"
+	mfaChallengePrefix   = "mfa_challenge:"
+	mfaAttemptsPrefix    = "mfa_attempts:"
+	totpUsedPrefix       = "totp_used:"
+	oidcStatePrefix      = "oidc_state:"
+	listPrefix           = "list:"
+	tagPrefix            = "tag:"
+	trendingPrefix       = "trending:"
+	reviewsFeedPrefix    = "feed:reviews:"
+	reviewsBacklogPrefix = "backlog:reviews:"
 )
 
+// set of the movie ids with pending views (views:movie:<id> not flushed yet)
+const pendingViewsKey = "views:pending"
+
+// list cache tags (see ListCache)
+const (
+	MoviesTag  = "movies"  // every page of the movie list
+	ReviewsTag = "reviews" // the list of all the reviews
+	ViewsTag   = "views"   // the movies ranked by views, bumped by every flush
+)
+
+// everything derived from one movie's reviews (its reviews list, its rating)
+func MovieTag(id uuid.UUID) string {
+	return fmt.Sprintf("movie:%s", id.String())
+}
+
+func TagVersionKey(tag string) string {
+	return fmt.Sprintf("%s%s:version", tagPrefix, tag)
+}
+
+func MoviesListKey(page domain.Page) string {
+	return fmt.Sprintf("%smovies:%d:%d", listPrefix, page.Limit, page.Offset)
+}
+
+func PopularMoviesListKey(page domain.Page) string {
+	return fmt.Sprintf("%spopular_movies:%d:%d", listPrefix, page.Limit, page.Offset)
+}
+
+func ReviewsListKey() string {
+	return fmt.Sprintf("%sreviews", listPrefix)
+}
+
+func MovieReviewsListKey(id uuid.UUID, page domain.Page) string {
+	return fmt.Sprintf("%smovie_reviews:%s:%d:%d", listPrefix, id.String(), page.Limit, page.Offset)
+}
+
+func MovieWithReviewsKey(id uuid.UUID) string {
+	return fmt.Sprintf("%smovie_with_reviews:%s", listPrefix, id.String())
+}
+
+// bucket: buckets of size since the epoch. the hourly ones keep the name
+// they had before the finer buckets, those get their size in it
+func TrendingBucketKey(size time.Duration, bucket int64) string {
+	if size == trendingBucket {
+		return fmt.Sprintf("%s%d", trendingPrefix, bucket)
+	}
+	return fmt.Sprintf("%s%dm:%d", trendingPrefix, int64(size.Minutes()), bucket)
+}
+
+// the buckets of a window merged with their weights, computed once per bucket
+// (and cached a short time, see trendingMergedTTL)
+func TrendingMergedKey(window time.Duration, bucket int64) string {
+	return fmt.Sprintf("%smerged:%d:%d", trendingPrefix, int64(window.Seconds()), bucket)
+}
+
+// v2: the value is a CachedMovie. the v1 keys held the bare movie, read as
+// a CachedMovie it looks like a negative entry: a new version never reads them
 func MovieKey(id uuid.UUID) string {
-	return fmt.Sprintf("%s%s", moviePrefix, id.String())
+	return fmt.Sprintf("%sv2:%s", moviePrefix, id.String())
 }
 
 func ViewsMovieKey(id uuid.UUID) string {
@@ -23,3 +91,37 @@ func ViewsMovieKey(id uuid.UUID) string {
 func RefreshTokenKey(id uuid.UUID) string {
 	return fmt.Sprintf("%s%s", refreshTokenPrefix, id.String())
 }
+
+func PasswordResetKey(tokenHash string) string {
+	return fmt.Sprintf("%s%s", passwordResetPrefix, tokenHash)
+}
+
+func MFAChallengeKey(tokenHash string) string {
+	return fmt.Sprintf("%s%s", mfaChallengePrefix, tokenHash)
+}
+
+func MFAChallengeAttemptsKey(tokenHash string) string {
+	return fmt.Sprintf("%s%s:attempts", mfaChallengePrefix, tokenHash)
+}
+
+func MFAAttemptsKey(userId uuid.UUID) string {
+	return fmt.Sprintf("%s%s", mfaAttemptsPrefix, userId.String())
+}
+
+func TOTPUsedKey(userId uuid.UUID, step int64) string {
+	return fmt.Sprintf("%s%s:%d", totpUsedPrefix, userId.String(), step)
+}
+
+func OIDCStateKey(state string) string {
+	return fmt.Sprintf("%s%s", oidcStatePrefix, state)
+}
+
+// pub/sub channel of the new reviews of a movie (see ReviewFeed)
+func ReviewsFeedChannel(movieId uuid.UUID) string {
+	return fmt.Sprintf("%s%s", reviewsFeedPrefix, movieId.String())
+}
+
+// stream of the last reviews of a movie, replayed to the watchers that reconnect
+func ReviewsBacklogKey(movieId uuid.UUID) string {
+	return fmt.Sprintf("%s%s", reviewsBacklogPrefix, movieId.String())
+}
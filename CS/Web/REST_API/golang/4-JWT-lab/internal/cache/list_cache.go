@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// lists change more often than a single movie, keep them shorter
+const listTTL = 5 * time.Minute
+
+type RedisListCache struct {
+	rdb *redis.Client
+}
+
+func NewRedisListCache(rdb *redis.Client) *RedisListCache {
+	return &RedisListCache{
+		rdb: rdb,
+	}
+}
+
+func (c *RedisListCache) GetList(ctx context.Context, key string, tags []string) ([]byte, string, error) {
+	versionKeys := make([]string, len(tags))
+	for idx, tag := range tags {
+		versionKeys[idx] = TagVersionKey(tag)
+	}
+
+	versions := make([]string, len(tags))
+	if len(tags) > 0 {
+		values, err := c.rdb.MGet(ctx, versionKeys...).Result()
+		if err != nil {
+			return nil, "", err
+		}
+		for idx, value := range values {
+			// never invalidated yet: version 0
+			versions[idx], _ = value.(string)
+		}
+	}
+
+	versionedKey := versionedListKey(key, versions)
+	data, err := c.rdb.Get(ctx, versionedKey).Bytes()
+	if err == redis.Nil {
+		return nil, versionedKey, nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	return data, versionedKey, nil
+}
+
+func (c *RedisListCache) SetList(ctx context.Context, versionedKey string, data []byte) error {
+	return c.rdb.Set(ctx, versionedKey, data, JitterTTL(listTTL)).Err()
+}
+
+func (c *RedisListCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	pipe := c.rdb.Pipeline()
+	for _, tag := range tags {
+		pipe.Incr(ctx, TagVersionKey(tag))
+	}
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// "list:movies:20:0" with tag versions [3] → "list:movies:20:0@3"
+func versionedListKey(key string, versions []string) string {
+	for idx, version := range versions {
+		if version == "" {
+			versions[idx] = "0"
+		}
+	}
+
+	return fmt.Sprintf("%s@%s", key, strings.Join(versions, "."))
+}
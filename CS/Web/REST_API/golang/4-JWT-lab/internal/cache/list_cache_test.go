@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"context"
+	"testing"
+)
+
+func TestVersionedListKey(t *testing.T) {
+	tests := []struct {
+		versions []string
+		want     string
+	}{
+		{nil, "list:movies:20:0@"},
+		{[]string{"3"}, "list:movies:20:0@3"},
+		// never invalidated: version 0
+		{[]string{"", "7"}, "list:movies:20:0@0.7"},
+	}
+
+	for _, tt := range tests {
+		if got := versionedListKey("list:movies:20:0", tt.versions); got != tt.want {
+			t.Fatalf("versionedListKey(%q) = %s, want %s", tt.versions, got, tt.want)
+		}
+	}
+}
+
+func TestMemoryListCache(t *testing.T) {
+	testListCache(t, NewMemoryListCache())
+}
+
+func TestRedisListCache(t *testing.T) {
+	testListCache(t, NewRedisListCache(newTestRedis(t)))
+}
+
+// what the services rely on, for both implementations
+func testListCache(t *testing.T, lists ListCache) {
+	ctx := context.Background()
+	key := "list:test"
+	tags := []string{"first", "second"}
+
+	data, versionedKey, err := lists.GetList(ctx, key, tags)
+	if err != nil || data != nil {
+		t.Fatalf("get of an empty cache = %q, %v", data, err)
+	}
+	if versionedKey != "list:test@0.0" {
+		t.Fatalf("versioned key = %s, want list:test@0.0", versionedKey)
+	}
+	if err := lists.SetList(ctx, versionedKey, []byte("v0")); err != nil {
+		t.Fatalf("set list: %v", err)
+	}
+	if data, _, _ := lists.GetList(ctx, key, tags); string(data) != "v0" {
+		t.Fatalf("get after set = %q, want v0", data)
+	}
+
+	// any of its tags invalidates it
+	if err := lists.InvalidateTags(ctx, "second"); err != nil {
+		t.Fatalf("invalidate: %v", err)
+	}
+	data, freshKey, err := lists.GetList(ctx, key, tags)
+	if err != nil || data != nil || freshKey != "list:test@0.1" {
+		t.Fatalf("get after invalidation = %q, %s, %v, want a miss on list:test@0.1", data, freshKey, err)
+	}
+
+	// a value computed before the invalidation lands under the old version:
+	// it can't overwrite the fresh one
+	lists.SetList(ctx, freshKey, []byte("v1"))
+	lists.SetList(ctx, versionedKey, []byte("computed before"))
+	if data, _, _ := lists.GetList(ctx, key, tags); string(data) != "v1" {
+		t.Fatalf("get = %q, want v1", data)
+	}
+
+	// an unrelated tag changes nothing
+	lists.InvalidateTags(ctx, "unrelated")
+	if data, _, _ := lists.GetList(ctx, key, tags); string(data) != "v1" {
+		t.Fatalf("get after an unrelated invalidation = %q, want v1", data)
+	}
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lru is a thread-safe, size bounded, least recently used map.
+// every entry also has its own expiration.
+type lru[K comparable, V any] struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // front: most recently used
+	items    map[K]*list.Element
+	now      func() time.Time
+}
+
+type lruItem[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+func newLRU[K comparable, V any](capacity int) *lru[K, V] {
+	return &lru[K, V]{
+		capacity: capacity,
+		order:    list.New(),
+		items:    map[K]*list.Element{},
+		now:      time.Now,
+	}
+}
+
+func (c *lru[K, V]) get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	elem, ok := c.items[key]
+	if !ok {
+		return zero, false
+	}
+
+	item := elem.Value.(*lruItem[K, V])
+	if !c.now().Before(item.expiresAt) {
+		c.removeElement(elem)
+		return zero, false
+	}
+
+	c.order.MoveToFront(elem)
+	return item.value, true
+}
+
+func (c *lru[K, V]) add(key K, value V, expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		item := elem.Value.(*lruItem[K, V])
+		item.value = value
+		item.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&lruItem[K, V]{key: key, value: value, expiresAt: expiresAt})
+
+	// full: drop the least recently used
+	for c.order.Len() > c.capacity {
+		c.removeElement(c.order.Back())
+	}
+}
+
+func (c *lru[K, V]) remove(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.removeElement(elem)
+	}
+}
+
+func (c *lru[K, V]) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.items = map[K]*list.Element{}
+}
+
+// callers hold the lock
+func (c *lru[K, V]) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*lruItem[K, V]).key)
+}
@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRUEviction(t *testing.T) {
+	clock := newFakeClock()
+	cache := newLRU[string, int](2)
+	cache.now = clock.Now
+	expiresAt := clock.now.Add(time.Hour)
+
+	cache.add("a", 1, expiresAt)
+	cache.add("b", 2, expiresAt)
+	// a read makes "a" the most recently used: "b" goes first
+	if value, ok := cache.get("a"); !ok || value != 1 {
+		t.Fatalf("get a = %d, %t", value, ok)
+	}
+	cache.add("c", 3, expiresAt)
+
+	if _, ok := cache.get("b"); ok {
+		t.Fatal("b still there, it was the least recently used")
+	}
+	for key, want := range map[string]int{"a": 1, "c": 3} {
+		if value, ok := cache.get(key); !ok || value != want {
+			t.Fatalf("get %s = %d, %t, want %d", key, value, ok, want)
+		}
+	}
+
+	// updating a key doesn't grow the cache
+	cache.add("a", 10, expiresAt)
+	if cache.order.Len() != 2 || len(cache.items) != 2 {
+		t.Fatalf("size after an update = %d/%d, want 2", cache.order.Len(), len(cache.items))
+	}
+	if value, _ := cache.get("a"); value != 10 {
+		t.Fatalf("get a after the update = %d, want 10", value)
+	}
+}
+
+func TestLRUExpiry(t *testing.T) {
+	clock := newFakeClock()
+	cache := newLRU[string, int](10)
+	cache.now = clock.Now
+
+	cache.add("short", 1, clock.now.Add(time.Second))
+	cache.add("long", 2, clock.now.Add(time.Minute))
+
+	clock.advance(time.Second)
+	if _, ok := cache.get("short"); ok {
+		t.Fatal("short: hit at its expiration")
+	}
+	if len(cache.items) != 1 {
+		t.Fatalf("expired entry kept: %d items, want 1", len(cache.items))
+	}
+	if _, ok := cache.get("long"); !ok {
+		t.Fatal("long: miss before its expiration")
+	}
+
+	cache.remove("long")
+	cache.add("again", 3, clock.now.Add(time.Minute))
+	cache.purge()
+	if _, ok := cache.get("again"); ok || cache.order.Len() != 0 {
+		t.Fatal("entries left after the purge")
+	}
+}
@@ -0,0 +1,621 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+// the in-memory versions behave like the Redis ones (same keys, same TTLs,
+// values stored as JSON so callers never share memory with the cache)
+// but only live in this process: for tests and local runs without Redis.
+
+// expired entries are dropped when read, and in bulk every sweepEvery writes
+const sweepEvery = 1000
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time // zero: no expiration
+}
+
+// memoryStore is a tiny thread-safe subset of Redis
+type memoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	writes  int
+	now     func() time.Time
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		entries: map[string]memoryEntry{},
+		now:     time.Now,
+	}
+}
+
+// callers hold the lock
+func (m *memoryStore) lookup(key string) (memoryEntry, bool) {
+	entry, ok := m.entries[key]
+	if !ok {
+		return memoryEntry{}, false
+	}
+	if !entry.expiresAt.IsZero() && !m.now().Before(entry.expiresAt) {
+		delete(m.entries, key)
+		return memoryEntry{}, false
+	}
+	return entry, true
+}
+
+// callers hold the lock
+func (m *memoryStore) store(key string, value []byte, ttl time.Duration) {
+	entry := memoryEntry{value: value}
+	if ttl > 0 {
+		entry.expiresAt = m.now().Add(ttl)
+	}
+	m.entries[key] = entry
+
+	m.writes++
+	if m.writes%sweepEvery == 0 {
+		now := m.now()
+		for k, e := range m.entries {
+			if !e.expiresAt.IsZero() && !now.Before(e.expiresAt) {
+				delete(m.entries, k)
+			}
+		}
+	}
+}
+
+func (m *memoryStore) get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.lookup(key)
+	return entry.value, ok
+}
+
+func (m *memoryStore) set(key string, value []byte, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.store(key, value, ttl)
+}
+
+// SET NX: false when the key already exists
+func (m *memoryStore) setNX(key string, value []byte, ttl time.Duration) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.lookup(key); ok {
+		return false
+	}
+	m.store(key, value, ttl)
+	return true
+}
+
+func (m *memoryStore) getDel(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.lookup(key)
+	delete(m.entries, key)
+	return entry.value, ok
+}
+
+// returns how many keys existed
+func (m *memoryStore) del(keys ...string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	deleted := 0
+	for _, key := range keys {
+		if _, ok := m.lookup(key); ok {
+			deleted++
+		}
+		delete(m.entries, key)
+	}
+	return deleted
+}
+
+// a copy of the live entries whose key starts with prefix
+func (m *memoryStore) scan(prefix string) map[string][]byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	values := map[string][]byte{}
+	for key := range m.entries {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if entry, ok := m.lookup(key); ok {
+			values[key] = entry.value
+		}
+	}
+	return values
+}
+
+// INCR (+ EXPIRE when ttl > 0, otherwise the current expiration is kept)
+func (m *memoryStore) incr(key string, ttl time.Duration) (int64, error) {
+	return m.incrBy(key, 1, ttl)
+}
+
+func (m *memoryStore) incrBy(key string, delta int64, ttl time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var count int64
+	entry, ok := m.lookup(key)
+	if ok {
+		var err error
+		count, err = strconv.ParseInt(string(entry.value), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+	}
+	count += delta
+
+	if ttl > 0 || !ok {
+		m.store(key, []byte(strconv.FormatInt(count, 10)), ttl)
+	} else {
+		entry.value = []byte(strconv.FormatInt(count, 10))
+		m.entries[key] = entry
+	}
+
+	return count, nil
+}
+
+// GETDEL of at most max keys starting with prefix
+func (m *memoryStore) getDelPrefix(prefix string, max int) map[string][]byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	values := map[string][]byte{}
+	for key := range m.entries {
+		if len(values) >= max {
+			break
+		}
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if entry, ok := m.lookup(key); ok {
+			values[key] = entry.value
+		}
+		delete(m.entries, key)
+	}
+
+	return values
+}
+
+func (m *memoryStore) setJSON(key string, value any, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	m.set(key, data, ttl)
+	return nil
+}
+
+// ---------- movies
+
+type MemoryMovieCache struct {
+	store *memoryStore
+}
+
+func NewMemoryMovieCache() *MemoryMovieCache {
+	return &MemoryMovieCache{
+		store: newMemoryStore(),
+	}
+}
+
+func (c *MemoryMovieCache) GetMovie(ctx context.Context, id uuid.UUID) (*CachedMovie, error) {
+	data, ok := c.store.get(MovieKey(id))
+	if !ok {
+		return nil, nil
+	}
+
+	var entry CachedMovie
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+func (c *MemoryMovieCache) SetMovie(ctx context.Context, id uuid.UUID, entry CachedMovie) error {
+	ttl := entry.ExpiresAt.Sub(c.store.now())
+	if ttl <= 0 {
+		return nil
+	}
+
+	return c.store.setJSON(MovieKey(id), entry, ttl)
+}
+
+func (c *MemoryMovieCache) DelMovie(ctx context.Context, id uuid.UUID) error {
+	c.store.del(MovieKey(id))
+	return nil
+}
+
+func (c *MemoryMovieCache) IncrementViewCount(ctx context.Context, id uuid.UUID) (int64, error) {
+	return c.store.incr(ViewsMovieKey(id), 0)
+}
+
+func (c *MemoryMovieCache) DrainViewCounts(ctx context.Context, max int) (map[uuid.UUID]int64, error) {
+	counts := map[uuid.UUID]int64{}
+	for key, value := range c.store.getDelPrefix(viewsMoviePrefix, max) {
+		id, err := uuid.Parse(strings.TrimPrefix(key, viewsMoviePrefix))
+		if err != nil {
+			continue
+		}
+		count, err := strconv.ParseInt(string(value), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		counts[id] = count
+	}
+
+	return counts, nil
+}
+
+func (c *MemoryMovieCache) RestoreViewCounts(ctx context.Context, counts map[uuid.UUID]int64) error {
+	for id, count := range counts {
+		if _, err := c.store.incrBy(ViewsMovieKey(id), count, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ---------- lists
+
+type MemoryListCache struct {
+	store *memoryStore
+}
+
+func NewMemoryListCache() *MemoryListCache {
+	return &MemoryListCache{
+		store: newMemoryStore(),
+	}
+}
+
+func (c *MemoryListCache) GetList(ctx context.Context, key string, tags []string) ([]byte, string, error) {
+	versions := make([]string, len(tags))
+	for idx, tag := range tags {
+		version, _ := c.store.get(TagVersionKey(tag))
+		versions[idx] = string(version)
+	}
+
+	versionedKey := versionedListKey(key, versions)
+	data, ok := c.store.get(versionedKey)
+	if !ok {
+		return nil, versionedKey, nil
+	}
+
+	return data, versionedKey, nil
+}
+
+func (c *MemoryListCache) SetList(ctx context.Context, versionedKey string, data []byte) error {
+	c.store.set(versionedKey, data, JitterTTL(listTTL))
+	return nil
+}
+
+func (c *MemoryListCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	for _, tag := range tags {
+		if _, err := c.store.incr(TagVersionKey(tag), 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ---------- trending
+
+type MemoryTrendingStore struct {
+	mu sync.Mutex
+	// bucket size → bucket → scores
+	buckets map[time.Duration]map[int64]map[uuid.UUID]float64
+	now     func() time.Time
+}
+
+func NewMemoryTrendingStore() *MemoryTrendingStore {
+	buckets := map[time.Duration]map[int64]map[uuid.UUID]float64{}
+	for _, resolution := range trendingResolutions {
+		buckets[resolution.size] = map[int64]map[uuid.UUID]float64{}
+	}
+
+	return &MemoryTrendingStore{
+		buckets: buckets,
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryTrendingStore) RecordActivity(ctx context.Context, id uuid.UUID, score float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for _, resolution := range trendingResolutions {
+		buckets := s.buckets[resolution.size]
+		current := trendingBucketAt(now, resolution.size)
+		scores, ok := buckets[current]
+		if !ok {
+			scores = map[uuid.UUID]float64{}
+			buckets[current] = scores
+
+			// new bucket: drop the ones no window can reach anymore
+			oldest := current - int64(resolution.keep/resolution.size)
+			for bucket := range buckets {
+				if bucket < oldest {
+					delete(buckets, bucket)
+				}
+			}
+		}
+		scores[id] += score
+	}
+
+	return nil
+}
+
+func (s *MemoryTrendingStore) TopMovies(ctx context.Context, window time.Duration, page domain.Page) ([]TrendingEntry, error) {
+	s.mu.Lock()
+	merged := map[uuid.UUID]float64{}
+	size, buckets, weights := trendingWeights(window, s.now())
+	for idx, bucket := range buckets {
+		for id, score := range s.buckets[size][bucket] {
+			merged[id] += score * weights[idx]
+		}
+	}
+	s.mu.Unlock()
+
+	entries := make([]TrendingEntry, 0, len(merged))
+	for id, score := range merged {
+		entries = append(entries, TrendingEntry{ID: id, Score: score})
+	}
+	// same order as ZREVRANGE: score, then member
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Score != entries[j].Score {
+			return entries[i].Score > entries[j].Score
+		}
+		return entries[i].ID.String() > entries[j].ID.String()
+	})
+
+	if page.Offset >= len(entries) {
+		return []TrendingEntry{}, nil
+	}
+	entries = entries[page.Offset:]
+	if page.Limit > 0 && page.Limit < len(entries) {
+		entries = entries[:page.Limit]
+	}
+
+	return entries, nil
+}
+
+// ---------- sessions
+
+type MemorySessionStore struct {
+	store *memoryStore
+}
+
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{
+		store: newMemoryStore(),
+	}
+}
+
+func (s *MemorySessionStore) GetUserByRefreshToken(ctx context.Context, refreshToken uuid.UUID) (*UserCache, error) {
+	return s.getUser(RefreshTokenKey(refreshToken))
+}
+
+func (s *MemorySessionStore) SetUserByRefreshToken(ctx context.Context, refreshToken uuid.UUID, user UserCache) error {
+	return s.store.setJSON(RefreshTokenKey(refreshToken), user, refreshTokenTTL)
+}
+
+func (s *MemorySessionStore) DelUserByRefreshToken(ctx context.Context, refreshToken uuid.UUID) error {
+	s.store.del(RefreshTokenKey(refreshToken))
+	return nil
+}
+
+func (s *MemorySessionStore) SetPasswordResetToken(ctx context.Context, tokenHash string, userId uuid.UUID) error {
+	s.store.set(PasswordResetKey(tokenHash), []byte(userId.String()), passwordResetTTL)
+	return nil
+}
+
+func (s *MemorySessionStore) ConsumePasswordResetToken(ctx context.Context, tokenHash string) (uuid.UUID, error) {
+	data, ok := s.store.getDel(PasswordResetKey(tokenHash))
+	if !ok {
+		return uuid.Nil, nil
+	}
+
+	return uuid.Parse(string(data))
+}
+
+func (s *MemorySessionStore) SetMFAChallenge(ctx context.Context, tokenHash string, user UserCache) error {
+	return s.store.setJSON(MFAChallengeKey(tokenHash), user, mfaChallengeTTL)
+}
+
+func (s *MemorySessionStore) GetMFAChallenge(ctx context.Context, tokenHash string) (*UserCache, error) {
+	return s.getUser(MFAChallengeKey(tokenHash))
+}
+
+func (s *MemorySessionStore) DelMFAChallenge(ctx context.Context, tokenHash string) error {
+	s.store.del(MFAChallengeKey(tokenHash), MFAChallengeAttemptsKey(tokenHash))
+	return nil
+}
+
+func (s *MemorySessionStore) IncrementMFAChallengeAttempts(ctx context.Context, tokenHash string) (int64, error) {
+	return s.store.incr(MFAChallengeAttemptsKey(tokenHash), mfaChallengeTTL)
+}
+
+func (s *MemorySessionStore) GetMFAAttempts(ctx context.Context, userId uuid.UUID) (int64, error) {
+	data, ok := s.store.get(MFAAttemptsKey(userId))
+	if !ok {
+		return 0, nil
+	}
+
+	return strconv.ParseInt(string(data), 10, 64)
+}
+
+func (s *MemorySessionStore) IncrementMFAAttempts(ctx context.Context, userId uuid.UUID) (int64, error) {
+	return s.store.incr(MFAAttemptsKey(userId), mfaAttemptsTTL)
+}
+
+func (s *MemorySessionStore) ResetMFAAttempts(ctx context.Context, userId uuid.UUID) error {
+	s.store.del(MFAAttemptsKey(userId))
+	return nil
+}
+
+func (s *MemorySessionStore) MarkTOTPStepUsed(ctx context.Context, userId uuid.UUID, step int64) (bool, error) {
+	return s.store.setNX(TOTPUsedKey(userId, step), []byte("1"), totpUsedTTL), nil
+}
+
+func (s *MemorySessionStore) SetOIDCState(ctx context.Context, state string, oidcState OIDCState) error {
+	return s.store.setJSON(OIDCStateKey(state), oidcState, OIDCStateTTL)
+}
+
+func (s *MemorySessionStore) ConsumeOIDCState(ctx context.Context, state string) (*OIDCState, error) {
+	data, ok := s.store.getDel(OIDCStateKey(state))
+	if !ok {
+		return nil, nil
+	}
+
+	var oidcState OIDCState
+	if err := json.Unmarshal(data, &oidcState); err != nil {
+		return nil, err
+	}
+
+	return &oidcState, nil
+}
+
+func (s *MemorySessionStore) getUser(key string) (*UserCache, error) {
+	data, ok := s.store.get(key)
+	if !ok {
+		return nil, nil
+	}
+
+	var user UserCache
+	if err := json.Unmarshal(data, &user); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// ---------- review feed
+type MemoryReviewFeed struct {
+	mu       sync.Mutex
+	watchers map[uuid.UUID]map[chan domain.FeedReview]struct{}
+	// the last reviews of each movie (no TTL here), oldest first
+	backlogs map[uuid.UUID][]domain.FeedReview
+	// the last id handed out: the next ones are greater, like Redis's
+	lastMs  uint64
+	lastSeq uint64
+}
+
+func NewMemoryReviewFeed() *MemoryReviewFeed {
+	return &MemoryReviewFeed{
+		watchers: map[uuid.UUID]map[chan domain.FeedReview]struct{}{},
+		backlogs: map[uuid.UUID][]domain.FeedReview{},
+	}
+}
+
+func (f *MemoryReviewFeed) PublishReview(ctx context.Context, review domain.Review) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if ms := uint64(time.Now().UnixMilli()); ms > f.lastMs {
+		f.lastMs, f.lastSeq = ms, 0
+	} else {
+		f.lastSeq++
+	}
+	feedReview := domain.FeedReview{ID: formatEventID(f.lastMs, f.lastSeq), Review: review}
+
+	backlog := append(f.backlogs[review.MovieID], feedReview)
+	if len(backlog) > reviewBacklogSize {
+		backlog = backlog[len(backlog)-reviewBacklogSize:]
+	}
+	f.backlogs[review.MovieID] = backlog
+
+	for watcher := range f.watchers[review.MovieID] {
+		select {
+		case watcher <- feedReview:
+		default:
+			// too slow, same as the Redis version: closed, the client
+			// reconnects and replays from the backlog
+			delete(f.watchers[review.MovieID], watcher)
+			close(watcher)
+		}
+	}
+	return nil
+}
+
+func (f *MemoryReviewFeed) SubscribeReviews(ctx context.Context, movieId uuid.UUID, lastEventId string) (<-chan domain.FeedReview, error) {
+	if lastEventId != "" {
+		if _, _, ok := parseEventID(lastEventId); !ok {
+			return nil, domain.ErrInvalidEventID
+		}
+	}
+
+	watcher := make(chan domain.FeedReview, reviewFeedBuffer)
+
+	// the backlog and the subscription under the same lock: every review is
+	// either in the backlog or sent to the watcher, never both
+	f.mu.Lock()
+	var backlog []domain.FeedReview
+	if lastEventId != "" {
+		for _, review := range f.backlogs[movieId] {
+			if eventIDAfter(review.ID, lastEventId) {
+				backlog = append(backlog, review)
+			}
+		}
+	}
+	if f.watchers[movieId] == nil {
+		f.watchers[movieId] = map[chan domain.FeedReview]struct{}{}
+	}
+	f.watchers[movieId][watcher] = struct{}{}
+	f.mu.Unlock()
+
+	reviews := make(chan domain.FeedReview, reviewFeedBuffer)
+	go func() {
+		defer close(reviews)
+		defer func() {
+			// under the lock: no publish writes to a watcher that's gone
+			f.mu.Lock()
+			delete(f.watchers[movieId], watcher)
+			if len(f.watchers[movieId]) == 0 {
+				delete(f.watchers, movieId)
+			}
+			f.mu.Unlock()
+		}()
+
+		for _, review := range backlog {
+			select {
+			case <-ctx.Done():
+				return
+			case reviews <- review:
+			}
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case review, ok := <-watcher:
+				if !ok {
+					return
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case reviews <- review:
+				}
+			}
+		}
+	}()
+
+	return reviews, nil
+}
@@ -0,0 +1,202 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+// fakeClock drives the now hook of the memory stores
+type fakeClock struct {
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	clock := newFakeClock()
+	store := newMemoryStore()
+	store.now = clock.Now
+
+	store.set("short", []byte("1"), time.Minute)
+	store.set("forever", []byte("1"), 0)
+	if _, ok := store.get("short"); !ok {
+		t.Fatal("short: miss before its expiration")
+	}
+
+	clock.advance(time.Minute)
+	if _, ok := store.get("short"); ok {
+		t.Fatal("short: hit at its expiration")
+	}
+	if _, ok := store.get("forever"); !ok {
+		t.Fatal("forever: miss, it has no TTL")
+	}
+
+	// INCR without a TTL keeps the expiration of the key
+	store.set("counter", []byte("1"), time.Minute)
+	if count, err := store.incr("counter", 0); err != nil || count != 2 {
+		t.Fatalf("incr = %d, %v, want 2", count, err)
+	}
+	clock.advance(time.Minute)
+	if count, err := store.incr("counter", 0); err != nil || count != 1 {
+		t.Fatalf("incr of an expired counter = %d, %v, want 1", count, err)
+	}
+
+	// an expired key doesn't block SET NX
+	store.set("lock", []byte("1"), time.Second)
+	if store.setNX("lock", []byte("2"), time.Second) {
+		t.Fatal("set nx over a live key")
+	}
+	clock.advance(time.Second)
+	if !store.setNX("lock", []byte("2"), time.Second) {
+		t.Fatal("set nx over an expired key failed")
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	clock := newFakeClock()
+	store := newMemoryStore()
+	store.now = clock.Now
+
+	store.set("expired", []byte("1"), time.Second)
+	clock.advance(time.Second)
+	// never read again: only the sweep drops it
+	for i := range sweepEvery {
+		store.set("key", []byte{byte(i)}, 0)
+	}
+
+	store.mu.Lock()
+	_, ok := store.entries["expired"]
+	store.mu.Unlock()
+	if ok {
+		t.Fatalf("expired entry still stored after %d writes", sweepEvery)
+	}
+}
+
+func TestMemorySessionStore(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	sessions := NewMemorySessionStore()
+	sessions.store.now = clock.Now
+
+	alice := UserCache{UserId: uuid.New(), Username: "alice", Role: domain.Regular}
+	bob := UserCache{UserId: uuid.New(), Username: "bob", Role: domain.Admin, MFA: true}
+
+	// refresh tokens
+	aliceToken, bobToken := uuid.New(), uuid.New()
+	for token, user := range map[uuid.UUID]UserCache{aliceToken: alice, bobToken: bob} {
+		if err := sessions.SetUserByRefreshToken(ctx, token, user); err != nil {
+			t.Fatalf("set refresh token: %v", err)
+		}
+	}
+	if got, err := sessions.GetUserByRefreshToken(ctx, bobToken); err != nil || got == nil || *got != bob {
+		t.Fatalf("get refresh token = %+v, %v, want %+v", got, err, bob)
+	}
+	if err := sessions.DelUserByRefreshToken(ctx, bobToken); err != nil {
+		t.Fatalf("del refresh token: %v", err)
+	}
+	if got, _ := sessions.GetUserByRefreshToken(ctx, bobToken); got != nil {
+		t.Fatalf("deleted refresh token still there: %+v", got)
+	}
+
+	// password reset tokens are single use
+	if err := sessions.SetPasswordResetToken(ctx, "reset-hash", alice.UserId); err != nil {
+		t.Fatalf("set reset token: %v", err)
+	}
+	if id, err := sessions.ConsumePasswordResetToken(ctx, "reset-hash"); err != nil || id != alice.UserId {
+		t.Fatalf("consume reset token = %s, %v, want %s", id, err, alice.UserId)
+	}
+	if id, _ := sessions.ConsumePasswordResetToken(ctx, "reset-hash"); id != uuid.Nil {
+		t.Fatalf("reset token consumed twice: %s", id)
+	}
+
+	// dropping a challenge drops its attempts
+	if err := sessions.SetMFAChallenge(ctx, "challenge-hash", alice); err != nil {
+		t.Fatalf("set challenge: %v", err)
+	}
+	for want := int64(1); want <= 2; want++ {
+		if attempts, err := sessions.IncrementMFAChallengeAttempts(ctx, "challenge-hash"); err != nil || attempts != want {
+			t.Fatalf("challenge attempts = %d, %v, want %d", attempts, err, want)
+		}
+	}
+	if err := sessions.DelMFAChallenge(ctx, "challenge-hash"); err != nil {
+		t.Fatalf("del challenge: %v", err)
+	}
+	if got, _ := sessions.GetMFAChallenge(ctx, "challenge-hash"); got != nil {
+		t.Fatalf("deleted challenge still there: %+v", got)
+	}
+	if attempts, _ := sessions.IncrementMFAChallengeAttempts(ctx, "challenge-hash"); attempts != 1 {
+		t.Fatalf("attempts of a deleted challenge = %d, want 1", attempts)
+	}
+
+	// a TOTP step is accepted once
+	if first, _ := sessions.MarkTOTPStepUsed(ctx, alice.UserId, 42); !first {
+		t.Fatal("first use of a step refused")
+	}
+	if again, _ := sessions.MarkTOTPStepUsed(ctx, alice.UserId, 42); again {
+		t.Fatal("step accepted twice")
+	}
+
+	// OIDC state is single use
+	state := OIDCState{CodeVerifier: "verifier", Nonce: "nonce", LinkUserID: &alice.UserId}
+	if err := sessions.SetOIDCState(ctx, "state", state); err != nil {
+		t.Fatalf("set oidc state: %v", err)
+	}
+	if got, err := sessions.ConsumeOIDCState(ctx, "state"); err != nil || got == nil || got.Nonce != "nonce" || *got.LinkUserID != alice.UserId {
+		t.Fatalf("consume oidc state = %+v, %v", got, err)
+	}
+	if got, _ := sessions.ConsumeOIDCState(ctx, "state"); got != nil {
+		t.Fatalf("oidc state consumed twice: %+v", got)
+	}
+
+	// and the sessions expire like in Redis
+	clock.advance(refreshTokenTTL)
+	if got, _ := sessions.GetUserByRefreshToken(ctx, aliceToken); got != nil {
+		t.Fatalf("refresh token alive after %s: %+v", refreshTokenTTL, got)
+	}
+}
+
+func TestMemoryPurgeSessions(t *testing.T) {
+	ctx := context.Background()
+	sessions := NewMemorySessionStore()
+
+	alice := UserCache{UserId: uuid.New(), Username: "alice"}
+	bob := UserCache{UserId: uuid.New(), Username: "bob"}
+	aliceToken, bobToken := uuid.New(), uuid.New()
+	sessions.SetUserByRefreshToken(ctx, aliceToken, alice)
+	sessions.SetUserByRefreshToken(ctx, bobToken, bob)
+	sessions.SetMFAChallenge(ctx, "alice-challenge", alice)
+	sessions.SetPasswordResetToken(ctx, "alice-reset", alice.UserId)
+	sessions.SetOIDCState(ctx, "state", OIDCState{Nonce: "nonce"})
+	sessions.IncrementMFAAttempts(ctx, alice.UserId)
+
+	deleted, err := sessions.PurgeSessions(ctx, &alice.UserId)
+	if err != nil || deleted != 3 {
+		t.Fatalf("purge alice = %d, %v, want 3", deleted, err)
+	}
+	if got, _ := sessions.GetUserByRefreshToken(ctx, bobToken); got == nil {
+		t.Fatal("purging alice logged bob out")
+	}
+	// not a session: the lockout stays
+	if attempts, _ := sessions.GetMFAAttempts(ctx, alice.UserId); attempts != 1 {
+		t.Fatalf("mfa attempts after the purge = %d, want 1", attempts)
+	}
+
+	// everyone: bob's token and the OIDC state (nobody's)
+	if deleted, err := sessions.PurgeSessions(ctx, nil); err != nil || deleted != 2 {
+		t.Fatalf("purge everyone = %d, %v, want 2", deleted, err)
+	}
+}
@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// the user has 5 minutes to open the authenticator app and type the code
+const mfaChallengeTTL = 5 * time.Minute
+
+// a 6 digits code is 1 in a million, without a limit it can be brute forced
+const MaxMFAChallengeAttempts = 5
+
+// the challenge limit alone is not enough: logging in again gives a new
+// challenge (the attacker has the password). so the user has a limit too,
+// across challenges, reset by a good code. the lockout ends mfaAttemptsTTL
+// after the last attempt.
+const (
+	MaxMFAAttempts = 10
+	mfaAttemptsTTL = 15 * time.Minute
+)
+
+// longer than the window a code is accepted in (see auth.ValidateTOTP)
+const totpUsedTTL = 2 * time.Minute
+
+// password was right, second factor pending.
+// tokenHash: sha256 of the challenge token returned by POST /auth/login
+func (s *RedisSessionStore) SetMFAChallenge(ctx context.Context, tokenHash string, userCache UserCache) error {
+	key := MFAChallengeKey(tokenHash)
+
+	data, err := json.Marshal(userCache)
+	if err != nil {
+		return err
+	}
+
+	return s.rdb.Set(ctx, key, data, mfaChallengeTTL).Err()
+}
+
+// returns (nil, nil) when the challenge is unknown or expired
+func (s *RedisSessionStore) GetMFAChallenge(ctx context.Context, tokenHash string) (*UserCache, error) {
+	val, err := s.rdb.Get(ctx, MFAChallengeKey(tokenHash)).Result()
+
+	if err == redis.Nil {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var userCache UserCache
+	if err := json.Unmarshal([]byte(val), &userCache); err != nil {
+		return nil, err
+	}
+
+	return &userCache, nil
+}
+
+func (s *RedisSessionStore) DelMFAChallenge(ctx context.Context, tokenHash string) error {
+	return s.rdb.Del(ctx, MFAChallengeKey(tokenHash), MFAChallengeAttemptsKey(tokenHash)).Err()
+}
+
+// counted before the code is checked (including this one): concurrent
+// guesses each get their own number, none of them can skip the limit
+func (s *RedisSessionStore) IncrementMFAChallengeAttempts(ctx context.Context, tokenHash string) (int64, error) {
+	return s.incrWithTTL(ctx, MFAChallengeAttemptsKey(tokenHash), mfaChallengeTTL)
+}
+
+// the attempts of the user since their last good code, 0 when none
+func (s *RedisSessionStore) GetMFAAttempts(ctx context.Context, userId uuid.UUID) (int64, error) {
+	attempts, err := s.rdb.Get(ctx, MFAAttemptsKey(userId)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+
+	return attempts, err
+}
+
+func (s *RedisSessionStore) IncrementMFAAttempts(ctx context.Context, userId uuid.UUID) (int64, error) {
+	return s.incrWithTTL(ctx, MFAAttemptsKey(userId), mfaAttemptsTTL)
+}
+
+func (s *RedisSessionStore) ResetMFAAttempts(ctx context.Context, userId uuid.UUID) error {
+	return s.rdb.Del(ctx, MFAAttemptsKey(userId)).Err()
+}
+
+func (s *RedisSessionStore) incrWithTTL(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	pipe := s.rdb.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	return incr.Val(), nil
+}
+
+// a TOTP code is valid for ~90s (skew), without this an attacker who
+// shoulder-surfs a code could replay it in the same window.
+// returns false when this (user, time step) was already used.
+func (s *RedisSessionStore) MarkTOTPStepUsed(ctx context.Context, userId uuid.UUID, step int64) (bool, error) {
+	return s.rdb.SetNX(ctx, TOTPUsedKey(userId, step), 1, totpUsedTTL).Result()
+}
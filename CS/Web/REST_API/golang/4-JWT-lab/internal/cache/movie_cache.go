@@ -3,16 +3,20 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/grainme/movie-api/internal/domain"
 	"github.com/redis/go-redis/v9"
 )
 
-func GetMovieById(ctx context.Context, rdb *redis.Client, id uuid.UUID) (*domain.Movie, error) {
+// before jitter (see stampede.go)
+const movieTTL = 10 * time.Minute
+
+func (c *RedisMovieCache) GetMovie(ctx context.Context, id uuid.UUID) (*CachedMovie, error) {
 	key := MovieKey(id)
-	val, err := rdb.Get(ctx, key).Result()
+	val, err := c.rdb.Get(ctx, key).Result()
 
 	if err == redis.Nil {
 		// cache miss (it's not an error)
@@ -23,32 +27,120 @@ func GetMovieById(ctx context.Context, rdb *redis.Client, id uuid.UUID) (*domain
 		return nil, err
 	}
 
-	var movie domain.Movie
-	if err := json.Unmarshal([]byte(val), &movie); err != nil {
+	var entry CachedMovie
+	if err := json.Unmarshal([]byte(val), &entry); err != nil {
 		return nil, err
 	}
 
-	return &movie, nil
+	return &entry, nil
 }
 
-func SetMovie(ctx context.Context, rdb *redis.Client, movieId uuid.UUID, movieVal domain.Movie) error {
+func (c *RedisMovieCache) SetMovie(ctx context.Context, movieId uuid.UUID, entry CachedMovie) error {
 	movieKey := MovieKey(movieId)
 
-	data, err := json.Marshal(movieVal)
+	ttl := time.Until(entry.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(entry)
 	if err != nil {
 		return err
 	}
 
-	return rdb.Set(ctx, movieKey, data, time.Minute*10).Err()
+	return c.rdb.Set(ctx, movieKey, data, ttl).Err()
 }
 
-func DelMovie(ctx context.Context, rdb *redis.Client, movieId uuid.UUID) error {
+func (c *RedisMovieCache) DelMovie(ctx context.Context, movieId uuid.UUID) error {
 	movieKey := MovieKey(movieId)
-	return rdb.Del(ctx, movieKey).Err()
+	return c.rdb.Del(ctx, movieKey).Err()
 }
 
-// atomic increment
-func IncrementViewCount(ctx context.Context, rdb *redis.Client, id uuid.UUID) (int64, error) {
+// atomic increment, the id is remembered for the next flush
+func (c *RedisMovieCache) IncrementViewCount(ctx context.Context, id uuid.UUID) (int64, error) {
 	key := ViewsMovieKey(id)
-	return rdb.Incr(ctx, key).Result()
+
+	pipe := c.rdb.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.SAdd(ctx, pendingViewsKey, id.String())
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	return incr.Val(), nil
+}
+
+// SPOP + GETDEL in one script: a flusher that dies in between can't leave
+// counters out of the pending set. a view counted after the script creates
+// a new counter and puts the id back in the set, it's flushed next time.
+// nothing is counted twice.
+// (the counter keys are not in KEYS: fine on a single Redis, not on a cluster)
+var drainViewsScript = redis.NewScript(`
+local ids = redis.call('SPOP', KEYS[1], ARGV[1])
+local drained = {}
+for _, id in ipairs(ids) do
+	local count = redis.call('GETDEL', ARGV[2] .. id)
+	if count then
+		table.insert(drained, id)
+		table.insert(drained, count)
+	end
+end
+return drained
+`)
+
+func (c *RedisMovieCache) DrainViewCounts(ctx context.Context, max int) (map[uuid.UUID]int64, error) {
+	// id, count, id, count...
+	drained, err := drainViewsScript.Run(ctx, c.rdb, []string{pendingViewsKey}, max, viewsMoviePrefix).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[uuid.UUID]int64{}
+	for idx := 0; idx+1 < len(drained); idx += 2 {
+		movieId, err := uuid.Parse(drained[idx])
+		if err != nil {
+			continue
+		}
+		count, err := strconv.ParseInt(drained[idx+1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		counts[movieId] = count
+	}
+
+	return counts, nil
+}
+
+func (c *RedisMovieCache) RestoreViewCounts(ctx context.Context, counts map[uuid.UUID]int64) error {
+	if len(counts) == 0 {
+		return nil
+	}
+
+	pipe := c.rdb.TxPipeline()
+	for id, count := range counts {
+		pipe.IncrBy(ctx, ViewsMovieKey(id), count)
+		pipe.SAdd(ctx, pendingViewsKey, id.String())
+	}
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// TrackPendingViews puts the counters missing from the pending set back in
+// it (left by a flusher of an older version that died between SPOP and
+// GETDEL): nothing would flush them otherwise. returns how many were missing.
+// run it at startup, it SCANs every counter.
+func TrackPendingViews(ctx context.Context, rdb *redis.Client) (int, error) {
+	tracked := 0
+	err := scanKeys(ctx, rdb, viewsMoviePrefix, func(keys []string) error {
+		ids := make([]any, len(keys))
+		for idx, key := range keys {
+			ids[idx] = strings.TrimPrefix(key, viewsMoviePrefix)
+		}
+		added, err := rdb.SAdd(ctx, pendingViewsKey, ids...).Result()
+		tracked += int(added)
+		return err
+	})
+
+	return tracked, err
 }
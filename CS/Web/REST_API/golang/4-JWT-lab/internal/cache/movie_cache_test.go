@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestMemoryViewCounts(t *testing.T) {
+	testViewCounts(t, NewMemoryMovieCache())
+}
+
+func TestRedisViewCounts(t *testing.T) {
+	rdb := newTestRedis(t)
+	testViewCounts(t, NewRedisMovieCache(rdb))
+
+	// a counter left out of the pending set is only drained once tracked
+	ctx := context.Background()
+	orphan := uuid.New()
+	if err := rdb.Set(ctx, ViewsMovieKey(orphan), 4, 0).Err(); err != nil {
+		t.Fatalf("set orphan counter: %v", err)
+	}
+	movieCache := NewRedisMovieCache(rdb)
+	if counts, _ := movieCache.DrainViewCounts(ctx, 10); len(counts) != 0 {
+		t.Fatalf("drained an untracked counter: %v", counts)
+	}
+	if tracked, err := TrackPendingViews(ctx, rdb); err != nil || tracked != 1 {
+		t.Fatalf("track pending views = %d, %v, want 1", tracked, err)
+	}
+	if counts, err := movieCache.DrainViewCounts(ctx, 10); err != nil || len(counts) != 1 || counts[orphan] != 4 {
+		t.Fatalf("drain after tracking = %v, %v, want 4 views of %s", counts, err, orphan)
+	}
+}
+
+// what the ViewFlusher relies on, for both implementations
+func testViewCounts(t *testing.T, movieCache MovieCache) {
+	ctx := context.Background()
+	first, second := uuid.New(), uuid.New()
+
+	for range 3 {
+		movieCache.IncrementViewCount(ctx, first)
+	}
+	if count, err := movieCache.IncrementViewCount(ctx, second); err != nil || count != 1 {
+		t.Fatalf("increment = %d, %v, want 1", count, err)
+	}
+
+	// at most max movies per drain
+	drained := map[uuid.UUID]int64{}
+	for range 2 {
+		counts, err := movieCache.DrainViewCounts(ctx, 1)
+		if err != nil || len(counts) != 1 {
+			t.Fatalf("drain one = %v, %v", counts, err)
+		}
+		for id, count := range counts {
+			drained[id] = count
+		}
+	}
+	if drained[first] != 3 || drained[second] != 1 {
+		t.Fatalf("drained = %v, want 3 and 1", drained)
+	}
+	if counts, err := movieCache.DrainViewCounts(ctx, 10); err != nil || len(counts) != 0 {
+		t.Fatalf("drain of nothing = %v, %v", counts, err)
+	}
+
+	// restored counts add up with the views counted meanwhile
+	movieCache.IncrementViewCount(ctx, first)
+	if err := movieCache.RestoreViewCounts(ctx, drained); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	counts, err := movieCache.DrainViewCounts(ctx, 10)
+	if err != nil || counts[first] != 4 || counts[second] != 1 {
+		t.Fatalf("drain after restore = %v, %v, want 4 and 1", counts, err)
+	}
+}
@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// time the user has to log in at the provider and come back
+const OIDCStateTTL = 10 * time.Minute
+
+// what we need to remember between the redirect to the provider and the callback
+type OIDCState struct {
+	CodeVerifier string // PKCE
+	Nonce        string
+	// hash of the binding cookie of the browser that started the flow, the
+	// callback must come from that browser
+	BindingHash string
+	// set when a logged-in user links the provider (POST /auth/oidc/link)
+	LinkUserID *uuid.UUID `json:",omitempty"`
+}
+
+func (s *RedisSessionStore) SetOIDCState(ctx context.Context, state string, oidcState OIDCState) error {
+	data, err := json.Marshal(oidcState)
+	if err != nil {
+		return err
+	}
+
+	return s.rdb.Set(ctx, OIDCStateKey(state), data, OIDCStateTTL).Err()
+}
+
+// single-use (GETDEL): a replayed callback finds nothing.
+// returns (nil, nil) when the state is unknown or expired
+func (s *RedisSessionStore) ConsumeOIDCState(ctx context.Context, state string) (*OIDCState, error) {
+	val, err := s.rdb.GetDel(ctx, OIDCStateKey(state)).Result()
+
+	if err == redis.Nil {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var oidcState OIDCState
+	if err := json.Unmarshal([]byte(val), &oidcState); err != nil {
+		return nil, err
+	}
+
+	return &oidcState, nil
+}
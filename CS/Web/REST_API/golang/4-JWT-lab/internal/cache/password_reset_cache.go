@@ -0,0 +1,37 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// a reset link is only useful for a short window
+const passwordResetTTL = 30 * time.Minute
+
+// tokenHash: sha256 of the token we emailed (see auth.HashToken)
+// we never store the raw token, a Redis dump should not let anyone reset passwords
+func (s *RedisSessionStore) SetPasswordResetToken(ctx context.Context, tokenHash string, userId uuid.UUID) error {
+	key := PasswordResetKey(tokenHash)
+	return s.rdb.Set(ctx, key, userId.String(), passwordResetTTL).Err()
+}
+
+// GETDEL makes the token single-use: two concurrent resets with the same
+// token can't both succeed, only the first one gets the user id back.
+// returns uuid.Nil (and no error) when the token is unknown or expired
+func (s *RedisSessionStore) ConsumePasswordResetToken(ctx context.Context, tokenHash string) (uuid.UUID, error) {
+	key := PasswordResetKey(tokenHash)
+	val, err := s.rdb.GetDel(ctx, key).Result()
+
+	if err == redis.Nil {
+		return uuid.Nil, nil
+	}
+
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return uuid.Parse(val)
+}
@@ -0,0 +1,166 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// operator tools (moviectl): purge the sessions, flush the caches.
+// both SCAN the keys by prefix, never KEYS (it blocks Redis on a big keyspace)
+
+const scanBatch = 500
+
+// the session keys, and whose session each one is (nil: not tied to a user).
+// totp_used:* is not a session: purging it would allow replaying codes,
+// neither is mfa_attempts:* (purging it would lift the lockouts).
+var sessionPrefixes = []struct {
+	prefix string
+	owner  func(value []byte) (uuid.UUID, bool)
+}{
+	{refreshTokenPrefix, userCacheOwner},
+	{mfaChallengePrefix, userCacheOwner},
+	{passwordResetPrefix, func(value []byte) (uuid.UUID, bool) {
+		id, err := uuid.ParseBytes(value)
+		return id, err == nil
+	}},
+	{oidcStatePrefix, nil},
+}
+
+func userCacheOwner(value []byte) (uuid.UUID, bool) {
+	var user UserCache
+	if err := json.Unmarshal(value, &user); err != nil {
+		return uuid.Nil, false
+	}
+	return user.UserId, true
+}
+
+// the keys to delete in a batch of session keys: all of them, or only the
+// ones of userId (and the failed attempts counters of its MFA challenges)
+func sessionKeysToPurge(prefix string, owner func([]byte) (uuid.UUID, bool), userId *uuid.UUID, keys []string, values [][]byte) []string {
+	if userId == nil {
+		return keys
+	}
+
+	var purge []string
+	for idx, key := range keys {
+		if values[idx] == nil {
+			continue
+		}
+		if id, ok := owner(values[idx]); ok && id == *userId {
+			purge = append(purge, key)
+			if prefix == mfaChallengePrefix {
+				purge = append(purge, key+":attempts")
+			}
+		}
+	}
+	return purge
+}
+
+// PurgeSessions logs out everyone (userId nil) or one user: refresh tokens,
+// MFA challenges, password reset tokens (and pending OIDC logins when purging
+// everything). returns how many keys were deleted.
+func (s *RedisSessionStore) PurgeSessions(ctx context.Context, userId *uuid.UUID) (int, error) {
+	deleted := 0
+	for _, session := range sessionPrefixes {
+		if userId != nil && session.owner == nil {
+			continue
+		}
+
+		err := scanKeys(ctx, s.rdb, session.prefix, func(keys []string) error {
+			values := make([][]byte, len(keys))
+			if userId != nil {
+				results, err := s.rdb.MGet(ctx, keys...).Result()
+				if err != nil {
+					return err
+				}
+				for idx, result := range results {
+					if value, ok := result.(string); ok {
+						values[idx] = []byte(value)
+					}
+				}
+			}
+
+			purge := sessionKeysToPurge(session.prefix, session.owner, userId, keys, values)
+			if len(purge) == 0 {
+				return nil
+			}
+			count, err := s.rdb.Del(ctx, purge...).Result()
+			deleted += int(count)
+			return err
+		})
+		if err != nil {
+			return deleted, err
+		}
+	}
+
+	return deleted, nil
+}
+
+func (s *MemorySessionStore) PurgeSessions(ctx context.Context, userId *uuid.UUID) (int, error) {
+	deleted := 0
+	for _, session := range sessionPrefixes {
+		if userId != nil && session.owner == nil {
+			continue
+		}
+
+		entries := s.store.scan(session.prefix)
+		keys := make([]string, 0, len(entries))
+		values := make([][]byte, 0, len(entries))
+		for key, value := range entries {
+			keys = append(keys, key)
+			values = append(values, value)
+		}
+
+		purge := sessionKeysToPurge(session.prefix, session.owner, userId, keys, values)
+		deleted += s.store.del(purge...)
+	}
+
+	return deleted, nil
+}
+
+// FlushCache deletes the cached copies of the DB (movies, lists, merged
+// trending windows) and tells the API replicas to drop their local copies.
+// the sessions, the pending view counts and the trending buckets stay: they
+// are not copies, they'd be lost.
+func FlushCache(ctx context.Context, rdb *redis.Client) (int, error) {
+	deleted := 0
+	for _, prefix := range []string{moviePrefix, listPrefix, trendingPrefix + "merged:"} {
+		err := scanKeys(ctx, rdb, prefix, func(keys []string) error {
+			count, err := rdb.Del(ctx, keys...).Result()
+			deleted += int(count)
+			return err
+		})
+		if err != nil {
+			return deleted, err
+		}
+	}
+
+	err := rdb.Publish(ctx, movieInvalidationChannel, "flush:"+allMovies).Err()
+	return deleted, err
+}
+
+// SCAN MATCH prefix*, fn gets the keys batch by batch
+func scanKeys(ctx context.Context, rdb *redis.Client, prefix string, fn func(keys []string) error) error {
+	pattern := strings.NewReplacer("*", `\*`, "?", `\?`, "[", `\[`).Replace(prefix) + "*"
+
+	var cursor uint64
+	for {
+		keys, next, err := rdb.Scan(ctx, cursor, pattern, scanBatch).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+func TestSessionKeysToPurge(t *testing.T) {
+	alice, bob := uuid.New(), uuid.New()
+	userValue := func(id uuid.UUID) []byte {
+		value, _ := json.Marshal(UserCache{UserId: id})
+		return value
+	}
+
+	keys := []string{"mfa_challenge:a", "mfa_challenge:b", "mfa_challenge:gone", "mfa_challenge:broken"}
+	values := [][]byte{userValue(alice), userValue(bob), nil, []byte("{")}
+
+	// everyone: every key, nothing to read
+	if purge := sessionKeysToPurge(mfaChallengePrefix, userCacheOwner, nil, keys, make([][]byte, len(keys))); !slices.Equal(purge, keys) {
+		t.Fatalf("purge everyone = %v, want %v", purge, keys)
+	}
+
+	// one user: their keys only, and the attempts of their challenges
+	purge := sessionKeysToPurge(mfaChallengePrefix, userCacheOwner, &alice, keys, values)
+	if want := []string{"mfa_challenge:a", "mfa_challenge:a:attempts"}; !slices.Equal(purge, want) {
+		t.Fatalf("purge alice = %v, want %v", purge, want)
+	}
+	purge = sessionKeysToPurge(refreshTokenPrefix, userCacheOwner, &bob, []string{"refresh_token:b"}, [][]byte{userValue(bob)})
+	if want := []string{"refresh_token:b"}; !slices.Equal(purge, want) {
+		t.Fatalf("purge bob = %v, want %v", purge, want)
+	}
+}
+
+func TestRedisPurgeSessions(t *testing.T) {
+	ctx := context.Background()
+	rdb := newTestRedis(t)
+	sessions := NewRedisSessionStore(rdb)
+
+	alice := UserCache{UserId: uuid.New(), Username: "alice"}
+	bob := UserCache{UserId: uuid.New(), Username: "bob"}
+	sessions.SetUserByRefreshToken(ctx, uuid.New(), alice)
+	sessions.SetMFAChallenge(ctx, "alice-challenge", alice)
+	sessions.IncrementMFAChallengeAttempts(ctx, "alice-challenge")
+	sessions.SetPasswordResetToken(ctx, "alice-reset", alice.UserId)
+	sessions.SetOIDCState(ctx, "state", OIDCState{Nonce: "nonce"})
+	sessions.IncrementMFAAttempts(ctx, alice.UserId)
+	sessions.MarkTOTPStepUsed(ctx, alice.UserId, 42)
+	// more than a SCAN batch
+	bobTokens := scanBatch + 100
+	for range bobTokens {
+		sessions.SetUserByRefreshToken(ctx, uuid.New(), bob)
+	}
+
+	// refresh token, challenge and its attempts, reset token
+	deleted, err := sessions.PurgeSessions(ctx, &alice.UserId)
+	if err != nil || deleted != 4 {
+		t.Fatalf("purge alice = %d, %v, want 4", deleted, err)
+	}
+	// not sessions: the lockout and the used TOTP steps stay
+	if attempts, _ := sessions.GetMFAAttempts(ctx, alice.UserId); attempts != 1 {
+		t.Fatalf("mfa attempts after the purge = %d, want 1", attempts)
+	}
+	if first, _ := sessions.MarkTOTPStepUsed(ctx, alice.UserId, 42); first {
+		t.Fatal("used TOTP step forgotten by the purge")
+	}
+
+	// everyone: bob's tokens and the OIDC state
+	if deleted, err := sessions.PurgeSessions(ctx, nil); err != nil || deleted != bobTokens+1 {
+		t.Fatalf("purge everyone = %d, %v, want %d", deleted, err, bobTokens+1)
+	}
+	if keys, _ := rdb.Keys(ctx, refreshTokenPrefix+"*").Result(); len(keys) != 0 {
+		t.Fatalf("refresh tokens left: %v", keys)
+	}
+}
+
+func TestFlushCache(t *testing.T) {
+	ctx := context.Background()
+	rdb := newTestRedis(t)
+
+	movieId := uuid.New()
+	copies := []string{
+		MovieKey(movieId),
+		MoviesListKey(domain.Page{Limit: 10}),
+		TrendingMergedKey(time.Hour, 1),
+	}
+	kept := []string{
+		RefreshTokenKey(uuid.New()),
+		ViewsMovieKey(movieId),
+		TrendingBucketKey(trendingBucket, 1),
+		TagVersionKey(MoviesTag),
+	}
+	for _, key := range append(slices.Clone(copies), kept...) {
+		if err := rdb.Set(ctx, key, "1", 0).Err(); err != nil {
+			t.Fatalf("set %s: %v", key, err)
+		}
+	}
+
+	// the replicas are told to drop their local copies
+	sub := rdb.Subscribe(ctx, movieInvalidationChannel)
+	defer sub.Close()
+	if _, err := sub.Receive(ctx); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	deleted, err := FlushCache(ctx, rdb)
+	if err != nil || deleted != len(copies) {
+		t.Fatalf("flush = %d, %v, want %d", deleted, err, len(copies))
+	}
+	for _, key := range kept {
+		if exists, _ := rdb.Exists(ctx, key).Result(); exists != 1 {
+			t.Fatalf("%s deleted by the flush", key)
+		}
+	}
+
+	select {
+	case msg := <-sub.Channel():
+		if msg.Payload != "flush:"+allMovies {
+			t.Fatalf("invalidation = %q, want a flush", msg.Payload)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no invalidation published")
+	}
+}
@@ -15,3 +15,23 @@ func NewRedisClient(addr string) (*redis.Client, error) {
 	connTest := rdb.Ping(context.Background()).Err()
 	return rdb, connTest
 }
+
+type RedisMovieCache struct {
+	rdb *redis.Client
+}
+
+func NewRedisMovieCache(rdb *redis.Client) *RedisMovieCache {
+	return &RedisMovieCache{
+		rdb: rdb,
+	}
+}
+
+type RedisSessionStore struct {
+	rdb *redis.Client
+}
+
+func NewRedisSessionStore(rdb *redis.Client) *RedisSessionStore {
+	return &RedisSessionStore{
+		rdb: rdb,
+	}
+}
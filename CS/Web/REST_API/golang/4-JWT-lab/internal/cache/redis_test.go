@@ -0,0 +1,31 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// testRedisDB is flushed by every test that uses it, keep it for the tests
+const testRedisDB = 15
+
+// needs a Redis: TEST_REDIS_ADDR=localhost:6379 (the tests use, and flush,
+// its database 15)
+func newTestRedis(t *testing.T) *redis.Client {
+	t.Helper()
+
+	addr := os.Getenv("TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("TEST_REDIS_ADDR not set")
+	}
+
+	rdb := redis.NewClient(&redis.Options{Addr: addr, DB: testRedisDB})
+	if err := rdb.FlushDB(context.Background()).Err(); err != nil {
+		t.Fatalf("flush test redis: %v", err)
+	}
+	t.Cleanup(func() { rdb.Close() })
+
+	return rdb
+}
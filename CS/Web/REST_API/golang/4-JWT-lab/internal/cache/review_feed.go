@@ -0,0 +1,375 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// how many reviews a watcher can lag behind before it misses some
+	reviewFeedBuffer = 64
+	// how many reviews of a movie are kept for the watchers that reconnect
+	reviewBacklogSize = 1000
+	// and for how long after the last one (a quiet movie's backlog goes away)
+	reviewBacklogTTL = 24 * time.Hour
+)
+
+// RedisReviewFeed: one pub/sub channel per movie, so a watcher connected to
+// any replica sees the reviews posted on all of them, and one stream per movie
+// for the backlog. the stream entry ids are the event ids.
+// a replica subscribes once per watched movie (one connection for all of
+// them) and fans the reviews out to its watchers
+type RedisReviewFeed struct {
+	rdb *redis.Client
+
+	mu sync.Mutex
+	// opened with the first watcher, then kept
+	pubsub *redis.PubSub
+	// the movies watched on this replica, by pub/sub channel
+	movies map[string]*movieWatchers
+	// the SUBSCRIBEs sent and not confirmed yet, by channel
+	pendingSubscribes map[string]int
+}
+
+// the watchers of a movie on this replica
+type movieWatchers struct {
+	watchers map[chan domain.FeedReview]struct{}
+	// closed once Redis confirmed the subscription: from then on the reviews
+	// published reach the watchers
+	subscribed   chan struct{}
+	isSubscribed bool
+}
+
+func NewRedisReviewFeed(rdb *redis.Client) *RedisReviewFeed {
+	return &RedisReviewFeed{
+		rdb:               rdb,
+		movies:            map[string]*movieWatchers{},
+		pendingSubscribes: map[string]int{},
+	}
+}
+
+// the backlog first (it gives the id), then the live message
+func (f *RedisReviewFeed) PublishReview(ctx context.Context, review domain.Review) error {
+	payload, err := json.Marshal(review)
+	if err != nil {
+		return err
+	}
+
+	backlogKey := ReviewsBacklogKey(review.MovieID)
+	var add *redis.StringCmd
+	_, err = f.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		add = pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: backlogKey,
+			// trimmed by whole nodes, cheaper than the exact length
+			MaxLen: reviewBacklogSize,
+			Approx: true,
+			Values: map[string]any{"review": payload},
+		})
+		pipe.Expire(ctx, backlogKey, reviewBacklogTTL)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	message, err := json.Marshal(domain.FeedReview{ID: add.Val(), Review: review})
+	if err != nil {
+		return err
+	}
+	return f.rdb.Publish(ctx, ReviewsFeedChannel(review.MovieID), message).Err()
+}
+
+func (f *RedisReviewFeed) SubscribeReviews(ctx context.Context, movieId uuid.UUID, lastEventId string) (<-chan domain.FeedReview, error) {
+	if lastEventId != "" {
+		if _, _, ok := parseEventID(lastEventId); !ok {
+			return nil, domain.ErrInvalidEventID
+		}
+	}
+
+	channel := ReviewsFeedChannel(movieId)
+	live := make(chan domain.FeedReview, reviewFeedBuffer)
+	subscribed, err := f.watch(ctx, channel, live)
+	if err != nil {
+		return nil, fmt.Errorf("subscribe to the reviews of movie %s: %w", movieId, err)
+	}
+	// wait for the confirmation: the reviews published after we return are seen
+	select {
+	case <-subscribed:
+	case <-ctx.Done():
+		f.unwatch(channel, live)
+		return nil, fmt.Errorf("subscribe to the reviews of movie %s: %w", movieId, ctx.Err())
+	}
+
+	// read after subscribing: no gap between the backlog and the live reviews,
+	// but the ones published in between come twice (skipped below)
+	var backlog []domain.FeedReview
+	if lastEventId != "" {
+		var err error
+		if backlog, err = f.backlog(ctx, movieId, lastEventId); err != nil {
+			f.unwatch(channel, live)
+			return nil, err
+		}
+	}
+	// everything up to it was in the backlog
+	replayedUntil := lastEventId
+	if len(backlog) > 0 {
+		replayedUntil = backlog[len(backlog)-1].ID
+	}
+
+	reviews := make(chan domain.FeedReview, reviewFeedBuffer)
+	go func() {
+		defer close(reviews)
+		defer f.unwatch(channel, live)
+
+		for _, review := range backlog {
+			select {
+			case <-ctx.Done():
+				return
+			case reviews <- review:
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case review, ok := <-live:
+				// dropped (too slow, or the subscription was lost): the
+				// client reconnects and replays the rest from the backlog
+				if !ok {
+					return
+				}
+				if replayedUntil != "" && !eventIDAfter(review.ID, replayedUntil) {
+					continue
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case reviews <- review:
+				}
+			}
+		}
+	}()
+
+	return reviews, nil
+}
+
+// watch adds a watcher of channel, subscribing to it if it's the first one
+// here. the returned channel is closed once the subscription is confirmed
+func (f *RedisReviewFeed) watch(ctx context.Context, channel string, live chan domain.FeedReview) (<-chan struct{}, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.pubsub == nil {
+		f.pubsub = f.rdb.Subscribe(context.Background())
+		go f.listen(f.pubsub)
+	}
+
+	movie := f.movies[channel]
+	if movie == nil {
+		if err := f.pubsub.Subscribe(ctx, channel); err != nil {
+			// go-redis keeps the channel even when the SUBSCRIBE failed
+			f.pubsub.Unsubscribe(context.WithoutCancel(ctx), channel)
+			return nil, err
+		}
+		f.pendingSubscribes[channel]++
+		movie = &movieWatchers{
+			watchers:   map[chan domain.FeedReview]struct{}{},
+			subscribed: make(chan struct{}),
+		}
+		f.movies[channel] = movie
+	}
+	movie.watchers[live] = struct{}{}
+
+	return movie.subscribed, nil
+}
+
+// unwatch removes a watcher (a no-op once it was dropped), and the
+// subscription with the movie's last one
+func (f *RedisReviewFeed) unwatch(channel string, live chan domain.FeedReview) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	movie := f.movies[channel]
+	if movie == nil {
+		return
+	}
+	delete(movie.watchers, live)
+	if len(movie.watchers) == 0 {
+		f.unsubscribe(channel)
+	}
+}
+
+// listen fans the messages of the subscriptions out to the watchers, for as
+// long as the process runs
+func (f *RedisReviewFeed) listen(pubsub *redis.PubSub) {
+	ctx := context.Background()
+	for {
+		msg, err := pubsub.Receive(ctx)
+		if err != nil {
+			// go-redis reconnects on the next Receive. the reviews published
+			// in between are lost: every watcher is dropped, they replay them
+			// from the backlog
+			log.Printf("review feed subscription failed: %v", err)
+			f.dropAll()
+			time.Sleep(time.Second)
+			continue
+		}
+
+		switch m := msg.(type) {
+		case *redis.Subscription:
+			if m.Kind == "subscribe" {
+				f.confirmSubscribe(m.Channel)
+			}
+		case *redis.Message:
+			var review domain.FeedReview
+			if err := json.Unmarshal([]byte(m.Payload), &review); err != nil {
+				log.Printf("invalid review in the feed channel %s: %v", m.Channel, err)
+				continue
+			}
+			f.fanOut(m.Channel, review)
+		}
+	}
+}
+
+func (f *RedisReviewFeed) confirmSubscribe(channel string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	movie := f.movies[channel]
+	if pending := f.pendingSubscribes[channel]; pending > 0 {
+		if pending > 1 {
+			// an older SUBSCRIBE (unsubscribed since): wait for the last one
+			f.pendingSubscribes[channel]--
+			return
+		}
+		delete(f.pendingSubscribes, channel)
+		if movie != nil && !movie.isSubscribed {
+			movie.isSubscribed = true
+			close(movie.subscribed)
+		}
+		return
+	}
+
+	// not asked for: go-redis resubscribed after a reconnection, the reviews
+	// published in between are lost
+	if movie != nil && movie.isSubscribed {
+		f.drop(channel, movie)
+	}
+}
+
+func (f *RedisReviewFeed) fanOut(channel string, review domain.FeedReview) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	movie := f.movies[channel]
+	if movie == nil {
+		return
+	}
+	for live := range movie.watchers {
+		select {
+		case live <- review:
+		default:
+			// too slow: closed rather than skipping a review, the client
+			// reconnects with its last event id and replays from the backlog
+			log.Printf("review watcher of movie %s is too slow, dropping it", review.Review.MovieID)
+			delete(movie.watchers, live)
+			close(live)
+		}
+	}
+	if len(movie.watchers) == 0 {
+		f.unsubscribe(channel)
+	}
+}
+
+// the caller holds the lock
+func (f *RedisReviewFeed) drop(channel string, movie *movieWatchers) {
+	for live := range movie.watchers {
+		close(live)
+	}
+	// the watchers still waiting for the confirmation: they end right away
+	if !movie.isSubscribed {
+		movie.isSubscribed = true
+		close(movie.subscribed)
+	}
+	f.unsubscribe(channel)
+}
+
+func (f *RedisReviewFeed) dropAll() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for channel, movie := range f.movies {
+		f.drop(channel, movie)
+	}
+	// the confirmations of before are meaningless now
+	clear(f.pendingSubscribes)
+}
+
+// the caller holds the lock
+func (f *RedisReviewFeed) unsubscribe(channel string) {
+	delete(f.movies, channel)
+	if err := f.pubsub.Unsubscribe(context.Background(), channel); err != nil {
+		// the connection is gone: it doesn't resubscribe the channel
+		log.Printf("could not unsubscribe from %s: %v", channel, err)
+	}
+}
+
+// the reviews after lastEventId, oldest first. older than the backlog: what's left of it
+func (f *RedisReviewFeed) backlog(ctx context.Context, movieId uuid.UUID, lastEventId string) ([]domain.FeedReview, error) {
+	entries, err := f.rdb.XRange(ctx, ReviewsBacklogKey(movieId), "("+lastEventId, "+").Result()
+	if err != nil {
+		return nil, fmt.Errorf("read the backlog of movie %s: %w", movieId, err)
+	}
+
+	backlog := make([]domain.FeedReview, 0, len(entries))
+	for _, entry := range entries {
+		payload, _ := entry.Values["review"].(string)
+		var review domain.Review
+		if err := json.Unmarshal([]byte(payload), &review); err != nil {
+			log.Printf("invalid review %s in the backlog of movie %s: %v", entry.ID, movieId, err)
+			continue
+		}
+		backlog = append(backlog, domain.FeedReview{ID: entry.ID, Review: review})
+	}
+	return backlog, nil
+}
+
+// -------- event ids: Redis stream ids, "<unix ms>-<sequence>"
+
+func parseEventID(id string) (uint64, uint64, bool) {
+	msPart, seqPart, found := strings.Cut(id, "-")
+	if !found {
+		return 0, 0, false
+	}
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	seq, err := strconv.ParseUint(seqPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return ms, seq, true
+}
+
+// whether a comes after b (both valid)
+func eventIDAfter(a, b string) bool {
+	aMs, aSeq, _ := parseEventID(a)
+	bMs, bSeq, _ := parseEventID(b)
+	return aMs > bMs || (aMs == bMs && aSeq > bSeq)
+}
+
+func formatEventID(ms, seq uint64) string {
+	return strconv.FormatUint(ms, 10) + "-" + strconv.FormatUint(seq, 10)
+}
@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+// a watcher that doesn't read is dropped, not skipped over: it resumes from
+// its last event id and gets every review, once
+func testSlowWatcher(t *testing.T, feed ReviewFeed, dropped func(movieId uuid.UUID) bool) {
+	t.Helper()
+	ctx := context.Background()
+	movieId := uuid.New()
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	reviews, err := feed.SubscribeReviews(watchCtx, movieId, "")
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	// more than both buffers
+	published := make([]uuid.UUID, 3*reviewFeedBuffer)
+	for idx := range published {
+		published[idx] = uuid.New()
+		if err := feed.PublishReview(ctx, domain.Review{ID: published[idx], MovieID: movieId, Username: "ripley", Rating: 5}); err != nil {
+			t.Fatalf("publish: %v", err)
+		}
+	}
+	waitFor(t, "the slow watcher to be dropped", func() bool { return dropped(movieId) })
+
+	// what it got before, then the channel is closed
+	var received []domain.FeedReview
+	for review := range reviews {
+		received = append(received, review)
+	}
+	if len(received) == 0 || len(received) == len(published) {
+		t.Fatalf("received %d of %d reviews before the drop", len(received), len(published))
+	}
+
+	// the reconnection replays the rest
+	resumed, err := feed.SubscribeReviews(watchCtx, movieId, received[len(received)-1].ID)
+	if err != nil {
+		t.Fatalf("resubscribe: %v", err)
+	}
+	for len(received) < len(published) {
+		select {
+		case review := <-resumed:
+			received = append(received, review)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("received %d of %d reviews after the reconnection", len(received), len(published))
+		}
+	}
+	for idx, review := range received {
+		if review.Review.ID != published[idx] {
+			t.Fatalf("review %d = %s, want %s (in order, none twice)", idx, review.Review.ID, published[idx])
+		}
+	}
+}
+
+func TestMemoryReviewFeedDropsSlowWatchers(t *testing.T) {
+	feed := NewMemoryReviewFeed()
+	testSlowWatcher(t, feed, func(movieId uuid.UUID) bool {
+		feed.mu.Lock()
+		defer feed.mu.Unlock()
+		return len(feed.watchers[movieId]) == 0
+	})
+}
+
+func TestRedisReviewFeedDropsSlowWatchers(t *testing.T) {
+	feed := NewRedisReviewFeed(newTestRedis(t))
+	testSlowWatcher(t, feed, func(movieId uuid.UUID) bool {
+		feed.mu.Lock()
+		defer feed.mu.Unlock()
+		return feed.movies[ReviewsFeedChannel(movieId)] == nil
+	})
+}
+
+func TestRedisReviewFeedSubscribesOncePerMovie(t *testing.T) {
+	ctx := context.Background()
+	rdb := newTestRedis(t)
+	feed := NewRedisReviewFeed(rdb)
+	movieId := uuid.New()
+	channel := ReviewsFeedChannel(movieId)
+
+	subscribers := func() int64 {
+		counts, err := rdb.PubSubNumSub(ctx, channel).Result()
+		if err != nil {
+			t.Fatalf("pubsub numsub: %v", err)
+		}
+		return counts[channel]
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	var watchers []<-chan domain.FeedReview
+	for range 3 {
+		reviews, err := feed.SubscribeReviews(watchCtx, movieId, "")
+		if err != nil {
+			t.Fatalf("subscribe: %v", err)
+		}
+		watchers = append(watchers, reviews)
+	}
+	if count := subscribers(); count != 1 {
+		t.Fatalf("%d subscriptions to %s, want 1 for the 3 watchers", count, channel)
+	}
+
+	reviewId := uuid.New()
+	if err := feed.PublishReview(ctx, domain.Review{ID: reviewId, MovieID: movieId, Username: "ripley", Rating: 5}); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	for idx, reviews := range watchers {
+		select {
+		case review := <-reviews:
+			if review.Review.ID != reviewId {
+				t.Fatalf("watcher %d got %+v", idx, review)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("watcher %d got nothing", idx)
+		}
+	}
+
+	// the last one gone: unsubscribed
+	cancel()
+	waitFor(t, "the unsubscription", func() bool { return subscribers() == 0 })
+}
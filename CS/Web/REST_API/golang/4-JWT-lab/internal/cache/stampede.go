@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+// stampede protection for hot keys: when a popular key expires, every
+// request misses at the same time and they all hit the DB.
+//   - the service coalesces concurrent misses (singleflight)
+//   - TTLs get some jitter so keys cached together don't expire together
+//   - XFetch (Vattani et al.): the closer to expiry, the more likely a hit
+//     recomputes the value early, so a hot key is refreshed before it expires
+//   - unknown ids are cached too (negative caching), 404 floods stay in the cache
+
+// how much a TTL can move around (10% → 10 minutes becomes 9 to 11 minutes)
+const ttlJitter = 0.1
+
+// how short a "movie not found" is remembered: a movie created with
+// that id later (not possible with generated ids, but still) shows up quickly
+const negativeTTL = 30 * time.Second
+
+// XFetch beta: > 1 favors earlier recomputation, 1 is the recommended default
+const earlyRefreshBeta = 1.0
+
+// CachedMovie is what the MovieCache stores for a movie id
+type CachedMovie struct {
+	Movie     *domain.Movie `json:"movie"`      // nil: the movie doesn't exist
+	Delta     time.Duration `json:"delta"`      // how long loading it from the DB took
+	ExpiresAt time.Time     `json:"expires_at"` // when the entry leaves the cache
+}
+
+// NewCachedMovie wraps a freshly loaded movie (nil for "not found") with its
+// expiration: movieTTL with jitter, or negativeTTL
+func NewCachedMovie(movie *domain.Movie, delta time.Duration) CachedMovie {
+	ttl := negativeTTL
+	if movie != nil {
+		ttl = JitterTTL(movieTTL)
+	}
+
+	return CachedMovie{
+		Movie:     movie,
+		Delta:     delta,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+}
+
+// JitterTTL spreads ttl uniformly within ±ttlJitter
+func JitterTTL(ttl time.Duration) time.Duration {
+	spread := float64(ttl) * ttlJitter
+	return ttl + time.Duration((rand.Float64()*2-1)*spread)
+}
+
+// ShouldRefresh is the XFetch test: true when this reader should recompute
+// the entry now, even though it's still in the cache.
+//
+//	now - delta * beta * ln(rand()) >= expiry
+//
+// ln(rand()) is negative, so the further from the expiry (or the cheaper the
+// computation) the less likely it is.
+func (e CachedMovie) ShouldRefresh(now time.Time) bool {
+	if e.Delta <= 0 {
+		return false
+	}
+
+	gap := -float64(e.Delta) * earlyRefreshBeta * math.Log(1-rand.Float64())
+	return !now.Add(time.Duration(gap)).Before(e.ExpiresAt)
+}
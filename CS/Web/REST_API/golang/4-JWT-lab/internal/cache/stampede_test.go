@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+func TestNewCachedMovieTTL(t *testing.T) {
+	start := time.Now()
+
+	negative := NewCachedMovie(nil, time.Millisecond)
+	if ttl := negative.ExpiresAt.Sub(start); ttl < negativeTTL || ttl > negativeTTL+time.Second {
+		t.Fatalf("negative entry kept %s, want %s", ttl, negativeTTL)
+	}
+
+	for range 100 {
+		entry := NewCachedMovie(&domain.Movie{Title: "Heat"}, time.Millisecond)
+		ttl := entry.ExpiresAt.Sub(start)
+		if ttl < movieTTL*9/10 || ttl > movieTTL*11/10+time.Second {
+			t.Fatalf("movie kept %s, want %s ±%.0f%%", ttl, movieTTL, ttlJitter*100)
+		}
+	}
+}
+
+func TestShouldRefresh(t *testing.T) {
+	now := time.Now()
+	delta := 100 * time.Millisecond
+
+	// -ln(rand()) is at most ~37 (the smallest float64 rand() can return):
+	// that far from the expiry, nobody refreshes
+	far := CachedMovie{Delta: delta, ExpiresAt: now.Add(40 * delta)}
+	// at (or past) the expiry, everybody does
+	expired := CachedMovie{Delta: delta, ExpiresAt: now}
+	// no load time: no early refresh at all
+	unknownDelta := CachedMovie{ExpiresAt: now}
+
+	for range 1000 {
+		if far.ShouldRefresh(now) {
+			t.Fatal("refresh 40 deltas before the expiry")
+		}
+		if !expired.ShouldRefresh(now) {
+			t.Fatal("no refresh at the expiry")
+		}
+		if unknownDelta.ShouldRefresh(now) {
+			t.Fatal("refresh without a delta")
+		}
+	}
+
+	// one delta before the expiry: P(-ln(rand()) >= 1) = 1/e
+	near := CachedMovie{Delta: delta, ExpiresAt: now.Add(delta)}
+	const samples = 10000
+	refreshes := 0
+	for range samples {
+		if near.ShouldRefresh(now) {
+			refreshes++
+		}
+	}
+	if rate := float64(refreshes) / samples; rate < 0.33 || rate > 0.41 {
+		t.Fatalf("refresh rate one delta before the expiry = %.3f, want ~0.368", rate)
+	}
+}
+
+func TestMovieKeyVersion(t *testing.T) {
+	// the v1 values (bare movies) must never be read as CachedMovie
+	id := uuid.New()
+	if key := MovieKey(id); key == moviePrefix+id.String() || !strings.HasPrefix(key, moviePrefix) {
+		t.Fatalf("MovieKey = %s, want a versioned key under %s", key, moviePrefix)
+	}
+}
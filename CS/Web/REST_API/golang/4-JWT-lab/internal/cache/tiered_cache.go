@@ -0,0 +1,169 @@
+package cache
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// every replica publishes "<instance id>:<movie id>" here when a movie is
+// written (DelMovie), the others drop their local copy
+const movieInvalidationChannel = "cache:invalidate:movie"
+
+// instead of a movie id: drop every local copy (see FlushCache)
+const allMovies = "*"
+
+// TieredMovieCache is a MovieCache with two levels:
+//
+//	L1: in-process LRU, no network round trip, bounded by size and TTL
+//	L2: Redis, shared by every replica
+//
+// pub/sub is fire and forget: a replica that misses a message keeps a stale
+// copy for at most the local TTL, keep it short.
+type TieredMovieCache struct {
+	local      *lru[uuid.UUID, CachedMovie]
+	localTTL   time.Duration
+	remote     *RedisMovieCache
+	rdb        *redis.Client
+	instanceId string
+}
+
+func NewTieredMovieCache(rdb *redis.Client, size int, localTTL time.Duration) *TieredMovieCache {
+	return &TieredMovieCache{
+		local:      newLRU[uuid.UUID, CachedMovie](size),
+		localTTL:   localTTL,
+		remote:     NewRedisMovieCache(rdb),
+		rdb:        rdb,
+		instanceId: uuid.NewString(),
+	}
+}
+
+func (c *TieredMovieCache) GetMovie(ctx context.Context, id uuid.UUID) (*CachedMovie, error) {
+	if entry, ok := c.local.get(id); ok {
+		// same isolation as a Redis hit: the caller gets its own movie
+		if entry.Movie != nil {
+			movie := *entry.Movie
+			entry.Movie = &movie
+		}
+		return &entry, nil
+	}
+
+	entry, err := c.remote.GetMovie(ctx, id)
+	if err != nil || entry == nil {
+		return entry, err
+	}
+
+	c.keepLocally(id, *entry)
+	return entry, nil
+}
+
+// a fill, not a change: the copies of the other replicas came from the same
+// DB row, no invalidation (every miss of every replica would publish one).
+// the write paths go through DelMovie
+func (c *TieredMovieCache) SetMovie(ctx context.Context, id uuid.UUID, entry CachedMovie) error {
+	if err := c.remote.SetMovie(ctx, id, entry); err != nil {
+		return err
+	}
+
+	c.keepLocally(id, entry)
+	return nil
+}
+
+func (c *TieredMovieCache) DelMovie(ctx context.Context, id uuid.UUID) error {
+	c.local.remove(id)
+
+	if err := c.remote.DelMovie(ctx, id); err != nil {
+		return err
+	}
+
+	c.publishInvalidation(ctx, id)
+	return nil
+}
+
+func (c *TieredMovieCache) IncrementViewCount(ctx context.Context, id uuid.UUID) (int64, error) {
+	return c.remote.IncrementViewCount(ctx, id)
+}
+
+func (c *TieredMovieCache) DrainViewCounts(ctx context.Context, max int) (map[uuid.UUID]int64, error) {
+	return c.remote.DrainViewCounts(ctx, max)
+}
+
+func (c *TieredMovieCache) RestoreViewCounts(ctx context.Context, counts map[uuid.UUID]int64) error {
+	return c.remote.RestoreViewCounts(ctx, counts)
+}
+
+// Listen applies the invalidations published by the other replicas until ctx
+// is cancelled (run it in its own goroutine).
+func (c *TieredMovieCache) Listen(ctx context.Context) {
+	pubsub := c.rdb.Subscribe(ctx, movieInvalidationChannel)
+	defer pubsub.Close()
+
+	for {
+		msg, err := pubsub.Receive(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			// go-redis reconnects on the next Receive. we may have missed
+			// invalidations in between: nothing local can be trusted anymore
+			log.Printf("cache invalidation subscription failed: %v", err)
+			c.local.purge()
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		switch m := msg.(type) {
+		case *redis.Subscription:
+			// (re)subscribed, same reason as above
+			c.local.purge()
+		case *redis.Message:
+			c.applyInvalidation(m.Payload)
+		}
+	}
+}
+
+// payload: "<instance id>:<movie id or allMovies>", ours are already applied
+func (c *TieredMovieCache) applyInvalidation(payload string) {
+	origin, movieId, ok := strings.Cut(payload, ":")
+	if !ok || origin == c.instanceId {
+		return
+	}
+	if movieId == allMovies {
+		c.local.purge()
+		return
+	}
+	if id, err := uuid.Parse(movieId); err == nil {
+		c.local.remove(id)
+	}
+}
+
+// the local copy never outlives the Redis entry
+func (c *TieredMovieCache) keepLocally(id uuid.UUID, entry CachedMovie) {
+	expiresAt := time.Now().Add(c.localTTL)
+	if entry.ExpiresAt.Before(expiresAt) {
+		expiresAt = entry.ExpiresAt
+	}
+
+	if entry.Movie != nil {
+		movie := *entry.Movie
+		entry.Movie = &movie
+	}
+
+	c.local.add(id, entry, expiresAt)
+}
+
+func (c *TieredMovieCache) publishInvalidation(ctx context.Context, id uuid.UUID) {
+	err := c.rdb.Publish(ctx, movieInvalidationChannel, c.instanceId+":"+id.String()).Err()
+	if err != nil {
+		// the other replicas will catch up when their local TTL expires
+		log.Printf("could not publish cache invalidation for movie %s: %v", id, err)
+	}
+}
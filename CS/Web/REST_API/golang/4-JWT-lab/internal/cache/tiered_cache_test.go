@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+func TestTieredApplyInvalidation(t *testing.T) {
+	c := &TieredMovieCache{local: newLRU[uuid.UUID, CachedMovie](10), instanceId: "self"}
+	expiresAt := time.Now().Add(time.Minute)
+	first, second := uuid.New(), uuid.New()
+	c.local.add(first, CachedMovie{}, expiresAt)
+	c.local.add(second, CachedMovie{}, expiresAt)
+
+	// ours: already applied locally
+	c.applyInvalidation("self:" + first.String())
+	// garbage: ignored
+	c.applyInvalidation("no separator")
+	c.applyInvalidation("other:not-a-uuid")
+	if _, ok := c.local.get(first); !ok {
+		t.Fatal("local copy dropped by our own invalidation")
+	}
+
+	c.applyInvalidation("other:" + first.String())
+	if _, ok := c.local.get(first); ok {
+		t.Fatal("local copy kept after another replica's invalidation")
+	}
+	if _, ok := c.local.get(second); !ok {
+		t.Fatal("invalidating one movie dropped another")
+	}
+
+	c.applyInvalidation("flush:" + allMovies)
+	if _, ok := c.local.get(second); ok {
+		t.Fatal("local copy kept after a flush")
+	}
+}
+
+// two replicas on one Redis
+func TestTieredInvalidation(t *testing.T) {
+	rdb := newTestRedis(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	replicaA := NewTieredMovieCache(rdb, 10, time.Minute)
+	replicaB := NewTieredMovieCache(rdb, 10, time.Minute)
+	go replicaA.Listen(ctx)
+	go replicaB.Listen(ctx)
+	waitFor(t, "the subscriptions", func() bool {
+		subs, err := rdb.PubSubNumSub(ctx, movieInvalidationChannel).Result()
+		return err == nil && subs[movieInvalidationChannel] >= 2
+	})
+	// and for Listen to read the confirmations (they purge the local copies)
+	time.Sleep(100 * time.Millisecond)
+
+	id := uuid.New()
+	entry := NewCachedMovie(&domain.Movie{ID: id, Title: "Heat"}, time.Millisecond)
+	if err := replicaA.SetMovie(ctx, id, entry); err != nil {
+		t.Fatalf("set movie: %v", err)
+	}
+	// B reads it from Redis, then keeps it locally
+	if got, err := replicaB.GetMovie(ctx, id); err != nil || got == nil || got.Movie.Title != "Heat" {
+		t.Fatalf("replica B get = %+v, %v", got, err)
+	}
+
+	// another fill is not a change: B keeps its copy
+	if err := replicaA.SetMovie(ctx, id, entry); err != nil {
+		t.Fatalf("set movie again: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if _, ok := replicaB.local.get(id); !ok {
+		t.Fatal("a fill invalidated the other replica")
+	}
+
+	// a write is
+	if err := replicaA.DelMovie(ctx, id); err != nil {
+		t.Fatalf("del movie: %v", err)
+	}
+	waitFor(t, "replica B to drop its copy", func() bool {
+		_, ok := replicaB.local.get(id)
+		return !ok
+	})
+	if got, err := replicaB.GetMovie(ctx, id); err != nil || got != nil {
+		t.Fatalf("replica B get after the delete = %+v, %v", got, err)
+	}
+
+	// moviectl cache flush: every local copy goes
+	replicaA.SetMovie(ctx, id, entry)
+	replicaB.GetMovie(ctx, id)
+	if _, err := FlushCache(ctx, rdb); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	waitFor(t, "the flush", func() bool {
+		_, okA := replicaA.local.get(id)
+		_, okB := replicaB.local.get(id)
+		return !okA && !okB
+	})
+}
+
+// waitFor polls cond for at most 2 seconds
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
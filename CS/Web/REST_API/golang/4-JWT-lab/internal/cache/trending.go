@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+// trending: every view or review adds to the movie's score in the bucket of
+// the current time (a sorted set per bucket). a window is the union of its
+// buckets, each one weighted by its age so the last ones count the most:
+//
+//	weight = 2^(-age / half life), half life = window / 4
+//
+// 1h:  5 minutes ago 0.79, 15 minutes ago 0.5, an hour ago 0.06
+// 24h: 6 hours ago 0.5, a day ago 0.06
+// 7d:  yesterday 0.67, 42 hours ago 0.5, a week ago 0.06
+//
+// hourly buckets would leave the 1h window with 2 of them (the current one
+// and the previous one at 0.06): the short windows get 5 minute buckets.
+// every activity goes in both.
+
+const (
+	trendingBucket     = time.Hour
+	trendingFineBucket = 5 * time.Minute
+	// the windows up to this one use the fine buckets, kept that long
+	trendingFineWindow = time.Hour
+)
+
+// the largest window, the buckets are kept that long
+const MaxTrendingWindow = 7 * 24 * time.Hour
+
+// how long a merged window is reused before being recomputed
+const trendingMergedTTL = time.Minute
+
+// the bucket sizes, and how long their buckets are needed
+var trendingResolutions = []struct {
+	size time.Duration
+	keep time.Duration
+}{
+	{trendingFineBucket, trendingFineWindow},
+	{trendingBucket, MaxTrendingWindow},
+}
+
+type TrendingEntry struct {
+	ID    uuid.UUID
+	Score float64
+}
+
+// buckets of size since the epoch
+func trendingBucketAt(t time.Time, size time.Duration) int64 {
+	return t.Unix() / int64(size.Seconds())
+}
+
+func trendingBucketSize(window time.Duration) time.Duration {
+	if window <= trendingFineWindow {
+		return trendingFineBucket
+	}
+	return trendingBucket
+}
+
+// the bucket size of the window, its buckets (current one first) and their weights
+func trendingWeights(window time.Duration, now time.Time) (time.Duration, []int64, []float64) {
+	window = min(window, MaxTrendingWindow)
+	halfLife := float64(window) / 4
+	size := trendingBucketSize(window)
+
+	current := trendingBucketAt(now, size)
+	count := int(window / size)
+
+	// +1: the current bucket is only partly elapsed
+	buckets := make([]int64, 0, count+1)
+	weights := make([]float64, 0, count+1)
+	for age := 0; age <= count; age++ {
+		buckets = append(buckets, current-int64(age))
+		weights = append(weights, math.Exp2(-float64(time.Duration(age)*size)/halfLife))
+	}
+
+	return size, buckets, weights
+}
+
+type RedisTrendingStore struct {
+	rdb *redis.Client
+}
+
+func NewRedisTrendingStore(rdb *redis.Client) *RedisTrendingStore {
+	return &RedisTrendingStore{
+		rdb: rdb,
+	}
+}
+
+func (s *RedisTrendingStore) RecordActivity(ctx context.Context, id uuid.UUID, score float64) error {
+	now := time.Now()
+
+	pipe := s.rdb.Pipeline()
+	for _, resolution := range trendingResolutions {
+		key := TrendingBucketKey(resolution.size, trendingBucketAt(now, resolution.size))
+		pipe.ZIncrBy(ctx, key, score, id.String())
+		pipe.Expire(ctx, key, resolution.keep+resolution.size)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (s *RedisTrendingStore) TopMovies(ctx context.Context, window time.Duration, page domain.Page) ([]TrendingEntry, error) {
+	size, buckets, weights := trendingWeights(window, time.Now())
+	mergedKey := TrendingMergedKey(window, buckets[0])
+
+	// ZUNIONSTORE over up to 169 sets: do it once a minute, not per request
+	exists, err := s.rdb.Exists(ctx, mergedKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	if exists == 0 {
+		keys := make([]string, len(buckets))
+		for idx, bucket := range buckets {
+			keys[idx] = TrendingBucketKey(size, bucket)
+		}
+
+		pipe := s.rdb.TxPipeline()
+		pipe.ZUnionStore(ctx, mergedKey, &redis.ZStore{Keys: keys, Weights: weights})
+		pipe.Expire(ctx, mergedKey, trendingMergedTTL)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	stop := int64(-1)
+	if page.Limit > 0 {
+		stop = int64(page.Offset + page.Limit - 1)
+	}
+	scores, err := s.rdb.ZRevRangeWithScores(ctx, mergedKey, int64(page.Offset), stop).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]TrendingEntry, 0, len(scores))
+	for _, score := range scores {
+		member, _ := score.Member.(string)
+		id, err := uuid.Parse(member)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, TrendingEntry{ID: id, Score: score.Score})
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+func TestTrendingWeights(t *testing.T) {
+	now := newFakeClock().Now()
+
+	tests := []struct {
+		window  time.Duration
+		size    time.Duration
+		buckets int
+		// bucket age → weight
+		weights map[int]float64
+	}{
+		{time.Hour, trendingFineBucket, 13, map[int]float64{0: 1, 1: 0.7937, 3: 0.5, 12: 0.0625}},
+		{24 * time.Hour, trendingBucket, 25, map[int]float64{0: 1, 6: 0.5, 24: 0.0625}},
+		{MaxTrendingWindow, trendingBucket, 169, map[int]float64{0: 1, 24: 0.6729, 42: 0.5, 168: 0.0625}},
+		// capped to the largest window
+		{30 * 24 * time.Hour, trendingBucket, 169, map[int]float64{168: 0.0625}},
+	}
+
+	for _, tt := range tests {
+		size, buckets, weights := trendingWeights(tt.window, now)
+		if size != tt.size || len(buckets) != tt.buckets || len(weights) != tt.buckets {
+			t.Fatalf("%s: %d buckets of %s, want %d of %s", tt.window, len(buckets), size, tt.buckets, tt.size)
+		}
+		current := trendingBucketAt(now, size)
+		for age, want := range tt.weights {
+			if buckets[age] != current-int64(age) {
+				t.Fatalf("%s: bucket %d = %d, want %d", tt.window, age, buckets[age], current-int64(age))
+			}
+			if math.Abs(weights[age]-want) > 0.0001 {
+				t.Fatalf("%s: weight of age %d = %.4f, want %.4f", tt.window, age, weights[age], want)
+			}
+		}
+	}
+}
+
+func TestTrendingBucketKey(t *testing.T) {
+	// the hourly keys are the ones written before the fine buckets
+	if key := TrendingBucketKey(trendingBucket, 42); key != "trending:42" {
+		t.Fatalf("hourly key = %s", key)
+	}
+	if key := TrendingBucketKey(trendingFineBucket, 42); key != "trending:5m:42" {
+		t.Fatalf("5 minute key = %s", key)
+	}
+}
+
+func TestMemoryTrendingStore(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	store := NewMemoryTrendingStore()
+	store.now = clock.Now
+	top := func(window time.Duration, page domain.Page) []TrendingEntry {
+		t.Helper()
+		entries, err := store.TopMovies(ctx, window, page)
+		if err != nil {
+			t.Fatalf("top movies: %v", err)
+		}
+		return entries
+	}
+
+	old, recent := uuid.New(), uuid.New()
+	store.RecordActivity(ctx, old, 10)
+	clock.advance(time.Hour)
+	store.RecordActivity(ctx, recent, 1)
+	store.RecordActivity(ctx, recent, 1)
+
+	// 1h: old is 12 buckets of 5 minutes back
+	entries := top(time.Hour, domain.Page{})
+	if len(entries) != 2 || entries[0].ID != recent || entries[0].Score != 2 || entries[1].ID != old || math.Abs(entries[1].Score-0.625) > 0.0001 {
+		t.Fatalf("1h = %+v, want recent (2) then old (0.625)", entries)
+	}
+	// 24h: one hour back barely counts less
+	entries = top(24*time.Hour, domain.Page{})
+	if len(entries) != 2 || entries[0].ID != old || math.Abs(entries[0].Score-10*math.Exp2(-1.0/6)) > 0.0001 {
+		t.Fatalf("24h = %+v, want old first (%.3f)", entries, 10*math.Exp2(-1.0/6))
+	}
+
+	// paging, like ZREVRANGE
+	if entries := top(24*time.Hour, domain.Page{Offset: 1, Limit: 1}); len(entries) != 1 || entries[0].ID != recent {
+		t.Fatalf("second page = %+v, want recent", entries)
+	}
+	if entries := top(24*time.Hour, domain.Page{Offset: 2, Limit: 1}); len(entries) != 0 {
+		t.Fatalf("past the end = %+v", entries)
+	}
+
+	// out of the 1h window, still in the 24h one
+	clock.advance(2 * time.Hour)
+	if entries := top(time.Hour, domain.Page{}); len(entries) != 0 {
+		t.Fatalf("1h three hours later = %+v, want none", entries)
+	}
+	if entries := top(24*time.Hour, domain.Page{}); len(entries) != 2 {
+		t.Fatalf("24h three hours later = %+v, want both", entries)
+	}
+
+	// the buckets no window reaches anymore are dropped
+	clock.advance(MaxTrendingWindow)
+	store.RecordActivity(ctx, recent, 1)
+	if fine, hourly := len(store.buckets[trendingFineBucket]), len(store.buckets[trendingBucket]); fine != 1 || hourly != 1 {
+		t.Fatalf("buckets kept a week later: %d fine, %d hourly, want 1 and 1", fine, hourly)
+	}
+}
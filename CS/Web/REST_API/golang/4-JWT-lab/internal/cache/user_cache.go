@@ -10,16 +10,20 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// refresh token is valid for 7 days
+const refreshTokenTTL = 7 * 24 * time.Hour
+
 type UserCache struct {
 	UserId   uuid.UUID
 	Username string
 	Role     domain.Role
+	MFA      bool // the refreshed access token keeps the second factor flag
 }
 
 // id: is the UUID of the refresh token
-func GetUserByRefreshToken(ctx context.Context, rdb *redis.Client, refreshToken uuid.UUID) (*UserCache, error) {
+func (s *RedisSessionStore) GetUserByRefreshToken(ctx context.Context, refreshToken uuid.UUID) (*UserCache, error) {
 	key := RefreshTokenKey(refreshToken)
-	val, err := rdb.Get(ctx, key).Result()
+	val, err := s.rdb.Get(ctx, key).Result()
 
 	if err == redis.Nil {
 		// cache miss (it's not an error)
@@ -45,7 +49,7 @@ func GetUserByRefreshToken(ctx context.Context, rdb *redis.Client, refreshToken
 // ---
 // what if I store a some struct that contains (userId, role)
 // the stuff, I need to generate another access token?
-func SetUserByRefreshToken(ctx context.Context, rdb *redis.Client, refreshToken uuid.UUID, userCache UserCache) error {
+func (s *RedisSessionStore) SetUserByRefreshToken(ctx context.Context, refreshToken uuid.UUID, userCache UserCache) error {
 	refreshTokenKey := RefreshTokenKey(refreshToken)
 
 	data, err := json.Marshal(userCache)
@@ -53,11 +57,10 @@ func SetUserByRefreshToken(ctx context.Context, rdb *redis.Client, refreshToken
 		return err
 	}
 
-	// refresh token is valid for 7 days
-	return rdb.Set(ctx, refreshTokenKey, data, time.Hour*24*7).Err()
+	return s.rdb.Set(ctx, refreshTokenKey, data, refreshTokenTTL).Err()
 }
 
-func DelUserByRefreshTokenId(ctx context.Context, rdb *redis.Client, refreshTokenId uuid.UUID) error {
+func (s *RedisSessionStore) DelUserByRefreshToken(ctx context.Context, refreshTokenId uuid.UUID) error {
 	refreshTokenKey := RefreshTokenKey(refreshTokenId)
-	return rdb.Del(ctx, refreshTokenKey).Err()
+	return s.rdb.Del(ctx, refreshTokenKey).Err()
 }
@@ -7,7 +7,9 @@ package database
 import (
 	"database/sql"
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -54,11 +56,53 @@ func (ns NullUserRole) Value() (driver.Value, error) {
 	return string(ns.UserRole), nil
 }
 
+type ApiKey struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	Name       string
+	Prefix     string
+	KeyHash    string
+	Scopes     []string
+	CreatedAt  time.Time
+	ExpiresAt  sql.NullTime
+	LastUsedAt sql.NullTime
+	RevokedAt  sql.NullTime
+}
+
+type AuditEvent struct {
+	ID         uuid.UUID
+	OccurredAt time.Time
+	ActorID    uuid.NullUUID
+	ActorName  sql.NullString
+	ApiKeyID   uuid.NullUUID
+	Action     string
+	TargetType sql.NullString
+	TargetID   sql.NullString
+	Ip         sql.NullString
+	UserAgent  sql.NullString
+	Outcome    string
+	Detail     sql.NullString
+}
+
 type Movie struct {
 	ID       uuid.UUID
 	Title    string
 	Director string
 	Year     int32
+	Views    int64
+}
+
+type Outbox struct {
+	Sequence      int64
+	ID            uuid.UUID
+	EventType     string
+	AggregateType string
+	AggregateID   uuid.UUID
+	Payload       json.RawMessage
+	OccurredAt    time.Time
+	PublishedAt   sql.NullTime
+	Attempts      int32
+	LastError     sql.NullString
 }
 
 type Review struct {
@@ -76,4 +120,46 @@ type User struct {
 	Role         NullUserRole
 	CreatedAt    sql.NullTime
 	UpdatedAt    sql.NullTime
+	Email        sql.NullString
+	TotpSecret   sql.NullString
+	TotpEnabled  bool
+}
+
+type UserIdentity struct {
+	Provider  string
+	Subject   string
+	UserID    uuid.UUID
+	Email     sql.NullString
+	CreatedAt time.Time
+}
+
+type UserRecoveryCode struct {
+	ID       uuid.UUID
+	UserID   uuid.UUID
+	CodeHash string
+	UsedAt   sql.NullTime
+}
+
+type Webhook struct {
+	ID        uuid.UUID
+	Url       string
+	Events    []string
+	Secret    string
+	CreatedBy uuid.NullUUID
+	CreatedAt time.Time
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID
+	WebhookID      uuid.UUID
+	EventID        uuid.UUID
+	EventType      string
+	Payload        json.RawMessage
+	Status         string
+	Attempts       int32
+	NextAttemptAt  time.Time
+	LastAttemptAt  sql.NullTime
+	ResponseStatus int32
+	LastError      sql.NullString
+	CreatedAt      time.Time
 }
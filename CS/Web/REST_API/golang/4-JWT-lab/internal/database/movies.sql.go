@@ -7,15 +7,17 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const addMovie = `-- name: AddMovie :one
 INSERT INTO
   movies (id, title, director, year)
 VALUES
-  ($1, $2, $3, $4) RETURNING id, title, director, year
+  ($1, $2, $3, $4) RETURNING id, title, director, year, views
 `
 
 type AddMovieParams struct {
@@ -38,24 +40,46 @@ func (q *Queries) AddMovie(ctx context.Context, arg AddMovieParams) (Movie, erro
 		&i.Title,
 		&i.Director,
 		&i.Year,
+		&i.Views,
 	)
 	return i, err
 }
 
-const deleteMovieById = `-- name: DeleteMovieById :exec
+const addMovieViews = `-- name: AddMovieViews :exec
+UPDATE movies
+SET
+  views = views + $1::bigint
+WHERE
+  id = $2::uuid
+`
+
+type AddMovieViewsParams struct {
+	Views int64
+	ID    uuid.UUID
+}
+
+func (q *Queries) AddMovieViews(ctx context.Context, arg AddMovieViewsParams) error {
+	_, err := q.db.ExecContext(ctx, addMovieViews, arg.Views, arg.ID)
+	return err
+}
+
+const deleteMovieById = `-- name: DeleteMovieById :execrows
 DELETE FROM movies
 WHERE
   id = $1
 `
 
-func (q *Queries) DeleteMovieById(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, deleteMovieById, id)
-	return err
+func (q *Queries) DeleteMovieById(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteMovieById, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const getMovieById = `-- name: GetMovieById :one
 SELECT
-  id, title, director, year
+  id, title, director, year, views
 FROM
   movies
 WHERE
@@ -70,14 +94,15 @@ func (q *Queries) GetMovieById(ctx context.Context, id uuid.UUID) (Movie, error)
 		&i.Title,
 		&i.Director,
 		&i.Year,
+		&i.Views,
 	)
 	return i, err
 }
 
 const getMovieWithReviews = `-- name: GetMovieWithReviews :one
 SELECT
-  m.id, m.title, m.director, m.year,
-  AVG(r.rating) as avg_rating,
+  m.id, m.title, m.director, m.year, m.views,
+  COALESCE(AVG(r.rating), 0)::float8 as avg_rating,
   COUNT(r.*) as reviews_count
 FROM
   movies m
@@ -93,6 +118,7 @@ type GetMovieWithReviewsRow struct {
 	Title        string
 	Director     string
 	Year         int32
+	Views        int64
 	AvgRating    float64
 	ReviewsCount int64
 }
@@ -105,6 +131,7 @@ func (q *Queries) GetMovieWithReviews(ctx context.Context, id uuid.UUID) (GetMov
 		&i.Title,
 		&i.Director,
 		&i.Year,
+		&i.Views,
 		&i.AvgRating,
 		&i.ReviewsCount,
 	)
@@ -113,13 +140,74 @@ func (q *Queries) GetMovieWithReviews(ctx context.Context, id uuid.UUID) (GetMov
 
 const getMovies = `-- name: GetMovies :many
 SELECT
-  id, title, director, year
+  id, title, director, year, views
+FROM
+  movies
+ORDER BY
+  lower(title COLLATE "C"),
+  id
+LIMIT
+  $1::int
+OFFSET
+  $2::int
+`
+
+type GetMoviesParams struct {
+	PageSize   sql.NullInt32
+	PageOffset int32
+}
+
+// case-insensitive, ASCII only and bytewise ("C"): the order of the SQLite
+// and the in-memory backends, whatever the collation of the database
+func (q *Queries) GetMovies(ctx context.Context, arg GetMoviesParams) ([]Movie, error) {
+	rows, err := q.db.QueryContext(ctx, getMovies, arg.PageSize, arg.PageOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Movie
+	for rows.Next() {
+		var i Movie
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Director,
+			&i.Year,
+			&i.Views,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMoviesAfter = `-- name: GetMoviesAfter :many
+SELECT
+  id, title, director, year, views
 FROM
   movies
+WHERE
+  id > $1::uuid
+ORDER BY
+  id
+LIMIT
+  $2::int
 `
 
-func (q *Queries) GetMovies(ctx context.Context) ([]Movie, error) {
-	rows, err := q.db.QueryContext(ctx, getMovies)
+type GetMoviesAfterParams struct {
+	After    uuid.UUID
+	PageSize int32
+}
+
+func (q *Queries) GetMoviesAfter(ctx context.Context, arg GetMoviesAfterParams) ([]Movie, error) {
+	rows, err := q.db.QueryContext(ctx, getMoviesAfter, arg.After, arg.PageSize)
 	if err != nil {
 		return nil, err
 	}
@@ -132,6 +220,7 @@ func (q *Queries) GetMovies(ctx context.Context) ([]Movie, error) {
 			&i.Title,
 			&i.Director,
 			&i.Year,
+			&i.Views,
 		); err != nil {
 			return nil, err
 		}
@@ -146,12 +235,117 @@ func (q *Queries) GetMovies(ctx context.Context) ([]Movie, error) {
 	return items, nil
 }
 
-const updateMovieTitleById = `-- name: UpdateMovieTitleById :exec
+const getMoviesWithReviewsByIds = `-- name: GetMoviesWithReviewsByIds :many
+SELECT
+  m.id, m.title, m.director, m.year, m.views,
+  COALESCE(AVG(r.rating), 0)::float8 as avg_rating,
+  COUNT(r.*) as reviews_count
+FROM
+  movies m
+  LEFT JOIN reviews r ON m.id = r.movie_id
+WHERE
+  m.id = ANY($1::text[]::uuid[])
+GROUP BY
+  m.id
+ORDER BY
+  m.id
+`
+
+type GetMoviesWithReviewsByIdsRow struct {
+	ID           uuid.UUID
+	Title        string
+	Director     string
+	Year         int32
+	Views        int64
+	AvgRating    float64
+	ReviewsCount int64
+}
+
+func (q *Queries) GetMoviesWithReviewsByIds(ctx context.Context, ids []string) ([]GetMoviesWithReviewsByIdsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getMoviesWithReviewsByIds, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetMoviesWithReviewsByIdsRow
+	for rows.Next() {
+		var i GetMoviesWithReviewsByIdsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Director,
+			&i.Year,
+			&i.Views,
+			&i.AvgRating,
+			&i.ReviewsCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPopularMovies = `-- name: GetPopularMovies :many
+SELECT
+  id, title, director, year, views
+FROM
+  movies
+ORDER BY
+  views DESC,
+  id
+LIMIT
+  $1::int
+OFFSET
+  $2::int
+`
+
+type GetPopularMoviesParams struct {
+	PageSize   sql.NullInt32
+	PageOffset int32
+}
+
+func (q *Queries) GetPopularMovies(ctx context.Context, arg GetPopularMoviesParams) ([]Movie, error) {
+	rows, err := q.db.QueryContext(ctx, getPopularMovies, arg.PageSize, arg.PageOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Movie
+	for rows.Next() {
+		var i Movie
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Director,
+			&i.Year,
+			&i.Views,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateMovieTitleById = `-- name: UpdateMovieTitleById :one
 UPDATE movies
 SET
   title = $2
 WHERE
-  id = $1
+  id = $1 RETURNING id, title, director, year, views
 `
 
 type UpdateMovieTitleByIdParams struct {
@@ -159,7 +353,50 @@ type UpdateMovieTitleByIdParams struct {
 	Title string
 }
 
-func (q *Queries) UpdateMovieTitleById(ctx context.Context, arg UpdateMovieTitleByIdParams) error {
-	_, err := q.db.ExecContext(ctx, updateMovieTitleById, arg.ID, arg.Title)
-	return err
+// the movie as updated: the data of its movie.updated event
+func (q *Queries) UpdateMovieTitleById(ctx context.Context, arg UpdateMovieTitleByIdParams) (Movie, error) {
+	row := q.db.QueryRowContext(ctx, updateMovieTitleById, arg.ID, arg.Title)
+	var i Movie
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Director,
+		&i.Year,
+		&i.Views,
+	)
+	return i, err
+}
+
+const getExistingMovieIds = `-- name: GetExistingMovieIds :many
+SELECT
+  id
+FROM
+  movies
+WHERE
+  id = ANY($1::text[]::uuid[])
+ORDER BY
+  id
+`
+
+func (q *Queries) GetExistingMovieIds(ctx context.Context, ids []string) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getExistingMovieIds, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
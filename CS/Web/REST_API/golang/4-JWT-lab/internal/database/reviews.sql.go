@@ -10,6 +10,7 @@ import (
 	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const addReview = `-- name: AddReview :one
@@ -88,11 +89,23 @@ SELECT
 FROM
   reviews
 WHERE
-  movie_id = $1
+  movie_id = $1::uuid
+ORDER BY
+  id
+LIMIT
+  $2::int
+OFFSET
+  $3::int
 `
 
-func (q *Queries) GetAllReviewsByMovieId(ctx context.Context, movieID uuid.UUID) ([]Review, error) {
-	rows, err := q.db.QueryContext(ctx, getAllReviewsByMovieId, movieID)
+type GetAllReviewsByMovieIdParams struct {
+	MovieID    uuid.UUID
+	PageSize   sql.NullInt32
+	PageOffset int32
+}
+
+func (q *Queries) GetAllReviewsByMovieId(ctx context.Context, arg GetAllReviewsByMovieIdParams) ([]Review, error) {
+	rows, err := q.db.QueryContext(ctx, getAllReviewsByMovieId, arg.MovieID, arg.PageSize, arg.PageOffset)
 	if err != nil {
 		return nil, err
 	}
@@ -119,3 +132,220 @@ func (q *Queries) GetAllReviewsByMovieId(ctx context.Context, movieID uuid.UUID)
 	}
 	return items, nil
 }
+
+const getReviewsAfter = `-- name: GetReviewsAfter :many
+SELECT
+  id, user_name, rating, comment, movie_id
+FROM
+  reviews
+WHERE
+  id > $1::uuid
+ORDER BY
+  id
+LIMIT
+  $2::int
+`
+
+type GetReviewsAfterParams struct {
+	After    uuid.UUID
+	PageSize int32
+}
+
+func (q *Queries) GetReviewsAfter(ctx context.Context, arg GetReviewsAfterParams) ([]Review, error) {
+	rows, err := q.db.QueryContext(ctx, getReviewsAfter, arg.After, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Review
+	for rows.Next() {
+		var i Review
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserName,
+			&i.Rating,
+			&i.Comment,
+			&i.MovieID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReviewsByMovieIds = `-- name: GetReviewsByMovieIds :many
+SELECT
+  id,
+  user_name,
+  rating,
+  comment,
+  movie_id
+FROM
+  (
+    SELECT
+      id, user_name, rating, comment, movie_id,
+      ROW_NUMBER() OVER (
+        PARTITION BY
+          movie_id
+        ORDER BY
+          id
+      ) AS position
+    FROM
+      reviews
+    WHERE
+      movie_id = ANY($1::text[]::uuid[])
+  ) ranked
+WHERE
+  position > $2::int
+  AND (
+    $3::int IS NULL
+    OR position <= $2::int + $3::int
+  )
+ORDER BY
+  movie_id,
+  id
+`
+
+type GetReviewsByMovieIdsParams struct {
+	MovieIds   []string
+	PageOffset int32
+	PageSize   sql.NullInt32
+}
+
+func (q *Queries) GetReviewsByMovieIds(ctx context.Context, arg GetReviewsByMovieIdsParams) ([]Review, error) {
+	rows, err := q.db.QueryContext(ctx, getReviewsByMovieIds, pq.Array(arg.MovieIds), arg.PageOffset, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Review
+	for rows.Next() {
+		var i Review
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserName,
+			&i.Rating,
+			&i.Comment,
+			&i.MovieID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReviewsByUsernames = `-- name: GetReviewsByUsernames :many
+SELECT
+  id,
+  user_name,
+  rating,
+  comment,
+  movie_id
+FROM
+  (
+    SELECT
+      id, user_name, rating, comment, movie_id,
+      ROW_NUMBER() OVER (
+        PARTITION BY
+          user_name
+        ORDER BY
+          id
+      ) AS position
+    FROM
+      reviews
+    WHERE
+      user_name = ANY($1::text[])
+  ) ranked
+WHERE
+  position > $2::int
+  AND (
+    $3::int IS NULL
+    OR position <= $2::int + $3::int
+  )
+ORDER BY
+  user_name,
+  id
+`
+
+type GetReviewsByUsernamesParams struct {
+	Usernames  []string
+	PageOffset int32
+	PageSize   sql.NullInt32
+}
+
+func (q *Queries) GetReviewsByUsernames(ctx context.Context, arg GetReviewsByUsernamesParams) ([]Review, error) {
+	rows, err := q.db.QueryContext(ctx, getReviewsByUsernames, pq.Array(arg.Usernames), arg.PageOffset, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Review
+	for rows.Next() {
+		var i Review
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserName,
+			&i.Rating,
+			&i.Comment,
+			&i.MovieID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getExistingReviewIds = `-- name: GetExistingReviewIds :many
+SELECT
+  id
+FROM
+  reviews
+WHERE
+  id = ANY($1::text[]::uuid[])
+ORDER BY
+  id
+`
+
+func (q *Queries) GetExistingReviewIds(ctx context.Context, ids []string) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getExistingReviewIds, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
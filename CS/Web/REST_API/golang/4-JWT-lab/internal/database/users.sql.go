@@ -7,25 +7,114 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const addUser = `-- name: AddUser :one
 INSERT INTO
-  users (id, username, password_hash)
+  users (id, username, password_hash, email)
 VALUES
-  ($1, $2, $3) RETURNING id, username, password_hash, role, created_at, updated_at
+  ($1, $2, $3, $4) RETURNING id, username, password_hash, role, created_at, updated_at, email, totp_secret, totp_enabled
 `
 
 type AddUserParams struct {
 	ID           uuid.UUID
 	Username     string
 	PasswordHash string
+	Email        sql.NullString
 }
 
 func (q *Queries) AddUser(ctx context.Context, arg AddUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, addUser, arg.ID, arg.Username, arg.PasswordHash)
+	row := q.db.QueryRowContext(ctx, addUser,
+		arg.ID,
+		arg.Username,
+		arg.PasswordHash,
+		arg.Email,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.PasswordHash,
+		&i.Role,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+	)
+	return i, err
+}
+
+const disableUserTotp = `-- name: DisableUserTotp :exec
+UPDATE users
+SET
+  totp_secret = NULL,
+  totp_enabled = FALSE,
+  updated_at = NOW()
+WHERE
+  id = $1
+`
+
+func (q *Queries) DisableUserTotp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, disableUserTotp, id)
+	return err
+}
+
+const enableUserTotp = `-- name: EnableUserTotp :exec
+UPDATE users
+SET
+  totp_enabled = TRUE,
+  updated_at = NOW()
+WHERE
+  id = $1
+`
+
+func (q *Queries) EnableUserTotp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, enableUserTotp, id)
+	return err
+}
+
+const findUserByEmail = `-- name: FindUserByEmail :one
+SELECT
+  id, username, password_hash, role, created_at, updated_at, email, totp_secret, totp_enabled
+FROM
+  users
+WHERE
+  email = $1
+`
+
+func (q *Queries) FindUserByEmail(ctx context.Context, email sql.NullString) (User, error) {
+	row := q.db.QueryRowContext(ctx, findUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.PasswordHash,
+		&i.Role,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+	)
+	return i, err
+}
+
+const findUserById = `-- name: FindUserById :one
+SELECT
+  id, username, password_hash, role, created_at, updated_at, email, totp_secret, totp_enabled
+FROM
+  users
+WHERE
+  id = $1
+`
+
+func (q *Queries) FindUserById(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, findUserById, id)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -34,13 +123,16 @@ func (q *Queries) AddUser(ctx context.Context, arg AddUserParams) (User, error)
 		&i.Role,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Email,
+		&i.TotpSecret,
+		&i.TotpEnabled,
 	)
 	return i, err
 }
 
 const findUserByName = `-- name: FindUserByName :one
 SELECT
-  id, username, password_hash, role, created_at, updated_at
+  id, username, password_hash, role, created_at, updated_at, email, totp_secret, totp_enabled
 FROM
   users
 WHERE
@@ -57,6 +149,111 @@ func (q *Queries) FindUserByName(ctx context.Context, username string) (User, er
 		&i.Role,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Email,
+		&i.TotpSecret,
+		&i.TotpEnabled,
 	)
 	return i, err
 }
+
+const findUsersByNames = `-- name: FindUsersByNames :many
+SELECT
+  id, username, password_hash, role, created_at, updated_at, email, totp_secret, totp_enabled
+FROM
+  users
+WHERE
+  username = ANY($1::text[])
+ORDER BY
+  username
+`
+
+func (q *Queries) FindUsersByNames(ctx context.Context, usernames []string) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, findUsersByNames, pq.Array(usernames))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.PasswordHash,
+			&i.Role,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.TotpSecret,
+			&i.TotpEnabled,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setUserTotpSecret = `-- name: SetUserTotpSecret :exec
+UPDATE users
+SET
+  totp_secret = $2,
+  totp_enabled = FALSE,
+  updated_at = NOW()
+WHERE
+  id = $1
+`
+
+type SetUserTotpSecretParams struct {
+	ID         uuid.UUID
+	TotpSecret sql.NullString
+}
+
+func (q *Queries) SetUserTotpSecret(ctx context.Context, arg SetUserTotpSecretParams) error {
+	_, err := q.db.ExecContext(ctx, setUserTotpSecret, arg.ID, arg.TotpSecret)
+	return err
+}
+
+const updateUserPasswordHash = `-- name: UpdateUserPasswordHash :exec
+UPDATE users
+SET
+  password_hash = $2,
+  updated_at = NOW()
+WHERE
+  id = $1
+`
+
+type UpdateUserPasswordHashParams struct {
+	ID           uuid.UUID
+	PasswordHash string
+}
+
+func (q *Queries) UpdateUserPasswordHash(ctx context.Context, arg UpdateUserPasswordHashParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserPasswordHash, arg.ID, arg.PasswordHash)
+	return err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :exec
+UPDATE users
+SET
+  role = $2,
+  updated_at = NOW()
+WHERE
+  id = $1
+`
+
+type UpdateUserRoleParams struct {
+	ID   uuid.UUID
+	Role NullUserRole
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserRole, arg.ID, arg.Role)
+	return err
+}
@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey never contains the key itself, only its prefix (to recognize it in a list)
+type APIKey struct {
+	ID         uuid.UUID    `json:"id"`
+	UserID     uuid.UUID    `json:"user_id"` // the admin who created it, the key acts on their behalf
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	Scopes     []Permission `json:"scopes"`
+	CreatedAt  time.Time    `json:"created_at"`
+	ExpiresAt  *time.Time   `json:"expires_at"`
+	LastUsedAt *time.Time   `json:"last_used_at"`
+	RevokedAt  *time.Time   `json:"revoked_at"`
+	OwnerRole  Role         `json:"-"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name          string       `json:"name"`
+	Scopes        []Permission `json:"scopes"`
+	ExpiresInDays int          `json:"expires_in_days,omitempty"` // 0 = never
+}
+
+// returned once, at creation. after that the key can't be recovered, only revoked.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuditOutcome string
+
+const (
+	AuditSuccess AuditOutcome = "success"
+	AuditFailure AuditOutcome = "failure" // bad credentials, invalid code...
+	AuditDenied  AuditOutcome = "denied"  // authenticated but not allowed
+)
+
+// actions are "<area>.<verb>", keep them stable: admins filter on them
+const (
+	AuditLogin              = "auth.login"
+	AuditLoginMFA           = "auth.login.mfa"
+	AuditLoginOIDC          = "auth.login.oidc"
+	AuditIdentityLink       = "auth.identity.link"
+	AuditRegister           = "auth.register"
+	AuditPasswordResetStart = "auth.password_reset.request"
+	AuditPasswordReset      = "auth.password_reset"
+	AuditTOTPEnable         = "auth.2fa.enable"
+	AuditTOTPDisable        = "auth.2fa.disable"
+	AuditUserRoleChange     = "user.role.change"
+	AuditSessionsPurge      = "auth.sessions.purge"
+	AuditMovieDelete        = "movie.delete"
+	AuditMoviesImport       = "movie.import"
+	AuditReviewsImport      = "review.import"
+	AuditAPIKeyCreate       = "api_key.create"
+	AuditAPIKeyRevoke       = "api_key.revoke"
+	AuditWebhookCreate      = "webhook.create"
+	AuditWebhookDelete      = "webhook.delete"
+	AuditWebhookRedeliver   = "webhook.redeliver"
+	AuditRead               = "audit.read"
+	AuditAuthorize          = "auth.authorize" // only the refused requests (outcome denied)
+)
+
+// AuditEvent: who (actor) did what (action) to what (target), from where (ip,
+// user agent), and whether it worked (outcome). append-only, never updated.
+type AuditEvent struct {
+	ID         uuid.UUID    `json:"id"`
+	OccurredAt time.Time    `json:"occurred_at"`
+	ActorID    *uuid.UUID   `json:"actor_id,omitempty"`   // nil: anonymous (e.g. failed login of an unknown user)
+	ActorName  string       `json:"actor_name,omitempty"` // the username at that time, or the one that was tried
+	APIKeyID   *uuid.UUID   `json:"api_key_id,omitempty"` // set when the actor used an API key
+	Action     string       `json:"action"`
+	TargetType string       `json:"target_type,omitempty"`
+	TargetID   string       `json:"target_id,omitempty"`
+	IP         string       `json:"ip,omitempty"`
+	UserAgent  string       `json:"user_agent,omitempty"`
+	Outcome    AuditOutcome `json:"outcome"`
+	Detail     string       `json:"detail,omitempty"` // never secrets (passwords, tokens, codes)
+}
+
+// AuditFilter: zero values mean "no filter"
+type AuditFilter struct {
+	ActorID    *uuid.UUID
+	Action     string
+	Outcome    AuditOutcome
+	TargetType string
+	TargetID   string
+	Since      *time.Time
+	Until      *time.Time
+	Limit      int
+	Offset     int
+}
+
+// RequestMeta is where a request comes from, stored in the context by
+// middleware.RequestMeta so the services can put it in audit events
+type RequestMeta struct {
+	IP        string
+	UserAgent string
+}
@@ -7,4 +7,24 @@ var (
 	ErrInvalidMovie      = errors.New("invalid movie")
 	ErrInvalidRating     = errors.New("rating should be between 0 and 10")
 	ErrInvalidMovieTitle = errors.New("Title length should not exceed 40 chars")
+	ErrUserNotFound      = errors.New("user not found")
+	ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+	ErrInvalidMFAToken   = errors.New("invalid or expired mfa token")
+	ErrInvalidMFACode    = errors.New("invalid two-factor code")
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication already enabled")
+	ErrMFANotEnrolled    = errors.New("two-factor authentication not enrolled")
+	ErrMFALocked         = errors.New("too many two-factor attempts, try again later")
+	ErrWeakPassword      = errors.New("password is too weak")
+	ErrBreachedPassword  = errors.New("password appears in a list of breached passwords")
+	ErrAPIKeyNotFound    = errors.New("api key not found")
+	ErrInvalidAPIKey     = errors.New("invalid api key")
+	ErrInvalidScope      = errors.New("invalid api key scope")
+	ErrInvalidOIDCState  = errors.New("invalid or expired login state")
+	ErrOIDCEmailTaken    = errors.New("an account already uses this email, log in to it and link the provider")
+	ErrOIDCIdentityTaken = errors.New("this provider account is linked to another user")
+	ErrInvalidImport     = errors.New("invalid import file")
+	ErrWebhookNotFound   = errors.New("webhook not found")
+	ErrDeliveryNotFound  = errors.New("webhook delivery not found")
+	ErrInvalidWebhook    = errors.New("invalid webhook")
+	ErrInvalidEventID    = errors.New("invalid last event id")
 )
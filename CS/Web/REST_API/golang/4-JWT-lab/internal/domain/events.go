@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// event types are "<aggregate>.<past tense>", they are the routing keys of
+// the RabbitMQ exchange: keep them stable, other teams bind queues on them
+const (
+	EventMovieCreated  = "movie.created"
+	EventMovieUpdated  = "movie.updated"
+	EventMovieDeleted  = "movie.deleted"
+	EventReviewCreated = "review.created"
+)
+
+// the events a webhook can subscribe to: all of them. the deliveries are
+// queued with the outbox row of the event (see WebhookDeliveries)
+var WebhookEvents = []string{EventMovieCreated, EventMovieUpdated, EventMovieDeleted, EventReviewCreated}
+
+// OutboxEvent is a domain event, written to the outbox table in the same
+// transaction as the change it describes, then published by the relay
+// (internal/outbox).
+// the order is per aggregate: a review is part of its movie's stream (its
+// AggregateID is the movie), so nobody sees a review after its movie's deletion
+type OutboxEvent struct {
+	// the idempotency key: the AMQP message id, the consumers dedupe on it
+	// (delivery is at-least-once)
+	ID            uuid.UUID `json:"id"`
+	Type          string    `json:"type"`
+	AggregateType string    `json:"aggregate_type"`
+	AggregateID   uuid.UUID `json:"aggregate_id"`
+	// the position in the outbox: increasing, the order of the events of an aggregate
+	Sequence   int64           `json:"sequence"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+
+	// relay bookkeeping, not published
+	Attempts  int    `json:"-"`
+	LastError string `json:"-"`
+}
+
+func NewMovieCreatedEvent(movie *Movie) OutboxEvent {
+	return newEvent(EventMovieCreated, "movie", movie.ID, movie)
+}
+
+func NewMovieUpdatedEvent(movie *Movie) OutboxEvent {
+	return newEvent(EventMovieUpdated, "movie", movie.ID, movie)
+}
+
+func NewMovieDeletedEvent(id uuid.UUID) OutboxEvent {
+	return newEvent(EventMovieDeleted, "movie", id, map[string]uuid.UUID{"id": id})
+}
+
+func NewReviewCreatedEvent(review Review) OutboxEvent {
+	return newEvent(EventReviewCreated, "movie", review.MovieID, review)
+}
+
+// WebhookDeliveries is the event for each of the webhooks subscribed to it,
+// due right away. written in the transaction of the event's outbox row: a
+// change is sent to the partners iff it was committed, and the deliveries
+// carry the event's id, the same as the AMQP message id
+func WebhookDeliveries(event OutboxEvent, webhooks []Webhook) []WebhookDelivery {
+	// the body of every delivery: the partners dedupe on id (the retries and
+	// the redeliveries keep it)
+	payload, _ := json.Marshal(struct {
+		ID         uuid.UUID       `json:"id"`
+		Type       string          `json:"type"`
+		OccurredAt time.Time       `json:"occurred_at"`
+		Data       json.RawMessage `json:"data"`
+	}{event.ID, event.Type, event.OccurredAt, event.Data})
+
+	deliveries := make([]WebhookDelivery, len(webhooks))
+	for idx, webhook := range webhooks {
+		deliveries[idx] = NewWebhookDelivery(webhook.ID, event.ID, event.Type, payload)
+	}
+	return deliveries
+}
+
+func newEvent(eventType, aggregateType string, aggregateId uuid.UUID, data any) OutboxEvent {
+	// plain structs of this package: Marshal can't fail on them
+	payload, _ := json.Marshal(data)
+
+	return OutboxEvent{
+		ID:            uuid.New(),
+		Type:          eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateId,
+		OccurredAt:    time.Now().UTC(),
+		Data:          payload,
+	}
+}
@@ -0,0 +1,30 @@
+package domain
+
+// at most this many row errors are listed in a report (all are counted)
+const maxImportErrors = 100
+
+// ImportReport is the outcome of a bulk import (or of a dry run)
+type ImportReport struct {
+	DryRun    bool          `json:"dry_run"`
+	Rows      int           `json:"rows"`     // data rows read
+	Valid     int           `json:"valid"`    // rows that passed validation
+	Invalid   int           `json:"invalid"`  // rows rejected (see Errors)
+	Skipped   int           `json:"skipped"`  // valid rows whose id already exists in the DB, not inserted
+	Inserted  int           `json:"inserted"` // valid rows minus the skipped ones, 0 on a dry run
+	Errors    []ImportError `json:"errors"`
+	Truncated bool          `json:"errors_truncated,omitempty"` // more than maxImportErrors
+}
+
+type ImportError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+func (r *ImportReport) AddError(line int, err error) {
+	r.Invalid++
+	if len(r.Errors) >= maxImportErrors {
+		r.Truncated = true
+		return
+	}
+	r.Errors = append(r.Errors, ImportError{Line: line, Error: err.Error()})
+}
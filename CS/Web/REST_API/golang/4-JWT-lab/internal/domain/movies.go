@@ -11,4 +11,11 @@ type Movie struct {
 	Year          int32     `json:"year"`
 	AverageRating float64   `json:"average_rating"`
 	ReviewsCount  int64     `json:"reviews_count"`
+	Views         int64     `json:"views"`
+}
+
+// a movie and its trending score (views and reviews, recent ones weigh more)
+type TrendingMovie struct {
+	Movie
+	Score float64 `json:"score"`
 }
@@ -0,0 +1,7 @@
+package domain
+
+// Page of a list: Limit == 0 means no limit (the whole list)
+type Page struct {
+	Limit  int
+	Offset int
+}
@@ -0,0 +1,28 @@
+package domain
+
+import "slices"
+
+// Permission is what a principal (user session or API key) is allowed to do.
+// users get the permissions of their role, API keys only get their scopes.
+type Permission string
+
+const (
+	PermMoviesWrite  Permission = "movies:write"
+	PermMoviesDelete Permission = "movies:delete"
+	PermReviewsWrite Permission = "reviews:write"
+	// the whole catalog (movies, reviews) as a file, for the backups
+	PermCatalogExport Permission = "catalog:export"
+)
+
+var rolePermissions = map[Role][]Permission{
+	Regular: {PermMoviesWrite, PermReviewsWrite},
+	Admin:   {PermMoviesWrite, PermMoviesDelete, PermReviewsWrite, PermCatalogExport},
+}
+
+func RolePermissions(role Role) []Permission {
+	return rolePermissions[role]
+}
+
+func (r Role) Can(p Permission) bool {
+	return slices.Contains(rolePermissions[r], p)
+}
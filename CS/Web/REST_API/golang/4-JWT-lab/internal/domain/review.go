@@ -9,3 +9,19 @@ type Review struct {
 	Comment  *string   `json:"comment"`
 	MovieID  uuid.UUID `json:"movie_id"`
 }
+
+// the body of POST /reviews: no author, it's the caller (see
+// handlers.ReviewHandler.AddReview)
+type CreateReviewRequest struct {
+	Rating  int32     `json:"rating"`
+	Comment *string   `json:"comment"`
+	MovieID uuid.UUID `json:"movie_id"`
+}
+
+// FeedReview is a review of the live feed of its movie. ID is its position in
+// the movie's backlog: a client that reconnects sends the last one it got
+// (SSE Last-Event-ID) and gets what it missed
+type FeedReview struct {
+	ID     string `json:"id"`
+	Review Review `json:"review"`
+}
@@ -16,13 +16,45 @@ const (
 type CreateUserRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	Email    string `json:"email,omitempty"` // optional, needed for password recovery
 }
 
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// when the account has 2FA enabled, login answers with MFARequired + MFAToken
+// (no access/refresh tokens) and the client finishes with POST /auth/login/mfa
 type UserResponse struct {
 	ID           uuid.UUID `json:"id"`
 	Username     string    `json:"username"`
-	AccessToken  string    `json:"access_token"`
-	RefreshToken string    `json:"refresh_token"`
+	AccessToken  string    `json:"access_token,omitempty"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	MFARequired  bool      `json:"mfa_required,omitempty"`
+	MFAToken     string    `json:"mfa_token,omitempty"`
+}
+
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"` // TOTP code or a recovery code
+}
+
+type TOTPCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type TOTPEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
 }
 
 type User struct {
@@ -30,6 +62,17 @@ type User struct {
 	Username     string
 	PasswordHash string // this is Argon2id hash not plain password
 	Role         Role
+	Email        string // empty when the user registered without one
+	TOTPSecret   string // set once enrollment starts, only trusted when TOTPEnabled
+	TOTPEnabled  bool
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 }
+
+// UserIdentity links a local user to an account at an OpenID Connect provider
+type UserIdentity struct {
+	Provider string // issuer URL
+	Subject  string // the "sub" claim, stable at the provider (unlike the email)
+	UserID   uuid.UUID
+	Email    string
+}
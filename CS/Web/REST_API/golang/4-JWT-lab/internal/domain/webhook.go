@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook is a partner's subscription: the events of Events are POSTed to URL,
+// signed with the secret (HMAC-SHA256, see auth.SignWebhook)
+type Webhook struct {
+	ID        uuid.UUID  `json:"id"`
+	URL       string     `json:"url"`
+	Events    []string   `json:"events"`
+	CreatedBy *uuid.UUID `json:"created_by"` // nil once the admin is deleted
+	CreatedAt time.Time  `json:"created_at"`
+	// kept in clear (signing needs it), only shown at creation
+	Secret string `json:"-"`
+}
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+// returned once, at creation: the partner checks the signatures with Secret
+type CreatedWebhook struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"   // waiting for its next attempt
+	DeliverySucceeded DeliveryStatus = "succeeded" // the partner answered 2xx
+	DeliveryDead      DeliveryStatus = "dead"      // gave up: too many failed attempts
+)
+
+// WebhookDelivery is one event for one webhook, and the story of its attempts
+type WebhookDelivery struct {
+	ID        uuid.UUID `json:"id"`
+	WebhookID uuid.UUID `json:"webhook_id"`
+	// the id of the event: the same for the retries and the redeliveries,
+	// the partners dedupe on it
+	EventID   uuid.UUID       `json:"event_id"`
+	EventType string          `json:"event_type"`
+	Payload   json.RawMessage `json:"payload"`
+	Status    DeliveryStatus  `json:"status"`
+	Attempts  int             `json:"attempts"`
+	// pending only: when the dispatcher tries again
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	LastAttemptAt *time.Time `json:"last_attempt_at"`
+	// the partner's answer to the last attempt (0: no answer, see LastError)
+	ResponseStatus int       `json:"response_status"`
+	LastError      string    `json:"last_error,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// due right away
+func NewWebhookDelivery(webhookId, eventId uuid.UUID, eventType string, payload json.RawMessage) WebhookDelivery {
+	return WebhookDelivery{
+		ID:            uuid.New(),
+		WebhookID:     webhookId,
+		EventID:       eventId,
+		EventType:     eventType,
+		Payload:       payload,
+		Status:        DeliveryPending,
+		NextAttemptAt: time.Now().UTC(),
+	}
+}
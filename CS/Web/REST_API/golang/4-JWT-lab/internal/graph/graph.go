@@ -0,0 +1,192 @@
+// Package graph is the GraphQL API (POST /graphql, schema.graphql): movies,
+// reviews and users on the same services as the REST routes, with the same
+// permissions. the nested fields go through per-request loaders (loader.go)
+// so a list of movies with their reviews is two queries, not N+1.
+package graph
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/auth"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/middleware"
+	"github.com/grainme/movie-api/internal/service"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+//go:embed schema.graphql
+var schemaSDL string
+
+// the largest page a client can ask for, like the REST routes
+const maxPageSize = 100
+
+// how deep a query can nest (movie { reviews { author { reviews { movie ... } } } })
+const maxDepth = 8
+
+type Handler struct {
+	schema  *graphql.Schema
+	movies  *service.MovieService
+	reviews *service.ReviewService
+	users   *service.UserService
+}
+
+// NewHandler parses the schema: it panics if the schema and the resolvers
+// don't match, like any programming error at startup
+func NewHandler(movies *service.MovieService, reviews *service.ReviewService, users *service.UserService, audit *service.AuditLogger) *Handler {
+	root := &resolver{movies: movies, reviews: reviews, users: users, audit: audit}
+
+	return &Handler{
+		schema:  graphql.MustParseSchema(schemaSDL, root, graphql.MaxDepth(maxDepth)),
+		movies:  movies,
+		reviews: reviews,
+		users:   users,
+	}
+}
+
+type request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// the claims (if any) are under "user": the route is behind
+// middleware.OptionalAuthenticate, the mutations check them
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Query == "" {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), loadersKey{}, newLoaders(h.movies, h.reviews, h.users))
+	response := h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+
+	// errors or not, a GraphQL response is a 200
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("failed to write graphql response: %v", err)
+	}
+}
+
+// -------- errors
+
+// the `extensions.code` of an error, for the clients to switch on
+const (
+	codeUnauthenticated = "UNAUTHENTICATED"
+	codeForbidden       = "FORBIDDEN"
+	codeBadInput        = "BAD_USER_INPUT"
+	codeNotFound        = "NOT_FOUND"
+	codeInternal        = "INTERNAL"
+)
+
+type gqlError struct {
+	code    string
+	message string
+}
+
+func (e *gqlError) Error() string {
+	return e.message
+}
+
+// graphql-go puts them in the error's "extensions"
+func (e *gqlError) Extensions() map[string]any {
+	return map[string]any{"code": e.code}
+}
+
+func newError(code, message string) error {
+	return &gqlError{code: code, message: message}
+}
+
+// the GraphQL version of handlers.respondError
+func toError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrMovieNotFound):
+		return newError(codeNotFound, "movie not found")
+	case errors.Is(err, domain.ErrUserNotFound):
+		return newError(codeNotFound, "user not found")
+	case errors.Is(err, domain.ErrInvalidMovie), errors.Is(err, domain.ErrInvalidMovieTitle), errors.Is(err, domain.ErrInvalidRating):
+		return newError(codeBadInput, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return err
+	default:
+		log.Printf("graphql resolver failed: %v", err)
+		return newError(codeInternal, "movie service failed")
+	}
+}
+
+// -------- auth
+
+// what a mutation needs, the same as its REST route (server.NewRouter)
+type policy struct {
+	permission domain.Permission
+	// middleware.Authorize: an admin role, see auth.Claims.Authorize
+	admin bool
+}
+
+// field is the query/mutation asking, for the audit log of the refusals
+func (r *resolver) authorize(ctx context.Context, field string, p policy) (*auth.Claims, error) {
+	claims, ok := ctx.Value("user").(*auth.Claims)
+	if !ok {
+		return nil, newError(codeUnauthenticated, "access token missing")
+	}
+
+	if err := claims.Authorize(p.permission, p.admin); err != nil {
+		middleware.AuditDenied(ctx, r.audit, "graphql "+field, err.Error())
+		return nil, newError(codeForbidden, err.Error())
+	}
+
+	return claims, nil
+}
+
+// the user the claims are about (for an API key: its owner)
+func (r *resolver) author(ctx context.Context, claims *auth.Claims) (domain.User, error) {
+	userId, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return domain.User{}, newError(codeUnauthenticated, "invalid token")
+	}
+
+	user, err := r.users.GetUserById(ctx, userId)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		// a token of a deleted account
+		return domain.User{}, newError(codeUnauthenticated, "invalid token")
+	}
+	if err != nil {
+		return domain.User{}, toError(err)
+	}
+	return user, nil
+}
+
+// -------- helpers
+
+func parseId(name string, id graphql.ID) (uuid.UUID, error) {
+	parsed, err := uuid.Parse(string(id))
+	if err != nil {
+		return uuid.UUID{}, newError(codeBadInput, "invalid "+name)
+	}
+	return parsed, nil
+}
+
+// no limit means the whole list
+func parsePage(limit, offset *int32) (domain.Page, error) {
+	var page domain.Page
+	if limit != nil {
+		page.Limit = int(*limit)
+	}
+	if offset != nil {
+		page.Offset = int(*offset)
+	}
+
+	if page.Limit < 0 || page.Offset < 0 {
+		return domain.Page{}, newError(codeBadInput, "invalid page")
+	}
+	if page.Limit > maxPageSize {
+		return domain.Page{}, newError(codeBadInput, "limit must be at most 100")
+	}
+	return page, nil
+}
@@ -0,0 +1,161 @@
+package graph_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/apitest"
+	"github.com/grainme/movie-api/internal/cache"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/graph"
+	"github.com/grainme/movie-api/internal/mailer"
+	"github.com/grainme/movie-api/internal/repository"
+	"github.com/grainme/movie-api/internal/repository/memory"
+	"github.com/grainme/movie-api/internal/service"
+)
+
+func TestMain(m *testing.M) {
+	apitest.Main(m)
+}
+
+// counts the batch queries: the point of the loaders
+type countingMovies struct {
+	repository.MovieRepository
+	batches atomic.Int32
+}
+
+func (r *countingMovies) GetMoviesWithReviewsByIds(ctx context.Context, ids []uuid.UUID) ([]*domain.Movie, error) {
+	r.batches.Add(1)
+	return r.MovieRepository.GetMoviesWithReviewsByIds(ctx, ids)
+}
+
+type countingReviews struct {
+	repository.ReviewRepository
+	batches atomic.Int32
+}
+
+func (r *countingReviews) GetReviewsByMovieIds(ctx context.Context, movieIds []uuid.UUID, page domain.Page) ([]domain.Review, error) {
+	r.batches.Add(1)
+	return r.ReviewRepository.GetReviewsByMovieIds(ctx, movieIds, page)
+}
+
+func (r *countingReviews) GetReviewsByUsernames(ctx context.Context, usernames []string, page domain.Page) ([]domain.Review, error) {
+	r.batches.Add(1)
+	return r.ReviewRepository.GetReviewsByUsernames(ctx, usernames, page)
+}
+
+type countingUsers struct {
+	repository.UserRepository
+	batches atomic.Int32
+}
+
+func (r *countingUsers) FindUsersByNames(ctx context.Context, usernames []string) ([]domain.User, error) {
+	r.batches.Add(1)
+	return r.UserRepository.FindUsersByNames(ctx, usernames)
+}
+
+func TestNestedFieldsAreBatched(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	movies := &countingMovies{MovieRepository: memory.NewMemoryMovieRepository(store)}
+	reviews := &countingReviews{ReviewRepository: memory.NewMemoryReviewRepository(store)}
+	users := &countingUsers{UserRepository: memory.NewMemoryUserRepository(store)}
+
+	audit := service.NewAuditLogger(memory.NewMemoryAuditRepository(store))
+	lists := cache.NewMemoryListCache()
+	handler := graph.NewHandler(
+		service.NewMovieService(movies, cache.NewMemoryMovieCache(), lists, cache.NewMemoryTrendingStore(), audit),
+		service.NewReviewService(reviews, cache.NewMemoryMovieCache(), lists, cache.NewMemoryTrendingStore(), cache.NewMemoryReviewFeed()),
+		service.NewUserService(users, cache.NewMemorySessionStore(), mailer.NewLogMailer(), audit),
+		audit,
+	)
+
+	// more movies than graphql-go resolves in parallel (10)
+	const movieCount = 25
+	for i := range movieCount {
+		movie, err := movies.AddMovie(ctx, &domain.Movie{Title: fmt.Sprintf("Movie %02d", i)})
+		if err != nil {
+			t.Fatalf("add movie: %v", err)
+		}
+		for _, username := range []string{"alice", "bob", "carol"} {
+			if _, err := reviews.AddReview(ctx, &domain.Review{Username: username, Rating: int32(i%10 + 1), MovieID: movie.ID}); err != nil {
+				t.Fatalf("add review: %v", err)
+			}
+		}
+	}
+	if _, err := users.AddUser(ctx, domain.CreateUserRequest{Username: "alice", Password: apitest.Password}); err != nil {
+		t.Fatalf("add user: %v", err)
+	}
+
+	var answer struct {
+		Data struct {
+			Movies []struct {
+				ReviewsCount int
+				Reviews      []struct {
+					Author *struct {
+						Reviews []struct{ Rating int }
+					}
+				}
+			}
+		}
+		Errors []any
+	}
+	query := `{ movies { reviewsCount reviews(limit: 2) { author { reviews { rating } } } } }`
+	body, _ := json.Marshal(map[string]string{"query": query})
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &answer); err != nil || len(answer.Errors) > 0 {
+		t.Fatalf("answer = %s (%v)", rec.Body, err)
+	}
+
+	if len(answer.Data.Movies) != movieCount {
+		t.Fatalf("expected %d movies, got %d", movieCount, len(answer.Data.Movies))
+	}
+	for _, movie := range answer.Data.Movies {
+		if movie.ReviewsCount != 3 || len(movie.Reviews) != 2 {
+			t.Fatalf("movie = %+v", movie)
+		}
+		// only alice is a user, she reviewed every movie
+		for _, review := range movie.Reviews {
+			if review.Author != nil && len(review.Author.Reviews) != movieCount {
+				t.Fatalf("author reviews = %+v", review.Author.Reviews)
+			}
+		}
+	}
+
+	// one query per level, whatever the number of movies
+	counts := map[string]int32{
+		"movie aggregates": movies.batches.Load(),
+		// the reviews of the movies, then the reviews of the authors
+		"reviews": reviews.batches.Load(),
+		"authors": users.batches.Load(),
+	}
+	want := map[string]int32{"movie aggregates": 1, "reviews": 2, "authors": 1}
+	for name, count := range counts {
+		if count != want[name] {
+			t.Errorf("%s: %d batch queries, want %d", name, count, want[name])
+		}
+	}
+}
+
+func TestInvalidBody(t *testing.T) {
+	handler := graph.NewHandler(nil, nil, nil, nil)
+
+	for _, body := range []string{`not json`, `{}`} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader([]byte(body))))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status = %d, want 400", body, rec.Code)
+		}
+	}
+}
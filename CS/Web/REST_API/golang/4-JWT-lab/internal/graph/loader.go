@@ -0,0 +1,67 @@
+package graph
+
+import (
+	"context"
+	"sync"
+)
+
+// Loader is a per-request cache in front of a batch function, against the
+// N+1 queries: `movies { reviews { ... } }` would otherwise query the reviews
+// once per movie.
+// there's no wait window to collect the keys (graphql-go caps the parallel
+// resolvers, a window would cut the batches at that cap): the resolvers know
+// their siblings, the other keys of the list they come from, and the first
+// Load of a key fetches it with all its siblings not loaded yet.
+type Loader[K comparable, V any] struct {
+	// the values by key, a missing key has the zero value
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu    sync.Mutex
+	calls map[K]*call[K, V]
+}
+
+// one fetch, shared by every key it loads
+type call[K comparable, V any] struct {
+	done   chan struct{}
+	values map[K]V
+	err    error
+}
+
+func NewLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *Loader[K, V] {
+	return &Loader[K, V]{
+		fetch: fetch,
+		calls: map[K]*call[K, V]{},
+	}
+}
+
+// Load the value of key, fetching its siblings along the way (key doesn't
+// need to be one of them)
+func (l *Loader[K, V]) Load(ctx context.Context, key K, siblings []K) (V, error) {
+	l.mu.Lock()
+	c, ok := l.calls[key]
+	if !ok {
+		c = &call[K, V]{done: make(chan struct{})}
+		keys := []K{key}
+		l.calls[key] = c
+		for _, sibling := range siblings {
+			if _, loading := l.calls[sibling]; !loading {
+				keys = append(keys, sibling)
+				l.calls[sibling] = c
+			}
+		}
+		l.mu.Unlock()
+
+		c.values, c.err = l.fetch(ctx, keys)
+		close(c.done)
+	} else {
+		l.mu.Unlock()
+	}
+
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+	return c.values[key], c.err
+}
@@ -0,0 +1,118 @@
+package graph
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/service"
+)
+
+// the loaders of one request: the values are cached for the whole query
+// (a mutation sees the values from before it in the same document, like any
+// dataloader)
+type loaders struct {
+	movies  *service.MovieService
+	reviews *service.ReviewService
+	users   *service.UserService
+
+	// the movies with their aggregates (average rating, reviews count)
+	aggregates *Loader[uuid.UUID, *domain.Movie]
+	authors    *Loader[string, *domain.User]
+
+	// one loader per page: the page is part of the query. the reviews come
+	// as resolvers built on the whole batch, so that their own siblings
+	// (for review { movie author }) span every movie (user) of the batch
+	mu           sync.Mutex
+	movieReviews map[domain.Page]*Loader[uuid.UUID, []*reviewResolver]
+	userReviews  map[domain.Page]*Loader[string, []*reviewResolver]
+}
+
+type loadersKey struct{}
+
+func newLoaders(movies *service.MovieService, reviews *service.ReviewService, users *service.UserService) *loaders {
+	l := &loaders{
+		movies:       movies,
+		reviews:      reviews,
+		users:        users,
+		movieReviews: map[domain.Page]*Loader[uuid.UUID, []*reviewResolver]{},
+		userReviews:  map[domain.Page]*Loader[string, []*reviewResolver]{},
+	}
+
+	l.aggregates = NewLoader(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*domain.Movie, error) {
+		movies, err := l.movies.GetMoviesWithReviewsByIds(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+
+		byId := make(map[uuid.UUID]*domain.Movie, len(movies))
+		for _, movie := range movies {
+			byId[movie.ID] = movie
+		}
+		return byId, nil
+	})
+	// reviews are anonymous: most authors are not users
+	l.authors = NewLoader(func(ctx context.Context, usernames []string) (map[string]*domain.User, error) {
+		users, err := l.users.FindUsersByNames(ctx, usernames)
+		if err != nil {
+			return nil, err
+		}
+
+		byName := make(map[string]*domain.User, len(users))
+		for idx := range users {
+			byName[users[idx].Username] = &users[idx]
+		}
+		return byName, nil
+	})
+
+	return l
+}
+
+func (l *loaders) reviewsByMovie(page domain.Page) *Loader[uuid.UUID, []*reviewResolver] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	loader, ok := l.movieReviews[page]
+	if !ok {
+		loader = NewLoader(func(ctx context.Context, movieIds []uuid.UUID) (map[uuid.UUID][]*reviewResolver, error) {
+			reviews, err := l.reviews.GetReviewsByMovieIds(ctx, movieIds, page)
+			if err != nil {
+				return nil, err
+			}
+			return groupBy(newReviewResolvers(reviews), func(review domain.Review) uuid.UUID { return review.MovieID }), nil
+		})
+		l.movieReviews[page] = loader
+	}
+	return loader
+}
+
+func (l *loaders) reviewsByUser(page domain.Page) *Loader[string, []*reviewResolver] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	loader, ok := l.userReviews[page]
+	if !ok {
+		loader = NewLoader(func(ctx context.Context, usernames []string) (map[string][]*reviewResolver, error) {
+			reviews, err := l.reviews.GetReviewsByUsernames(ctx, usernames, page)
+			if err != nil {
+				return nil, err
+			}
+			return groupBy(newReviewResolvers(reviews), func(review domain.Review) string { return review.Username }), nil
+		})
+		l.userReviews[page] = loader
+	}
+	return loader
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+func groupBy[K comparable](reviews []*reviewResolver, key func(domain.Review) K) map[K][]*reviewResolver {
+	groups := map[K][]*reviewResolver{}
+	for _, review := range reviews {
+		groups[key(review.review)] = append(groups[key(review.review)], review)
+	}
+	return groups
+}
@@ -0,0 +1,183 @@
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/service"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// the Query and Mutation fields
+type resolver struct {
+	movies  *service.MovieService
+	reviews *service.ReviewService
+	users   *service.UserService
+	audit   *service.AuditLogger
+}
+
+type pageArgs struct {
+	Limit  *int32
+	Offset *int32
+}
+
+// -------- queries
+
+func (r *resolver) Movies(ctx context.Context, args pageArgs) ([]*movieResolver, error) {
+	page, err := parsePage(args.Limit, args.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	movies, err := r.movies.GetAllMovies(ctx, page)
+	if err != nil {
+		return nil, toError(err)
+	}
+	return newMovieResolvers(movies), nil
+}
+
+func (r *resolver) Movie(ctx context.Context, args struct{ ID graphql.ID }) (*movieResolver, error) {
+	id, err := parseId("id", args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	movie, err := r.movies.GetMovieById(ctx, id)
+	// null, the field is optional
+	if errors.Is(err, domain.ErrMovieNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, toError(err)
+	}
+	return newMovieResolvers([]*domain.Movie{movie})[0], nil
+}
+
+func (r *resolver) Reviews(ctx context.Context) ([]*reviewResolver, error) {
+	reviews, err := r.reviews.GetAllReviews(ctx)
+	if err != nil {
+		return nil, toError(err)
+	}
+	return newReviewResolvers(reviews), nil
+}
+
+func (r *resolver) Me(ctx context.Context) (*userResolver, error) {
+	claims, err := r.authorize(ctx, "me", policy{})
+	if err != nil {
+		return nil, err
+	}
+	// the owner of an API key
+	userId, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, newError(codeUnauthenticated, "invalid token")
+	}
+
+	user, err := r.users.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, toError(err)
+	}
+	return newUserResolvers([]*domain.User{&user})[0], nil
+}
+
+// -------- mutations
+
+type createMovieInput struct {
+	Title    string
+	Director *string
+	Year     *int32
+}
+
+func (r *resolver) CreateMovie(ctx context.Context, args struct{ Input createMovieInput }) (*movieResolver, error) {
+	if _, err := r.authorize(ctx, "createMovie", policy{permission: domain.PermMoviesWrite}); err != nil {
+		return nil, err
+	}
+
+	movie := &domain.Movie{Title: args.Input.Title}
+	if args.Input.Director != nil {
+		movie.Director = *args.Input.Director
+	}
+	if args.Input.Year != nil {
+		movie.Year = *args.Input.Year
+	}
+
+	movie, err := r.movies.AddMovie(ctx, movie)
+	if err != nil {
+		return nil, toError(err)
+	}
+	return newMovieResolvers([]*domain.Movie{movie})[0], nil
+}
+
+func (r *resolver) UpdateMovieTitle(ctx context.Context, args struct {
+	ID    graphql.ID
+	Title string
+}) (*movieResolver, error) {
+	if _, err := r.authorize(ctx, "updateMovieTitle", policy{permission: domain.PermMoviesWrite}); err != nil {
+		return nil, err
+	}
+	id, err := parseId("id", args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.movies.UpdateMovieTitleById(ctx, id, args.Title); err != nil {
+		return nil, toError(err)
+	}
+	// not GetMovieById: an update is not a view
+	movie, err := r.movies.GetMovieWithReviews(ctx, id)
+	if err != nil {
+		return nil, toError(err)
+	}
+	return newMovieResolvers([]*domain.Movie{movie})[0], nil
+}
+
+func (r *resolver) DeleteMovie(ctx context.Context, args struct{ ID graphql.ID }) (bool, error) {
+	if _, err := r.authorize(ctx, "deleteMovie", policy{permission: domain.PermMoviesDelete, admin: true}); err != nil {
+		return false, err
+	}
+	id, err := parseId("id", args.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if err := r.movies.DeleteMovieById(ctx, id); err != nil {
+		return false, toError(err)
+	}
+	return true, nil
+}
+
+type createReviewInput struct {
+	Rating  int32
+	Comment *string
+	MovieID graphql.ID
+}
+
+// the author is the caller (for an API key: its owner)
+func (r *resolver) CreateReview(ctx context.Context, args struct{ Input createReviewInput }) (*reviewResolver, error) {
+	claims, err := r.authorize(ctx, "createReview", policy{permission: domain.PermReviewsWrite})
+	if err != nil {
+		return nil, err
+	}
+
+	movieId, err := parseId("movieId", args.Input.MovieID)
+	if err != nil {
+		return nil, err
+	}
+
+	author, err := r.author(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	review, err := r.reviews.AddReview(ctx, &domain.Review{
+		Username: author.Username,
+		Rating:   args.Input.Rating,
+		Comment:  args.Input.Comment,
+		MovieID:  movieId,
+	})
+	if err != nil {
+		return nil, toError(err)
+	}
+	return newReviewResolvers([]domain.Review{review})[0], nil
+}
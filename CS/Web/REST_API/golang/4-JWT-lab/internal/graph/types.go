@@ -0,0 +1,221 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// every resolver keeps the keys of the list it comes from (its siblings):
+// the first nested field to hit a loader fetches it for the whole list
+
+// -------- Movie
+
+type movieResolver struct {
+	movie    *domain.Movie
+	siblings []uuid.UUID
+}
+
+func newMovieResolvers(movies []*domain.Movie) []*movieResolver {
+	ids := make([]uuid.UUID, len(movies))
+	for idx, movie := range movies {
+		ids[idx] = movie.ID
+	}
+
+	resolvers := make([]*movieResolver, len(movies))
+	for idx, movie := range movies {
+		resolvers[idx] = &movieResolver{movie: movie, siblings: ids}
+	}
+	return resolvers
+}
+
+func (r *movieResolver) ID() graphql.ID {
+	return graphql.ID(r.movie.ID.String())
+}
+
+func (r *movieResolver) Title() string {
+	return r.movie.Title
+}
+
+func (r *movieResolver) Director() string {
+	return r.movie.Director
+}
+
+func (r *movieResolver) Year() int32 {
+	return r.movie.Year
+}
+
+func (r *movieResolver) Views() int32 {
+	return int32(r.movie.Views)
+}
+
+func (r *movieResolver) AverageRating(ctx context.Context) (float64, error) {
+	aggregate, err := r.aggregate(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return aggregate.AverageRating, nil
+}
+
+func (r *movieResolver) ReviewsCount(ctx context.Context) (int32, error) {
+	aggregate, err := r.aggregate(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return int32(aggregate.ReviewsCount), nil
+}
+
+// the lists don't carry the aggregates (GET /movies doesn't either)
+func (r *movieResolver) aggregate(ctx context.Context) (*domain.Movie, error) {
+	aggregate, err := loadersFrom(ctx).aggregates.Load(ctx, r.movie.ID, r.siblings)
+	if err != nil {
+		return nil, toError(err)
+	}
+	// deleted since the list was read
+	if aggregate == nil {
+		return nil, toError(domain.ErrMovieNotFound)
+	}
+	return aggregate, nil
+}
+
+func (r *movieResolver) Reviews(ctx context.Context, args pageArgs) ([]*reviewResolver, error) {
+	page, err := parsePage(args.Limit, args.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	reviews, err := loadersFrom(ctx).reviewsByMovie(page).Load(ctx, r.movie.ID, r.siblings)
+	if err != nil {
+		return nil, toError(err)
+	}
+	return nonNil(reviews), nil
+}
+
+// -------- Review
+
+type reviewResolver struct {
+	review    domain.Review
+	movieIds  []uuid.UUID
+	usernames []string
+}
+
+func newReviewResolvers(reviews []domain.Review) []*reviewResolver {
+	var movieIds []uuid.UUID
+	var usernames []string
+	seenMovies := map[uuid.UUID]bool{}
+	seenUsers := map[string]bool{}
+	for _, review := range reviews {
+		if !seenMovies[review.MovieID] {
+			seenMovies[review.MovieID] = true
+			movieIds = append(movieIds, review.MovieID)
+		}
+		if !seenUsers[review.Username] {
+			seenUsers[review.Username] = true
+			usernames = append(usernames, review.Username)
+		}
+	}
+
+	resolvers := make([]*reviewResolver, len(reviews))
+	for idx, review := range reviews {
+		resolvers[idx] = &reviewResolver{review: review, movieIds: movieIds, usernames: usernames}
+	}
+	return resolvers
+}
+
+func (r *reviewResolver) ID() graphql.ID {
+	return graphql.ID(r.review.ID.String())
+}
+
+func (r *reviewResolver) Username() string {
+	return r.review.Username
+}
+
+func (r *reviewResolver) Rating() int32 {
+	return r.review.Rating
+}
+
+func (r *reviewResolver) Comment() *string {
+	return r.review.Comment
+}
+
+func (r *reviewResolver) MovieID() graphql.ID {
+	return graphql.ID(r.review.MovieID.String())
+}
+
+func (r *reviewResolver) Movie(ctx context.Context) (*movieResolver, error) {
+	movie, err := loadersFrom(ctx).aggregates.Load(ctx, r.review.MovieID, r.movieIds)
+	if err != nil || movie == nil {
+		return nil, toErrorOrNil(err)
+	}
+	// the siblings of the movie are the movies of the other reviews
+	return &movieResolver{movie: movie, siblings: r.movieIds}, nil
+}
+
+func (r *reviewResolver) Author(ctx context.Context) (*userResolver, error) {
+	user, err := loadersFrom(ctx).authors.Load(ctx, r.review.Username, r.usernames)
+	if err != nil || user == nil {
+		return nil, toErrorOrNil(err)
+	}
+	return &userResolver{user: user, siblings: r.usernames}, nil
+}
+
+// -------- User
+
+type userResolver struct {
+	user     *domain.User
+	siblings []string
+}
+
+func newUserResolvers(users []*domain.User) []*userResolver {
+	usernames := make([]string, len(users))
+	for idx, user := range users {
+		usernames[idx] = user.Username
+	}
+
+	resolvers := make([]*userResolver, len(users))
+	for idx, user := range users {
+		resolvers[idx] = &userResolver{user: user, siblings: usernames}
+	}
+	return resolvers
+}
+
+func (r *userResolver) ID() graphql.ID {
+	return graphql.ID(r.user.ID.String())
+}
+
+func (r *userResolver) Username() string {
+	return r.user.Username
+}
+
+func (r *userResolver) Reviews(ctx context.Context, args pageArgs) ([]*reviewResolver, error) {
+	page, err := parsePage(args.Limit, args.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	reviews, err := loadersFrom(ctx).reviewsByUser(page).Load(ctx, r.user.Username, r.siblings)
+	if err != nil {
+		return nil, toError(err)
+	}
+	return nonNil(reviews), nil
+}
+
+// -------- helpers
+
+// a missing movie/author is a null, not an error
+func toErrorOrNil(err error) error {
+	if err == nil {
+		return nil
+	}
+	return toError(err)
+}
+
+// no reviews is [], not null: the lists are non-null in the schema
+func nonNil(reviews []*reviewResolver) []*reviewResolver {
+	if reviews == nil {
+		return []*reviewResolver{}
+	}
+	return reviews
+}
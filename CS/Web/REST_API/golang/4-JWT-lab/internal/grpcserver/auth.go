@@ -0,0 +1,144 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/grainme/movie-api/internal/auth"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/middleware"
+	moviev1 "github.com/grainme/movie-api/proto/movie/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// what a method needs, the same as its REST route (server.NewRouter).
+// the methods not listed here are public
+type policy struct {
+	permission domain.Permission
+	// middleware.Authorize: an admin role, see auth.Claims.Authorize
+	admin bool
+}
+
+var policies = map[string]policy{
+	moviev1.MovieService_CreateMovie_FullMethodName:      {permission: domain.PermMoviesWrite},
+	moviev1.MovieService_UpdateMovieTitle_FullMethodName: {permission: domain.PermMoviesWrite},
+	moviev1.MovieService_DeleteMovie_FullMethodName:      {permission: domain.PermMoviesDelete, admin: true},
+	moviev1.ReviewService_CreateReview_FullMethodName:    {permission: domain.PermReviewsWrite},
+}
+
+// authenticator is middleware.Authenticate + Authorize + RequirePermission
+// for gRPC: a JWT in `authorization: Bearer <token>` or an API key in
+// `x-api-key`, the claims end up under "user" in the context
+type authenticator struct {
+	apiKeys middleware.APIKeyResolver
+	audit   middleware.AuditLogger
+}
+
+func (a *authenticator) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := a.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *authenticator) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+func (a *authenticator) authenticate(ctx context.Context, method string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	// client ip + user agent for the audit log, like middleware.RequestMeta
+	ctx = context.WithValue(ctx, "request_meta", requestMeta(ctx, md))
+
+	policy, ok := policies[method]
+	if !ok {
+		return ctx, nil
+	}
+
+	claims, err := a.claims(ctx, md)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = context.WithValue(ctx, "user", claims)
+	if err := claims.Authorize(policy.permission, policy.admin); err != nil {
+		middleware.AuditDenied(ctx, a.audit, method, err.Error())
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	return ctx, nil
+}
+
+func (a *authenticator) claims(ctx context.Context, md metadata.MD) (*auth.Claims, error) {
+	if apiKey := first(md, "x-api-key"); apiKey != "" {
+		if a.apiKeys == nil {
+			return nil, status.Error(codes.Unauthenticated, "api keys are not accepted")
+		}
+		claims, err := a.apiKeys.ResolveAPIKey(ctx, apiKey)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid api key")
+		}
+		return claims, nil
+	}
+
+	scheme, token, ok := strings.Cut(first(md, "authorization"), " ")
+	if !ok || strings.ToLower(scheme) != "bearer" {
+		return nil, status.Error(codes.Unauthenticated, "access token missing")
+	}
+	claims, err := auth.ValidateAccessToken(token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+	return claims, nil
+}
+
+// -------- helpers
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// the peer address is the client's unless we're behind a proxy that says
+// otherwise (TRUST_PROXY_HEADERS=true, same as the REST side)
+func requestMeta(ctx context.Context, md metadata.MD) domain.RequestMeta {
+	meta := domain.RequestMeta{UserAgent: first(md, "user-agent")}
+
+	if os.Getenv("TRUST_PROXY_HEADERS") == "true" {
+		if forwarded := first(md, "x-forwarded-for"); forwarded != "" {
+			ip, _, _ := strings.Cut(forwarded, ",")
+			meta.IP = strings.TrimSpace(ip)
+			return meta
+		}
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		meta.IP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(meta.IP); err == nil {
+			meta.IP = host
+		}
+	}
+	return meta
+}
+
+// a ServerStream with the authenticated context
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,261 @@
+package grpcserver_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/grainme/movie-api/internal/apitest"
+	"github.com/grainme/movie-api/internal/cache"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/grpcserver"
+	"github.com/grainme/movie-api/internal/mailer"
+	"github.com/grainme/movie-api/internal/repository/memory"
+	"github.com/grainme/movie-api/internal/server"
+	moviev1 "github.com/grainme/movie-api/proto/movie/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestMain(m *testing.M) {
+	apitest.Main(m)
+}
+
+func TestMovieJourney(t *testing.T) {
+	api := startServer(t)
+	ctx := context.Background()
+
+	token := api.login(t, "alice", false)
+
+	// writes need a token
+	_, err := api.movies.CreateMovie(ctx, &moviev1.CreateMovieRequest{Title: "Heat"})
+	expectCode(t, err, codes.Unauthenticated)
+	_, err = api.movies.CreateMovie(withToken(ctx, "not-a-jwt"), &moviev1.CreateMovieRequest{Title: "Heat"})
+	expectCode(t, err, codes.Unauthenticated)
+
+	created, err := api.movies.CreateMovie(withToken(ctx, token), &moviev1.CreateMovieRequest{Title: "Heat", Director: "Michael Mann", Year: 1995})
+	if err != nil {
+		t.Fatalf("create movie: %v", err)
+	}
+	_, err = api.movies.CreateMovie(withToken(ctx, token), &moviev1.CreateMovieRequest{})
+	expectCode(t, err, codes.InvalidArgument)
+
+	movie, err := api.movies.GetMovie(ctx, &moviev1.GetMovieRequest{Id: created.Id})
+	if err != nil || movie.Title != "Heat" || movie.Year != 1995 {
+		t.Fatalf("get movie = %v, %v", movie, err)
+	}
+	_, err = api.movies.GetMovie(ctx, &moviev1.GetMovieRequest{Id: "42"})
+	expectCode(t, err, codes.InvalidArgument)
+
+	_, err = api.reviews.CreateReview(ctx, &moviev1.CreateReviewRequest{Rating: 8, MovieId: created.Id})
+	expectCode(t, err, codes.Unauthenticated)
+	review, err := api.reviews.CreateReview(withToken(ctx, token), &moviev1.CreateReviewRequest{Rating: 8, MovieId: created.Id})
+	if err != nil {
+		t.Fatalf("create review: %v", err)
+	}
+	// the author is the caller
+	if review.Username != "alice" {
+		t.Fatalf("review author = %q, want alice", review.Username)
+	}
+	aggregate, err := api.movies.GetMovieWithReviews(ctx, &moviev1.GetMovieRequest{Id: created.Id})
+	if err != nil || aggregate.ReviewsCount != 1 || aggregate.AverageRating != 8 {
+		t.Fatalf("movie with reviews = %v, %v", aggregate, err)
+	}
+
+	// a regular user can't delete (a movie without reviews: they'd block it)
+	doomed, err := api.movies.CreateMovie(withToken(ctx, token), &moviev1.CreateMovieRequest{Title: "Ronin"})
+	if err != nil {
+		t.Fatalf("create movie: %v", err)
+	}
+	_, err = api.movies.DeleteMovie(withToken(ctx, token), &moviev1.DeleteMovieRequest{Id: doomed.Id})
+	expectCode(t, err, codes.PermissionDenied)
+
+	adminToken := api.login(t, "root", true)
+	if _, err := api.movies.DeleteMovie(withToken(ctx, adminToken), &moviev1.DeleteMovieRequest{Id: doomed.Id}); err != nil {
+		t.Fatalf("delete movie: %v", err)
+	}
+	_, err = api.movies.GetMovie(ctx, &moviev1.GetMovieRequest{Id: doomed.Id})
+	expectCode(t, err, codes.NotFound)
+
+	// the interceptor put the admin in the context: the audit log knows who did it
+	events, err := api.deps.Audit.ListAuditEvents(ctx, domain.AuditFilter{Action: domain.AuditMovieDelete, Limit: 10})
+	if err != nil || len(events) != 1 || events[0].ActorID == nil || events[0].TargetID != doomed.Id {
+		t.Fatalf("audit events = %+v, %v", events, err)
+	}
+}
+
+func TestSessions(t *testing.T) {
+	api := startServer(t)
+	ctx := context.Background()
+
+	if _, err := api.users.Register(ctx, &moviev1.RegisterRequest{Username: "alice", Password: "short"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("register with a weak password: %v", err)
+	}
+	if _, err := api.users.Register(ctx, &moviev1.RegisterRequest{Username: "alice", Password: apitest.Password}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	_, err := api.users.Login(ctx, &moviev1.LoginRequest{Username: "alice", Password: "wrong password"})
+	expectCode(t, err, codes.Unauthenticated)
+
+	session, err := api.users.Login(ctx, &moviev1.LoginRequest{Username: "alice", Password: apitest.Password})
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	refreshed, err := api.users.RefreshToken(ctx, &moviev1.RefreshTokenRequest{RefreshToken: session.RefreshToken})
+	if err != nil || refreshed.AccessToken == "" || refreshed.RefreshToken == session.RefreshToken {
+		t.Fatalf("refresh = %v, %v", refreshed, err)
+	}
+	// rotated
+	_, err = api.users.RefreshToken(ctx, &moviev1.RefreshTokenRequest{RefreshToken: session.RefreshToken})
+	expectCode(t, err, codes.Unauthenticated)
+
+	if _, err := api.users.Logout(ctx, &moviev1.LogoutRequest{RefreshToken: refreshed.RefreshToken}); err != nil {
+		t.Fatalf("logout: %v", err)
+	}
+	_, err = api.users.RefreshToken(ctx, &moviev1.RefreshTokenRequest{RefreshToken: refreshed.RefreshToken})
+	expectCode(t, err, codes.Unauthenticated)
+}
+
+func TestWatchMovieReviews(t *testing.T) {
+	api := startServer(t)
+	token := api.login(t, "alice", false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	movie, err := api.movies.CreateMovie(withToken(ctx, token), &moviev1.CreateMovieRequest{Title: "Heat"})
+	if err != nil {
+		t.Fatalf("create movie: %v", err)
+	}
+	other, err := api.movies.CreateMovie(withToken(ctx, token), &moviev1.CreateMovieRequest{Title: "Ronin"})
+	if err != nil {
+		t.Fatalf("create movie: %v", err)
+	}
+
+	stream, err := api.reviews.WatchMovieReviews(ctx, &moviev1.WatchMovieReviewsRequest{MovieId: movie.Id})
+	if err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	// the headers come once the server is subscribed
+	if _, err := stream.Header(); err != nil {
+		t.Fatalf("watch headers: %v", err)
+	}
+
+	comment := "the diner scene"
+	for _, review := range []*moviev1.CreateReviewRequest{
+		{Rating: 5, MovieId: other.Id},
+		{Rating: 9, Comment: &comment, MovieId: movie.Id},
+	} {
+		if _, err := api.reviews.CreateReview(withToken(ctx, token), review); err != nil {
+			t.Fatalf("create review: %v", err)
+		}
+	}
+
+	// only the watched movie's review
+	review, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("recv: %v", err)
+	}
+	if review.MovieId != movie.Id || review.Username != "alice" || review.GetComment() != comment {
+		t.Fatalf("watched review = %v", review)
+	}
+
+	unknown, err := api.reviews.WatchMovieReviews(ctx, &moviev1.WatchMovieReviewsRequest{MovieId: "00000000-0000-0000-0000-000000000001"})
+	if err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	_, err = unknown.Recv()
+	expectCode(t, err, codes.NotFound)
+}
+
+// -------- helpers
+type testAPI struct {
+	deps    server.Deps
+	movies  moviev1.MovieServiceClient
+	reviews moviev1.ReviewServiceClient
+	users   moviev1.UserServiceClient
+}
+
+// the gRPC server on the in-memory repositories and cache, over an in-memory connection
+func startServer(t *testing.T) *testAPI {
+	store := memory.NewStore()
+	deps := server.Deps{
+		Movies:     memory.NewMemoryMovieRepository(store),
+		Reviews:    memory.NewMemoryReviewRepository(store),
+		Users:      memory.NewMemoryUserRepository(store),
+		APIKeys:    memory.NewMemoryAPIKeyRepository(store),
+		Audit:      memory.NewMemoryAuditRepository(store),
+		Webhooks:   memory.NewMemoryWebhookRepository(store),
+		MovieCache: cache.NewMemoryMovieCache(),
+		Lists:      cache.NewMemoryListCache(),
+		Trending:   cache.NewMemoryTrendingStore(),
+		Sessions:   cache.NewMemorySessionStore(),
+		ReviewFeed: cache.NewMemoryReviewFeed(),
+		Mailer:     mailer.NewLogMailer(),
+	}
+
+	listener := bufconn.Listen(1 << 20)
+	s := grpcserver.NewServer(deps)
+	go s.Serve(listener)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return &testAPI{
+		deps:    deps,
+		movies:  moviev1.NewMovieServiceClient(conn),
+		reviews: moviev1.NewReviewServiceClient(conn),
+		users:   moviev1.NewUserServiceClient(conn),
+	}
+}
+
+// registers and logs in a user, an admin when admin is true: the access token
+func (api *testAPI) login(t *testing.T, username string, admin bool) string {
+	t.Helper()
+	ctx := context.Background()
+
+	if _, err := api.users.Register(ctx, &moviev1.RegisterRequest{Username: username, Password: apitest.Password}); err != nil {
+		t.Fatalf("register %s: %v", username, err)
+	}
+	if admin {
+		user, err := api.deps.Users.FindUserByName(ctx, username)
+		if err != nil {
+			t.Fatalf("find user: %v", err)
+		}
+		if err := api.deps.Users.UpdateUserRole(ctx, user.ID, domain.Admin); err != nil {
+			t.Fatalf("update role: %v", err)
+		}
+	}
+
+	session, err := api.users.Login(ctx, &moviev1.LoginRequest{Username: username, Password: apitest.Password})
+	if err != nil {
+		t.Fatalf("login %s: %v", username, err)
+	}
+	return session.AccessToken
+}
+
+func withToken(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+func expectCode(t *testing.T, err error, code codes.Code) {
+	t.Helper()
+	if status.Code(err) != code {
+		t.Fatalf("got %v, want %s", err, code)
+	}
+}
@@ -0,0 +1,179 @@
+package grpcserver
+
+import (
+	"context"
+
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/service"
+	moviev1 "github.com/grainme/movie-api/proto/movie/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// the defaults of the REST routes
+const (
+	defaultPopularLimit   = 10
+	defaultTrendingWindow = "24h"
+)
+
+type movieServer struct {
+	moviev1.UnimplementedMovieServiceServer
+	movies *service.MovieService
+}
+
+func (s *movieServer) ListMovies(ctx context.Context, req *moviev1.ListMoviesRequest) (*moviev1.ListMoviesResponse, error) {
+	page, err := parsePage(req.Page)
+	if err != nil {
+		return nil, err
+	}
+
+	movies, err := s.movies.GetAllMovies(ctx, page)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	response := &moviev1.ListMoviesResponse{Movies: make([]*moviev1.Movie, len(movies))}
+	for idx, movie := range movies {
+		response.Movies[idx] = movieSummary(movie)
+	}
+	return response, nil
+}
+
+func (s *movieServer) ListPopularMovies(ctx context.Context, req *moviev1.ListPopularMoviesRequest) (*moviev1.ListMoviesResponse, error) {
+	page, err := parsePage(req.Page)
+	if err != nil {
+		return nil, err
+	}
+	if page.Limit == 0 {
+		page.Limit = defaultPopularLimit
+	}
+
+	movies, err := s.movies.GetPopularMovies(ctx, page)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	response := &moviev1.ListMoviesResponse{Movies: make([]*moviev1.Movie, len(movies))}
+	for idx, movie := range movies {
+		response.Movies[idx] = movieSummary(movie)
+		response.Movies[idx].Views = movie.Views
+	}
+	return response, nil
+}
+
+func (s *movieServer) ListTrendingMovies(ctx context.Context, req *moviev1.ListTrendingMoviesRequest) (*moviev1.ListTrendingMoviesResponse, error) {
+	windowName := req.Window
+	if windowName == "" {
+		windowName = defaultTrendingWindow
+	}
+	window, ok := service.TrendingWindows[windowName]
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, "window must be one of 1h, 24h, 7d")
+	}
+
+	page, err := parsePage(req.Page)
+	if err != nil {
+		return nil, err
+	}
+	if page.Limit == 0 {
+		page.Limit = defaultPopularLimit
+	}
+
+	movies, err := s.movies.GetTrendingMovies(ctx, window, page)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	response := &moviev1.ListTrendingMoviesResponse{Movies: make([]*moviev1.TrendingMovie, len(movies))}
+	for idx, movie := range movies {
+		response.Movies[idx] = &moviev1.TrendingMovie{Movie: movieSummary(&movie.Movie), Score: movie.Score}
+	}
+	return response, nil
+}
+
+func (s *movieServer) GetMovie(ctx context.Context, req *moviev1.GetMovieRequest) (*moviev1.Movie, error) {
+	id, err := parseId("id", req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	movie, err := s.movies.GetMovieById(ctx, id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return movieSummary(movie), nil
+}
+
+func (s *movieServer) GetMovieWithReviews(ctx context.Context, req *moviev1.GetMovieRequest) (*moviev1.Movie, error) {
+	id, err := parseId("id", req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	movie, err := s.movies.GetMovieWithReviews(ctx, id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toMovie(movie), nil
+}
+
+func (s *movieServer) CreateMovie(ctx context.Context, req *moviev1.CreateMovieRequest) (*moviev1.Movie, error) {
+	movie, err := s.movies.AddMovie(ctx, &domain.Movie{
+		Title:    req.Title,
+		Director: req.Director,
+		Year:     req.Year,
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toMovie(movie), nil
+}
+
+func (s *movieServer) UpdateMovieTitle(ctx context.Context, req *moviev1.UpdateMovieTitleRequest) (*emptypb.Empty, error) {
+	id, err := parseId("id", req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.movies.UpdateMovieTitleById(ctx, id, req.Title); err != nil {
+		return nil, toStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *movieServer) DeleteMovie(ctx context.Context, req *moviev1.DeleteMovieRequest) (*emptypb.Empty, error) {
+	id, err := parseId("id", req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.movies.DeleteMovieById(ctx, id); err != nil {
+		return nil, toStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// -------- helpers
+
+// what the REST lists answer (handlers.MovieResponse): no aggregates
+func movieSummary(movie *domain.Movie) *moviev1.Movie {
+	return &moviev1.Movie{
+		Id:       movie.ID.String(),
+		Title:    movie.Title,
+		Director: movie.Director,
+		Year:     movie.Year,
+	}
+}
+
+func toMovie(movie *domain.Movie) *moviev1.Movie {
+	return &moviev1.Movie{
+		Id:            movie.ID.String(),
+		Title:         movie.Title,
+		Director:      movie.Director,
+		Year:          movie.Year,
+		AverageRating: movie.AverageRating,
+		ReviewsCount:  movie.ReviewsCount,
+		Views:         movie.Views,
+	}
+}
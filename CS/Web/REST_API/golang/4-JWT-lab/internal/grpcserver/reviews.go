@@ -0,0 +1,146 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/auth"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/service"
+	moviev1 "github.com/grainme/movie-api/proto/movie/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type reviewServer struct {
+	moviev1.UnimplementedReviewServiceServer
+	reviews *service.ReviewService
+	movies  *service.MovieService
+	users   *service.UserService
+}
+
+func (s *reviewServer) ListReviews(ctx context.Context, req *moviev1.ListReviewsRequest) (*moviev1.ListReviewsResponse, error) {
+	reviews, err := s.reviews.GetAllReviews(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toReviews(reviews), nil
+}
+
+func (s *reviewServer) ListMovieReviews(ctx context.Context, req *moviev1.ListMovieReviewsRequest) (*moviev1.ListReviewsResponse, error) {
+	movieId, err := parseId("movie_id", req.MovieId)
+	if err != nil {
+		return nil, err
+	}
+	page, err := parsePage(req.Page)
+	if err != nil {
+		return nil, err
+	}
+
+	reviews, err := s.reviews.GetAllReviewsByMovieId(ctx, movieId, page)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toReviews(reviews), nil
+}
+
+// the author is the caller (for an API key: its owner)
+func (s *reviewServer) CreateReview(ctx context.Context, req *moviev1.CreateReviewRequest) (*moviev1.Review, error) {
+	movieId, err := parseId("movie_id", req.MovieId)
+	if err != nil {
+		return nil, err
+	}
+
+	author, err := s.author(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	review, err := s.reviews.AddReview(ctx, &domain.Review{
+		Username: author.Username,
+		Rating:   req.Rating,
+		Comment:  req.Comment,
+		MovieID:  movieId,
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toReview(review), nil
+}
+
+func (s *reviewServer) WatchMovieReviews(req *moviev1.WatchMovieReviewsRequest, stream grpc.ServerStreamingServer[moviev1.Review]) error {
+	ctx := stream.Context()
+	movieId, err := parseId("movie_id", req.MovieId)
+	if err != nil {
+		return err
+	}
+
+	// NotFound right away rather than a stream that never sends anything
+	if _, err := s.movies.GetMovieWithReviews(ctx, movieId); err != nil {
+		return toStatus(err)
+	}
+
+	reviews, err := s.reviews.WatchMovieReviews(ctx, movieId, "")
+	if err != nil {
+		return toStatus(err)
+	}
+	// the headers now: the client knows it's subscribed before the first review
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+
+	for review := range reviews {
+		if err := stream.Send(toReview(review.Review)); err != nil {
+			return err
+		}
+	}
+
+	if ctx.Err() != nil {
+		return toStatus(ctx.Err())
+	}
+	// the feed itself went away (e.g. Redis): the client can reconnect
+	return status.Error(codes.Unavailable, "review feed closed")
+}
+
+// -------- helpers
+func toReview(review domain.Review) *moviev1.Review {
+	return &moviev1.Review{
+		Id:       review.ID.String(),
+		Username: review.Username,
+		Rating:   review.Rating,
+		Comment:  review.Comment,
+		MovieId:  review.MovieID.String(),
+	}
+}
+
+func toReviews(reviews []domain.Review) *moviev1.ListReviewsResponse {
+	response := &moviev1.ListReviewsResponse{Reviews: make([]*moviev1.Review, len(reviews))}
+	for idx, review := range reviews {
+		response.Reviews[idx] = toReview(review)
+	}
+	return response
+}
+
+// the user of the claims the authenticator put under "user"
+func (s *reviewServer) author(ctx context.Context) (domain.User, error) {
+	claims, ok := ctx.Value("user").(*auth.Claims)
+	if !ok {
+		return domain.User{}, status.Error(codes.Unauthenticated, "access token missing")
+	}
+	userId, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return domain.User{}, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	user, err := s.users.GetUserById(ctx, userId)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		// a token of a deleted account
+		return domain.User{}, status.Error(codes.Unauthenticated, "invalid token")
+	}
+	if err != nil {
+		return domain.User{}, toStatus(err)
+	}
+	return user, nil
+}
@@ -0,0 +1,81 @@
+// Package grpcserver is the gRPC API (proto/movie/v1): MovieService,
+// ReviewService and UserService on the same services as the REST routes,
+// with the same permissions (see auth.go).
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/server"
+	moviev1 "github.com/grainme/movie-api/proto/movie/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// the largest page a client can ask for, like the REST routes
+const maxPageSize = 100
+
+// NewServer registers the three services on a new gRPC server, the caller
+// Serves it on its own listener
+func NewServer(deps server.Deps, opts ...grpc.ServerOption) *grpc.Server {
+	services := server.NewServices(deps)
+	authenticator := &authenticator{apiKeys: services.APIKeys, audit: services.Audit}
+
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(authenticator.unary),
+		grpc.ChainStreamInterceptor(authenticator.stream),
+	)
+	s := grpc.NewServer(opts...)
+
+	moviev1.RegisterMovieServiceServer(s, &movieServer{movies: services.Movies})
+	moviev1.RegisterReviewServiceServer(s, &reviewServer{reviews: services.Reviews, movies: services.Movies, users: services.Users})
+	moviev1.RegisterUserServiceServer(s, &userServer{users: services.Users})
+
+	return s
+}
+
+// -------- helpers
+
+// the gRPC version of handlers.respondError
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrMovieNotFound):
+		return status.Error(codes.NotFound, "movie not found")
+	case errors.Is(err, domain.ErrInvalidMovie), errors.Is(err, domain.ErrInvalidMovieTitle), errors.Is(err, domain.ErrInvalidRating):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, "request canceled")
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, "deadline exceeded")
+	default:
+		log.Printf("grpc call failed: %v", err)
+		return status.Error(codes.Internal, "movie service failed")
+	}
+}
+
+func parseId(name, id string) (uuid.UUID, error) {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.UUID{}, status.Errorf(codes.InvalidArgument, "invalid %s", name)
+	}
+	return parsed, nil
+}
+
+// no page means the whole list
+func parsePage(page *moviev1.Page) (domain.Page, error) {
+	if page == nil {
+		return domain.Page{}, nil
+	}
+	if page.Limit < 0 || page.Offset < 0 {
+		return domain.Page{}, status.Error(codes.InvalidArgument, "invalid page")
+	}
+	if page.Limit > maxPageSize {
+		return domain.Page{}, status.Errorf(codes.InvalidArgument, "limit must be at most %d", maxPageSize)
+	}
+	return domain.Page{Limit: int(page.Limit), Offset: int(page.Offset)}, nil
+}
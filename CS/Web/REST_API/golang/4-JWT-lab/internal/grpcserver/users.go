@@ -0,0 +1,103 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/service"
+	moviev1 "github.com/grainme/movie-api/proto/movie/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// the same answers as handlers.UserHandler: the failures don't say why
+// (unknown user, bad password...), except the ones the user can fix
+type userServer struct {
+	moviev1.UnimplementedUserServiceServer
+	users *service.UserService
+}
+
+func (s *userServer) Register(ctx context.Context, req *moviev1.RegisterRequest) (*emptypb.Empty, error) {
+	_, err := s.users.Register(ctx, domain.CreateUserRequest{
+		Username: req.Username,
+		Password: req.Password,
+		Email:    req.Email,
+	})
+	if errors.Is(err, domain.ErrWeakPassword) || errors.Is(err, domain.ErrBreachedPassword) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		log.Printf("user could not sign up: %v", err)
+		return nil, status.Error(codes.InvalidArgument, "registration failed")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *userServer) Login(ctx context.Context, req *moviev1.LoginRequest) (*moviev1.Session, error) {
+	session, err := s.users.Login(ctx, req.Username, req.Password)
+	if errors.Is(err, domain.ErrMFALocked) {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	}
+	if err != nil {
+		log.Printf("user could not login: %v", err)
+		return nil, status.Error(codes.Unauthenticated, "login failed")
+	}
+	return toSession(session), nil
+}
+
+func (s *userServer) LoginMFA(ctx context.Context, req *moviev1.LoginMFARequest) (*moviev1.Session, error) {
+	session, err := s.users.VerifyMFA(ctx, req.MfaToken, req.Code)
+	if errors.Is(err, domain.ErrInvalidMFAToken) || errors.Is(err, domain.ErrInvalidMFACode) {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if errors.Is(err, domain.ErrMFALocked) {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	}
+	if err != nil {
+		log.Printf("two-factor operation failed: %v", err)
+		return nil, status.Error(codes.Internal, "two-factor operation failed")
+	}
+	return toSession(session), nil
+}
+
+func (s *userServer) RefreshToken(ctx context.Context, req *moviev1.RefreshTokenRequest) (*moviev1.Session, error) {
+	refreshToken, err := parseId("refresh_token", req.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.users.RefreshToken(ctx, refreshToken)
+	if err != nil {
+		log.Printf("refresh token not found: %v", err)
+		return nil, status.Error(codes.Unauthenticated, "refresh token not found")
+	}
+	return toSession(session), nil
+}
+
+func (s *userServer) Logout(ctx context.Context, req *moviev1.LogoutRequest) (*emptypb.Empty, error) {
+	refreshToken, err := parseId("refresh_token", req.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.users.Logout(ctx, refreshToken); err != nil {
+		log.Printf("user could not logout: %v", err)
+		return nil, status.Error(codes.Internal, "logout failed")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// -------- helpers
+func toSession(session domain.UserResponse) *moviev1.Session {
+	return &moviev1.Session{
+		Id:           session.ID.String(),
+		Username:     session.Username,
+		AccessToken:  session.AccessToken,
+		RefreshToken: session.RefreshToken,
+		MfaRequired:  session.MFARequired,
+		MfaToken:     session.MFAToken,
+	}
+}
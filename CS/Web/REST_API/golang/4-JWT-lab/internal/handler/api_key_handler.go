@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/service"
+)
+
+type APIKeyHandler struct {
+	apiKeyService *service.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	claims, userId, err := currentClaims(w, r)
+	if err != nil {
+		return
+	}
+
+	var keyRequest domain.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&keyRequest); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+
+	createdKey, err := h.apiKeyService.CreateAPIKey(r.Context(), userId, domain.Role(claims.Role), keyRequest)
+	if errors.Is(err, domain.ErrInvalidScope) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("could not create api key: %v", err)
+		http.Error(w, "API key creation failed", http.StatusBadRequest)
+		return
+	}
+
+	// the only time the key is ever shown
+	respondJSON(w, http.StatusCreated, createdKey)
+}
+
+func (h *APIKeyHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.apiKeyService.ListAPIKeys(r.Context())
+	if err != nil {
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, keys)
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	keyId, err := extractIdAndParse(w, r)
+	if err != nil {
+		return
+	}
+
+	err = h.apiKeyService.RevokeAPIKey(r.Context(), keyId)
+	if errors.Is(err, domain.ErrAPIKeyNotFound) {
+		respondJSON(w, http.StatusNotFound, map[string]string{"error": "api key not found"})
+		return
+	}
+	if err != nil {
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/service"
+)
+
+type AuditHandler struct {
+	auditLogger *service.AuditLogger
+}
+
+func NewAuditHandler(auditLogger *service.AuditLogger) *AuditHandler {
+	return &AuditHandler{
+		auditLogger: auditLogger,
+	}
+}
+
+// `GET /admin/audit?actor_id=&action=&outcome=&target_type=&target_id=&since=&until=&limit=&offset=`
+// since/until are RFC 3339 timestamps, every filter is optional
+func (h *AuditHandler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := domain.AuditFilter{
+		Action:     query.Get("action"),
+		Outcome:    domain.AuditOutcome(query.Get("outcome")),
+		TargetType: query.Get("target_type"),
+		TargetID:   query.Get("target_id"),
+	}
+
+	if actorId := query.Get("actor_id"); actorId != "" {
+		id, err := uuid.Parse(actorId)
+		if err != nil {
+			http.Error(w, "invalid actor_id", http.StatusBadRequest)
+			return
+		}
+		filter.ActorID = &id
+	}
+
+	for name, dest := range map[string]**time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if value := query.Get(name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				http.Error(w, "invalid "+name+" (expected RFC 3339)", http.StatusBadRequest)
+				return
+			}
+			*dest = &t
+		}
+	}
+
+	for name, dest := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		if value := query.Get(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				http.Error(w, "invalid "+name, http.StatusBadRequest)
+				return
+			}
+			*dest = n
+		}
+	}
+
+	events, err := h.auditLogger.ListEvents(r.Context(), filter)
+	if err != nil {
+		log.Printf("could not list audit events: %v", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, events)
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/grainme/movie-api/internal/bulk"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/service"
+)
+
+type BulkHandler struct {
+	bulkService *service.BulkService
+}
+
+func NewBulkHandler(bulkService *service.BulkService) *BulkHandler {
+	return &BulkHandler{
+		bulkService: bulkService,
+	}
+}
+
+// `POST /admin/import/movies?format=csv|ndjson&dry_run=true`, the file is the body
+func (h *BulkHandler) ImportMovies(w http.ResponseWriter, r *http.Request) {
+	format, ok := requestFormat(w, r)
+	if !ok {
+		return
+	}
+
+	report, err := h.bulkService.ImportMovies(r.Context(), bulk.NewMovieReader(r.Body, format), r.URL.Query().Get("dry_run") == "true")
+	respondImport(w, report, err)
+}
+
+// `POST /admin/import/reviews?format=csv|ndjson&dry_run=true`, the file is the body
+func (h *BulkHandler) ImportReviews(w http.ResponseWriter, r *http.Request) {
+	format, ok := requestFormat(w, r)
+	if !ok {
+		return
+	}
+
+	report, err := h.bulkService.ImportReviews(r.Context(), bulk.NewReviewReader(r.Body, format), r.URL.Query().Get("dry_run") == "true")
+	respondImport(w, report, err)
+}
+
+// `GET /admin/export/movies?format=csv|ndjson`
+func (h *BulkHandler) ExportMovies(w http.ResponseWriter, r *http.Request) {
+	format, ok := requestFormat(w, r)
+	if !ok {
+		return
+	}
+
+	startExport(w, "movies", format)
+	if _, err := h.bulkService.ExportMovies(r.Context(), bulk.NewMovieWriter(w, format)); err != nil {
+		// the status is already sent: the client gets a truncated file
+		log.Printf("movies export failed: %v", err)
+	}
+}
+
+// `GET /admin/export/reviews?format=csv|ndjson`
+func (h *BulkHandler) ExportReviews(w http.ResponseWriter, r *http.Request) {
+	format, ok := requestFormat(w, r)
+	if !ok {
+		return
+	}
+
+	startExport(w, "reviews", format)
+	if _, err := h.bulkService.ExportReviews(r.Context(), bulk.NewReviewWriter(w, format)); err != nil {
+		log.Printf("reviews export failed: %v", err)
+	}
+}
+
+// ?format= first, then the Content-Type of the body, csv by default for exports
+func requestFormat(w http.ResponseWriter, r *http.Request) (bulk.Format, bool) {
+	if name := r.URL.Query().Get("format"); name != "" {
+		format, err := bulk.ParseFormat(name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return "", false
+		}
+		return format, true
+	}
+
+	if r.Method == http.MethodGet {
+		return bulk.CSV, true
+	}
+	if format := bulk.FormatFromContentType(r.Header.Get("Content-Type")); format != "" {
+		return format, true
+	}
+
+	http.Error(w, "unknown format: use ?format=csv|ndjson or a text/csv, application/x-ndjson body", http.StatusBadRequest)
+	return "", false
+}
+
+func startExport(w http.ResponseWriter, name string, format bulk.Format) {
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+"."+string(format)+`"`)
+	w.WriteHeader(http.StatusOK)
+}
+
+// the report even when the import stopped half way: the batches before the
+// error are inserted, the admin needs to know
+func respondImport(w http.ResponseWriter, report domain.ImportReport, err error) {
+	if err == nil {
+		respondJSON(w, http.StatusOK, report)
+		return
+	}
+
+	status := http.StatusInternalServerError
+	message := "import failed"
+	if errors.Is(err, domain.ErrInvalidImport) {
+		status = http.StatusBadRequest
+		message = err.Error()
+	} else {
+		log.Printf("import failed: %v", err)
+	}
+
+	respondJSON(w, status, map[string]any{
+		"error":  message,
+		"report": report,
+	})
+}
@@ -16,6 +16,22 @@ type MovieResponse struct {
 	Year     int32     `json:"year"`
 }
 
+type PopularMovieResponse struct {
+	MovieResponse
+	Views int64 `json:"views"`
+}
+
+type TrendingMovieResponse struct {
+	MovieResponse
+	Score float64 `json:"score"`
+}
+
+// the top 10 when the client doesn't say
+const defaultPopularLimit = 10
+
+// when the client doesn't give a window
+const defaultTrendingWindow = "24h"
+
 type MovieHandler struct {
 	movieService *service.MovieService
 }
@@ -27,7 +43,16 @@ func NewMovieHandler(service *service.MovieService) *MovieHandler {
 }
 
 func (h *MovieHandler) GetAllMovies(w http.ResponseWriter, r *http.Request) {
-	movies := h.movieService.GetAllMovies(r.Context())
+	page, err := parsePage(w, r)
+	if err != nil {
+		return
+	}
+
+	movies, err := h.movieService.GetAllMovies(r.Context(), page)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
 
 	moviesResponse := make([]MovieResponse, len(movies))
 	for idx, movie := range movies {
@@ -43,6 +68,80 @@ func (h *MovieHandler) GetAllMovies(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, moviesResponse)
 }
 
+// `GET /movies/popular?limit=&offset=`, most viewed first
+func (h *MovieHandler) GetPopularMovies(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePage(w, r)
+	if err != nil {
+		return
+	}
+	if page.Limit == 0 {
+		page.Limit = defaultPopularLimit
+	}
+
+	movies, err := h.movieService.GetPopularMovies(r.Context(), page)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	moviesResponse := make([]PopularMovieResponse, len(movies))
+	for idx, movie := range movies {
+		moviesResponse[idx] = PopularMovieResponse{
+			MovieResponse: MovieResponse{
+				ID:       movie.ID,
+				Title:    movie.Title,
+				Director: movie.Director,
+				Year:     movie.Year,
+			},
+			Views: movie.Views,
+		}
+	}
+
+	respondJSON(w, http.StatusOK, moviesResponse)
+}
+
+// `GET /movies/trending?window=1h|24h|7d&limit=&offset=`, most active first
+func (h *MovieHandler) GetTrendingMovies(w http.ResponseWriter, r *http.Request) {
+	windowName := r.URL.Query().Get("window")
+	if windowName == "" {
+		windowName = defaultTrendingWindow
+	}
+	window, ok := service.TrendingWindows[windowName]
+	if !ok {
+		http.Error(w, "window must be one of 1h, 24h, 7d", http.StatusBadRequest)
+		return
+	}
+
+	page, err := parsePage(w, r)
+	if err != nil {
+		return
+	}
+	if page.Limit == 0 {
+		page.Limit = defaultPopularLimit
+	}
+
+	movies, err := h.movieService.GetTrendingMovies(r.Context(), window, page)
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	moviesResponse := make([]TrendingMovieResponse, len(movies))
+	for idx, movie := range movies {
+		moviesResponse[idx] = TrendingMovieResponse{
+			MovieResponse: MovieResponse{
+				ID:       movie.ID,
+				Title:    movie.Title,
+				Director: movie.Director,
+				Year:     movie.Year,
+			},
+			Score: movie.Score,
+		}
+	}
+
+	respondJSON(w, http.StatusOK, moviesResponse)
+}
+
 func (h *MovieHandler) GetMovieById(w http.ResponseWriter, r *http.Request) {
 	uuidFromId, err := extractIdAndParse(w, r)
 	if err != nil {
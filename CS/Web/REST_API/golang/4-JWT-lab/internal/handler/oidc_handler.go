@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/grainme/movie-api/internal/cache"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/oidc"
+	"github.com/grainme/movie-api/internal/service"
+)
+
+type OIDCHandler struct {
+	oidcService *service.OIDCService
+}
+
+func NewOIDCHandler(oidcService *service.OIDCService) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService: oidcService,
+	}
+}
+
+// the cookie of the browser that started a login/link, the callback only
+// finishes the flows of that browser (see OIDCService.begin). __Host-: only
+// set by us, over https, for the whole site
+const oidcBindingCookie = "__Host-oidc_binding"
+
+func (h *OIDCHandler) Login(w http.ResponseWriter, r *http.Request) {
+	authURL, binding, err := h.oidcService.BeginLogin(r.Context())
+	if err != nil {
+		log.Printf("could not start oidc login: %v", err)
+		http.Error(w, "Login failed", http.StatusInternalServerError)
+		return
+	}
+
+	setOIDCBinding(w, binding, int(cache.OIDCStateTTL.Seconds()))
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// same as Login, for the logged-in user: the provider account gets linked
+// to them (the only way to link one to an existing account). a POST that
+// answers with the URL: the front end calls it with the access token (a
+// browser redirect can't carry it), then sends the browser there
+func (h *OIDCHandler) Link(w http.ResponseWriter, r *http.Request) {
+	userId, err := currentUserId(w, r)
+	if err != nil {
+		return
+	}
+
+	authURL, binding, err := h.oidcService.BeginLink(r.Context(), userId)
+	if err != nil {
+		log.Printf("could not start oidc link: %v", err)
+		http.Error(w, "Link failed", http.StatusInternalServerError)
+		return
+	}
+
+	setOIDCBinding(w, binding, int(cache.OIDCStateTTL.Seconds()))
+	respondJSON(w, http.StatusOK, map[string]string{"authorization_url": authURL})
+}
+
+// the provider redirects the browser here with ?state=...&code=...
+// (or ?error=... when the user refused / something went wrong on their side)
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if providerErr := query.Get("error"); providerErr != "" {
+		log.Printf("oidc provider returned an error: %s (%s)", providerErr, query.Get("error_description"))
+		http.Error(w, "Login failed", http.StatusBadRequest)
+		return
+	}
+
+	// spent either way
+	var binding string
+	if cookie, err := r.Cookie(oidcBindingCookie); err == nil {
+		binding = cookie.Value
+	}
+	setOIDCBinding(w, "", -1)
+
+	userResponse, err := h.oidcService.CompleteLogin(r.Context(), query.Get("state"), query.Get("code"), binding)
+	if err != nil {
+		log.Printf("oidc login failed: %v", err)
+		switch {
+		case errors.Is(err, domain.ErrInvalidOIDCState):
+			http.Error(w, "Invalid or expired login state", http.StatusBadRequest)
+		case errors.Is(err, oidc.ErrExchangeFailed), errors.Is(err, oidc.ErrInvalidIDToken):
+			http.Error(w, "Login failed", http.StatusUnauthorized)
+		case errors.Is(err, domain.ErrOIDCEmailTaken), errors.Is(err, domain.ErrOIDCIdentityTaken):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Login failed", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, userResponse)
+}
+
+// maxAge < 0 deletes it. SameSite=Lax: still sent when the provider sends
+// the browser back (a top-level GET)
+func setOIDCBinding(w http.ResponseWriter, binding string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcBindingCookie,
+		Value:    binding,
+		Path:     "/",
+		MaxAge:   maxAge,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
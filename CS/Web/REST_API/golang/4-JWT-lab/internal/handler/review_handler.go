@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/grainme/movie-api/internal/domain"
@@ -10,11 +11,13 @@ import (
 
 type ReviewHandler struct {
 	reviewService *service.ReviewService
+	userService   *service.UserService
 }
 
-func NewReviewHandler(reviewService *service.ReviewService) *ReviewHandler {
+func NewReviewHandler(reviewService *service.ReviewService, userService *service.UserService) *ReviewHandler {
 	return &ReviewHandler{
 		reviewService: reviewService,
+		userService:   userService,
 	}
 }
 
@@ -33,7 +36,12 @@ func (h *ReviewHandler) GetAllReviewsByMovieId(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	reviews, err := h.reviewService.GetAllReviewsByMovieId(r.Context(), movieId)
+	page, err := parsePage(w, r)
+	if err != nil {
+		return
+	}
+
+	reviews, err := h.reviewService.GetAllReviewsByMovieId(r.Context(), movieId, page)
 	if err != nil {
 		respondError(w, err)
 		return
@@ -42,14 +50,36 @@ func (h *ReviewHandler) GetAllReviewsByMovieId(w http.ResponseWriter, r *http.Re
 	respondJSON(w, http.StatusOK, reviews)
 }
 
+// the author is the caller (for an API key: its owner), whatever the body says
 func (h *ReviewHandler) AddReview(w http.ResponseWriter, r *http.Request) {
-	var review domain.Review
-	if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+	userId, err := currentUserId(w, r)
+	if err != nil {
+		return
+	}
+
+	var req domain.CreateReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "invalid body", http.StatusBadRequest)
 		return
 	}
 
-	insertedReview, err := h.reviewService.AddReview(r.Context(), &review)
+	author, err := h.userService.GetUserById(r.Context(), userId)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		// a token of a deleted account
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		respondError(w, err)
+		return
+	}
+
+	insertedReview, err := h.reviewService.AddReview(r.Context(), &domain.Review{
+		Username: author.Username,
+		Rating:   req.Rating,
+		Comment:  req.Comment,
+		MovieID:  req.MovieID,
+	})
 	if err != nil {
 		respondError(w, err)
 		return
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/service"
+)
+
+const (
+	// a comment (SSE) or a ping (WebSocket) on a quiet stream, so the
+	// proxies don't close it
+	streamHeartbeat = 15 * time.Second
+	// how long an EventSource waits before reconnecting
+	sseRetry = 3 * time.Second
+	// a WebSocket client that doesn't answer the pings for that long is gone
+	wsPongWait = 2 * streamHeartbeat
+	// a write that takes longer: the client is gone too
+	wsWriteWait = 10 * time.Second
+)
+
+// ReviewStreamHandler serves the live reviews of a movie, over Server-Sent
+// Events or a WebSocket. both resume after the last event id the client got
+type ReviewStreamHandler struct {
+	movieService  *service.MovieService
+	reviewService *service.ReviewService
+	// closed when the server shuts down: the streams end, the clients reconnect to another replica
+	shutdown <-chan struct{}
+	upgrader websocket.Upgrader
+}
+
+func NewReviewStreamHandler(movieService *service.MovieService, reviewService *service.ReviewService, shutdown <-chan struct{}) *ReviewStreamHandler {
+	return &ReviewStreamHandler{
+		movieService:  movieService,
+		reviewService: reviewService,
+		shutdown:      shutdown,
+		upgrader: websocket.Upgrader{
+			// public, read-only data: any page can show it
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+// `GET /movies/{id}/reviews/stream` (text/event-stream). a reconnecting
+// EventSource sends the Last-Event-ID header, other clients can use `?last_event_id=`
+func (h *ReviewStreamHandler) StreamReviews(w http.ResponseWriter, r *http.Request) {
+	lastEventId := r.Header.Get("Last-Event-ID")
+	if lastEventId == "" {
+		lastEventId = r.URL.Query().Get("last_event_id")
+	}
+
+	reviews, ok := h.watch(r.Context(), w, r, lastEventId)
+	if !ok {
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// nginx buffers the responses by default
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds())
+	if err := rc.Flush(); err != nil {
+		log.Printf("review stream can't flush: %v", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-h.shutdown:
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case review, open := <-reviews:
+			// the client left, or the feed went away (it reconnects)
+			if !open {
+				return
+			}
+			data, err := json.Marshal(review.Review)
+			if err != nil {
+				log.Printf("could not marshal review %s: %v", review.Review.ID, err)
+				continue
+			}
+			fmt.Fprintf(w, "id: %s\nevent: review\ndata: %s\n\n", review.ID, data)
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// `GET /movies/{id}/reviews/ws`: one JSON message ({"id", "review"}) per
+// review. the client only answers the pings, `?last_event_id=` resumes
+func (h *ReviewStreamHandler) WatchReviews(w http.ResponseWriter, r *http.Request) {
+	// the request's context isn't cancelled when a hijacked connection
+	// closes: the read loop below does it
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	reviews, ok := h.watch(ctx, w, r, r.URL.Query().Get("last_event_id"))
+	if !ok {
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader already answered
+		return
+	}
+	defer conn.Close()
+
+	// reads the pongs and the close frame, nothing else is expected
+	conn.SetReadLimit(512)
+	conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-h.shutdown:
+			closeWebSocket(conn, websocket.CloseGoingAway, "server shutting down")
+			return
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				return
+			}
+		case review, open := <-reviews:
+			if !open {
+				if ctx.Err() == nil {
+					closeWebSocket(conn, websocket.CloseTryAgainLater, "review feed closed")
+				}
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteJSON(review); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// watch checks the movie and subscribes to its reviews, answering the errors
+func (h *ReviewStreamHandler) watch(ctx context.Context, w http.ResponseWriter, r *http.Request, lastEventId string) (<-chan domain.FeedReview, bool) {
+	movieId, err := extractIdAndParse(w, r)
+	if err != nil {
+		return nil, false
+	}
+
+	// 404 right away rather than a stream that never sends anything
+	if _, err := h.movieService.GetMovieWithReviews(ctx, movieId); err != nil {
+		respondError(w, err)
+		return nil, false
+	}
+
+	reviews, err := h.reviewService.WatchMovieReviews(ctx, movieId, lastEventId)
+	if errors.Is(err, domain.ErrInvalidEventID) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("could not watch the reviews of movie %s: %v", movieId, err)
+		http.Error(w, "review feed unavailable", http.StatusServiceUnavailable)
+		return nil, false
+	}
+
+	return reviews, true
+}
+
+func closeWebSocket(conn *websocket.Conn, code int, reason string) {
+	message := websocket.FormatCloseMessage(code, reason)
+	conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(wsWriteWait))
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 
@@ -31,6 +32,10 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 	r.Body.Close()
 
 	userResponse, err := h.userService.Login(r.Context(), userRequest.Username, userRequest.Password)
+	if errors.Is(err, domain.ErrMFALocked) {
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	}
 	if err != nil {
 		log.Printf("user could not login: %v", err)
 		http.Error(w, "Login failed", http.StatusBadRequest)
@@ -84,6 +89,11 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 	r.Body.Close()
 
 	user, err := h.userService.Register(r.Context(), userRequest)
+	if errors.Is(err, domain.ErrWeakPassword) || errors.Is(err, domain.ErrBreachedPassword) {
+		// the user can fix these, tell them what's wrong
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		log.Printf("user could not sign up: %v", err)
 		http.Error(w, "Registration failed", http.StatusBadRequest)
@@ -128,3 +138,145 @@ func (h *UserHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 	// here we  should refreshToken via the service.
 	respondJSON(w, http.StatusOK, user)
 }
+
+func (h *UserHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var forgotRequest domain.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&forgotRequest); err != nil {
+		log.Printf("Decoding failed: %v", err)
+		http.Error(w, "Decoding failed", http.StatusBadRequest)
+		return
+	}
+	r.Body.Close()
+
+	// same answer whether the email exists or not: a 500 would only happen
+	// for registered emails (token store, mailer) and give them away
+	err := h.userService.ForgotPassword(r.Context(), forgotRequest.Email)
+	if err != nil {
+		log.Printf("could not issue password reset: %v", err)
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *UserHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var resetRequest domain.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&resetRequest); err != nil {
+		log.Printf("Decoding failed: %v", err)
+		http.Error(w, "Decoding failed", http.StatusBadRequest)
+		return
+	}
+	r.Body.Close()
+
+	err := h.userService.ResetPassword(r.Context(), resetRequest.Token, resetRequest.Password)
+	if errors.Is(err, domain.ErrInvalidResetToken) {
+		http.Error(w, "Invalid or expired token", http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, domain.ErrWeakPassword) || errors.Is(err, domain.ErrBreachedPassword) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("could not reset password: %v", err)
+		http.Error(w, "Password reset failed", http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// second step of the login when the account has 2FA enabled
+func (h *UserHandler) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	var mfaRequest domain.MFALoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&mfaRequest); err != nil {
+		log.Printf("Decoding failed: %v", err)
+		http.Error(w, "Decoding failed", http.StatusBadRequest)
+		return
+	}
+	r.Body.Close()
+
+	userResponse, err := h.userService.VerifyMFA(r.Context(), mfaRequest.MFAToken, mfaRequest.Code)
+	if err != nil {
+		respondMFAError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, userResponse)
+}
+
+func (h *UserHandler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	userId, err := currentUserId(w, r)
+	if err != nil {
+		return
+	}
+
+	enrollment, err := h.userService.EnrollTOTP(r.Context(), userId)
+	if err != nil {
+		respondMFAError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, enrollment)
+}
+
+func (h *UserHandler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	userId, err := currentUserId(w, r)
+	if err != nil {
+		return
+	}
+
+	var codeRequest domain.TOTPCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&codeRequest); err != nil {
+		log.Printf("Decoding failed: %v", err)
+		http.Error(w, "Decoding failed", http.StatusBadRequest)
+		return
+	}
+	r.Body.Close()
+
+	codes, err := h.userService.ConfirmTOTP(r.Context(), userId, codeRequest.Code)
+	if err != nil {
+		respondMFAError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, domain.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (h *UserHandler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	userId, err := currentUserId(w, r)
+	if err != nil {
+		return
+	}
+
+	var codeRequest domain.TOTPCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&codeRequest); err != nil {
+		log.Printf("Decoding failed: %v", err)
+		http.Error(w, "Decoding failed", http.StatusBadRequest)
+		return
+	}
+	r.Body.Close()
+
+	err = h.userService.DisableTOTP(r.Context(), userId, codeRequest.Code)
+	if err != nil {
+		respondMFAError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func respondMFAError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidMFAToken), errors.Is(err, domain.ErrInvalidMFACode):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, domain.ErrMFAAlreadyEnabled):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, domain.ErrMFANotEnrolled):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrMFALocked):
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	default:
+		log.Printf("two-factor operation failed: %v", err)
+		http.Error(w, "Two-factor operation failed", http.StatusInternalServerError)
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/auth"
 	"github.com/grainme/movie-api/internal/domain"
 )
 
@@ -42,3 +44,53 @@ func extractIdAndParse(w http.ResponseWriter, r *http.Request) (uuid.UUID, error
 
 	return uuidFromId, nil
 }
+
+// the largest page a client can ask for
+const maxPageSize = 100
+
+// optional `?limit=&offset=`, no limit means the whole list
+func parsePage(w http.ResponseWriter, r *http.Request) (domain.Page, error) {
+	var page domain.Page
+	query := r.URL.Query()
+
+	for name, dest := range map[string]*int{"limit": &page.Limit, "offset": &page.Offset} {
+		if value := query.Get(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				http.Error(w, "invalid "+name, http.StatusBadRequest)
+				return domain.Page{}, errors.New("invalid " + name)
+			}
+			*dest = n
+		}
+	}
+
+	if page.Limit > maxPageSize {
+		http.Error(w, "limit must be at most "+strconv.Itoa(maxPageSize), http.StatusBadRequest)
+		return domain.Page{}, errors.New("limit too large")
+	}
+
+	return page, nil
+}
+
+// only for routes behind middleware.Authenticate, which stores the claims under "user"
+func currentUserId(w http.ResponseWriter, r *http.Request) (uuid.UUID, error) {
+	_, userId, err := currentClaims(w, r)
+	return userId, err
+}
+
+// the claims and the user id they are about (for API keys: the key's owner)
+func currentClaims(w http.ResponseWriter, r *http.Request) (*auth.Claims, uuid.UUID, error) {
+	claims, ok := r.Context().Value("user").(*auth.Claims)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, uuid.UUID{}, errors.New("missing user claims")
+	}
+
+	userId, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, uuid.UUID{}, err
+	}
+
+	return claims, userId, nil
+}
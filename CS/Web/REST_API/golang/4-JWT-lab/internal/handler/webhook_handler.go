@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/service"
+)
+
+type WebhookHandler struct {
+	webhookService *service.WebhookService
+}
+
+func NewWebhookHandler(webhookService *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	userId, err := currentUserId(w, r)
+	if err != nil {
+		return
+	}
+
+	var webhookRequest domain.CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&webhookRequest); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+
+	createdWebhook, err := h.webhookService.CreateWebhook(r.Context(), userId, webhookRequest)
+	if errors.Is(err, domain.ErrInvalidWebhook) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("could not create webhook: %v", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	// the only time the secret is ever shown
+	respondJSON(w, http.StatusCreated, createdWebhook)
+}
+
+func (h *WebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := h.webhookService.ListWebhooks(r.Context())
+	if err != nil {
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, webhooks)
+}
+
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookId, err := extractIdAndParse(w, r)
+	if err != nil {
+		return
+	}
+
+	err = h.webhookService.DeleteWebhook(r.Context(), webhookId)
+	if errors.Is(err, domain.ErrWebhookNotFound) {
+		respondJSON(w, http.StatusNotFound, map[string]string{"error": "webhook not found"})
+		return
+	}
+	if err != nil {
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// `GET /admin/webhooks/{id}/deliveries?limit=&offset=`, newest first
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	webhookId, err := extractIdAndParse(w, r)
+	if err != nil {
+		return
+	}
+	page, err := parsePage(w, r)
+	if err != nil {
+		return
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(r.Context(), webhookId, page)
+	if errors.Is(err, domain.ErrWebhookNotFound) {
+		respondJSON(w, http.StatusNotFound, map[string]string{"error": "webhook not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("could not list the deliveries of webhook %s: %v", webhookId, err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, deliveries)
+}
+
+// queues the event again: the new delivery is sent by the dispatcher
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	webhookId, err := extractIdAndParse(w, r)
+	if err != nil {
+		return
+	}
+	deliveryId, err := uuid.Parse(chi.URLParam(r, "deliveryId"))
+	if err != nil {
+		http.Error(w, "UUID parsing failed", http.StatusBadRequest)
+		return
+	}
+
+	redelivery, err := h.webhookService.Redeliver(r.Context(), webhookId, deliveryId)
+	if errors.Is(err, domain.ErrDeliveryNotFound) {
+		respondJSON(w, http.StatusNotFound, map[string]string{"error": "delivery not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("could not redeliver delivery %s: %v", deliveryId, err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, redelivery)
+}
@@ -0,0 +1,40 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileMailer writes every email as a text file in a directory,
+// handy for tests (read the file, grab the link) and local dev.
+type FileMailer struct {
+	mu  sync.Mutex
+	dir string
+	seq int
+}
+
+func NewFileMailer(dir string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &FileMailer{
+		dir: dir,
+	}, nil
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// the sequence number keeps files ordered (and unique) within the same nanosecond
+	m.seq++
+	name := fmt.Sprintf("%d-%04d.eml", time.Now().UnixNano(), m.seq)
+
+	content := fmt.Sprintf("To: %s\nSubject: %s\n\n%s\n", msg.To, msg.Subject, msg.Body)
+	return os.WriteFile(filepath.Join(m.dir, name), []byte(content), 0o644)
+}
@@ -0,0 +1,18 @@
+package mailer
+
+import (
+	"context"
+	"log"
+)
+
+// LogMailer prints emails to the server logs instead of sending them.
+type LogMailer struct{}
+
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	log.Printf("MAIL to=%s subject=%q\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
@@ -0,0 +1,16 @@
+package mailer
+
+import "context"
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer is how the API talks to the outside world by email.
+// the services only know this interface, so swapping the log/file mailers
+// (local dev, tests) for a real SMTP/provider one is a main.go change.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
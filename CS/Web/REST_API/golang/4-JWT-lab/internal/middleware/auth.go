@@ -8,26 +8,73 @@ import (
 	"github.com/grainme/movie-api/internal/auth"
 )
 
-func Authenticate(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// extract the token and validates it and then attach to context
-		bearerToken := strings.Split(r.Header.Get("Authorization"), " ")
-		if len(bearerToken) < 2 || strings.ToLower(bearerToken[0]) != "bearer" {
-			w.WriteHeader(http.StatusUnauthorized)
-			w.Write([]byte("Access token missing"))
-			return
-		}
-
-		token := bearerToken[1]
-		claims, err := auth.ValidateAccessToken(token)
-		if err != nil {
-			w.WriteHeader(http.StatusUnauthorized)
-			w.Write([]byte("invalid token"))
-			return
-		}
-		newCtx := context.WithValue(r.Context(), "user", claims)
-		requestWithModifiedCtx := r.WithContext(newCtx)
-
-		next.ServeHTTP(w, requestWithModifiedCtx)
-	})
+// implemented by service.APIKeyService
+type APIKeyResolver interface {
+	ResolveAPIKey(ctx context.Context, rawKey string) (*auth.Claims, error)
+}
+
+// Authenticate accepts either a JWT (Authorization: Bearer <token>) for users
+// or an API key (X-API-Key: <key>) for service-to-service clients.
+// both end up as *auth.Claims under "user" in the context, so the next
+// middlewares/handlers don't care how the caller authenticated.
+// apiKeys can be nil: API keys are then rejected.
+func Authenticate(apiKeys APIKeyResolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if apiKey := r.Header.Get("X-API-Key"); apiKey != "" {
+				if apiKeys == nil {
+					w.WriteHeader(http.StatusUnauthorized)
+					w.Write([]byte("api keys are not accepted"))
+					return
+				}
+
+				claims, err := apiKeys.ResolveAPIKey(r.Context(), apiKey)
+				if err != nil {
+					w.WriteHeader(http.StatusUnauthorized)
+					w.Write([]byte("invalid api key"))
+					return
+				}
+
+				newCtx := context.WithValue(r.Context(), "user", claims)
+				next.ServeHTTP(w, r.WithContext(newCtx))
+				return
+			}
+
+			// extract the token and validates it and then attach to context
+			bearerToken := strings.Split(r.Header.Get("Authorization"), " ")
+			if len(bearerToken) < 2 || strings.ToLower(bearerToken[0]) != "bearer" {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte("Access token missing"))
+				return
+			}
+
+			token := bearerToken[1]
+			claims, err := auth.ValidateAccessToken(token)
+			if err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte("invalid token"))
+				return
+			}
+			newCtx := context.WithValue(r.Context(), "user", claims)
+			requestWithModifiedCtx := r.WithContext(newCtx)
+
+			next.ServeHTTP(w, requestWithModifiedCtx)
+		})
+	}
+}
+
+// OptionalAuthenticate is Authenticate for routes that anonymous callers can
+// use too (/graphql: its queries are public, its mutations are not):
+// no credentials go through without claims, wrong ones are still a 401
+func OptionalAuthenticate(apiKeys APIKeyResolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		authenticated := Authenticate(apiKeys)(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-API-Key") == "" && r.Header.Get("Authorization") == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			authenticated.ServeHTTP(w, r)
+		})
+	}
 }
@@ -1,28 +1,100 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
 	"github.com/grainme/movie-api/internal/auth"
 	"github.com/grainme/movie-api/internal/domain"
 )
 
+// AuditLogger is what the access checks need of service.AuditLogger
+type AuditLogger interface {
+	Log(ctx context.Context, event domain.AuditEvent)
+}
+
+// AuditDenied records a request refused to an authenticated caller (the
+// actor comes from the claims in ctx). resource is what was asked: the
+// route, the gRPC method, the GraphQL mutation.
+func AuditDenied(ctx context.Context, audit AuditLogger, resource, reason string) {
+	if audit == nil {
+		return
+	}
+
+	audit.Log(ctx, domain.AuditEvent{
+		Action:  domain.AuditAuthorize,
+		Outcome: domain.AuditDenied,
+		Detail:  resource + ": " + reason,
+	})
+}
+
+// the 403 of the checks below, audited
+func forbid(w http.ResponseWriter, r *http.Request, audit AuditLogger, reason string) {
+	AuditDenied(r.Context(), audit, r.Method+" "+r.URL.Path, reason)
+	w.WriteHeader(http.StatusForbidden)
+}
+
 // at this point, we already used the auth middleware
 // which means that we have "user" as key in r.Context
 // therefor we can extract it and check the role ("admin" or "regular")
-func Authorize(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		userRole, ok := r.Context().Value("user").(*auth.Claims)
-		if !ok {
-			w.WriteHeader(http.StatusForbidden)
-			return
-		}
-
-		if userRole.Role != string(domain.Admin) {
-			w.WriteHeader(http.StatusForbidden)
-			return
-		}
-
-		next.ServeHTTP(w, r)
-	})
+func Authorize(audit AuditLogger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userRole, ok := r.Context().Value("user").(*auth.Claims)
+			if !ok {
+				forbid(w, r, audit, "not authenticated")
+				return
+			}
+
+			// REQUIRE_ADMIN_MFA=true: an admin password alone is not enough,
+			// the access token must come from a login that passed the second factor
+			if err := userRole.Authorize("", true); err != nil {
+				forbid(w, r, audit, err.Error())
+				if errors.Is(err, auth.ErrMFARequired) {
+					w.Write([]byte(err.Error()))
+				}
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequirePermission is the fine-grained version of Authorize: users pass
+// with their role permissions, API keys only with the matching scope.
+func RequirePermission(audit AuditLogger, permission domain.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := r.Context().Value("user").(*auth.Claims)
+			if !ok {
+				forbid(w, r, audit, "not authenticated")
+				return
+			}
+			if err := claims.Authorize(permission, false); err != nil {
+				forbid(w, r, audit, err.Error())
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// some routes are for humans only (e.g. creating API keys with an API key
+// would let a leaked key mint new ones)
+func RejectAPIKeys(audit AuditLogger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := r.Context().Value("user").(*auth.Claims)
+			if !ok || claims.APIKeyID != "" {
+				forbid(w, r, audit, "api keys are not allowed here")
+				w.Write([]byte("api keys are not allowed here"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
 }
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+// RequestMeta stores the client IP and user agent under "request_meta"
+// in the context (read by service.AuditLogger).
+func RequestMeta(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		meta := domain.RequestMeta{
+			IP:        clientIP(r),
+			UserAgent: r.UserAgent(),
+		}
+
+		newCtx := context.WithValue(r.Context(), "request_meta", meta)
+		next.ServeHTTP(w, r.WithContext(newCtx))
+	})
+}
+
+// X-Forwarded-For is set by the client unless a proxy overwrites it:
+// only trust it when we know we're behind one (TRUST_PROXY_HEADERS=true),
+// otherwise anybody could write whatever IP they want in the audit log
+func clientIP(r *http.Request) string {
+	if os.Getenv("TRUST_PROXY_HEADERS") == "true" {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			return strings.TrimSpace(first)
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,170 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// don't let a stream of tokens with random kids hammer the provider
+const jwksMinRefreshInterval = 30 * time.Second
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// keySet caches the provider signing keys.
+// providers rotate keys: an unknown kid triggers a refetch (rate limited).
+type keySet struct {
+	mu                 sync.RWMutex
+	uri                string
+	httpClient         *http.Client
+	keys               map[string]any
+	lastRefresh        time.Time
+	minRefreshInterval time.Duration
+}
+
+func newKeySet(uri string, httpClient *http.Client) *keySet {
+	return &keySet{
+		uri:                uri,
+		httpClient:         httpClient,
+		keys:               map[string]any{},
+		minRefreshInterval: jwksMinRefreshInterval,
+	}
+}
+
+func (s *keySet) key(ctx context.Context, kid string) (any, error) {
+	s.mu.RLock()
+	key, ok := s.keys[kid]
+	s.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	if err := s.refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok = s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	return key, nil
+}
+
+func (s *keySet) refresh(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Since(s.lastRefresh) < s.minRefreshInterval {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.uri, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("jwks: unexpected status %d", resp.StatusCode)
+	}
+
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&document); err != nil {
+		return fmt.Errorf("jwks: %w", err)
+	}
+
+	keys := map[string]any{}
+	for _, jwk := range document.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			// skip key types we don't support, keep the others
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	s.keys = keys
+	s.lastRefresh = time.Now()
+	return nil
+}
+
+func (k jsonWebKey) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+	}
+
+	return nil, errors.New("unsupported key type " + k.Kty)
+}
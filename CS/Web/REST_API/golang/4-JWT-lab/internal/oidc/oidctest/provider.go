@@ -0,0 +1,232 @@
+// Package oidctest is an in-process OpenID Connect provider for the tests
+// of the oidc package and of the login flow (internal/server).
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Provider serves discovery, authorize (auto-consent), token (with PKCE
+// check) and JWKS endpoints. every login is the user set with SetUser.
+type Provider struct {
+	t      *testing.T
+	server *httptest.Server
+
+	ClientID     string
+	ClientSecret string
+
+	mu            sync.Mutex
+	key           *rsa.PrivateKey
+	kid           string
+	subject       string
+	email         string
+	emailVerified bool
+	codes         map[string]pendingCode
+	// lets a test tamper with the claims of the next id token
+	MutateClaims func(claims jwt.MapClaims)
+}
+
+type pendingCode struct {
+	challenge   string
+	nonce       string
+	redirectURI string
+}
+
+// NewProvider starts the provider (stopped with the test), logging in
+// "user-123" with the verified email alice@example.com
+func NewProvider(t *testing.T) *Provider {
+	t.Helper()
+
+	fp := &Provider{
+		t:             t,
+		ClientID:      "movie-api",
+		ClientSecret:  "s3cret",
+		subject:       "user-123",
+		email:         "alice@example.com",
+		emailVerified: true,
+		codes:         map[string]pendingCode{},
+	}
+	fp.RotateKey()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", fp.discovery)
+	mux.HandleFunc("GET /authorize", fp.authorize)
+	mux.HandleFunc("POST /token", fp.token)
+	mux.HandleFunc("GET /jwks", fp.jwks)
+
+	fp.server = httptest.NewServer(mux)
+	t.Cleanup(fp.server.Close)
+
+	return fp
+}
+
+func (fp *Provider) Issuer() string {
+	return fp.server.URL
+}
+
+// the user of the next logins
+func (fp *Provider) SetUser(subject, email string, emailVerified bool) {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	fp.subject = subject
+	fp.email = email
+	fp.emailVerified = emailVerified
+}
+
+func (fp *Provider) KeyID() string {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	return fp.kid
+}
+
+func (fp *Provider) RotateKey() {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		fp.t.Fatalf("generate key: %v", err)
+	}
+	fp.key = key
+
+	kid := make([]byte, 6)
+	rand.Read(kid)
+	fp.kid = base64.RawURLEncoding.EncodeToString(kid)
+}
+
+func (fp *Provider) discovery(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]string{
+		"issuer":                 fp.Issuer(),
+		"authorization_endpoint": fp.Issuer() + "/authorize",
+		"token_endpoint":         fp.Issuer() + "/token",
+		"jwks_uri":               fp.Issuer() + "/jwks",
+	})
+}
+
+// the user "logs in" instantly and is sent back with a code
+func (fp *Provider) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != fp.ClientID || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	code := base64.RawURLEncoding.EncodeToString([]byte(time.Now().String()))
+	fp.mu.Lock()
+	fp.codes[code] = pendingCode{
+		challenge:   query.Get("code_challenge"),
+		nonce:       query.Get("nonce"),
+		redirectURI: query.Get("redirect_uri"),
+	}
+	fp.mu.Unlock()
+
+	callback, _ := url.Parse(query.Get("redirect_uri"))
+	callbackQuery := callback.Query()
+	callbackQuery.Set("code", code)
+	callbackQuery.Set("state", query.Get("state"))
+	callback.RawQuery = callbackQuery.Encode()
+
+	http.Redirect(w, r, callback.String(), http.StatusFound)
+}
+
+func (fp *Provider) token(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok || clientID != fp.ClientID || clientSecret != fp.ClientSecret {
+		http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+
+	fp.mu.Lock()
+	pending, found := fp.codes[r.PostForm.Get("code")]
+	delete(fp.codes, r.PostForm.Get("code"))
+	fp.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !found ||
+		pending.redirectURI != r.PostForm.Get("redirect_uri") ||
+		base64.RawURLEncoding.EncodeToString(sum[:]) != pending.challenge {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"access_token": "provider-access-token",
+		"token_type":   "Bearer",
+		"id_token":     fp.IDToken(pending.nonce),
+	})
+}
+
+func (fp *Provider) jwks(w http.ResponseWriter, r *http.Request) {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": fp.kid,
+			"n":   base64.RawURLEncoding.EncodeToString(fp.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(fp.key.E)).Bytes()),
+		}},
+	})
+}
+
+// IDToken is what the token endpoint returns for nonce
+func (fp *Provider) IDToken(nonce string) string {
+	fp.mu.Lock()
+	subject, email, emailVerified := fp.subject, fp.email, fp.emailVerified
+	fp.mu.Unlock()
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":                fp.Issuer(),
+		"sub":                subject,
+		"aud":                fp.ClientID,
+		"exp":                now.Add(5 * time.Minute).Unix(),
+		"iat":                now.Unix(),
+		"nonce":              nonce,
+		"email":              email,
+		"email_verified":     emailVerified,
+		"preferred_username": "alice",
+	}
+
+	if fp.MutateClaims != nil {
+		fp.MutateClaims(claims)
+	}
+
+	return fp.sign(claims)
+}
+
+func (fp *Provider) sign(claims jwt.MapClaims) string {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = fp.kid
+
+	signed, err := token.SignedString(fp.key)
+	if err != nil {
+		fp.t.Fatalf("sign id token: %v", err)
+	}
+	return signed
+}
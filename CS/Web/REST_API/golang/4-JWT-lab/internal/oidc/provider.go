@@ -0,0 +1,225 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrInvalidIDToken = errors.New("invalid id token")
+	ErrExchangeFailed = errors.New("authorization code exchange failed")
+)
+
+// what we need from /.well-known/openid-configuration
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string // "openid" is always added
+}
+
+// Provider is an OpenID Connect relying party for one identity provider:
+// builds the authorization URL, exchanges the code, verifies the ID token.
+type Provider struct {
+	config     Config
+	discovery  discoveryDocument
+	keys       *keySet
+	httpClient *http.Client
+}
+
+// IDToken holds the claims we use for account linking
+type IDToken struct {
+	Issuer            string
+	Subject           string // stable id of the user at the provider, email can change
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+	Name              string
+}
+
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce             string `json:"nonce"`
+	AuthorizedParty   string `json:"azp"`
+	Email             string `json:"email"`
+	EmailVerified     any    `json:"email_verified"` // some providers send "true" as a string
+	PreferredUsername string `json:"preferred_username"`
+	Name              string `json:"name"`
+}
+
+// Discover fetches the provider metadata, the JWKS is fetched lazily.
+// httpClient can be nil (http.DefaultClient with a timeout).
+func Discover(ctx context.Context, config Config, httpClient *http.Client) (*Provider, error) {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	wellKnown := strings.TrimSuffix(config.Issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc discovery: unexpected status %d", resp.StatusCode)
+	}
+
+	var doc discoveryDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+
+	// the spec requires an exact match, otherwise a provider could vouch for another one
+	if doc.Issuer != config.Issuer {
+		return nil, fmt.Errorf("oidc discovery: issuer mismatch, expected %q got %q", config.Issuer, doc.Issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, errors.New("oidc discovery: incomplete provider metadata")
+	}
+
+	return &Provider{
+		config:     config,
+		discovery:  doc,
+		keys:       newKeySet(doc.JWKSURI, httpClient),
+		httpClient: httpClient,
+	}, nil
+}
+
+func (p *Provider) Issuer() string {
+	return p.discovery.Issuer
+}
+
+// AuthCodeURL is where we redirect the browser.
+// state: CSRF protection for the callback, nonce: binds the ID token to this login,
+// codeChallenge: PKCE (see NewPKCEVerifier)
+func (p *Provider) AuthCodeURL(state, nonce, codeChallenge string) string {
+	scopes := append([]string{"openid"}, p.config.Scopes...)
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.config.ClientID)
+	query.Set("redirect_uri", p.config.RedirectURL)
+	query.Set("scope", strings.Join(scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(p.discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return p.discovery.AuthorizationEndpoint + separator + query.Encode()
+}
+
+// Exchange trades the authorization code (+ PKCE verifier) for the ID token.
+// we don't keep the provider access token: after login the user gets our own tokens.
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	// client_secret_basic
+	req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: status %d: %s", ErrExchangeFailed, resp.StatusCode, body)
+	}
+
+	var tokenResponse struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.Unmarshal(body, &tokenResponse); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrExchangeFailed, err)
+	}
+	if tokenResponse.IDToken == "" {
+		return "", fmt.Errorf("%w: no id_token in response", ErrExchangeFailed)
+	}
+
+	return tokenResponse.IDToken, nil
+}
+
+// VerifyIDToken checks the signature against the provider JWKS, then the claims:
+// iss, aud (our client id), exp/iat, and the nonce we generated for this login.
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*IDToken, error) {
+	var claims idTokenClaims
+	_, err := jwt.ParseWithClaims(rawIDToken, &claims,
+		func(t *jwt.Token) (any, error) {
+			kid, _ := t.Header["kid"].(string)
+			return p.keys.key(ctx, kid)
+		},
+		// never let the token pick "none" or HS256 (signed with a public key as secret...)
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384"}),
+		jwt.WithIssuer(p.discovery.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(30*time.Second),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	// with several audiences, azp must be us
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.config.ClientID {
+		return nil, fmt.Errorf("%w: unexpected azp", ErrInvalidIDToken)
+	}
+	if nonce == "" || claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing sub", ErrInvalidIDToken)
+	}
+
+	return &IDToken{
+		Issuer:            claims.Issuer,
+		Subject:           claims.Subject,
+		Email:             claims.Email,
+		EmailVerified:     claims.EmailVerified == true || claims.EmailVerified == "true",
+		PreferredUsername: claims.PreferredUsername,
+		Name:              claims.Name,
+	}, nil
+}
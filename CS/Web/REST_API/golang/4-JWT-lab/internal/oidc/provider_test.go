@@ -0,0 +1,204 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/grainme/movie-api/internal/oidc/oidctest"
+)
+
+// runs the browser part of the flow: follow our authorization URL to the
+// fake provider and read the code/state it redirects back with
+func authorize(t *testing.T, provider *Provider, state, nonce, verifier string) (string, string) {
+	t.Helper()
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := client.Get(provider.AuthCodeURL(state, nonce, PKCEChallenge(verifier)))
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize: expected 302, got %d", resp.StatusCode)
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("authorize: bad location: %v", err)
+	}
+
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func config(fp *oidctest.Provider) Config {
+	return Config{
+		Issuer:       fp.Issuer(),
+		ClientID:     fp.ClientID,
+		ClientSecret: fp.ClientSecret,
+		RedirectURL:  "http://localhost:3000/auth/oidc/callback",
+	}
+}
+
+func discover(t *testing.T, fp *oidctest.Provider) *Provider {
+	t.Helper()
+
+	provider, err := Discover(context.Background(), config(fp), nil)
+	if err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+	return provider
+}
+
+func TestAuthorizationCodeFlowWithPKCE(t *testing.T) {
+	fp := oidctest.NewProvider(t)
+	provider := discover(t, fp)
+	ctx := context.Background()
+
+	verifier, _ := NewPKCEVerifier()
+	code, state := authorize(t, provider, "state-1", "nonce-1", verifier)
+	if state != "state-1" {
+		t.Fatalf("expected state to round-trip, got %q", state)
+	}
+
+	rawIDToken, err := provider.Exchange(ctx, code, verifier)
+	if err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+
+	idToken, err := provider.VerifyIDToken(ctx, rawIDToken, "nonce-1")
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	if idToken.Subject != "user-123" || idToken.Email != "alice@example.com" || !idToken.EmailVerified {
+		t.Errorf("unexpected claims: %+v", idToken)
+	}
+	if idToken.PreferredUsername != "alice" || idToken.Issuer != fp.Issuer() {
+		t.Errorf("unexpected claims: %+v", idToken)
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	fp := oidctest.NewProvider(t)
+	provider := discover(t, fp)
+
+	verifier, _ := NewPKCEVerifier()
+	code, _ := authorize(t, provider, "state", "nonce", verifier)
+
+	// an attacker who intercepted the code doesn't have our verifier
+	otherVerifier, _ := NewPKCEVerifier()
+	_, err := provider.Exchange(context.Background(), code, otherVerifier)
+	if !errors.Is(err, ErrExchangeFailed) {
+		t.Fatalf("expected ErrExchangeFailed, got %v", err)
+	}
+}
+
+func TestExchangeCodeIsSingleUse(t *testing.T) {
+	fp := oidctest.NewProvider(t)
+	provider := discover(t, fp)
+
+	verifier, _ := NewPKCEVerifier()
+	code, _ := authorize(t, provider, "state", "nonce", verifier)
+
+	if _, err := provider.Exchange(context.Background(), code, verifier); err != nil {
+		t.Fatalf("first exchange: %v", err)
+	}
+	if _, err := provider.Exchange(context.Background(), code, verifier); !errors.Is(err, ErrExchangeFailed) {
+		t.Fatalf("expected replayed code to fail, got %v", err)
+	}
+}
+
+func TestVerifyIDTokenRejectsInvalidTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		nonce  string
+		mutate func(claims jwt.MapClaims)
+	}{
+		{name: "nonce mismatch", nonce: "other-nonce"},
+		{name: "empty expected nonce", nonce: ""},
+		{name: "wrong audience", nonce: "nonce", mutate: func(c jwt.MapClaims) { c["aud"] = "another-client" }},
+		{name: "wrong issuer", nonce: "nonce", mutate: func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }},
+		{name: "expired", nonce: "nonce", mutate: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }},
+		{name: "missing exp", nonce: "nonce", mutate: func(c jwt.MapClaims) { delete(c, "exp") }},
+		{name: "missing sub", nonce: "nonce", mutate: func(c jwt.MapClaims) { delete(c, "sub") }},
+		{
+			name:  "multiple audiences without azp",
+			nonce: "nonce",
+			mutate: func(c jwt.MapClaims) {
+				c["aud"] = []string{"movie-api", "another-client"}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp := oidctest.NewProvider(t)
+			provider := discover(t, fp)
+			fp.MutateClaims = tt.mutate
+
+			_, err := provider.VerifyIDToken(context.Background(), fp.IDToken("nonce"), tt.nonce)
+			if !errors.Is(err, ErrInvalidIDToken) {
+				t.Fatalf("expected ErrInvalidIDToken, got %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenRejectsHMACSignedToken(t *testing.T) {
+	fp := oidctest.NewProvider(t)
+	provider := discover(t, fp)
+
+	// "alg confusion": HS256 signed with something the attacker knows
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss":   fp.Issuer(),
+		"sub":   "admin",
+		"aud":   fp.ClientID,
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"nonce": "nonce",
+	})
+	token.Header["kid"] = fp.KeyID()
+	signed, _ := token.SignedString([]byte("public-key-bytes"))
+
+	if _, err := provider.VerifyIDToken(context.Background(), signed, "nonce"); !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("expected ErrInvalidIDToken, got %v", err)
+	}
+}
+
+func TestVerifyIDTokenAfterKeyRotation(t *testing.T) {
+	fp := oidctest.NewProvider(t)
+	provider := discover(t, fp)
+	provider.keys.minRefreshInterval = 0
+	ctx := context.Background()
+
+	if _, err := provider.VerifyIDToken(ctx, fp.IDToken("nonce"), "nonce"); err != nil {
+		t.Fatalf("verify before rotation: %v", err)
+	}
+
+	// new kid: the cached JWKS doesn't know it, it must be fetched again
+	fp.RotateKey()
+	if _, err := provider.VerifyIDToken(ctx, fp.IDToken("nonce"), "nonce"); err != nil {
+		t.Fatalf("verify after rotation: %v", err)
+	}
+}
+
+func TestDiscoverRejectsIssuerMismatch(t *testing.T) {
+	fp := oidctest.NewProvider(t)
+
+	mismatched := config(fp)
+	mismatched.Issuer = fp.Issuer() + "/"
+
+	if _, err := Discover(context.Background(), mismatched, nil); err == nil {
+		t.Fatal("expected an issuer mismatch error")
+	}
+}
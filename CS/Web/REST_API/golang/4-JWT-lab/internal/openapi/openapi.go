@@ -0,0 +1,62 @@
+// Package openapi is the OpenAPI 3.1 description of the API (openapi.json,
+// written by hand next to the router) and what goes with it: the /openapi.json
+// and /docs routes, and a middleware checking the traffic against it.
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+//go:embed openapi.json
+var spec []byte
+
+// Spec is the document, as served
+func Spec() []byte {
+	return spec
+}
+
+// Load parses and validates the document
+func Load() (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// `GET /openapi.json`
+func ServeSpec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(spec)
+}
+
+// `GET /docs`: Swagger UI (from a CDN) on /openapi.json
+func ServeDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(docsPage))
+}
+
+const docsPage = `<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>Movie API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
@@ -0,0 +1,91 @@
+package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSpecIsValid(t *testing.T) {
+	doc, err := Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !doc.IsOpenAPI31OrLater() {
+		t.Fatalf("openapi = %s, want 3.1", doc.OpenAPI)
+	}
+}
+
+func TestValidatorReports(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		status  int
+		respond string
+		want    string // in the reported error, "" = no report
+	}{
+		{
+			name: "matching", method: "GET", path: "/movies/8b0f6f3e-3a8c-4d8e-9a52-9d1c43d3c8e1",
+			status: 200, respond: `{"id":"8b0f6f3e-3a8c-4d8e-9a52-9d1c43d3c8e1","title":"Heat","director":"Michael Mann","year":1995}`,
+		},
+		{
+			name: "missing field", method: "GET", path: "/movies/8b0f6f3e-3a8c-4d8e-9a52-9d1c43d3c8e1",
+			status: 200, respond: `{"id":"8b0f6f3e-3a8c-4d8e-9a52-9d1c43d3c8e1","title":"Heat"}`,
+			want: "response",
+		},
+		{
+			name: "undocumented status", method: "GET", path: "/movies",
+			status: http.StatusTeapot, respond: `[]`,
+			want: "status is not supported",
+		},
+		{
+			name: "invalid request", method: "POST", path: "/reviews", body: `{"rating":11,"movie_id":"8b0f6f3e-3a8c-4d8e-9a52-9d1c43d3c8e1"}`,
+			status: 400, respond: "invalid body",
+			want: "request",
+		},
+		{
+			name: "unknown route", method: "GET", path: "/nope",
+			status: 404, respond: "404 page not found",
+			want: "not in the spec",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reported []error
+			validator, err := NewValidator(func(r *http.Request, err error) {
+				reported = append(reported, err)
+			})
+			if err != nil {
+				t.Fatalf("new validator: %v", err)
+			}
+
+			handler := validator.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasPrefix(tt.respond, "{") || strings.HasPrefix(tt.respond, "[") {
+					w.Header().Set("Content-Type", "application/json")
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.respond))
+			}))
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if tt.want == "" {
+				if len(reported) > 0 {
+					t.Fatalf("reported %v, want nothing", reported)
+				}
+				return
+			}
+			if len(reported) != 1 || !strings.Contains(reported[0].Error(), tt.want) {
+				t.Fatalf("reported %v, want one error with %q", reported, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package openapi
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+)
+
+func init() {
+	// the imports accept NDJSON: a string like any other file
+	openapi3filter.RegisterBodyDecoder("application/x-ndjson", openapi3filter.PlainBodyDecoder)
+	// the review stream (the events are not checked one by one)
+	openapi3filter.RegisterBodyDecoder("text/event-stream", openapi3filter.PlainBodyDecoder)
+}
+
+// Validator checks requests and responses against the document.
+// it's for the tests (every e2e request doubles as a spec check): it buffers
+// each response, and only reports, the requests are served either way.
+type Validator struct {
+	router routers.Router
+	report func(r *http.Request, err error)
+}
+
+// NewValidator calls report for every request or response that doesn't
+// match the document (undocumented route or status, wrong shape...)
+func NewValidator(report func(r *http.Request, err error)) (*Validator, error) {
+	doc, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Validator{router: router, report: report}, nil
+}
+
+func (v *Validator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, pathParams, err := v.router.FindRoute(r)
+		if err != nil {
+			v.report(r, fmt.Errorf("not in the spec: %w", err))
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		requestInput := &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				// authentication is the middlewares' job, a missing token is a
+				// documented 401, not a spec violation
+				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+			},
+		}
+		// (reads the body and puts it back)
+		if err := openapi3filter.ValidateRequest(r.Context(), requestInput); err != nil {
+			v.report(r, fmt.Errorf("request: %w", err))
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		// a WebSocket: no HTTP response past the upgrade
+		if recorder.hijacked {
+			return
+		}
+
+		// net/http sniffs the Content-Type of a body written without one,
+		// but that doesn't show in the handler's headers
+		header := recorder.Header()
+		if header.Get("Content-Type") == "" && recorder.body.Len() > 0 {
+			header = header.Clone()
+			header.Set("Content-Type", http.DetectContentType(recorder.body.Bytes()))
+		}
+
+		responseInput := &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: requestInput,
+			Status:                 recorder.status,
+			Header:                 header,
+			Body:                   io.NopCloser(&recorder.body),
+			Options:                &openapi3filter.Options{IncludeResponseStatus: true},
+		}
+		if err := openapi3filter.ValidateResponse(r.Context(), responseInput); err != nil {
+			v.report(r, fmt.Errorf("response: %w", err))
+		}
+	})
+}
+
+// -------- helpers
+
+// passes everything through, and keeps a copy of the status and body
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	hijacked    bool
+	body        bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if !rr.wroteHeader {
+		rr.status = status
+		rr.wroteHeader = true
+	}
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(data []byte) (int, error) {
+	rr.wroteHeader = true
+	rr.body.Write(data)
+	return rr.ResponseWriter.Write(data)
+}
+
+// the exports and the review streams flush as they go
+func (rr *responseRecorder) Flush() {
+	if flusher, ok := rr.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// the WebSocket upgrades take the connection over
+func (rr *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rr.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T can't be hijacked", rr.ResponseWriter)
+	}
+	rr.hijacked = true
+	return hijacker.Hijack()
+}
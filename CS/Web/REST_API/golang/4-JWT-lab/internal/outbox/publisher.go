@@ -0,0 +1,89 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/pubsub"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Publisher sends one event to the broker, and only returns nil once the
+// broker has it
+type Publisher interface {
+	Publish(ctx context.Context, event domain.OutboxEvent) error
+}
+
+// RabbitPublisher publishes on a topic exchange, the routing key is the event
+// type. the connection is (re)opened on demand: after a broker restart the
+// next cycle reconnects.
+type RabbitPublisher struct {
+	url      string
+	exchange string
+
+	mu   sync.Mutex
+	conn *amqp.Connection
+	ch   *amqp.Channel
+}
+
+func NewRabbitPublisher(url, exchange string) *RabbitPublisher {
+	return &RabbitPublisher{
+		url:      url,
+		exchange: exchange,
+	}
+}
+
+func (p *RabbitPublisher) Publish(ctx context.Context, event domain.OutboxEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ch, err := p.channel()
+	if err != nil {
+		return err
+	}
+
+	return pubsub.PublishJSON(ctx, ch, p.exchange, event.Type, event.ID.String(), event)
+}
+
+func (p *RabbitPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn == nil {
+		return nil
+	}
+	return p.conn.Close()
+}
+
+// the caller holds the lock
+func (p *RabbitPublisher) channel() (*amqp.Channel, error) {
+	if p.ch != nil && !p.ch.IsClosed() {
+		return p.ch, nil
+	}
+
+	if p.conn == nil || p.conn.IsClosed() {
+		conn, err := amqp.Dial(p.url)
+		if err != nil {
+			return nil, fmt.Errorf("could not connect to RabbitMQ: %v", err)
+		}
+		p.conn = conn
+	}
+
+	ch, err := p.conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("could not create channel: %v", err)
+	}
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, fmt.Errorf("could not enable publisher confirms: %v", err)
+	}
+	if err := pubsub.DeclareExchange(ch, p.exchange); err != nil {
+		ch.Close()
+		return nil, err
+	}
+
+	p.ch = ch
+	return ch, nil
+}
@@ -0,0 +1,162 @@
+// Package outbox publishes the domain events of the outbox table (written by
+// the repositories, in the transaction of their change) to the broker.
+//
+// delivery is at-least-once: an event is marked published after the broker's
+// confirm, a crash in between publishes it again. the consumers dedupe on the
+// message id (the event id).
+// the order is kept per aggregate: the events go out by sequence, and once an
+// event fails the next events of its aggregate wait for the next cycle.
+//
+// the sequence is taken at the insert, not at the commit: a transaction can
+// commit after one with a higher sequence. the relay has no high-water mark
+// (it reads every unpublished row, not "sequence > the last one"), so a late
+// event is published on the next cycle, never skipped. two events of one
+// aggregate still go out in commit order: the writes of a movie lock it
+// (its row, or its key for a review) before they insert their event, so the
+// second one waits for the first's commit. only unrelated concurrent events,
+// e.g. two reviews of a movie or a review and a title change, can go out in
+// either order.
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/repository"
+)
+
+// how many events are read from the outbox at a time
+const relayBatch = 100
+
+// how long the last cycle (on shutdown) can take
+const finalRelayTimeout = 10 * time.Second
+
+// Relay polls the outbox every interval and publishes the pending events.
+// with several replicas only one relays at a time (TryLockRelay).
+// without a publisher (no broker) it only prunes: the events nobody publishes
+// are deleted after the retention too, the outbox doesn't grow forever.
+type Relay struct {
+	outboxRepo repository.OutboxRepository
+	publisher  Publisher
+	interval   time.Duration
+	// published events are kept that long (replay, debugging), then pruned.
+	// 0 keeps them forever
+	retention time.Duration
+}
+
+// publisher nil: the events are not published, only pruned
+func NewRelay(repo repository.OutboxRepository, publisher Publisher, interval, retention time.Duration) *Relay {
+	return &Relay{
+		outboxRepo: repo,
+		publisher:  publisher,
+		interval:   interval,
+		retention:  retention,
+	}
+}
+
+// Run relays every interval until ctx is cancelled, then one last time so the
+// events of the last requests go out before the shutdown.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			relayCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), finalRelayTimeout)
+			if _, err := r.Relay(relayCtx); err != nil {
+				log.Printf("last outbox relay failed: %v", err)
+			}
+			cancel()
+			return
+		case <-ticker.C:
+			if _, err := r.Relay(ctx); err != nil {
+				log.Printf("outbox relay failed: %v", err)
+			}
+		}
+	}
+}
+
+// Relay publishes the pending events and returns how many went out, then
+// prunes. it's a no-op while another replica holds the relay lock.
+func (r *Relay) Relay(ctx context.Context) (int, error) {
+	release, ok, err := r.outboxRepo.TryLockRelay(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("could not lock the relay: %w", err)
+	}
+	if !ok {
+		return 0, nil
+	}
+	defer release()
+
+	published := 0
+	if r.publisher != nil {
+		if published, err = r.publish(ctx); err != nil {
+			return published, err
+		}
+	}
+
+	if r.retention > 0 {
+		before := time.Now().Add(-r.retention)
+		if _, err := r.outboxRepo.DeletePublishedEvents(ctx, before); err != nil {
+			return published, err
+		}
+		if r.publisher == nil {
+			deleted, err := r.outboxRepo.DeleteUnpublishedEvents(ctx, before)
+			if err != nil {
+				return published, err
+			}
+			if deleted > 0 {
+				log.Printf("pruned %d unpublished events from the outbox (no broker)", deleted)
+			}
+		}
+	}
+
+	return published, nil
+}
+
+// the caller holds the relay lock
+func (r *Relay) publish(ctx context.Context) (int, error) {
+	published := 0
+	for {
+		events, err := r.outboxRepo.GetUnpublishedEvents(ctx, relayBatch)
+		if err != nil {
+			return published, err
+		}
+
+		// the aggregates with a failed event: their next events wait
+		blocked := map[uuid.UUID]bool{}
+		var publishErr error
+		for _, event := range events {
+			if blocked[event.AggregateID] {
+				continue
+			}
+
+			if err := r.publisher.Publish(ctx, event); err != nil {
+				blocked[event.AggregateID] = true
+				publishErr = err
+				if err := r.outboxRepo.MarkEventFailed(ctx, event.ID, err.Error()); err != nil {
+					return published, err
+				}
+				continue
+			}
+
+			// the DB is down: the event goes out again next time (at-least-once)
+			if err := r.outboxRepo.MarkEventPublished(ctx, event.ID); err != nil {
+				return published, err
+			}
+			published++
+		}
+
+		if publishErr != nil {
+			// stop here: the broker is probably down, the next tick tries again
+			return published, fmt.Errorf("could not publish the events of %d aggregates: %w", len(blocked), publishErr)
+		}
+		if len(events) < relayBatch {
+			return published, nil
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/outbox"
+	"github.com/grainme/movie-api/internal/repository/memory"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// records what was published, fails the events of the failing aggregates
+type fakePublisher struct {
+	published []domain.OutboxEvent
+	failing   map[uuid.UUID]bool
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, event domain.OutboxEvent) error {
+	if p.failing[event.AggregateID] {
+		return errors.New("broker down")
+	}
+	p.published = append(p.published, event)
+	return nil
+}
+
+type fixture struct {
+	movies    *memory.MemoryMovieRepository
+	reviews   *memory.MemoryReviewRepository
+	outbox    *memory.MemoryOutboxRepository
+	publisher *fakePublisher
+	relay     *outbox.Relay
+}
+
+func newFixture() *fixture {
+	store := memory.NewStore()
+	f := &fixture{
+		movies:    memory.NewMemoryMovieRepository(store),
+		reviews:   memory.NewMemoryReviewRepository(store),
+		outbox:    memory.NewMemoryOutboxRepository(store),
+		publisher: &fakePublisher{failing: map[uuid.UUID]bool{}},
+	}
+	f.relay = outbox.NewRelay(f.outbox, f.publisher, time.Minute, time.Hour)
+	return f
+}
+
+func (f *fixture) addMovie(t *testing.T, title string) *domain.Movie {
+	t.Helper()
+	movie, err := f.movies.AddMovie(context.Background(), &domain.Movie{Title: title})
+	if err != nil {
+		t.Fatalf("add movie: %v", err)
+	}
+	return movie
+}
+
+func (f *fixture) addReview(t *testing.T, movieId uuid.UUID) {
+	t.Helper()
+	if _, err := f.reviews.AddReview(context.Background(), &domain.Review{Username: "ripley", Rating: 8, MovieID: movieId}); err != nil {
+		t.Fatalf("add review: %v", err)
+	}
+}
+
+func TestRelayPublishesOnce(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture()
+
+	movie := f.addMovie(t, "Alien")
+	f.addReview(t, movie.ID)
+
+	published, err := f.relay.Relay(ctx)
+	if err != nil || published != 2 {
+		t.Fatalf("relay = %d (%v)", published, err)
+	}
+	if f.publisher.published[0].Type != domain.EventMovieCreated || f.publisher.published[1].Type != domain.EventReviewCreated {
+		t.Fatalf("published = %+v", f.publisher.published)
+	}
+
+	// nothing left
+	published, err = f.relay.Relay(ctx)
+	if err != nil || published != 0 || len(f.publisher.published) != 2 {
+		t.Fatalf("second relay = %d (%v), published %d events", published, err, len(f.publisher.published))
+	}
+}
+
+func TestRelayKeepsTheOrderPerAggregate(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture()
+
+	blocked := f.addMovie(t, "Alien")
+	other := f.addMovie(t, "Heat")
+	f.addReview(t, blocked.ID)
+	f.addReview(t, other.ID)
+
+	// one aggregate can't go out: the other one does
+	f.publisher.failing[blocked.ID] = true
+	published, err := f.relay.Relay(ctx)
+	if err == nil || published != 2 {
+		t.Fatalf("relay = %d (%v), want 2 and an error", published, err)
+	}
+	for _, event := range f.publisher.published {
+		if event.AggregateID != other.ID {
+			t.Fatalf("published an event of the failing aggregate: %+v", event)
+		}
+	}
+
+	// only the first event of the blocked aggregate was tried
+	pending, _ := f.outbox.GetUnpublishedEvents(ctx, 10)
+	if len(pending) != 2 || pending[0].Attempts != 1 || pending[0].LastError != "broker down" || pending[1].Attempts != 0 {
+		t.Fatalf("pending = %+v", pending)
+	}
+
+	// back up: the blocked events go out, in their order
+	f.publisher.failing = map[uuid.UUID]bool{}
+	published, err = f.relay.Relay(ctx)
+	if err != nil || published != 2 {
+		t.Fatalf("relay after recovery = %d (%v)", published, err)
+	}
+	last := f.publisher.published[2:]
+	if last[0].Type != domain.EventMovieCreated || last[1].Type != domain.EventReviewCreated || last[1].AggregateID != blocked.ID {
+		t.Fatalf("published after recovery = %+v", last)
+	}
+}
+
+func TestRelayPrunesPublishedEvents(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture()
+	// no retention: every published event is pruned right away
+	relay := outbox.NewRelay(f.outbox, f.publisher, time.Minute, time.Nanosecond)
+
+	f.addMovie(t, "Alien")
+	if _, err := relay.Relay(ctx); err != nil {
+		t.Fatalf("relay: %v", err)
+	}
+	if deleted, _ := f.outbox.DeletePublishedEvents(ctx, time.Now().Add(time.Hour)); deleted != 0 {
+		t.Fatalf("%d published events left after the relay", deleted)
+	}
+}
+
+func TestRelayWithoutPublisherPrunes(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture()
+	f.addMovie(t, "Alien")
+
+	// kept during the retention
+	relay := outbox.NewRelay(f.outbox, nil, time.Minute, time.Hour)
+	if published, err := relay.Relay(ctx); err != nil || published != 0 {
+		t.Fatalf("relay = %d (%v)", published, err)
+	}
+	if pending, _ := f.outbox.GetUnpublishedEvents(ctx, 10); len(pending) != 1 {
+		t.Fatalf("pending = %+v, want the event kept", pending)
+	}
+
+	// then dropped, never published
+	relay = outbox.NewRelay(f.outbox, nil, time.Minute, time.Nanosecond)
+	if _, err := relay.Relay(ctx); err != nil {
+		t.Fatalf("relay: %v", err)
+	}
+	if pending, _ := f.outbox.GetUnpublishedEvents(ctx, 10); len(pending) != 0 {
+		t.Fatalf("pending = %+v, want it pruned", pending)
+	}
+}
+
+// a lock held elsewhere: the relay does nothing
+type lockedOutbox struct {
+	*memory.MemoryOutboxRepository
+}
+
+func (lockedOutbox) TryLockRelay(ctx context.Context) (func(), bool, error) {
+	return nil, false, nil
+}
+
+func TestRelaySkipsWithoutTheLock(t *testing.T) {
+	f := newFixture()
+	f.addMovie(t, "Alien")
+
+	relay := outbox.NewRelay(lockedOutbox{f.outbox}, f.publisher, time.Minute, time.Hour)
+	published, err := relay.Relay(context.Background())
+	if err != nil || published != 0 || len(f.publisher.published) != 0 {
+		t.Fatalf("relay = %d (%v)", published, err)
+	}
+}
@@ -0,0 +1,57 @@
+// Package pubsub is the RabbitMQ side of the domain events: same helpers as
+// the rabbit project's pubsub package, plus what the outbox relay needs
+// (persistent messages, a message id, the broker's confirm).
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+var ErrNotConfirmed = errors.New("the broker refused the message (nack)")
+
+// DeclareExchange declares the durable topic exchange of the events: the
+// consumers bind their own queues on the routing keys (the event types)
+func DeclareExchange(ch *amqp.Channel, exchange string) error {
+	err := ch.ExchangeDeclare(exchange, amqp.ExchangeTopic, true, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("could not declare exchange: %v", err)
+	}
+	return nil
+}
+
+// PublishJSON publishes val as JSON and waits for the broker's confirm: ch
+// must be in confirm mode (ch.Confirm). the message is persistent, messageId
+// is the key the consumers dedupe on.
+func PublishJSON[T any](ctx context.Context, ch *amqp.Channel, exchange, key, messageId string, val T) error {
+	body, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+
+	confirmation, err := ch.PublishWithDeferredConfirmWithContext(ctx, exchange, key, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    messageId,
+		Type:         key,
+		Timestamp:    time.Now().UTC(),
+		Body:         body,
+	})
+	if err != nil {
+		return err
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return ErrNotConfirmed
+	}
+	return nil
+}
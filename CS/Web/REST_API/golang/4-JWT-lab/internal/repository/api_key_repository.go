@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+type APIKeyRepository interface {
+	AddAPIKey(ctx context.Context, key domain.APIKey, keyHash string) (domain.APIKey, error)
+	// also fills OwnerRole, the key acts with (at most) its owner's permissions
+	FindAPIKeyByHash(ctx context.Context, keyHash string) (domain.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) error
+	TouchAPIKey(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+// append-only on purpose: no update, no delete
+type AuditRepository interface {
+	AddAuditEvent(ctx context.Context, event domain.AuditEvent) error
+	// newest first
+	ListAuditEvents(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEvent, error)
+}
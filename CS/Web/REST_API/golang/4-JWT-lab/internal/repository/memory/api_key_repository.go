@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+type MemoryAPIKeyRepository struct {
+	store *Store
+}
+
+func NewMemoryAPIKeyRepository(store *Store) *MemoryAPIKeyRepository {
+	return &MemoryAPIKeyRepository{
+		store: store,
+	}
+}
+
+func (r *MemoryAPIKeyRepository) AddAPIKey(ctx context.Context, key domain.APIKey, keyHash string) (domain.APIKey, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// api_keys.user_id REFERENCES users, key_hash UNIQUE
+	if _, ok := r.store.users[key.UserID]; !ok {
+		return domain.APIKey{}, ErrForeignKeyViolation
+	}
+	for _, row := range r.store.apiKeys {
+		if row.keyHash == keyHash {
+			return domain.APIKey{}, ErrUniqueViolation
+		}
+	}
+
+	insertedKey := domain.APIKey{
+		ID:        uuid.New(),
+		UserID:    key.UserID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Scopes:    slices.Clone(key.Scopes),
+		CreatedAt: now(),
+		ExpiresAt: copyTime(key.ExpiresAt),
+	}
+	if insertedKey.Scopes == nil {
+		insertedKey.Scopes = []domain.Permission{}
+	}
+	r.store.apiKeys[insertedKey.ID] = &apiKeyRow{key: insertedKey, keyHash: keyHash}
+
+	return copyAPIKey(insertedKey), nil
+}
+
+func (r *MemoryAPIKeyRepository) FindAPIKeyByHash(ctx context.Context, keyHash string) (domain.APIKey, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, row := range r.store.apiKeys {
+		if row.keyHash != keyHash {
+			continue
+		}
+
+		key := copyAPIKey(row.key)
+		if owner, ok := r.store.users[key.UserID]; ok {
+			key.OwnerRole = owner.Role
+		}
+		return key, nil
+	}
+
+	return domain.APIKey{}, domain.ErrAPIKeyNotFound
+}
+
+// newest first
+func (r *MemoryAPIKeyRepository) ListAPIKeys(ctx context.Context) ([]domain.APIKey, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := sortedValues(r.store.apiKeys, func(a, b *apiKeyRow) int {
+		return b.key.CreatedAt.Compare(a.key.CreatedAt)
+	})
+
+	keys := make([]domain.APIKey, len(rows))
+	for idx, row := range rows {
+		keys[idx] = copyAPIKey(row.key)
+	}
+	return keys, nil
+}
+
+func (r *MemoryAPIKeyRepository) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	row, ok := r.store.apiKeys[id]
+	// unknown or already revoked
+	if !ok || row.key.RevokedAt != nil {
+		return domain.ErrAPIKeyNotFound
+	}
+
+	revokedAt := now()
+	row.key.RevokedAt = &revokedAt
+	return nil
+}
+
+func (r *MemoryAPIKeyRepository) TouchAPIKey(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if row, ok := r.store.apiKeys[id]; ok {
+		row.key.LastUsedAt = &usedAt
+	}
+
+	return nil
+}
+
+// -------- helpers
+func copyAPIKey(key domain.APIKey) domain.APIKey {
+	key.Scopes = slices.Clone(key.Scopes)
+	key.ExpiresAt = copyTime(key.ExpiresAt)
+	key.LastUsedAt = copyTime(key.LastUsedAt)
+	key.RevokedAt = copyTime(key.RevokedAt)
+	return key
+}
@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+type MemoryAuditRepository struct {
+	store *Store
+}
+
+func NewMemoryAuditRepository(store *Store) *MemoryAuditRepository {
+	return &MemoryAuditRepository{
+		store: store,
+	}
+}
+
+func (r *MemoryAuditRepository) AddAuditEvent(ctx context.Context, event domain.AuditEvent) error {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+
+	// the CHECK of the outcome column
+	switch event.Outcome {
+	case domain.AuditSuccess, domain.AuditFailure, domain.AuditDenied:
+	default:
+		return ErrCheckViolation
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, existing := range r.store.auditEvents {
+		if existing.ID == event.ID {
+			return ErrUniqueViolation
+		}
+	}
+
+	r.store.auditEvents = append(r.store.auditEvents, copyAuditEvent(event))
+	return nil
+}
+
+// same filters as the SQL query: Since included, Until excluded.
+// like its LIMIT, Limit is required (0 returns nothing), the AuditLogger sets it
+func (r *MemoryAuditRepository) ListAuditEvents(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEvent, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	events := []domain.AuditEvent{}
+	for _, event := range r.store.auditEvents {
+		if matchesAuditFilter(event, filter) {
+			events = append(events, event)
+		}
+	}
+
+	slices.SortFunc(events, func(a, b domain.AuditEvent) int {
+		return cmp.Or(b.OccurredAt.Compare(a.OccurredAt), compareIDs(b.ID, a.ID))
+	})
+
+	if filter.Limit <= 0 {
+		return []domain.AuditEvent{}, nil
+	}
+	events = paginate(events, domain.Page{Limit: filter.Limit, Offset: filter.Offset})
+
+	eventsList := make([]domain.AuditEvent, len(events))
+	for idx, event := range events {
+		eventsList[idx] = copyAuditEvent(event)
+	}
+	return eventsList, nil
+}
+
+// -------- helpers
+func matchesAuditFilter(event domain.AuditEvent, filter domain.AuditFilter) bool {
+	switch {
+	case filter.ActorID != nil && (event.ActorID == nil || *event.ActorID != *filter.ActorID):
+		return false
+	case filter.Action != "" && event.Action != filter.Action:
+		return false
+	case filter.Outcome != "" && event.Outcome != filter.Outcome:
+		return false
+	case filter.TargetType != "" && event.TargetType != filter.TargetType:
+		return false
+	case filter.TargetID != "" && event.TargetID != filter.TargetID:
+		return false
+	case filter.Since != nil && event.OccurredAt.Before(*filter.Since):
+		return false
+	case filter.Until != nil && !event.OccurredAt.Before(*filter.Until):
+		return false
+	}
+	return true
+}
+
+func copyAuditEvent(event domain.AuditEvent) domain.AuditEvent {
+	event.ActorID = copyID(event.ActorID)
+	event.APIKeyID = copyID(event.APIKeyID)
+	return event
+}
@@ -0,0 +1,22 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/grainme/movie-api/internal/repository/repotest"
+)
+
+func TestConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repositories {
+		store := NewStore()
+		return repotest.Repositories{
+			Movies:   NewMemoryMovieRepository(store),
+			Reviews:  NewMemoryReviewRepository(store),
+			Users:    NewMemoryUserRepository(store),
+			APIKeys:  NewMemoryAPIKeyRepository(store),
+			Audit:    NewMemoryAuditRepository(store),
+			Outbox:   NewMemoryOutboxRepository(store),
+			Webhooks: NewMemoryWebhookRepository(store),
+		}
+	})
+}
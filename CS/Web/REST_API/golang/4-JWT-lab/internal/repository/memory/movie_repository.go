@@ -0,0 +1,225 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+type MemoryMovieRepository struct {
+	store *Store
+}
+
+func NewMemoryMovieRepository(store *Store) *MemoryMovieRepository {
+	return &MemoryMovieRepository{
+		store: store,
+	}
+}
+
+func (r *MemoryMovieRepository) GetAllMovies(ctx context.Context, page domain.Page) ([]*domain.Movie, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	movies := sortedValues(r.store.movies, func(a, b *domain.Movie) int {
+		return cmp.Or(compareTitles(a.Title, b.Title), compareIDs(a.ID, b.ID))
+	})
+
+	return copyMovies(paginate(movies, page)), nil
+}
+
+func (r *MemoryMovieRepository) GetMovieById(ctx context.Context, id uuid.UUID) (*domain.Movie, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	movie, ok := r.store.movies[id]
+	if !ok {
+		return nil, domain.ErrMovieNotFound
+	}
+
+	return copyMovie(movie), nil
+}
+
+// the movie gets a new ID, like the Postgres version
+func (r *MemoryMovieRepository) AddMovie(ctx context.Context, movie *domain.Movie) (*domain.Movie, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	insertedMovie := newMovieRow(movie)
+	insertedMovie.ID = uuid.New()
+	r.store.movies[insertedMovie.ID] = insertedMovie
+	r.store.addOutboxEvent(domain.NewMovieCreatedEvent(insertedMovie))
+
+	return copyMovie(insertedMovie), nil
+}
+
+func (r *MemoryMovieRepository) UpdateMovieTitleById(ctx context.Context, id uuid.UUID, title string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// an UPDATE that matches nothing is not an error (and no event)
+	if movie, ok := r.store.movies[id]; ok {
+		movie.Title = title
+		r.store.addOutboxEvent(domain.NewMovieUpdatedEvent(movie))
+	}
+
+	return nil
+}
+
+func (r *MemoryMovieRepository) DeleteMovieById(ctx context.Context, id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// reviews.movie_id has no ON DELETE CASCADE: a reviewed movie can't be deleted
+	for _, review := range r.store.reviews {
+		if review.MovieID == id {
+			return ErrForeignKeyViolation
+		}
+	}
+
+	// the event only when a movie was actually deleted
+	if _, ok := r.store.movies[id]; ok {
+		delete(r.store.movies, id)
+		r.store.addOutboxEvent(domain.NewMovieDeletedEvent(id))
+	}
+	return nil
+}
+
+func (r *MemoryMovieRepository) GetMovieWithReviews(ctx context.Context, id uuid.UUID) (*domain.Movie, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	movie, ok := r.store.movies[id]
+	if !ok {
+		return nil, domain.ErrMovieNotFound
+	}
+
+	return r.withReviewStats(movie), nil
+}
+
+func (r *MemoryMovieRepository) GetMoviesWithReviewsByIds(ctx context.Context, ids []uuid.UUID) ([]*domain.Movie, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	movies := []*domain.Movie{}
+	for _, id := range ids {
+		if movie, ok := r.store.movies[id]; ok && !slices.ContainsFunc(movies, func(m *domain.Movie) bool { return m.ID == id }) {
+			movies = append(movies, r.withReviewStats(movie))
+		}
+	}
+	slices.SortFunc(movies, func(a, b *domain.Movie) int { return compareIDs(a.ID, b.ID) })
+
+	return movies, nil
+}
+
+func (r *MemoryMovieRepository) AddMovieViews(ctx context.Context, id uuid.UUID, views int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if movie, ok := r.store.movies[id]; ok {
+		movie.Views += views
+	}
+
+	return nil
+}
+
+func (r *MemoryMovieRepository) GetPopularMovies(ctx context.Context, page domain.Page) ([]*domain.Movie, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	movies := sortedValues(r.store.movies, func(a, b *domain.Movie) int {
+		return cmp.Or(cmp.Compare(b.Views, a.Views), compareIDs(a.ID, b.ID))
+	})
+
+	return copyMovies(paginate(movies, page)), nil
+}
+
+func (r *MemoryMovieRepository) AddMovies(ctx context.Context, movies []*domain.Movie) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	inserted := 0
+	for _, movie := range movies {
+		// ON CONFLICT (id) DO NOTHING
+		if _, ok := r.store.movies[movie.ID]; ok {
+			continue
+		}
+
+		insertedMovie := newMovieRow(movie)
+		r.store.movies[movie.ID] = insertedMovie
+		r.store.addOutboxEvent(domain.NewMovieCreatedEvent(insertedMovie))
+		inserted++
+	}
+
+	return inserted, nil
+}
+
+func (r *MemoryMovieRepository) GetExistingMovieIds(ctx context.Context, ids []uuid.UUID) ([]uuid.UUID, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return existingIds(r.store.movies, ids), nil
+}
+
+func (r *MemoryMovieRepository) GetMoviesAfter(ctx context.Context, after uuid.UUID, limit int) ([]*domain.Movie, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	movies := sortedValues(r.store.movies, func(a, b *domain.Movie) int {
+		return compareIDs(a.ID, b.ID)
+	})
+
+	page := make([]*domain.Movie, 0, limit)
+	for _, movie := range movies {
+		if len(page) == limit {
+			break
+		}
+		if compareIDs(movie.ID, after) > 0 {
+			page = append(page, copyMovie(movie))
+		}
+	}
+
+	return page, nil
+}
+
+// -------- helpers
+
+// only the columns of the movies table: the views start at 0 and the
+// aggregates (rating, reviews count) are computed from the reviews
+func newMovieRow(movie *domain.Movie) *domain.Movie {
+	return &domain.Movie{
+		ID:       movie.ID,
+		Title:    movie.Title,
+		Director: movie.Director,
+		Year:     movie.Year,
+	}
+}
+
+func copyMovies(movies []*domain.Movie) []*domain.Movie {
+	moviesList := make([]*domain.Movie, len(movies))
+	for idx, movie := range movies {
+		moviesList[idx] = copyMovie(movie)
+	}
+	return moviesList
+}
+
+// a copy of the movie with its average rating and reviews count.
+// the caller holds the lock
+func (r *MemoryMovieRepository) withReviewStats(movie *domain.Movie) *domain.Movie {
+	var ratingsSum int64
+	movieCopy := copyMovie(movie)
+	for _, review := range r.store.reviews {
+		if review.MovieID == movie.ID {
+			ratingsSum += int64(review.Rating)
+			movieCopy.ReviewsCount++
+		}
+	}
+	// no reviews: 0, like COALESCE(AVG(...), 0)
+	if movieCopy.ReviewsCount > 0 {
+		movieCopy.AverageRating = float64(ratingsSum) / float64(movieCopy.ReviewsCount)
+	}
+
+	return movieCopy
+}
@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+type MemoryOutboxRepository struct {
+	store *Store
+}
+
+func NewMemoryOutboxRepository(store *Store) *MemoryOutboxRepository {
+	return &MemoryOutboxRepository{store: store}
+}
+
+func (r *MemoryOutboxRepository) GetUnpublishedEvents(ctx context.Context, limit int) ([]domain.OutboxEvent, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	// the rows are appended: already in sequence order
+	events := []domain.OutboxEvent{}
+	for _, row := range r.store.outbox {
+		if len(events) == limit {
+			break
+		}
+		if row.publishedAt == nil {
+			events = append(events, row.event)
+		}
+	}
+
+	return events, nil
+}
+
+func (r *MemoryOutboxRepository) MarkEventPublished(ctx context.Context, id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if row := r.findEvent(id); row != nil {
+		publishedAt := now()
+		row.publishedAt = &publishedAt
+	}
+	return nil
+}
+
+func (r *MemoryOutboxRepository) MarkEventFailed(ctx context.Context, id uuid.UUID, reason string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if row := r.findEvent(id); row != nil {
+		row.event.Attempts++
+		row.event.LastError = reason
+	}
+	return nil
+}
+
+func (r *MemoryOutboxRepository) DeletePublishedEvents(ctx context.Context, before time.Time) (int, error) {
+	return r.deleteEvents(func(row *outboxRow) bool {
+		return row.publishedAt != nil && row.publishedAt.Before(before)
+	}), nil
+}
+
+func (r *MemoryOutboxRepository) DeleteUnpublishedEvents(ctx context.Context, before time.Time) (int, error) {
+	return r.deleteEvents(func(row *outboxRow) bool {
+		return row.publishedAt == nil && row.event.OccurredAt.Before(before)
+	}), nil
+}
+
+func (r *MemoryOutboxRepository) deleteEvents(deleteRow func(row *outboxRow) bool) int {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	kept := r.store.outbox[:0]
+	for _, row := range r.store.outbox {
+		if !deleteRow(row) {
+			kept = append(kept, row)
+		}
+	}
+	deleted := len(r.store.outbox) - len(kept)
+	r.store.outbox = kept
+
+	return deleted
+}
+
+// one process, one store: the relay is alone
+func (r *MemoryOutboxRepository) TryLockRelay(ctx context.Context) (func(), bool, error) {
+	return func() {}, true, nil
+}
+
+// the caller holds the lock
+func (r *MemoryOutboxRepository) findEvent(id uuid.UUID) *outboxRow {
+	for _, row := range r.store.outbox {
+		if row.event.ID == id {
+			return row
+		}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+type MemoryReviewRepository struct {
+	store *Store
+}
+
+func NewMemoryReviewRepository(store *Store) *MemoryReviewRepository {
+	return &MemoryReviewRepository{
+		store: store,
+	}
+}
+
+func (r *MemoryReviewRepository) AddReview(ctx context.Context, review *domain.Review) (domain.Review, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if err := r.checkReview(review); err != nil {
+		return domain.Review{}, err
+	}
+
+	insertedReview := copyReview(review)
+	insertedReview.ID = uuid.New()
+	r.store.reviews[insertedReview.ID] = &insertedReview
+	r.store.addOutboxEvent(domain.NewReviewCreatedEvent(copyReview(&insertedReview)))
+
+	return copyReview(&insertedReview), nil
+}
+
+// ordered by id (the SQL query doesn't say, any order is fine for the callers)
+func (r *MemoryReviewRepository) GetAllReviews(ctx context.Context) ([]domain.Review, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return copyReviews(r.sortedReviews()), nil
+}
+
+func (r *MemoryReviewRepository) GetAllReviewsByMovieId(ctx context.Context, movieId uuid.UUID, page domain.Page) ([]domain.Review, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	reviews := []*domain.Review{}
+	for _, review := range r.sortedReviews() {
+		if review.MovieID == movieId {
+			reviews = append(reviews, review)
+		}
+	}
+
+	return copyReviews(paginate(reviews, page)), nil
+}
+
+func (r *MemoryReviewRepository) GetReviewsByMovieIds(ctx context.Context, movieIds []uuid.UUID, page domain.Page) ([]domain.Review, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return firstPages(r.sortedReviews(), page, func(review *domain.Review) (uuid.UUID, bool) {
+		return review.MovieID, slices.Contains(movieIds, review.MovieID)
+	}, compareIDs), nil
+}
+
+func (r *MemoryReviewRepository) GetReviewsByUsernames(ctx context.Context, usernames []string, page domain.Page) ([]domain.Review, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return firstPages(r.sortedReviews(), page, func(review *domain.Review) (string, bool) {
+		return review.Username, slices.Contains(usernames, review.Username)
+	}, strings.Compare), nil
+}
+
+// one statement in Postgres: a bad review fails the whole batch, nothing is inserted
+func (r *MemoryReviewRepository) AddReviews(ctx context.Context, reviews []domain.Review) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for idx := range reviews {
+		if err := r.checkReview(&reviews[idx]); err != nil {
+			return 0, err
+		}
+	}
+
+	inserted := 0
+	for idx := range reviews {
+		// ON CONFLICT (id) DO NOTHING
+		if _, ok := r.store.reviews[reviews[idx].ID]; ok {
+			continue
+		}
+
+		insertedReview := copyReview(&reviews[idx])
+		r.store.reviews[insertedReview.ID] = &insertedReview
+		r.store.addOutboxEvent(domain.NewReviewCreatedEvent(copyReview(&insertedReview)))
+		inserted++
+	}
+
+	return inserted, nil
+}
+
+func (r *MemoryReviewRepository) GetExistingReviewIds(ctx context.Context, ids []uuid.UUID) ([]uuid.UUID, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return existingIds(r.store.reviews, ids), nil
+}
+
+func (r *MemoryReviewRepository) GetReviewsAfter(ctx context.Context, after uuid.UUID, limit int) ([]domain.Review, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	page := make([]domain.Review, 0, limit)
+	for _, review := range r.sortedReviews() {
+		if len(page) == limit {
+			break
+		}
+		if compareIDs(review.ID, after) > 0 {
+			page = append(page, copyReview(review))
+		}
+	}
+
+	return page, nil
+}
+
+// -------- helpers
+
+// the constraints of the reviews table: rating CHECK and movie_id REFERENCES movies.
+// the caller holds the lock
+func (r *MemoryReviewRepository) checkReview(review *domain.Review) error {
+	if review.Rating < 1 || review.Rating > 10 {
+		return ErrCheckViolation
+	}
+	if _, ok := r.store.movies[review.MovieID]; !ok {
+		return ErrForeignKeyViolation
+	}
+	return nil
+}
+
+// the caller holds the lock
+func (r *MemoryReviewRepository) sortedReviews() []*domain.Review {
+	return sortedValues(r.store.reviews, func(a, b *domain.Review) int {
+		return compareIDs(a.ID, b.ID)
+	})
+}
+
+func copyReviews(reviews []*domain.Review) []domain.Review {
+	reviewsList := make([]domain.Review, len(reviews))
+	for idx, review := range reviews {
+		reviewsList[idx] = copyReview(review)
+	}
+	return reviewsList
+}
+
+// the reviews (sorted by id) grouped by key, the page applied to every group:
+// ROW_NUMBER() OVER (PARTITION BY key ORDER BY id). key says whether the
+// review is wanted at all
+func firstPages[K comparable](reviews []*domain.Review, page domain.Page, key func(*domain.Review) (K, bool), compareKeys func(a, b K) int) []domain.Review {
+	groups := map[K][]*domain.Review{}
+	keys := []K{}
+	for _, review := range reviews {
+		k, ok := key(review)
+		if !ok {
+			continue
+		}
+		if _, seen := groups[k]; !seen {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], review)
+	}
+	slices.SortFunc(keys, compareKeys)
+
+	reviewsList := []domain.Review{}
+	for _, k := range keys {
+		reviewsList = append(reviewsList, copyReviews(paginate(groups[k], page))...)
+	}
+	return reviewsList
+}
@@ -0,0 +1,190 @@
+/*
+ * Concrete implementation for in-memory storage
+ */
+package memory
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+// what Postgres would refuse (constraints of the migrations), the in-memory
+// repositories refuse it too, with these errors
+var (
+	ErrUniqueViolation     = errors.New("duplicate key value violates unique constraint")
+	ErrForeignKeyViolation = errors.New("violates foreign key constraint")
+	ErrCheckViolation      = errors.New("violates check constraint")
+)
+
+// Store is the in-memory database: every table behind one lock.
+// the repositories built on the same Store see each other's rows, like
+// the Postgres ones sharing a *sql.DB (a review needs its movie, an api key its owner...)
+type Store struct {
+	mu            sync.RWMutex
+	movies        map[uuid.UUID]*domain.Movie
+	reviews       map[uuid.UUID]*domain.Review
+	users         map[uuid.UUID]*domain.User
+	identities    map[identityKey]domain.UserIdentity
+	recoveryCodes map[uuid.UUID][]*recoveryCode
+	apiKeys       map[uuid.UUID]*apiKeyRow
+	auditEvents   []domain.AuditEvent
+	webhooks      map[uuid.UUID]*domain.Webhook
+	deliveries    map[uuid.UUID]*domain.WebhookDelivery
+	outbox        []*outboxRow
+	// the last outbox sequence (BIGSERIAL)
+	outboxSequence int64
+}
+
+type identityKey struct {
+	provider string
+	subject  string
+}
+
+type recoveryCode struct {
+	codeHash string
+	used     bool
+}
+
+type outboxRow struct {
+	event       domain.OutboxEvent
+	publishedAt *time.Time
+}
+
+type apiKeyRow struct {
+	key     domain.APIKey
+	keyHash string
+}
+
+func NewStore() *Store {
+	return &Store{
+		movies:        map[uuid.UUID]*domain.Movie{},
+		reviews:       map[uuid.UUID]*domain.Review{},
+		users:         map[uuid.UUID]*domain.User{},
+		identities:    map[identityKey]domain.UserIdentity{},
+		recoveryCodes: map[uuid.UUID][]*recoveryCode{},
+		apiKeys:       map[uuid.UUID]*apiKeyRow{},
+		webhooks:      map[uuid.UUID]*domain.Webhook{},
+		deliveries:    map[uuid.UUID]*domain.WebhookDelivery{},
+	}
+}
+
+// -------- helpers
+
+// the INSERT INTO outbox of a write: the caller holds the write lock, the
+// event is stored with its change (the same "transaction"), and so are its
+// webhook deliveries
+func (s *Store) addOutboxEvent(event domain.OutboxEvent) {
+	s.outboxSequence++
+	event.Sequence = s.outboxSequence
+	s.outbox = append(s.outbox, &outboxRow{event: event})
+
+	webhooks := []domain.Webhook{}
+	for _, webhook := range s.webhooks {
+		if slices.Contains(webhook.Events, event.Type) {
+			webhooks = append(webhooks, *webhook)
+		}
+	}
+	createdAt := now()
+	for _, delivery := range domain.WebhookDeliveries(event, webhooks) {
+		delivery.CreatedAt = createdAt
+		s.deliveries[delivery.ID] = &delivery
+	}
+}
+
+// same order as Postgres sorts uuid columns (byte by byte)
+func compareIDs(a, b uuid.UUID) int {
+	return bytes.Compare(a[:], b[:])
+}
+
+// lower(title) with ASCII folding, bytewise: the order of the SQL backends
+// (strings.ToLower would fold the other letters too, SQLite doesn't)
+func compareTitles(a, b string) int {
+	return strings.Compare(asciiLower(a), asciiLower(b))
+}
+
+func asciiLower(s string) string {
+	return strings.Map(func(r rune) rune {
+		if 'A' <= r && r <= 'Z' {
+			return r + 'a' - 'A'
+		}
+		return r
+	}, s)
+}
+
+// the ones of ids that are keys of rows, ordered, without duplicates
+func existingIds[T any](rows map[uuid.UUID]*T, ids []uuid.UUID) []uuid.UUID {
+	found := []uuid.UUID{}
+	for _, id := range ids {
+		if _, ok := rows[id]; ok {
+			found = append(found, id)
+		}
+	}
+	slices.SortFunc(found, compareIDs)
+	return slices.Compact(found)
+}
+
+// the values of a map, sorted
+func sortedValues[T any](rows map[uuid.UUID]*T, cmp func(a, b *T) int) []*T {
+	values := make([]*T, 0, len(rows))
+	for _, row := range rows {
+		values = append(values, row)
+	}
+	slices.SortFunc(values, cmp)
+	return values
+}
+
+// LIMIT/OFFSET: Limit == 0 means no limit (see domain.Page)
+func paginate[T any](rows []T, page domain.Page) []T {
+	if page.Offset >= len(rows) {
+		return rows[:0]
+	}
+	rows = rows[page.Offset:]
+
+	if page.Limit > 0 && page.Limit < len(rows) {
+		rows = rows[:page.Limit]
+	}
+	return rows
+}
+
+// the timestamps of the DB (NOW()) are in UTC
+func now() time.Time {
+	return time.Now().UTC()
+}
+
+// the callers get copies: changing them must not change the stored rows
+func copyMovie(movie *domain.Movie) *domain.Movie {
+	movieCopy := *movie
+	return &movieCopy
+}
+
+func copyReview(review *domain.Review) domain.Review {
+	reviewCopy := *review
+	if review.Comment != nil {
+		comment := *review.Comment
+		reviewCopy.Comment = &comment
+	}
+	return reviewCopy
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	timeCopy := *t
+	return &timeCopy
+}
+
+func copyID(id *uuid.UUID) *uuid.UUID {
+	if id == nil {
+		return nil
+	}
+	idCopy := *id
+	return &idCopy
+}
@@ -0,0 +1,283 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/auth"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+type MemoryUserRepository struct {
+	store *Store
+}
+
+func NewMemoryUserRepository(store *Store) *MemoryUserRepository {
+	return &MemoryUserRepository{
+		store: store,
+	}
+}
+
+func (r *MemoryUserRepository) AddUser(ctx context.Context, user domain.CreateUserRequest) (domain.User, error) {
+	// hashing is slow (on purpose), not under the lock
+	hashedPassword, err := auth.HashPassword(user.Password)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return r.addUser(user, hashedPassword)
+}
+
+func (r *MemoryUserRepository) AddUserWithRole(ctx context.Context, user domain.CreateUserRequest, role domain.Role) (domain.User, error) {
+	hashedPassword, err := auth.HashPassword(user.Password)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	createdUser, err := r.addUser(user, hashedPassword)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	r.store.users[createdUser.ID].Role = role
+	createdUser.Role = role
+	return createdUser, nil
+}
+
+func (r *MemoryUserRepository) AddUserWithIdentity(ctx context.Context, user domain.CreateUserRequest, identity domain.UserIdentity) (domain.User, error) {
+	hashedPassword, err := auth.HashPassword(user.Password)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// checked first: both are created or none
+	key := identityKey{provider: identity.Provider, subject: identity.Subject}
+	if _, ok := r.store.identities[key]; ok {
+		return domain.User{}, ErrUniqueViolation
+	}
+
+	createdUser, err := r.addUser(user, hashedPassword)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	identity.UserID = createdUser.ID
+	r.store.identities[key] = identity
+	return createdUser, nil
+}
+
+func (r *MemoryUserRepository) LinkIdentity(ctx context.Context, identity domain.UserIdentity) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.users[identity.UserID]; !ok {
+		return ErrForeignKeyViolation
+	}
+
+	key := identityKey{provider: identity.Provider, subject: identity.Subject}
+	if _, ok := r.store.identities[key]; ok {
+		return ErrUniqueViolation
+	}
+
+	r.store.identities[key] = identity
+	return nil
+}
+
+func (r *MemoryUserRepository) FindUserByIdentity(ctx context.Context, provider, subject string) (domain.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	identity, ok := r.store.identities[identityKey{provider: provider, subject: subject}]
+	if !ok {
+		return domain.User{}, domain.ErrUserNotFound
+	}
+
+	return r.findUserById(identity.UserID)
+}
+
+func (r *MemoryUserRepository) FindUserByName(ctx context.Context, username string) (domain.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.findUser(func(user *domain.User) bool { return user.Username == username })
+}
+
+func (r *MemoryUserRepository) FindUsersByNames(ctx context.Context, usernames []string) ([]domain.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	users := []domain.User{}
+	for _, user := range r.store.users {
+		if slices.Contains(usernames, user.Username) {
+			users = append(users, *user)
+		}
+	}
+	slices.SortFunc(users, func(a, b domain.User) int { return strings.Compare(a.Username, b.Username) })
+
+	return users, nil
+}
+
+func (r *MemoryUserRepository) FindUserByEmail(ctx context.Context, email string) (domain.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	// a user without email (NULL) never matches
+	if email == "" {
+		return domain.User{}, domain.ErrUserNotFound
+	}
+	return r.findUser(func(user *domain.User) bool { return user.Email == email })
+}
+
+func (r *MemoryUserRepository) FindUserById(ctx context.Context, id uuid.UUID) (domain.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.findUserById(id)
+}
+
+// the caller is responsible for hashing (see auth.HashPassword)
+func (r *MemoryUserRepository) UpdatePasswordHash(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	return r.updateUser(id, func(user *domain.User) {
+		user.PasswordHash = passwordHash
+	})
+}
+
+func (r *MemoryUserRepository) UpdateUserRole(ctx context.Context, id uuid.UUID, role domain.Role) error {
+	return r.updateUser(id, func(user *domain.User) {
+		user.Role = role
+	})
+}
+
+func (r *MemoryUserRepository) SetTOTPSecret(ctx context.Context, id uuid.UUID, secret string) error {
+	return r.updateUser(id, func(user *domain.User) {
+		user.TOTPSecret = secret
+		user.TOTPEnabled = false
+	})
+}
+
+// same as the Postgres transaction: 2FA on and the new codes, or nothing
+func (r *MemoryUserRepository) EnableTOTP(ctx context.Context, id uuid.UUID, recoveryCodeHashes []string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.users[id]
+	if !ok {
+		// user_recovery_codes.user_id REFERENCES users
+		if len(recoveryCodeHashes) > 0 {
+			return ErrForeignKeyViolation
+		}
+		return nil
+	}
+
+	codes := make([]*recoveryCode, len(recoveryCodeHashes))
+	for idx, codeHash := range recoveryCodeHashes {
+		codes[idx] = &recoveryCode{codeHash: codeHash}
+	}
+
+	user.TOTPEnabled = true
+	user.UpdatedAt = now()
+	r.store.recoveryCodes[id] = codes
+	return nil
+}
+
+func (r *MemoryUserRepository) DisableTOTP(ctx context.Context, id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if user, ok := r.store.users[id]; ok {
+		user.TOTPSecret = ""
+		user.TOTPEnabled = false
+		user.UpdatedAt = now()
+	}
+
+	delete(r.store.recoveryCodes, id)
+	return nil
+}
+
+func (r *MemoryUserRepository) UseRecoveryCode(ctx context.Context, id uuid.UUID, codeHash string) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, code := range r.store.recoveryCodes[id] {
+		if code.codeHash == codeHash && !code.used {
+			code.used = true
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// -------- helpers
+
+// the constraints of the users table: username UNIQUE, email UNIQUE (when set).
+// the caller holds the lock
+func (r *MemoryUserRepository) addUser(user domain.CreateUserRequest, passwordHash string) (domain.User, error) {
+	for _, existing := range r.store.users {
+		if existing.Username == user.Username {
+			return domain.User{}, ErrUniqueViolation
+		}
+		if user.Email != "" && existing.Email == user.Email {
+			return domain.User{}, ErrUniqueViolation
+		}
+	}
+
+	createdAt := now()
+	createdUser := &domain.User{
+		ID:           uuid.New(),
+		Username:     user.Username,
+		PasswordHash: passwordHash,
+		Role:         domain.Regular,
+		Email:        user.Email,
+		CreatedAt:    createdAt,
+		UpdatedAt:    createdAt,
+	}
+	r.store.users[createdUser.ID] = createdUser
+
+	return *createdUser, nil
+}
+
+// the caller holds the lock
+func (r *MemoryUserRepository) findUser(match func(user *domain.User) bool) (domain.User, error) {
+	for _, user := range r.store.users {
+		if match(user) {
+			return *user, nil
+		}
+	}
+
+	return domain.User{}, domain.ErrUserNotFound
+}
+
+// the caller holds the lock
+func (r *MemoryUserRepository) findUserById(id uuid.UUID) (domain.User, error) {
+	user, ok := r.store.users[id]
+	if !ok {
+		return domain.User{}, domain.ErrUserNotFound
+	}
+
+	return *user, nil
+}
+
+// an UPDATE that matches nothing is not an error
+func (r *MemoryUserRepository) updateUser(id uuid.UUID, update func(user *domain.User)) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if user, ok := r.store.users[id]; ok {
+		update(user)
+		user.UpdatedAt = now()
+	}
+
+	return nil
+}
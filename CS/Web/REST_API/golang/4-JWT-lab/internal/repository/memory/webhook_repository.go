@@ -0,0 +1,244 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+type MemoryWebhookRepository struct {
+	store *Store
+}
+
+func NewMemoryWebhookRepository(store *Store) *MemoryWebhookRepository {
+	return &MemoryWebhookRepository{
+		store: store,
+	}
+}
+
+func (r *MemoryWebhookRepository) AddWebhook(ctx context.Context, webhook domain.Webhook) (domain.Webhook, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// webhooks.created_by REFERENCES users
+	if webhook.CreatedBy != nil {
+		if _, ok := r.store.users[*webhook.CreatedBy]; !ok {
+			return domain.Webhook{}, ErrForeignKeyViolation
+		}
+	}
+
+	insertedWebhook := domain.Webhook{
+		ID:        uuid.New(),
+		URL:       webhook.URL,
+		Events:    slices.Clone(webhook.Events),
+		Secret:    webhook.Secret,
+		CreatedBy: copyID(webhook.CreatedBy),
+		CreatedAt: now(),
+	}
+	if insertedWebhook.Events == nil {
+		insertedWebhook.Events = []string{}
+	}
+	r.store.webhooks[insertedWebhook.ID] = &insertedWebhook
+
+	return copyWebhook(&insertedWebhook), nil
+}
+
+// newest first
+func (r *MemoryWebhookRepository) ListWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := sortedValues(r.store.webhooks, func(a, b *domain.Webhook) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return compareIDs(a.ID, b.ID)
+	})
+
+	webhooks := make([]domain.Webhook, len(rows))
+	for idx, row := range rows {
+		webhooks[idx] = copyWebhook(row)
+	}
+	return webhooks, nil
+}
+
+func (r *MemoryWebhookRepository) GetWebhookById(ctx context.Context, id uuid.UUID) (domain.Webhook, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	webhook, ok := r.store.webhooks[id]
+	if !ok {
+		return domain.Webhook{}, domain.ErrWebhookNotFound
+	}
+	return copyWebhook(webhook), nil
+}
+
+// oldest first
+func (r *MemoryWebhookRepository) FindWebhooksByEvent(ctx context.Context, eventType string) ([]domain.Webhook, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := sortedValues(r.store.webhooks, func(a, b *domain.Webhook) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return compareIDs(a.ID, b.ID)
+	})
+
+	webhooks := []domain.Webhook{}
+	for _, row := range rows {
+		if slices.Contains(row.Events, eventType) {
+			webhooks = append(webhooks, copyWebhook(row))
+		}
+	}
+	return webhooks, nil
+}
+
+func (r *MemoryWebhookRepository) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.webhooks[id]; !ok {
+		return domain.ErrWebhookNotFound
+	}
+	delete(r.store.webhooks, id)
+
+	// ON DELETE CASCADE
+	for deliveryId, delivery := range r.store.deliveries {
+		if delivery.WebhookID == id {
+			delete(r.store.deliveries, deliveryId)
+		}
+	}
+	return nil
+}
+
+func (r *MemoryWebhookRepository) AddDeliveries(ctx context.Context, deliveries []domain.WebhookDelivery) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// checked first: all or nothing, like the transaction
+	for _, delivery := range deliveries {
+		if _, ok := r.store.webhooks[delivery.WebhookID]; !ok {
+			return ErrForeignKeyViolation
+		}
+		if _, ok := r.store.deliveries[delivery.ID]; ok {
+			return ErrUniqueViolation
+		}
+	}
+
+	createdAt := now()
+	for _, delivery := range deliveries {
+		insertedDelivery := domain.WebhookDelivery{
+			ID:            delivery.ID,
+			WebhookID:     delivery.WebhookID,
+			EventID:       delivery.EventID,
+			EventType:     delivery.EventType,
+			Payload:       slices.Clone(delivery.Payload),
+			Status:        domain.DeliveryPending,
+			NextAttemptAt: delivery.NextAttemptAt.UTC(),
+			CreatedAt:     createdAt,
+		}
+		r.store.deliveries[insertedDelivery.ID] = &insertedDelivery
+	}
+	return nil
+}
+
+func (r *MemoryWebhookRepository) ClaimDueDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	rows := sortedValues(r.store.deliveries, func(a, b *domain.WebhookDelivery) int {
+		return a.NextAttemptAt.Compare(b.NextAttemptAt)
+	})
+
+	claimed := []domain.WebhookDelivery{}
+	for _, row := range rows {
+		if len(claimed) == limit {
+			break
+		}
+		if row.Status != domain.DeliveryPending || row.NextAttemptAt.After(now) {
+			continue
+		}
+
+		row.NextAttemptAt = leaseUntil.UTC()
+		claimed = append(claimed, copyDelivery(row))
+	}
+	return claimed, nil
+}
+
+func (r *MemoryWebhookRepository) UpdateDelivery(ctx context.Context, delivery domain.WebhookDelivery) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// deliveries.status CHECK
+	switch delivery.Status {
+	case domain.DeliveryPending, domain.DeliverySucceeded, domain.DeliveryDead:
+	default:
+		return ErrCheckViolation
+	}
+
+	row, ok := r.store.deliveries[delivery.ID]
+	// an UPDATE of nothing
+	if !ok {
+		return nil
+	}
+
+	row.Status = delivery.Status
+	row.Attempts = delivery.Attempts
+	row.NextAttemptAt = delivery.NextAttemptAt.UTC()
+	row.LastAttemptAt = copyTime(delivery.LastAttemptAt)
+	row.ResponseStatus = delivery.ResponseStatus
+	row.LastError = delivery.LastError
+	return nil
+}
+
+// newest first
+func (r *MemoryWebhookRepository) ListDeliveries(ctx context.Context, webhookId uuid.UUID, page domain.Page) ([]domain.WebhookDelivery, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rows := sortedValues(r.store.deliveries, func(a, b *domain.WebhookDelivery) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return compareIDs(b.ID, a.ID)
+	})
+	rows = slices.DeleteFunc(rows, func(delivery *domain.WebhookDelivery) bool {
+		return delivery.WebhookID != webhookId
+	})
+
+	deliveries := []domain.WebhookDelivery{}
+	for _, row := range paginate(rows, page) {
+		deliveries = append(deliveries, copyDelivery(row))
+	}
+	return deliveries, nil
+}
+
+func (r *MemoryWebhookRepository) GetDeliveryById(ctx context.Context, id uuid.UUID) (domain.WebhookDelivery, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	delivery, ok := r.store.deliveries[id]
+	if !ok {
+		return domain.WebhookDelivery{}, domain.ErrDeliveryNotFound
+	}
+	return copyDelivery(delivery), nil
+}
+
+// -------- helpers
+func copyWebhook(webhook *domain.Webhook) domain.Webhook {
+	webhookCopy := *webhook
+	webhookCopy.Events = slices.Clone(webhook.Events)
+	webhookCopy.CreatedBy = copyID(webhook.CreatedBy)
+	return webhookCopy
+}
+
+func copyDelivery(delivery *domain.WebhookDelivery) domain.WebhookDelivery {
+	deliveryCopy := *delivery
+	deliveryCopy.Payload = slices.Clone(delivery.Payload)
+	deliveryCopy.LastAttemptAt = copyTime(delivery.LastAttemptAt)
+	return deliveryCopy
+}
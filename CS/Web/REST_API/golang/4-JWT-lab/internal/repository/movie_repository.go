@@ -10,10 +10,25 @@ import (
 // interface definition for movie repository (Data Access Layer)
 // regardles the data source (cache, files, Db, in-memory...)
 type MovieRepository interface {
-	GetAllMovies(ctx context.Context) []*domain.Movie
+	// ordered by title (ASCII case-insensitive, then bytewise), then id
+	GetAllMovies(ctx context.Context, page domain.Page) ([]*domain.Movie, error)
 	GetMovieById(ctx context.Context, id uuid.UUID) (*domain.Movie, error)
 	AddMovie(ctx context.Context, movie *domain.Movie) (*domain.Movie, error)
 	UpdateMovieTitleById(ctx context.Context, id uuid.UUID, title string) error
 	DeleteMovieById(ctx context.Context, id uuid.UUID) error
 	GetMovieWithReviews(ctx context.Context, id uuid.UUID) (*domain.Movie, error)
+	// GetMovieWithReviews for many movies in one query (the GraphQL loaders),
+	// ordered by id. the unknown ids are skipped
+	GetMoviesWithReviewsByIds(ctx context.Context, ids []uuid.UUID) ([]*domain.Movie, error)
+	// adds to the persisted view count, no-op for an unknown movie
+	AddMovieViews(ctx context.Context, id uuid.UUID, views int64) error
+	// ordered by views, most viewed first
+	GetPopularMovies(ctx context.Context, page domain.Page) ([]*domain.Movie, error)
+	// bulk insert (movies keep their ID), the ids that already exist are skipped.
+	// returns how many movies were inserted
+	AddMovies(ctx context.Context, movies []*domain.Movie) (int, error)
+	// keyset pagination by id (uuid.Nil for the first page), for exports
+	GetMoviesAfter(ctx context.Context, after uuid.UUID, limit int) ([]*domain.Movie, error)
+	// the ones of ids that are already taken, ordered by id (the import dry runs)
+	GetExistingMovieIds(ctx context.Context, ids []uuid.UUID) ([]uuid.UUID, error)
 }
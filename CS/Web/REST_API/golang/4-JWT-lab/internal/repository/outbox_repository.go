@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+// the relay's side of the outbox. the events themselves are written by the
+// movie and review repositories, in the transaction of the change (AddMovie,
+// AddMovies, UpdateMovieTitleById, DeleteMovieById, AddReview, AddReviews),
+// with a delivery per subscribed webhook (domain.WebhookDeliveries)
+type OutboxRepository interface {
+	// the oldest unpublished events, by sequence
+	GetUnpublishedEvents(ctx context.Context, limit int) ([]domain.OutboxEvent, error)
+	MarkEventPublished(ctx context.Context, id uuid.UUID) error
+	// counts a failed attempt, the event stays unpublished
+	MarkEventFailed(ctx context.Context, id uuid.UUID, reason string) error
+	// prunes the events published before `before`, returns how many
+	DeletePublishedEvents(ctx context.Context, before time.Time) (int, error)
+	// prunes the events that occurred before `before` and were never
+	// published (no broker to publish them), returns how many
+	DeleteUnpublishedEvents(ctx context.Context, before time.Time) (int, error)
+	// one relay at a time (several API instances on one database): ok is
+	// false when another relay holds the lock. release is only set when ok
+	TryLockRelay(ctx context.Context) (release func(), ok bool, err error)
+}
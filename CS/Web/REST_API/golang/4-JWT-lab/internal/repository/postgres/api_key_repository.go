@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/database"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+type PostgresAPIKeyRepository struct {
+	dbQueries *database.Queries
+}
+
+func NewPostgresAPIKeyRepository(db database.DBTX) *PostgresAPIKeyRepository {
+	return &PostgresAPIKeyRepository{
+		dbQueries: database.New(db),
+	}
+}
+
+func (r *PostgresAPIKeyRepository) AddAPIKey(ctx context.Context, key domain.APIKey, keyHash string) (domain.APIKey, error) {
+	expiresAt := sql.NullTime{}
+	if key.ExpiresAt != nil {
+		expiresAt = sql.NullTime{Time: *key.ExpiresAt, Valid: true}
+	}
+
+	dbKey, err := r.dbQueries.AddApiKey(ctx, database.AddApiKeyParams{
+		ID:        uuid.New(),
+		UserID:    key.UserID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		KeyHash:   keyHash,
+		Scopes:    fromPermissions(key.Scopes),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+
+	return toDomainAPIKey(dbKey), nil
+}
+
+func (r *PostgresAPIKeyRepository) FindAPIKeyByHash(ctx context.Context, keyHash string) (domain.APIKey, error) {
+	row, err := r.dbQueries.FindApiKeyByHash(ctx, keyHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.APIKey{}, domain.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+
+	key := toDomainAPIKey(database.ApiKey{
+		ID:         row.ID,
+		UserID:     row.UserID,
+		Name:       row.Name,
+		Prefix:     row.Prefix,
+		KeyHash:    row.KeyHash,
+		Scopes:     row.Scopes,
+		CreatedAt:  row.CreatedAt,
+		ExpiresAt:  row.ExpiresAt,
+		LastUsedAt: row.LastUsedAt,
+		RevokedAt:  row.RevokedAt,
+	})
+	if row.OwnerRole.Valid {
+		key.OwnerRole = domain.Role(row.OwnerRole.UserRole)
+	}
+
+	return key, nil
+}
+
+func (r *PostgresAPIKeyRepository) ListAPIKeys(ctx context.Context) ([]domain.APIKey, error) {
+	dbKeys, err := r.dbQueries.ListApiKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]domain.APIKey, len(dbKeys))
+	for idx, k := range dbKeys {
+		keys[idx] = toDomainAPIKey(k)
+	}
+	return keys, nil
+}
+
+func (r *PostgresAPIKeyRepository) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	rows, err := r.dbQueries.RevokeApiKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	// unknown or already revoked
+	if rows == 0 {
+		return domain.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+func (r *PostgresAPIKeyRepository) TouchAPIKey(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	return r.dbQueries.TouchApiKey(ctx, database.TouchApiKeyParams{
+		ID:         id,
+		LastUsedAt: sql.NullTime{Time: usedAt, Valid: true},
+	})
+}
+
+// -------- helpers (mappers)
+func toDomainAPIKey(k database.ApiKey) domain.APIKey {
+	return domain.APIKey{
+		ID:         k.ID,
+		UserID:     k.UserID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     toPermissions(k.Scopes),
+		CreatedAt:  k.CreatedAt,
+		ExpiresAt:  nullTimeToPtr(k.ExpiresAt),
+		LastUsedAt: nullTimeToPtr(k.LastUsedAt),
+		RevokedAt:  nullTimeToPtr(k.RevokedAt),
+	}
+}
+
+func toPermissions(scopes []string) []domain.Permission {
+	perms := make([]domain.Permission, len(scopes))
+	for idx, s := range scopes {
+		perms[idx] = domain.Permission(s)
+	}
+	return perms
+}
+
+func fromPermissions(perms []domain.Permission) []string {
+	scopes := make([]string, len(perms))
+	for idx, p := range perms {
+		scopes[idx] = string(p)
+	}
+	return scopes
+}
+
+func nullTimeToPtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/database"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+type PostgresAuditRepository struct {
+	dbQueries *database.Queries
+}
+
+func NewPostgresAuditRepository(db database.DBTX) *PostgresAuditRepository {
+	return &PostgresAuditRepository{
+		dbQueries: database.New(db),
+	}
+}
+
+func (r *PostgresAuditRepository) AddAuditEvent(ctx context.Context, event domain.AuditEvent) error {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+
+	return r.dbQueries.AddAuditEvent(ctx, database.AddAuditEventParams{
+		ID:         event.ID,
+		OccurredAt: event.OccurredAt,
+		ActorID:    uuidPtrToNull(event.ActorID),
+		ActorName:  toNullString(event.ActorName),
+		ApiKeyID:   uuidPtrToNull(event.APIKeyID),
+		Action:     event.Action,
+		TargetType: toNullString(event.TargetType),
+		TargetID:   toNullString(event.TargetID),
+		Ip:         toNullString(event.IP),
+		UserAgent:  toNullString(event.UserAgent),
+		Outcome:    string(event.Outcome),
+		Detail:     toNullString(event.Detail),
+	})
+}
+
+func (r *PostgresAuditRepository) ListAuditEvents(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEvent, error) {
+	params := database.ListAuditEventsParams{
+		ActorID:    uuidPtrToNull(filter.ActorID),
+		Action:     toNullString(filter.Action),
+		Outcome:    toNullString(string(filter.Outcome)),
+		TargetType: toNullString(filter.TargetType),
+		TargetID:   toNullString(filter.TargetID),
+		PageSize:   int32(filter.Limit),
+		PageOffset: int32(filter.Offset),
+	}
+	if filter.Since != nil {
+		params.Since = sql.NullTime{Time: *filter.Since, Valid: true}
+	}
+	if filter.Until != nil {
+		params.Until = sql.NullTime{Time: *filter.Until, Valid: true}
+	}
+
+	dbEvents, err := r.dbQueries.ListAuditEvents(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]domain.AuditEvent, len(dbEvents))
+	for idx, e := range dbEvents {
+		events[idx] = toDomainAuditEvent(e)
+	}
+	return events, nil
+}
+
+// -------- helpers (mappers)
+func toDomainAuditEvent(e database.AuditEvent) domain.AuditEvent {
+	return domain.AuditEvent{
+		ID:         e.ID,
+		OccurredAt: e.OccurredAt,
+		ActorID:    nullUUIDToPtr(e.ActorID),
+		ActorName:  e.ActorName.String,
+		APIKeyID:   nullUUIDToPtr(e.ApiKeyID),
+		Action:     e.Action,
+		TargetType: e.TargetType.String,
+		TargetID:   e.TargetID.String,
+		IP:         e.Ip.String,
+		UserAgent:  e.UserAgent.String,
+		Outcome:    domain.AuditOutcome(e.Outcome),
+		Detail:     e.Detail.String,
+	}
+}
+
+// empty string → NULL, so the "IS NULL" filters work
+func toNullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func uuidPtrToNull(id *uuid.UUID) uuid.NullUUID {
+	if id == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: *id, Valid: true}
+}
+
+func nullUUIDToPtr(id uuid.NullUUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	return &id.UUID
+}
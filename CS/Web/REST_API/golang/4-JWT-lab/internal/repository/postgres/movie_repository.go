@@ -2,7 +2,8 @@ package postgres
 
 import (
 	"context"
-	"log"
+	"database/sql"
+	"errors"
 
 	"github.com/google/uuid"
 	"github.com/grainme/movie-api/internal/database"
@@ -10,19 +11,24 @@ import (
 )
 
 type PostgresMovieRepository struct {
+	db        database.DBTX
 	dbQueries *database.Queries
 }
 
 func NewPostgresMovieRepository(db database.DBTX) *PostgresMovieRepository {
 	return &PostgresMovieRepository{
+		db:        db,
 		dbQueries: database.New(db),
 	}
 }
 
-func (r *PostgresMovieRepository) GetAllMovies(ctx context.Context) []*domain.Movie {
-	movies, err := r.dbQueries.GetMovies(ctx)
+func (r *PostgresMovieRepository) GetAllMovies(ctx context.Context, page domain.Page) ([]*domain.Movie, error) {
+	movies, err := r.dbQueries.GetMovies(ctx, database.GetMoviesParams{
+		PageSize:   pageSize(page),
+		PageOffset: int32(page.Offset),
+	})
 	if err != nil {
-		log.Fatalf("Error: %v", err)
+		return nil, err
 	}
 
 	moviesList := make([]*domain.Movie, len(movies))
@@ -30,11 +36,14 @@ func (r *PostgresMovieRepository) GetAllMovies(ctx context.Context) []*domain.Mo
 		moviesList[idx] = toDomainMovieFromDatabaseMovie(mv)
 	}
 
-	return moviesList
+	return moviesList, nil
 }
 
 func (r *PostgresMovieRepository) GetMovieById(ctx context.Context, id uuid.UUID) (*domain.Movie, error) {
 	movie, err := r.dbQueries.GetMovieById(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrMovieNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -43,40 +52,67 @@ func (r *PostgresMovieRepository) GetMovieById(ctx context.Context, id uuid.UUID
 }
 
 func (r *PostgresMovieRepository) AddMovie(ctx context.Context, movie *domain.Movie) (*domain.Movie, error) {
-	dbMovie, err := r.dbQueries.AddMovie(ctx, database.AddMovieParams{
-		ID:       uuid.New(),
-		Title:    movie.Title,
-		Director: movie.Director,
-		Year:     movie.Year,
+	var insertedMovie *domain.Movie
+	// the movie and its event commit together
+	err := withTx(ctx, r.db, func(q *database.Queries) error {
+		dbMovie, err := q.AddMovie(ctx, database.AddMovieParams{
+			ID:       uuid.New(),
+			Title:    movie.Title,
+			Director: movie.Director,
+			Year:     movie.Year,
+		})
+		if err != nil {
+			return err
+		}
+
+		insertedMovie = toDomainMovieFromDatabaseMovie(dbMovie)
+		return addOutboxEvent(ctx, q, domain.NewMovieCreatedEvent(insertedMovie))
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	insertedMovie := toDomainMovieFromDatabaseMovie(dbMovie)
 	return insertedMovie, nil
 }
 
 func (r *PostgresMovieRepository) UpdateMovieTitleById(ctx context.Context, id uuid.UUID, title string) error {
-	err := r.dbQueries.UpdateMovieTitleById(ctx, database.UpdateMovieTitleByIdParams{
-		ID:    id,
-		Title: title,
+	return withTx(ctx, r.db, func(q *database.Queries) error {
+		updatedMovie, err := q.UpdateMovieTitleById(ctx, database.UpdateMovieTitleByIdParams{
+			ID:    id,
+			Title: title,
+		})
+		// updating an unknown movie is a no-op: no event
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		return addOutboxEvent(ctx, q, domain.NewMovieUpdatedEvent(toDomainMovieFromDatabaseMovie(updatedMovie)))
 	})
-
-	return err
 }
 
 func (r *PostgresMovieRepository) DeleteMovieById(ctx context.Context, id uuid.UUID) error {
-	err := r.dbQueries.DeleteMovieById(ctx, id)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return withTx(ctx, r.db, func(q *database.Queries) error {
+		deleted, err := q.DeleteMovieById(ctx, id)
+		if err != nil {
+			return err
+		}
+		// deleting an unknown movie is a no-op: no event
+		if deleted == 0 {
+			return nil
+		}
+
+		return addOutboxEvent(ctx, q, domain.NewMovieDeletedEvent(id))
+	})
 }
 
 func (r *PostgresMovieRepository) GetMovieWithReviews(ctx context.Context, id uuid.UUID) (*domain.Movie, error) {
 	movie, err := r.dbQueries.GetMovieWithReviews(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrMovieNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -84,6 +120,94 @@ func (r *PostgresMovieRepository) GetMovieWithReviews(ctx context.Context, id uu
 	return toDomainMovieFromGetMovieWithReviewsRow(movie), nil
 }
 
+func (r *PostgresMovieRepository) GetMoviesWithReviewsByIds(ctx context.Context, ids []uuid.UUID) ([]*domain.Movie, error) {
+	movies, err := r.dbQueries.GetMoviesWithReviewsByIds(ctx, idStrings(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	moviesList := make([]*domain.Movie, len(movies))
+	for idx, mv := range movies {
+		moviesList[idx] = toDomainMovieFromGetMovieWithReviewsRow(database.GetMovieWithReviewsRow(mv))
+	}
+
+	return moviesList, nil
+}
+
+func (r *PostgresMovieRepository) AddMovieViews(ctx context.Context, id uuid.UUID, views int64) error {
+	return r.dbQueries.AddMovieViews(ctx, database.AddMovieViewsParams{
+		ID:    id,
+		Views: views,
+	})
+}
+
+func (r *PostgresMovieRepository) GetPopularMovies(ctx context.Context, page domain.Page) ([]*domain.Movie, error) {
+	movies, err := r.dbQueries.GetPopularMovies(ctx, database.GetPopularMoviesParams{
+		PageSize:   pageSize(page),
+		PageOffset: int32(page.Offset),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	moviesList := make([]*domain.Movie, len(movies))
+	for idx, mv := range movies {
+		moviesList[idx] = toDomainMovieFromDatabaseMovie(mv)
+	}
+
+	return moviesList, nil
+}
+
+func (r *PostgresMovieRepository) AddMovies(ctx context.Context, movies []*domain.Movie) (int, error) {
+	rows := make([][]any, len(movies))
+	for idx, movie := range movies {
+		rows[idx] = []any{movie.ID, movie.Title, movie.Director, movie.Year}
+	}
+
+	inserted := 0
+	err := withDBTx(ctx, r.db, func(tx database.DBTX) error {
+		ids, err := insertRows(ctx, tx, "movies", []string{"id", "title", "director", "year"}, rows)
+		if err != nil {
+			return err
+		}
+		inserted = len(ids)
+
+		// like AddMovie: an event per movie actually inserted, in the transaction
+		q := database.New(tx)
+		for _, movie := range insertedOnly(movies, ids, func(movie *domain.Movie) uuid.UUID { return movie.ID }) {
+			if err := addOutboxEvent(ctx, q, domain.NewMovieCreatedEvent(movie)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return inserted, err
+}
+
+func (r *PostgresMovieRepository) GetMoviesAfter(ctx context.Context, after uuid.UUID, limit int) ([]*domain.Movie, error) {
+	movies, err := r.dbQueries.GetMoviesAfter(ctx, database.GetMoviesAfterParams{
+		After:    after,
+		PageSize: int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	moviesList := make([]*domain.Movie, len(movies))
+	for idx, mv := range movies {
+		moviesList[idx] = toDomainMovieFromDatabaseMovie(mv)
+	}
+
+	return moviesList, nil
+}
+
+func (r *PostgresMovieRepository) GetExistingMovieIds(ctx context.Context, ids []uuid.UUID) ([]uuid.UUID, error) {
+	if len(ids) == 0 {
+		return []uuid.UUID{}, nil
+	}
+	return r.dbQueries.GetExistingMovieIds(ctx, idStrings(ids))
+}
+
 // Helper function (Mapper)
 func toDomainMovieFromDatabaseMovie(movie database.Movie) *domain.Movie {
 	domainMovie := domain.Movie{
@@ -91,6 +215,7 @@ func toDomainMovieFromDatabaseMovie(movie database.Movie) *domain.Movie {
 		Title:    movie.Title,
 		Director: movie.Director,
 		Year:     movie.Year,
+		Views:    movie.Views,
 	}
 
 	return &domainMovie
@@ -104,7 +229,22 @@ func toDomainMovieFromGetMovieWithReviewsRow(movie database.GetMovieWithReviewsR
 		Year:          movie.Year,
 		AverageRating: movie.AvgRating,
 		ReviewsCount:  movie.ReviewsCount,
+		Views:         movie.Views,
 	}
 
 	return &domainMovie
 }
+
+// the uuid[] parameters go as text[] (pq.Array can't encode []uuid.UUID)
+func idStrings(ids []uuid.UUID) []string {
+	strs := make([]string, len(ids))
+	for idx, id := range ids {
+		strs[idx] = id.String()
+	}
+	return strs
+}
+
+// LIMIT NULL is "no limit" for Postgres
+func pageSize(page domain.Page) sql.NullInt32 {
+	return sql.NullInt32{Int32: int32(page.Limit), Valid: page.Limit > 0}
+}
@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/database"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+// the key of the relay's advisory lock: any constant, unique in the database
+const outboxRelayLockKey int64 = 0x6d6f766965 // "movie"
+
+type PostgresOutboxRepository struct {
+	db        database.DBTX
+	dbQueries *database.Queries
+}
+
+func NewPostgresOutboxRepository(db database.DBTX) *PostgresOutboxRepository {
+	return &PostgresOutboxRepository{
+		db:        db,
+		dbQueries: database.New(db),
+	}
+}
+
+func (r *PostgresOutboxRepository) GetUnpublishedEvents(ctx context.Context, limit int) ([]domain.OutboxEvent, error) {
+	rows, err := r.dbQueries.GetUnpublishedOutboxEvents(ctx, int32(limit))
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]domain.OutboxEvent, len(rows))
+	for idx, row := range rows {
+		events[idx] = toDomainOutboxEvent(row)
+	}
+	return events, nil
+}
+
+func (r *PostgresOutboxRepository) MarkEventPublished(ctx context.Context, id uuid.UUID) error {
+	return r.dbQueries.MarkOutboxEventPublished(ctx, id)
+}
+
+func (r *PostgresOutboxRepository) MarkEventFailed(ctx context.Context, id uuid.UUID, reason string) error {
+	return r.dbQueries.MarkOutboxEventFailed(ctx, database.MarkOutboxEventFailedParams{
+		ID:        id,
+		LastError: sql.NullString{String: reason, Valid: true},
+	})
+}
+
+func (r *PostgresOutboxRepository) DeletePublishedEvents(ctx context.Context, before time.Time) (int, error) {
+	deleted, err := r.dbQueries.DeletePublishedOutboxEvents(ctx, sql.NullTime{Time: before, Valid: true})
+	return int(deleted), err
+}
+
+func (r *PostgresOutboxRepository) DeleteUnpublishedEvents(ctx context.Context, before time.Time) (int, error) {
+	deleted, err := r.dbQueries.DeleteUnpublishedOutboxEvents(ctx, before)
+	return int(deleted), err
+}
+
+// TryLockRelay takes the advisory lock on a connection of its own: a session
+// lock belongs to a connection, the pool would hand the unlock to another one
+func (r *PostgresOutboxRepository) TryLockRelay(ctx context.Context) (func(), bool, error) {
+	sqlDB, ok := r.db.(*sql.DB)
+	if !ok {
+		// inside a caller's transaction (tests): nobody to compete with
+		return func() {}, true, nil
+	}
+
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	queries := database.New(conn)
+	locked, err := queries.TryLockOutboxRelay(ctx, outboxRelayLockKey)
+	if err != nil || !locked {
+		conn.Close()
+		return nil, false, err
+	}
+
+	release := func() {
+		// a fresh context: the cycle's one may be done already
+		queries.UnlockOutboxRelay(context.Background(), outboxRelayLockKey)
+		conn.Close()
+	}
+	return release, true, nil
+}
+
+// the caller's transaction: the event is part of the change, and so are its
+// webhook deliveries
+func addOutboxEvent(ctx context.Context, q *database.Queries, event domain.OutboxEvent) error {
+	err := q.AddOutboxEvent(ctx, database.AddOutboxEventParams{
+		ID:            event.ID,
+		EventType:     event.Type,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		Payload:       event.Data,
+		OccurredAt:    event.OccurredAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	webhooks, err := q.FindWebhooksByEvent(ctx, event.Type)
+	if err != nil {
+		return err
+	}
+	return addWebhookDeliveries(ctx, q, domain.WebhookDeliveries(event, toDomainWebhooks(webhooks)))
+}
+
+func toDomainOutboxEvent(row database.Outbox) domain.OutboxEvent {
+	return domain.OutboxEvent{
+		ID:            row.ID,
+		Type:          row.EventType,
+		AggregateType: row.AggregateType,
+		AggregateID:   row.AggregateID,
+		Sequence:      row.Sequence,
+		OccurredAt:    row.OccurredAt,
+		Data:          row.Payload,
+		Attempts:      int(row.Attempts),
+		LastError:     row.LastError.String,
+	}
+}
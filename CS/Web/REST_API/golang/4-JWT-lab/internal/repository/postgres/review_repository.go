@@ -10,11 +10,13 @@ import (
 )
 
 type PostgresReviewRepository struct {
+	db        database.DBTX
 	dbQueries *database.Queries
 }
 
 func NewPostgresReviewRepository(db database.DBTX) *PostgresReviewRepository {
 	return &PostgresReviewRepository{
+		db:        db,
 		dbQueries: database.New(db),
 	}
 }
@@ -41,22 +43,52 @@ func (r *PostgresReviewRepository) AddReview(ctx context.Context, review *domain
 		}
 	}
 
-	dbReview, err := r.dbQueries.AddReview(ctx, database.AddReviewParams{
-		ID:       uuid.New(),
-		UserName: review.Username,
-		Rating:   review.Rating,
-		Comment:  comment,
-		MovieID:  review.MovieID,
+	var insertedReview domain.Review
+	err := withTx(ctx, r.db, func(q *database.Queries) error {
+		dbReview, err := q.AddReview(ctx, database.AddReviewParams{
+			ID:       uuid.New(),
+			UserName: review.Username,
+			Rating:   review.Rating,
+			Comment:  comment,
+			MovieID:  review.MovieID,
+		})
+		if err != nil {
+			return err
+		}
+
+		insertedReview = toDomainReview(dbReview)
+		return addOutboxEvent(ctx, q, domain.NewReviewCreatedEvent(insertedReview))
 	})
 	if err != nil {
 		return domain.Review{}, err
 	}
 
-	return toDomainReview(dbReview), nil
+	return insertedReview, nil
+}
+
+func (r *PostgresReviewRepository) GetAllReviewsByMovieId(ctx context.Context, movieId uuid.UUID, page domain.Page) ([]domain.Review, error) {
+	reviews, err := r.dbQueries.GetAllReviewsByMovieId(ctx, database.GetAllReviewsByMovieIdParams{
+		MovieID:    movieId,
+		PageSize:   pageSize(page),
+		PageOffset: int32(page.Offset),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reviewsList := make([]domain.Review, len(reviews))
+	for idx, r := range reviews {
+		reviewsList[idx] = toDomainReview(r)
+	}
+	return reviewsList, nil
 }
 
-func (r *PostgresReviewRepository) GetAllReviewsByMovieId(ctx context.Context, movieId uuid.UUID) ([]domain.Review, error) {
-	reviews, err := r.dbQueries.GetAllReviewsByMovieId(ctx, movieId)
+func (r *PostgresReviewRepository) GetReviewsByMovieIds(ctx context.Context, movieIds []uuid.UUID, page domain.Page) ([]domain.Review, error) {
+	reviews, err := r.dbQueries.GetReviewsByMovieIds(ctx, database.GetReviewsByMovieIdsParams{
+		MovieIds:   idStrings(movieIds),
+		PageOffset: int32(page.Offset),
+		PageSize:   pageSize(page),
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -68,6 +100,75 @@ func (r *PostgresReviewRepository) GetAllReviewsByMovieId(ctx context.Context, m
 	return reviewsList, nil
 }
 
+func (r *PostgresReviewRepository) GetReviewsByUsernames(ctx context.Context, usernames []string, page domain.Page) ([]domain.Review, error) {
+	reviews, err := r.dbQueries.GetReviewsByUsernames(ctx, database.GetReviewsByUsernamesParams{
+		Usernames:  usernames,
+		PageOffset: int32(page.Offset),
+		PageSize:   pageSize(page),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reviewsList := make([]domain.Review, len(reviews))
+	for idx, r := range reviews {
+		reviewsList[idx] = toDomainReview(r)
+	}
+	return reviewsList, nil
+}
+
+func (r *PostgresReviewRepository) AddReviews(ctx context.Context, reviews []domain.Review) (int, error) {
+	rows := make([][]any, len(reviews))
+	for idx, review := range reviews {
+		comment := sql.NullString{}
+		if review.Comment != nil {
+			comment = sql.NullString{String: *review.Comment, Valid: true}
+		}
+		rows[idx] = []any{review.ID, review.Username, review.Rating, comment, review.MovieID}
+	}
+
+	inserted := 0
+	err := withDBTx(ctx, r.db, func(tx database.DBTX) error {
+		ids, err := insertRows(ctx, tx, "reviews", []string{"id", "user_name", "rating", "comment", "movie_id"}, rows)
+		if err != nil {
+			return err
+		}
+		inserted = len(ids)
+
+		q := database.New(tx)
+		for _, review := range insertedOnly(reviews, ids, func(review domain.Review) uuid.UUID { return review.ID }) {
+			if err := addOutboxEvent(ctx, q, domain.NewReviewCreatedEvent(review)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return inserted, err
+}
+
+func (r *PostgresReviewRepository) GetReviewsAfter(ctx context.Context, after uuid.UUID, limit int) ([]domain.Review, error) {
+	reviews, err := r.dbQueries.GetReviewsAfter(ctx, database.GetReviewsAfterParams{
+		After:    after,
+		PageSize: int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reviewsList := make([]domain.Review, len(reviews))
+	for idx, r := range reviews {
+		reviewsList[idx] = toDomainReview(r)
+	}
+	return reviewsList, nil
+}
+
+func (r *PostgresReviewRepository) GetExistingReviewIds(ctx context.Context, ids []uuid.UUID) ([]uuid.UUID, error) {
+	if len(ids) == 0 {
+		return []uuid.UUID{}, nil
+	}
+	return r.dbQueries.GetExistingReviewIds(ctx, idStrings(ids))
+}
+
 // Helper(Mapper)
 func toDomainReview(dbReview database.Review) domain.Review {
 	review := domain.Review{
@@ -0,0 +1,37 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/grainme/movie-api/internal/database"
+)
+
+// withTx runs fn in a transaction when the repository was built on a *sql.DB.
+// if db is already a *sql.Tx (caller owns the transaction), fn just runs on it.
+func withTx(ctx context.Context, db database.DBTX, fn func(q *database.Queries) error) error {
+	return withDBTx(ctx, db, func(tx database.DBTX) error {
+		return fn(database.New(tx))
+	})
+}
+
+// same, for the queries sqlc doesn't generate (insertRows)
+func withDBTx(ctx context.Context, db database.DBTX, fn func(tx database.DBTX) error) error {
+	sqlDB, ok := db.(*sql.DB)
+	if !ok {
+		return fn(db)
+	}
+
+	tx, err := sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// no-op once committed
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
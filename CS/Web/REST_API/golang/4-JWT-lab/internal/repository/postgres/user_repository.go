@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 
 	"github.com/google/uuid"
@@ -11,40 +12,103 @@ import (
 )
 
 type PostgresUserRepository struct {
+	db        database.DBTX
 	dbQueries *database.Queries
 }
 
 func NewPostgresUserRepository(db database.DBTX) *PostgresUserRepository {
 	return &PostgresUserRepository{
+		db:        db,
 		dbQueries: database.New(db),
 	}
 }
 
 func (r *PostgresUserRepository) AddUser(ctx context.Context, user domain.CreateUserRequest) (domain.User, error) {
-	hashedPassword, err := auth.HashPassword(user.Password)
+	return addUser(ctx, r.dbQueries, user)
+}
+
+func (r *PostgresUserRepository) AddUserWithRole(ctx context.Context, user domain.CreateUserRequest, role domain.Role) (domain.User, error) {
+	var createdUser domain.User
+	err := withTx(ctx, r.db, func(q *database.Queries) error {
+		var err error
+		createdUser, err = addUser(ctx, q, user)
+		if err != nil {
+			return err
+		}
+
+		createdUser.Role = role
+		return q.UpdateUserRole(ctx, database.UpdateUserRoleParams{
+			ID:   createdUser.ID,
+			Role: database.NullUserRole{UserRole: database.UserRole(role), Valid: true},
+		})
+	})
 	if err != nil {
 		return domain.User{}, err
 	}
 
-	createdUser, err := r.dbQueries.AddUser(ctx, database.AddUserParams{
-		ID:           uuid.New(),
-		Username:     user.Username,
-		PasswordHash: hashedPassword,
+	return createdUser, nil
+}
+
+func (r *PostgresUserRepository) AddUserWithIdentity(ctx context.Context, user domain.CreateUserRequest, identity domain.UserIdentity) (domain.User, error) {
+	var createdUser domain.User
+	err := withTx(ctx, r.db, func(q *database.Queries) error {
+		var err error
+		createdUser, err = addUser(ctx, q, user)
+		if err != nil {
+			return err
+		}
+
+		identity.UserID = createdUser.ID
+		return addIdentity(ctx, q, identity)
 	})
 	if err != nil {
 		return domain.User{}, err
 	}
 
-	toDomainUser, err := DatabaseUserToDomainUser(createdUser)
+	return createdUser, nil
+}
+
+func (r *PostgresUserRepository) LinkIdentity(ctx context.Context, identity domain.UserIdentity) error {
+	return addIdentity(ctx, r.dbQueries, identity)
+}
+
+func (r *PostgresUserRepository) FindUserByIdentity(ctx context.Context, provider, subject string) (domain.User, error) {
+	dbUser, err := r.dbQueries.FindUserByIdentity(ctx, database.FindUserByIdentityParams{
+		Provider: provider,
+		Subject:  subject,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.User{}, domain.ErrUserNotFound
+	}
 	if err != nil {
 		return domain.User{}, err
 	}
 
-	return toDomainUser, nil
+	return DatabaseUserToDomainUser(dbUser)
 }
 
 func (r *PostgresUserRepository) FindUserByName(ctx context.Context, username string) (domain.User, error) {
 	dbUser, err := r.dbQueries.FindUserByName(ctx, username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.User{}, domain.ErrUserNotFound
+	}
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	toDomainUser, err := DatabaseUserToDomainUser(dbUser)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	return toDomainUser, nil
+}
+
+func (r *PostgresUserRepository) FindUserByEmail(ctx context.Context, email string) (domain.User, error) {
+	dbUser, err := r.dbQueries.FindUserByEmail(ctx, sql.NullString{String: email, Valid: true})
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.User{}, domain.ErrUserNotFound
+	}
 	if err != nil {
 		return domain.User{}, err
 	}
@@ -57,6 +121,154 @@ func (r *PostgresUserRepository) FindUserByName(ctx context.Context, username st
 	return toDomainUser, nil
 }
 
+func (r *PostgresUserRepository) FindUsersByNames(ctx context.Context, usernames []string) ([]domain.User, error) {
+	dbUsers, err := r.dbQueries.FindUsersByNames(ctx, usernames)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]domain.User, len(dbUsers))
+	for idx, dbUser := range dbUsers {
+		if users[idx], err = DatabaseUserToDomainUser(dbUser); err != nil {
+			return nil, err
+		}
+	}
+
+	return users, nil
+}
+
+func (r *PostgresUserRepository) FindUserById(ctx context.Context, id uuid.UUID) (domain.User, error) {
+	dbUser, err := r.dbQueries.FindUserById(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.User{}, domain.ErrUserNotFound
+	}
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	return DatabaseUserToDomainUser(dbUser)
+}
+
+// the caller is responsible for hashing (see auth.HashPassword)
+func (r *PostgresUserRepository) UpdatePasswordHash(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	return r.dbQueries.UpdateUserPasswordHash(ctx, database.UpdateUserPasswordHashParams{
+		ID:           id,
+		PasswordHash: passwordHash,
+	})
+}
+
+func (r *PostgresUserRepository) UpdateUserRole(ctx context.Context, id uuid.UUID, role domain.Role) error {
+	return r.dbQueries.UpdateUserRole(ctx, database.UpdateUserRoleParams{
+		ID:   id,
+		Role: database.NullUserRole{UserRole: database.UserRole(role), Valid: true},
+	})
+}
+
+func (r *PostgresUserRepository) SetTOTPSecret(ctx context.Context, id uuid.UUID, secret string) error {
+	return r.dbQueries.SetUserTotpSecret(ctx, database.SetUserTotpSecretParams{
+		ID:         id,
+		TotpSecret: sql.NullString{String: secret, Valid: true},
+	})
+}
+
+// enabling 2FA and issuing the recovery codes must happen together:
+// 2FA on without codes (or old codes still valid) is a lockout/backdoor
+func (r *PostgresUserRepository) EnableTOTP(ctx context.Context, id uuid.UUID, recoveryCodeHashes []string) error {
+	return withTx(ctx, r.db, func(q *database.Queries) error {
+		if err := q.EnableUserTotp(ctx, id); err != nil {
+			return err
+		}
+
+		if err := q.DeleteRecoveryCodesByUserId(ctx, id); err != nil {
+			return err
+		}
+
+		for _, codeHash := range recoveryCodeHashes {
+			err := q.AddRecoveryCode(ctx, database.AddRecoveryCodeParams{
+				ID:       uuid.New(),
+				UserID:   id,
+				CodeHash: codeHash,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *PostgresUserRepository) DisableTOTP(ctx context.Context, id uuid.UUID) error {
+	return withTx(ctx, r.db, func(q *database.Queries) error {
+		if err := q.DisableUserTotp(ctx, id); err != nil {
+			return err
+		}
+
+		return q.DeleteRecoveryCodesByUserId(ctx, id)
+	})
+}
+
+func (r *PostgresUserRepository) UseRecoveryCode(ctx context.Context, id uuid.UUID, codeHash string) (bool, error) {
+	// the UPDATE only matches an unused code, so two concurrent logins
+	// with the same code can't both succeed
+	rows, err := r.dbQueries.UseRecoveryCode(ctx, database.UseRecoveryCodeParams{
+		UserID:   id,
+		CodeHash: codeHash,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+// -------- helpers
+func addUser(ctx context.Context, q *database.Queries, user domain.CreateUserRequest) (domain.User, error) {
+	hashedPassword, err := auth.HashPassword(user.Password)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	email := sql.NullString{}
+	if user.Email != "" {
+		email = sql.NullString{
+			String: user.Email,
+			Valid:  true,
+		}
+	}
+
+	createdUser, err := q.AddUser(ctx, database.AddUserParams{
+		ID:           uuid.New(),
+		Username:     user.Username,
+		PasswordHash: hashedPassword,
+		Email:        email,
+	})
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	toDomainUser, err := DatabaseUserToDomainUser(createdUser)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	return toDomainUser, nil
+}
+
+func addIdentity(ctx context.Context, q *database.Queries, identity domain.UserIdentity) error {
+	email := sql.NullString{}
+	if identity.Email != "" {
+		email = sql.NullString{String: identity.Email, Valid: true}
+	}
+
+	return q.AddUserIdentity(ctx, database.AddUserIdentityParams{
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		UserID:   identity.UserID,
+		Email:    email,
+	})
+}
+
 // -------- helpers (mappers)
 func DatabaseUserToDomainUser(du database.User) (domain.User, error) {
 	var role domain.Role
@@ -71,6 +283,9 @@ func DatabaseUserToDomainUser(du database.User) (domain.User, error) {
 		Username:     du.Username,
 		PasswordHash: du.PasswordHash,
 		Role:         role,
+		Email:        du.Email.String,
+		TOTPSecret:   du.TotpSecret.String,
+		TOTPEnabled:  du.TotpEnabled,
 		CreatedAt:    du.CreatedAt.Time,
 		UpdatedAt:    du.UpdatedAt.Time,
 	}, nil
@@ -0,0 +1,217 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/database"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+type PostgresWebhookRepository struct {
+	db        database.DBTX
+	dbQueries *database.Queries
+}
+
+func NewPostgresWebhookRepository(db database.DBTX) *PostgresWebhookRepository {
+	return &PostgresWebhookRepository{
+		db:        db,
+		dbQueries: database.New(db),
+	}
+}
+
+func (r *PostgresWebhookRepository) AddWebhook(ctx context.Context, webhook domain.Webhook) (domain.Webhook, error) {
+	createdBy := uuid.NullUUID{}
+	if webhook.CreatedBy != nil {
+		createdBy = uuid.NullUUID{UUID: *webhook.CreatedBy, Valid: true}
+	}
+
+	dbWebhook, err := r.dbQueries.AddWebhook(ctx, database.AddWebhookParams{
+		ID:        uuid.New(),
+		Url:       webhook.URL,
+		Events:    webhook.Events,
+		Secret:    webhook.Secret,
+		CreatedBy: createdBy,
+	})
+	if err != nil {
+		return domain.Webhook{}, err
+	}
+
+	return toDomainWebhook(dbWebhook), nil
+}
+
+func (r *PostgresWebhookRepository) ListWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	dbWebhooks, err := r.dbQueries.ListWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return toDomainWebhooks(dbWebhooks), nil
+}
+
+func (r *PostgresWebhookRepository) GetWebhookById(ctx context.Context, id uuid.UUID) (domain.Webhook, error) {
+	dbWebhook, err := r.dbQueries.GetWebhookById(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Webhook{}, domain.ErrWebhookNotFound
+	}
+	if err != nil {
+		return domain.Webhook{}, err
+	}
+
+	return toDomainWebhook(dbWebhook), nil
+}
+
+func (r *PostgresWebhookRepository) FindWebhooksByEvent(ctx context.Context, eventType string) ([]domain.Webhook, error) {
+	dbWebhooks, err := r.dbQueries.FindWebhooksByEvent(ctx, eventType)
+	if err != nil {
+		return nil, err
+	}
+
+	return toDomainWebhooks(dbWebhooks), nil
+}
+
+func (r *PostgresWebhookRepository) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	deleted, err := r.dbQueries.DeleteWebhook(ctx, id)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return domain.ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+func (r *PostgresWebhookRepository) AddDeliveries(ctx context.Context, deliveries []domain.WebhookDelivery) error {
+	return withTx(ctx, r.db, func(q *database.Queries) error {
+		return addWebhookDeliveries(ctx, q, deliveries)
+	})
+}
+
+func (r *PostgresWebhookRepository) ClaimDueDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	dbDeliveries, err := r.dbQueries.ClaimDueWebhookDeliveries(ctx, database.ClaimDueWebhookDeliveriesParams{
+		LeaseUntil: leaseUntil.UTC(),
+		Now:        now.UTC(),
+		PageSize:   int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toDomainDeliveries(dbDeliveries), nil
+}
+
+func (r *PostgresWebhookRepository) UpdateDelivery(ctx context.Context, delivery domain.WebhookDelivery) error {
+	lastAttemptAt := sql.NullTime{}
+	if delivery.LastAttemptAt != nil {
+		lastAttemptAt = sql.NullTime{Time: delivery.LastAttemptAt.UTC(), Valid: true}
+	}
+
+	return r.dbQueries.UpdateWebhookDelivery(ctx, database.UpdateWebhookDeliveryParams{
+		ID:             delivery.ID,
+		Status:         string(delivery.Status),
+		Attempts:       int32(delivery.Attempts),
+		NextAttemptAt:  delivery.NextAttemptAt.UTC(),
+		LastAttemptAt:  lastAttemptAt,
+		ResponseStatus: int32(delivery.ResponseStatus),
+		LastError:      sql.NullString{String: delivery.LastError, Valid: delivery.LastError != ""},
+	})
+}
+
+func (r *PostgresWebhookRepository) ListDeliveries(ctx context.Context, webhookId uuid.UUID, page domain.Page) ([]domain.WebhookDelivery, error) {
+	dbDeliveries, err := r.dbQueries.ListWebhookDeliveries(ctx, database.ListWebhookDeliveriesParams{
+		WebhookID:  webhookId,
+		PageSize:   pageSize(page),
+		PageOffset: int32(page.Offset),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toDomainDeliveries(dbDeliveries), nil
+}
+
+func (r *PostgresWebhookRepository) GetDeliveryById(ctx context.Context, id uuid.UUID) (domain.WebhookDelivery, error) {
+	dbDelivery, err := r.dbQueries.GetWebhookDeliveryById(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.WebhookDelivery{}, domain.ErrDeliveryNotFound
+	}
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+
+	return toDomainDelivery(dbDelivery), nil
+}
+
+// Helper functions (Mappers)
+func addWebhookDeliveries(ctx context.Context, q *database.Queries, deliveries []domain.WebhookDelivery) error {
+	for _, delivery := range deliveries {
+		err := q.AddWebhookDelivery(ctx, database.AddWebhookDeliveryParams{
+			ID:            delivery.ID,
+			WebhookID:     delivery.WebhookID,
+			EventID:       delivery.EventID,
+			EventType:     delivery.EventType,
+			Payload:       delivery.Payload,
+			NextAttemptAt: delivery.NextAttemptAt.UTC(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func toDomainWebhook(webhook database.Webhook) domain.Webhook {
+	events := webhook.Events
+	if events == nil {
+		events = []string{}
+	}
+
+	return domain.Webhook{
+		ID:        webhook.ID,
+		URL:       webhook.Url,
+		Events:    events,
+		Secret:    webhook.Secret,
+		CreatedBy: nullUUIDToPtr(webhook.CreatedBy),
+		CreatedAt: webhook.CreatedAt,
+	}
+}
+
+func toDomainWebhooks(webhooks []database.Webhook) []domain.Webhook {
+	webhooksList := make([]domain.Webhook, len(webhooks))
+	for idx, webhook := range webhooks {
+		webhooksList[idx] = toDomainWebhook(webhook)
+	}
+	return webhooksList
+}
+
+func toDomainDelivery(delivery database.WebhookDelivery) domain.WebhookDelivery {
+	domainDelivery := domain.WebhookDelivery{
+		ID:             delivery.ID,
+		WebhookID:      delivery.WebhookID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Payload:        delivery.Payload,
+		Status:         domain.DeliveryStatus(delivery.Status),
+		Attempts:       int(delivery.Attempts),
+		NextAttemptAt:  delivery.NextAttemptAt,
+		ResponseStatus: int(delivery.ResponseStatus),
+		LastError:      delivery.LastError.String,
+		CreatedAt:      delivery.CreatedAt,
+	}
+	if delivery.LastAttemptAt.Valid {
+		domainDelivery.LastAttemptAt = &delivery.LastAttemptAt.Time
+	}
+	return domainDelivery
+}
+
+func toDomainDeliveries(deliveries []database.WebhookDelivery) []domain.WebhookDelivery {
+	deliveriesList := make([]domain.WebhookDelivery, len(deliveries))
+	for idx, delivery := range deliveries {
+		deliveriesList[idx] = toDomainDelivery(delivery)
+	}
+	return deliveriesList
+}
@@ -0,0 +1,124 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+func testAddAndFindAPIKey(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	owner := addUser(t, repos, "ripley", "")
+	if err := repos.Users.UpdateUserRole(ctx, owner.ID, domain.Admin); err != nil {
+		t.Fatalf("update role: %v", err)
+	}
+
+	// truncated: the DB only keeps microseconds
+	expiresAt := time.Now().UTC().Add(24 * time.Hour).Truncate(time.Second)
+	key, err := repos.APIKeys.AddAPIKey(ctx, domain.APIKey{
+		UserID:    owner.ID,
+		Name:      "ci",
+		Prefix:    "mk_abcd",
+		Scopes:    []domain.Permission{domain.PermMoviesWrite, domain.PermReviewsWrite},
+		ExpiresAt: &expiresAt,
+	}, "hash-1")
+	if err != nil {
+		t.Fatalf("add api key: %v", err)
+	}
+	if key.ID == uuid.Nil || key.UserID != owner.ID || key.Name != "ci" || key.Prefix != "mk_abcd" {
+		t.Fatalf("unexpected inserted key: %+v", key)
+	}
+	if key.CreatedAt.IsZero() || key.RevokedAt != nil || key.LastUsedAt != nil {
+		t.Fatalf("unexpected inserted key timestamps: %+v", key)
+	}
+
+	found, err := repos.APIKeys.FindAPIKeyByHash(ctx, "hash-1")
+	if err != nil {
+		t.Fatalf("find api key: %v", err)
+	}
+	if found.ID != key.ID || found.OwnerRole != domain.Admin {
+		t.Fatalf("expected key %s owned by an admin, got %+v", key.ID, found)
+	}
+	if !slices.Equal(found.Scopes, []domain.Permission{domain.PermMoviesWrite, domain.PermReviewsWrite}) {
+		t.Fatalf("unexpected scopes: %v", found.Scopes)
+	}
+	if found.ExpiresAt == nil || !found.ExpiresAt.Equal(expiresAt) {
+		t.Fatalf("expected expiration %v, got %v", expiresAt, found.ExpiresAt)
+	}
+
+	if _, err := repos.APIKeys.FindAPIKeyByHash(ctx, "unknown"); !errors.Is(err, domain.ErrAPIKeyNotFound) {
+		t.Fatalf("expected ErrAPIKeyNotFound, got %v", err)
+	}
+
+	// key_hash is unique, the owner must exist
+	if _, err := repos.APIKeys.AddAPIKey(ctx, domain.APIKey{UserID: owner.ID, Name: "copy", Prefix: "mk_abcd"}, "hash-1"); err == nil {
+		t.Fatal("expected an error for a duplicate key hash")
+	}
+	if _, err := repos.APIKeys.AddAPIKey(ctx, domain.APIKey{UserID: uuid.New(), Name: "orphan", Prefix: "mk_efgh"}, "hash-2"); err == nil {
+		t.Fatal("expected an error for an unknown owner")
+	}
+}
+
+func testListRevokeTouchAPIKeys(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	owner := addUser(t, repos, "ripley", "")
+
+	var ids []uuid.UUID
+	for idx, keyHash := range []string{"hash-1", "hash-2", "hash-3"} {
+		if idx > 0 {
+			// distinct creation times
+			time.Sleep(5 * time.Millisecond)
+		}
+		key, err := repos.APIKeys.AddAPIKey(ctx, domain.APIKey{UserID: owner.ID, Name: keyHash, Prefix: "mk_" + keyHash}, keyHash)
+		if err != nil {
+			t.Fatalf("add api key: %v", err)
+		}
+		ids = append(ids, key.ID)
+	}
+
+	keys, err := repos.APIKeys.ListAPIKeys(ctx)
+	if err != nil {
+		t.Fatalf("list api keys: %v", err)
+	}
+	listed := make([]uuid.UUID, len(keys))
+	for idx, key := range keys {
+		listed[idx] = key.ID
+	}
+	// newest first
+	expectIds(t, "api keys", listed, []uuid.UUID{ids[2], ids[1], ids[0]})
+
+	if err := repos.APIKeys.RevokeAPIKey(ctx, ids[0]); err != nil {
+		t.Fatalf("revoke api key: %v", err)
+	}
+	found, err := repos.APIKeys.FindAPIKeyByHash(ctx, "hash-1")
+	if err != nil {
+		t.Fatalf("find api key: %v", err)
+	}
+	if found.RevokedAt == nil {
+		t.Fatal("expected the key to be revoked")
+	}
+	// already revoked, or unknown
+	if err := repos.APIKeys.RevokeAPIKey(ctx, ids[0]); !errors.Is(err, domain.ErrAPIKeyNotFound) {
+		t.Fatalf("revoke twice: expected ErrAPIKeyNotFound, got %v", err)
+	}
+	if err := repos.APIKeys.RevokeAPIKey(ctx, uuid.New()); !errors.Is(err, domain.ErrAPIKeyNotFound) {
+		t.Fatalf("revoke unknown: expected ErrAPIKeyNotFound, got %v", err)
+	}
+
+	usedAt := time.Now().UTC().Truncate(time.Second)
+	if err := repos.APIKeys.TouchAPIKey(ctx, ids[1], usedAt); err != nil {
+		t.Fatalf("touch api key: %v", err)
+	}
+	found, err = repos.APIKeys.FindAPIKeyByHash(ctx, "hash-2")
+	if err != nil {
+		t.Fatalf("find api key: %v", err)
+	}
+	if found.LastUsedAt == nil || !found.LastUsedAt.Equal(usedAt) {
+		t.Fatalf("expected last use at %v, got %v", usedAt, found.LastUsedAt)
+	}
+}
@@ -0,0 +1,75 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+func testAuditEvents(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	start := time.Now().UTC().Truncate(time.Second)
+	actor := uuid.New()
+
+	events := []domain.AuditEvent{
+		{OccurredAt: start, ActorID: &actor, ActorName: "ripley", Action: domain.AuditLogin, Outcome: domain.AuditSuccess, IP: "10.0.0.1"},
+		{OccurredAt: start.Add(time.Minute), ActorName: "ash", Action: domain.AuditLogin, Outcome: domain.AuditFailure},
+		{OccurredAt: start.Add(2 * time.Minute), ActorID: &actor, Action: domain.AuditMovieDelete, TargetType: "movie", TargetID: "42", Outcome: domain.AuditSuccess},
+		{OccurredAt: start.Add(3 * time.Minute), ActorID: &actor, Action: domain.AuditAPIKeyCreate, Outcome: domain.AuditDenied},
+	}
+	for idx := range events {
+		events[idx].ID = uuid.New()
+		if err := repos.Audit.AddAuditEvent(ctx, events[idx]); err != nil {
+			t.Fatalf("add audit event: %v", err)
+		}
+	}
+	// the id is optional
+	if err := repos.Audit.AddAuditEvent(ctx, domain.AuditEvent{OccurredAt: start.Add(-time.Hour), Action: domain.AuditRegister, Outcome: domain.AuditSuccess}); err != nil {
+		t.Fatalf("add audit event without id: %v", err)
+	}
+	// outcome is one of success, failure, denied
+	if err := repos.Audit.AddAuditEvent(ctx, domain.AuditEvent{ID: uuid.New(), OccurredAt: start, Action: domain.AuditLogin, Outcome: "maybe"}); err == nil {
+		t.Fatal("expected an error for an unknown outcome")
+	}
+
+	since, until := start.Add(time.Minute), start.Add(3*time.Minute)
+	filters := []struct {
+		name   string
+		filter domain.AuditFilter
+		want   []uuid.UUID
+	}{
+		{"newest first", domain.AuditFilter{Limit: 4}, []uuid.UUID{events[3].ID, events[2].ID, events[1].ID, events[0].ID}},
+		{"offset", domain.AuditFilter{Limit: 2, Offset: 1}, []uuid.UUID{events[2].ID, events[1].ID}},
+		{"actor", domain.AuditFilter{ActorID: &actor, Limit: 10}, []uuid.UUID{events[3].ID, events[2].ID, events[0].ID}},
+		{"action", domain.AuditFilter{Action: domain.AuditLogin, Limit: 10}, []uuid.UUID{events[1].ID, events[0].ID}},
+		{"outcome", domain.AuditFilter{Outcome: domain.AuditFailure, Limit: 10}, []uuid.UUID{events[1].ID}},
+		{"target", domain.AuditFilter{TargetType: "movie", TargetID: "42", Limit: 10}, []uuid.UUID{events[2].ID}},
+		// since included, until excluded
+		{"time range", domain.AuditFilter{Since: &since, Until: &until, Limit: 10}, []uuid.UUID{events[2].ID, events[1].ID}},
+	}
+	for _, test := range filters {
+		listed, err := repos.Audit.ListAuditEvents(ctx, test.filter)
+		if err != nil {
+			t.Fatalf("%s: list audit events: %v", test.name, err)
+		}
+
+		ids := make([]uuid.UUID, len(listed))
+		for idx, event := range listed {
+			ids[idx] = event.ID
+		}
+		expectIds(t, test.name, ids, test.want)
+	}
+
+	listed, err := repos.Audit.ListAuditEvents(ctx, domain.AuditFilter{TargetID: "42", Limit: 1})
+	if err != nil {
+		t.Fatalf("list audit events: %v", err)
+	}
+	got, want := listed[0], events[2]
+	if got.ActorID == nil || *got.ActorID != actor || got.Action != want.Action || got.TargetType != "movie" ||
+		got.Outcome != want.Outcome || !got.OccurredAt.Equal(want.OccurredAt) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
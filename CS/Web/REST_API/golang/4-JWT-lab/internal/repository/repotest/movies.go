@@ -0,0 +1,354 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+func testAddAndGetMovie(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+
+	givenId := uuid.New()
+	movie, err := repos.Movies.AddMovie(ctx, &domain.Movie{ID: givenId, Title: "Alien", Director: "Ridley Scott", Year: 1979})
+	if err != nil {
+		t.Fatalf("add movie: %v", err)
+	}
+	// the repository picks the id
+	if movie.ID == uuid.Nil || movie.ID == givenId {
+		t.Fatalf("expected a new id, got %s", movie.ID)
+	}
+	if movie.Title != "Alien" || movie.Director != "Ridley Scott" || movie.Year != 1979 || movie.Views != 0 {
+		t.Fatalf("unexpected inserted movie: %+v", movie)
+	}
+
+	found, err := repos.Movies.GetMovieById(ctx, movie.ID)
+	if err != nil {
+		t.Fatalf("get movie: %v", err)
+	}
+	if *found != *movie {
+		t.Fatalf("expected %+v, got %+v", movie, found)
+	}
+
+	_, err = repos.Movies.GetMovieById(ctx, uuid.New())
+	if !errors.Is(err, domain.ErrMovieNotFound) {
+		t.Fatalf("expected ErrMovieNotFound, got %v", err)
+	}
+}
+
+func testGetAllMovies(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+
+	movies, err := repos.Movies.GetAllMovies(ctx, domain.Page{})
+	if err != nil {
+		t.Fatalf("get all movies: %v", err)
+	}
+	if len(movies) != 0 {
+		t.Fatalf("expected no movies, got %d", len(movies))
+	}
+
+	// two "Brazil": same title, ordered by id
+	brazil1, brazil2 := addMovie(t, repos, "Brazil"), addMovie(t, repos, "Brazil")
+	if slices.Compare(brazil2.ID[:], brazil1.ID[:]) < 0 {
+		brazil1, brazil2 = brazil2, brazil1
+	}
+	casablanca := addMovie(t, repos, "Casablanca")
+	alien := addMovie(t, repos, "Alien")
+	want := []uuid.UUID{alien.ID, brazil1.ID, brazil2.ID, casablanca.ID}
+
+	pages := []struct {
+		page domain.Page
+		want []uuid.UUID
+	}{
+		{domain.Page{}, want},
+		{domain.Page{Limit: 2}, want[:2]},
+		{domain.Page{Limit: 2, Offset: 2}, want[2:]},
+		{domain.Page{Offset: 3}, want[3:]},
+		{domain.Page{Limit: 10, Offset: 4}, nil},
+	}
+	for _, p := range pages {
+		movies, err := repos.Movies.GetAllMovies(ctx, p.page)
+		if err != nil {
+			t.Fatalf("get all movies %+v: %v", p.page, err)
+		}
+		expectIds(t, "get all movies", movieIds(movies), p.want)
+	}
+}
+
+func testGetAllMoviesMixedCase(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+
+	// case-insensitive (ASCII only), then bytewise whatever the collation of
+	// the database: spaces and digits count, non-ASCII letters come last
+	titles := []string{"Zardoz", "Über", "ALIENS", "the Birds", "Alien3", "12 Monkeys", "alien", "The Thing", "Alien 3", "brazil"}
+	ids := map[string]uuid.UUID{}
+	for _, title := range titles {
+		ids[title] = addMovie(t, repos, title).ID
+	}
+
+	var want []uuid.UUID
+	for _, title := range []string{"12 Monkeys", "alien", "Alien 3", "Alien3", "ALIENS", "brazil", "the Birds", "The Thing", "Zardoz", "Über"} {
+		want = append(want, ids[title])
+	}
+
+	movies, err := repos.Movies.GetAllMovies(ctx, domain.Page{})
+	if err != nil {
+		t.Fatalf("get all movies: %v", err)
+	}
+	if !slices.Equal(movieIds(movies), want) {
+		got := make([]string, len(movies))
+		for idx, movie := range movies {
+			got[idx] = movie.Title
+		}
+		t.Fatalf("titles ordered as %q", got)
+	}
+}
+
+func testUpdateMovieTitle(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	movie := addMovie(t, repos, "Alien")
+
+	if err := repos.Movies.UpdateMovieTitleById(ctx, movie.ID, "Aliens"); err != nil {
+		t.Fatalf("update title: %v", err)
+	}
+
+	found, err := repos.Movies.GetMovieById(ctx, movie.ID)
+	if err != nil {
+		t.Fatalf("get movie: %v", err)
+	}
+	if found.Title != "Aliens" {
+		t.Fatalf("expected title Aliens, got %q", found.Title)
+	}
+
+	// no rows updated is not an error
+	if err := repos.Movies.UpdateMovieTitleById(ctx, uuid.New(), "Nothing"); err != nil {
+		t.Fatalf("update unknown movie: %v", err)
+	}
+}
+
+func testDeleteMovie(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	movie := addMovie(t, repos, "Alien")
+	reviewed := addMovie(t, repos, "Brazil")
+	addReview(t, repos, reviewed.ID, 8)
+
+	if err := repos.Movies.DeleteMovieById(ctx, movie.ID); err != nil {
+		t.Fatalf("delete movie: %v", err)
+	}
+	if _, err := repos.Movies.GetMovieById(ctx, movie.ID); !errors.Is(err, domain.ErrMovieNotFound) {
+		t.Fatalf("expected ErrMovieNotFound after delete, got %v", err)
+	}
+
+	if err := repos.Movies.DeleteMovieById(ctx, uuid.New()); err != nil {
+		t.Fatalf("delete unknown movie: %v", err)
+	}
+
+	// the reviews reference their movie (no cascade)
+	if err := repos.Movies.DeleteMovieById(ctx, reviewed.ID); err == nil {
+		t.Fatal("expected an error deleting a reviewed movie")
+	}
+	if _, err := repos.Movies.GetMovieById(ctx, reviewed.ID); err != nil {
+		t.Fatalf("reviewed movie should still exist: %v", err)
+	}
+}
+
+func testGetMovieWithReviews(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	movie := addMovie(t, repos, "Alien")
+	other := addMovie(t, repos, "Brazil")
+
+	withReviews, err := repos.Movies.GetMovieWithReviews(ctx, movie.ID)
+	if err != nil {
+		t.Fatalf("get movie with reviews: %v", err)
+	}
+	if withReviews.AverageRating != 0 || withReviews.ReviewsCount != 0 {
+		t.Fatalf("expected no rating yet, got %v (%d reviews)", withReviews.AverageRating, withReviews.ReviewsCount)
+	}
+
+	addReview(t, repos, movie.ID, 4)
+	addReview(t, repos, movie.ID, 7)
+	addReview(t, repos, other.ID, 10)
+
+	withReviews, err = repos.Movies.GetMovieWithReviews(ctx, movie.ID)
+	if err != nil {
+		t.Fatalf("get movie with reviews: %v", err)
+	}
+	if withReviews.ID != movie.ID || withReviews.Title != "Alien" {
+		t.Fatalf("unexpected movie: %+v", withReviews)
+	}
+	if withReviews.AverageRating != 5.5 || withReviews.ReviewsCount != 2 {
+		t.Fatalf("expected 5.5 over 2 reviews, got %v over %d", withReviews.AverageRating, withReviews.ReviewsCount)
+	}
+
+	_, err = repos.Movies.GetMovieWithReviews(ctx, uuid.New())
+	if !errors.Is(err, domain.ErrMovieNotFound) {
+		t.Fatalf("expected ErrMovieNotFound, got %v", err)
+	}
+}
+
+func testGetMoviesWithReviewsByIds(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	alien := addMovie(t, repos, "Alien")
+	brazil := addMovie(t, repos, "Brazil")
+	addMovie(t, repos, "Casablanca")
+
+	addReview(t, repos, alien.ID, 4)
+	addReview(t, repos, alien.ID, 7)
+
+	// unknown ids and duplicates are ignored, ordered by id
+	movies, err := repos.Movies.GetMoviesWithReviewsByIds(ctx, []uuid.UUID{brazil.ID, uuid.New(), alien.ID, brazil.ID})
+	if err != nil {
+		t.Fatalf("get movies with reviews: %v", err)
+	}
+	want := []uuid.UUID{alien.ID, brazil.ID}
+	slices.SortFunc(want, func(a, b uuid.UUID) int { return slices.Compare(a[:], b[:]) })
+	expectIds(t, "movies by ids", movieIds(movies), want)
+
+	for _, movie := range movies {
+		switch movie.ID {
+		case alien.ID:
+			if movie.Title != "Alien" || movie.AverageRating != 5.5 || movie.ReviewsCount != 2 {
+				t.Fatalf("expected Alien at 5.5 over 2 reviews, got %+v", movie)
+			}
+		case brazil.ID:
+			if movie.Title != "Brazil" || movie.AverageRating != 0 || movie.ReviewsCount != 0 {
+				t.Fatalf("expected Brazil without reviews, got %+v", movie)
+			}
+		}
+	}
+
+	movies, err = repos.Movies.GetMoviesWithReviewsByIds(ctx, nil)
+	if err != nil || len(movies) != 0 {
+		t.Fatalf("no ids: expected no movies, got %d (%v)", len(movies), err)
+	}
+}
+
+func testMovieViews(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	alien := addMovie(t, repos, "Alien")
+	brazil := addMovie(t, repos, "Brazil")
+	casablanca := addMovie(t, repos, "Casablanca")
+
+	for _, views := range []struct {
+		id    uuid.UUID
+		views int64
+	}{{brazil.ID, 5}, {casablanca.ID, 2}, {brazil.ID, 3}, {uuid.New(), 100}} {
+		if err := repos.Movies.AddMovieViews(ctx, views.id, views.views); err != nil {
+			t.Fatalf("add movie views: %v", err)
+		}
+	}
+
+	found, err := repos.Movies.GetMovieById(ctx, brazil.ID)
+	if err != nil {
+		t.Fatalf("get movie: %v", err)
+	}
+	if found.Views != 8 {
+		t.Fatalf("expected 8 views, got %d", found.Views)
+	}
+
+	popular, err := repos.Movies.GetPopularMovies(ctx, domain.Page{})
+	if err != nil {
+		t.Fatalf("get popular movies: %v", err)
+	}
+	expectIds(t, "popular movies", movieIds(popular), []uuid.UUID{brazil.ID, casablanca.ID, alien.ID})
+
+	popular, err = repos.Movies.GetPopularMovies(ctx, domain.Page{Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatalf("get popular movies: %v", err)
+	}
+	expectIds(t, "popular movies page", movieIds(popular), []uuid.UUID{casablanca.ID})
+}
+
+func testAddMoviesAndGetAfter(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	ids := orderedIds(5)
+
+	batch := make([]*domain.Movie, 0, len(ids))
+	// inserted out of order on purpose
+	for _, idx := range []int{3, 0, 4, 1} {
+		batch = append(batch, &domain.Movie{ID: ids[idx], Title: "Movie", Director: "Director", Year: 1990 + int32(idx)})
+	}
+
+	inserted, err := repos.Movies.AddMovies(ctx, batch)
+	if err != nil {
+		t.Fatalf("add movies: %v", err)
+	}
+	if inserted != 4 {
+		t.Fatalf("expected 4 inserted, got %d", inserted)
+	}
+
+	// the ids that already exist are skipped
+	inserted, err = repos.Movies.AddMovies(ctx, []*domain.Movie{
+		{ID: ids[0], Title: "Changed", Director: "Director", Year: 2020},
+		{ID: ids[2], Title: "Movie", Director: "Director", Year: 1992},
+	})
+	if err != nil {
+		t.Fatalf("add movies again: %v", err)
+	}
+	if inserted != 1 {
+		t.Fatalf("expected 1 inserted, got %d", inserted)
+	}
+
+	kept, err := repos.Movies.GetMovieById(ctx, ids[0])
+	if err != nil {
+		t.Fatalf("get movie: %v", err)
+	}
+	if kept.Title != "Movie" || kept.Year != 1990 {
+		t.Fatalf("existing movie should be untouched, got %+v", kept)
+	}
+
+	if inserted, err := repos.Movies.AddMovies(ctx, nil); err != nil || inserted != 0 {
+		t.Fatalf("add no movies: %d, %v", inserted, err)
+	}
+
+	// what an import would skip: unknown ids and duplicates left out
+	existing, err := repos.Movies.GetExistingMovieIds(ctx, []uuid.UUID{ids[4], uuid.New(), ids[0], ids[4]})
+	if err != nil {
+		t.Fatalf("get existing movie ids: %v", err)
+	}
+	expectIds(t, "existing movie ids", existing, []uuid.UUID{ids[0], ids[4]})
+	if existing, err := repos.Movies.GetExistingMovieIds(ctx, nil); err != nil || len(existing) != 0 {
+		t.Fatalf("existing of no ids: %v, %v", existing, err)
+	}
+
+	// the way the exports read them: pages of 2 after the last id
+	var all []uuid.UUID
+	after := uuid.Nil
+	for {
+		page, err := repos.Movies.GetMoviesAfter(ctx, after, 2)
+		if err != nil {
+			t.Fatalf("get movies after %s: %v", after, err)
+		}
+		all = append(all, movieIds(page)...)
+		if len(page) < 2 {
+			break
+		}
+		after = page[len(page)-1].ID
+	}
+	expectIds(t, "keyset pages", all, ids)
+}
+
+func testMovieCopies(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	movie := addMovie(t, repos, "Alien")
+
+	// the callers can't change the stored movies through what they get back
+	movie.Title = "Changed"
+	found, err := repos.Movies.GetMovieById(ctx, movie.ID)
+	if err != nil {
+		t.Fatalf("get movie: %v", err)
+	}
+	found.Title = "Changed again"
+
+	movies, err := repos.Movies.GetAllMovies(ctx, domain.Page{})
+	if err != nil {
+		t.Fatalf("get all movies: %v", err)
+	}
+	if len(movies) != 1 || movies[0].Title != "Alien" {
+		t.Fatalf("stored movie was changed: %+v", movies)
+	}
+}
@@ -0,0 +1,255 @@
+package repotest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+func testOutboxEvents(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+
+	movie := addMovie(t, repos, "Alien")
+	review := addReview(t, repos, movie.ID, 9)
+	// a movie with reviews can't be deleted (foreign key): another one
+	deleted := addMovie(t, repos, "Prometheus")
+	if err := repos.Movies.DeleteMovieById(ctx, deleted.ID); err != nil {
+		t.Fatalf("delete movie: %v", err)
+	}
+	// deleting it again changes nothing: no event
+	if err := repos.Movies.DeleteMovieById(ctx, deleted.ID); err != nil {
+		t.Fatalf("delete movie again: %v", err)
+	}
+	// a failed write leaves no event either
+	if _, err := repos.Reviews.AddReview(ctx, &domain.Review{Username: "ripley", Rating: 5, MovieID: uuid.New()}); err == nil {
+		t.Fatal("expected an error for a review of an unknown movie")
+	}
+
+	events, err := repos.Outbox.GetUnpublishedEvents(ctx, 10)
+	if err != nil {
+		t.Fatalf("get unpublished events: %v", err)
+	}
+	// a review belongs to the stream of its movie
+	want := []struct {
+		eventType string
+		aggregate uuid.UUID
+	}{
+		{domain.EventMovieCreated, movie.ID},
+		{domain.EventReviewCreated, movie.ID},
+		{domain.EventMovieCreated, deleted.ID},
+		{domain.EventMovieDeleted, deleted.ID},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %+v", len(want), events)
+	}
+	for idx, event := range events {
+		if event.Type != want[idx].eventType || event.AggregateType != "movie" || event.AggregateID != want[idx].aggregate {
+			t.Fatalf("event %d = %+v, want a %s of movie %s", idx, event, want[idx].eventType, want[idx].aggregate)
+		}
+		if idx > 0 && event.Sequence <= events[idx-1].Sequence {
+			t.Fatalf("sequences are not increasing: %+v", events)
+		}
+	}
+
+	var created domain.Review
+	if err := json.Unmarshal(events[1].Data, &created); err != nil || created.ID != review.ID {
+		t.Fatalf("review.created data = %s (%v)", events[1].Data, err)
+	}
+
+	// the limit keeps the oldest
+	events, err = repos.Outbox.GetUnpublishedEvents(ctx, 1)
+	if err != nil || len(events) != 1 || events[0].Type != domain.EventMovieCreated {
+		t.Fatalf("limited events = %+v (%v)", events, err)
+	}
+}
+
+func testOutboxBulkEvents(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+
+	existing := addMovie(t, repos, "Alien")
+	newIds := orderedIds(2)
+	inserted, err := repos.Movies.AddMovies(ctx, []*domain.Movie{
+		{ID: newIds[1], Title: "Aliens"},
+		{ID: existing.ID, Title: "Alien again"},
+		{ID: newIds[0], Title: "Alien 3"},
+		// the second row of an id is skipped like an existing one
+		{ID: newIds[0], Title: "Alien 3 again"},
+	})
+	if err != nil || inserted != 2 {
+		t.Fatalf("add movies = %d (%v), want 2", inserted, err)
+	}
+
+	reviewId := uuid.New()
+	if inserted, err := repos.Reviews.AddReviews(ctx, []domain.Review{{ID: reviewId, Username: "ripley", Rating: 9, MovieID: existing.ID}}); err != nil || inserted != 1 {
+		t.Fatalf("add reviews = %d (%v), want 1", inserted, err)
+	}
+	// a refused batch leaves no event
+	if _, err := repos.Reviews.AddReviews(ctx, []domain.Review{
+		{ID: uuid.New(), Username: "hicks", Rating: 8, MovieID: existing.ID},
+		{ID: uuid.New(), Username: "bishop", Rating: 8, MovieID: uuid.New()},
+	}); err == nil {
+		t.Fatal("expected an error for a review of an unknown movie")
+	}
+	// a re-import inserts nothing: no event
+	if _, err := repos.Reviews.AddReviews(ctx, []domain.Review{{ID: reviewId, Username: "ripley", Rating: 9, MovieID: existing.ID}}); err != nil {
+		t.Fatalf("add reviews again: %v", err)
+	}
+
+	events, err := repos.Outbox.GetUnpublishedEvents(ctx, 10)
+	if err != nil {
+		t.Fatalf("get unpublished events: %v", err)
+	}
+	// the movies in the order of the batch
+	want := []struct {
+		eventType string
+		aggregate uuid.UUID
+	}{
+		{domain.EventMovieCreated, existing.ID},
+		{domain.EventMovieCreated, newIds[1]},
+		{domain.EventMovieCreated, newIds[0]},
+		{domain.EventReviewCreated, existing.ID},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %+v", len(want), events)
+	}
+	for idx, event := range events {
+		if event.Type != want[idx].eventType || event.AggregateID != want[idx].aggregate {
+			t.Fatalf("event %d = %+v, want a %s of movie %s", idx, event, want[idx].eventType, want[idx].aggregate)
+		}
+	}
+
+	var created domain.Movie
+	if err := json.Unmarshal(events[2].Data, &created); err != nil || created.Title != "Alien 3" {
+		t.Fatalf("movie.created data = %s (%v), want the first row of its id", events[2].Data, err)
+	}
+	var review domain.Review
+	if err := json.Unmarshal(events[3].Data, &review); err != nil || review.ID != reviewId {
+		t.Fatalf("review.created data = %s (%v)", events[3].Data, err)
+	}
+}
+
+func testOutboxPublishAndPrune(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+
+	addMovie(t, repos, "Alien")
+	addMovie(t, repos, "Aliens")
+	events, err := repos.Outbox.GetUnpublishedEvents(ctx, 10)
+	if err != nil || len(events) != 2 {
+		t.Fatalf("events = %+v (%v)", events, err)
+	}
+
+	if err := repos.Outbox.MarkEventFailed(ctx, events[0].ID, "broker down"); err != nil {
+		t.Fatalf("mark failed: %v", err)
+	}
+	if err := repos.Outbox.MarkEventFailed(ctx, events[0].ID, "still down"); err != nil {
+		t.Fatalf("mark failed: %v", err)
+	}
+	if err := repos.Outbox.MarkEventPublished(ctx, events[1].ID); err != nil {
+		t.Fatalf("mark published: %v", err)
+	}
+
+	// the failed one stays, with its bookkeeping
+	unpublished, err := repos.Outbox.GetUnpublishedEvents(ctx, 10)
+	if err != nil || len(unpublished) != 1 || unpublished[0].ID != events[0].ID {
+		t.Fatalf("unpublished = %+v (%v)", unpublished, err)
+	}
+	if unpublished[0].Attempts != 2 || unpublished[0].LastError != "still down" {
+		t.Fatalf("failed event = %+v", unpublished[0])
+	}
+
+	// the retention cut: nothing was published before an hour ago
+	deleted, err := repos.Outbox.DeletePublishedEvents(ctx, time.Now().Add(-time.Hour))
+	if err != nil || deleted != 0 {
+		t.Fatalf("delete before an hour ago = %d (%v)", deleted, err)
+	}
+	// unpublished events are never pruned
+	deleted, err = repos.Outbox.DeletePublishedEvents(ctx, time.Now().Add(time.Hour))
+	if err != nil || deleted != 1 {
+		t.Fatalf("delete published = %d (%v)", deleted, err)
+	}
+	if unpublished, _ := repos.Outbox.GetUnpublishedEvents(ctx, 10); len(unpublished) != 1 {
+		t.Fatalf("unpublished after the prune = %+v", unpublished)
+	}
+
+	// no broker: the old unpublished events go too
+	deleted, err = repos.Outbox.DeleteUnpublishedEvents(ctx, time.Now().Add(-time.Hour))
+	if err != nil || deleted != 0 {
+		t.Fatalf("delete unpublished before an hour ago = %d (%v)", deleted, err)
+	}
+	deleted, err = repos.Outbox.DeleteUnpublishedEvents(ctx, time.Now().Add(time.Hour))
+	if err != nil || deleted != 1 {
+		t.Fatalf("delete unpublished = %d (%v)", deleted, err)
+	}
+	if unpublished, _ := repos.Outbox.GetUnpublishedEvents(ctx, 10); len(unpublished) != 0 {
+		t.Fatalf("unpublished after the prune = %+v", unpublished)
+	}
+
+	release, ok, err := repos.Outbox.TryLockRelay(ctx)
+	if err != nil || !ok {
+		t.Fatalf("lock relay = %v (%v)", ok, err)
+	}
+	release()
+}
+
+func testOutboxWebhookDeliveries(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+
+	movies, err := repos.Webhooks.AddWebhook(ctx, domain.Webhook{URL: "https://example.com/movies", Events: []string{domain.EventMovieUpdated, domain.EventMovieDeleted}, Secret: "secret"})
+	if err != nil {
+		t.Fatalf("add webhook: %v", err)
+	}
+
+	// movie.created: nobody subscribed, no delivery
+	movie := addMovie(t, repos, "Alien")
+	if err := repos.Movies.UpdateMovieTitleById(ctx, movie.ID, "Aliens"); err != nil {
+		t.Fatalf("update title: %v", err)
+	}
+	if err := repos.Movies.DeleteMovieById(ctx, movie.ID); err != nil {
+		t.Fatalf("delete movie: %v", err)
+	}
+	// unknown movies: nothing changed, nothing to tell
+	if err := repos.Movies.UpdateMovieTitleById(ctx, uuid.New(), "Prometheus"); err != nil {
+		t.Fatalf("update unknown title: %v", err)
+	}
+	if err := repos.Movies.DeleteMovieById(ctx, movie.ID); err != nil {
+		t.Fatalf("delete movie again: %v", err)
+	}
+
+	events, err := repos.Outbox.GetUnpublishedEvents(ctx, 10)
+	if err != nil || len(events) != 3 || events[1].Type != domain.EventMovieUpdated {
+		t.Fatalf("events = %+v (%v), want created, updated and deleted", events, err)
+	}
+	var updated domain.Movie
+	if err := json.Unmarshal(events[1].Data, &updated); err != nil || updated.ID != movie.ID || updated.Title != "Aliens" {
+		t.Fatalf("movie.updated data = %s (%v)", events[1].Data, err)
+	}
+
+	// the deliveries carry the ids of the outbox events
+	deliveries, err := repos.Webhooks.ListDeliveries(ctx, movies.ID, domain.Page{Limit: 10})
+	if err != nil || len(deliveries) != 2 {
+		t.Fatalf("deliveries = %+v (%v), want 2", deliveries, err)
+	}
+	byEvent := map[uuid.UUID]domain.WebhookDelivery{}
+	for _, delivery := range deliveries {
+		byEvent[delivery.EventID] = delivery
+	}
+	for _, event := range events[1:] {
+		delivery, ok := byEvent[event.ID]
+		if !ok || delivery.EventType != event.Type || delivery.Status != domain.DeliveryPending {
+			t.Fatalf("deliveries = %+v, want a pending delivery of %s %s", deliveries, event.Type, event.ID)
+		}
+
+		var payload struct {
+			ID   uuid.UUID       `json:"id"`
+			Type string          `json:"type"`
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(delivery.Payload, &payload); err != nil || payload.ID != event.ID || payload.Type != event.Type {
+			t.Fatalf("delivery payload = %s (%v), want event %s", delivery.Payload, err, event.ID)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Package repotest is the conformance suite of the repository interfaces:
+// every backend (memory, postgres) runs the same tests, so they can't drift
+// apart (ordering, pagination, constraints, not found errors...).
+package repotest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/repository"
+)
+
+// Repositories of one backend, sharing the same storage
+type Repositories struct {
+	Movies   repository.MovieRepository
+	Reviews  repository.ReviewRepository
+	Users    repository.UserRepository
+	APIKeys  repository.APIKeyRepository
+	Audit    repository.AuditRepository
+	Outbox   repository.OutboxRepository
+	Webhooks repository.WebhookRepository
+}
+
+// Run runs the whole suite. newRepos is called for every test and must
+// return empty repositories (its cleanup goes in t.Cleanup).
+func Run(t *testing.T, newRepos func(t *testing.T) Repositories) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, repos Repositories)
+	}{
+		{"Movies/AddAndGet", testAddAndGetMovie},
+		{"Movies/GetAllOrderedByTitle", testGetAllMovies},
+		{"Movies/GetAllMixedCaseTitles", testGetAllMoviesMixedCase},
+		{"Movies/UpdateTitle", testUpdateMovieTitle},
+		{"Movies/Delete", testDeleteMovie},
+		{"Movies/WithReviews", testGetMovieWithReviews},
+		{"Movies/WithReviewsByIds", testGetMoviesWithReviewsByIds},
+		{"Movies/Views", testMovieViews},
+		{"Movies/BulkAndKeyset", testAddMoviesAndGetAfter},
+		{"Movies/ReturnsCopies", testMovieCopies},
+		{"Reviews/Add", testAddReview},
+		{"Reviews/ByMovie", testGetReviewsByMovie},
+		{"Reviews/ByMovieIdsAndUsernames", testGetReviewsByKeys},
+		{"Reviews/BulkAndKeyset", testAddReviewsAndGetAfter},
+		{"Reviews/Concurrent", testConcurrentReviews},
+		{"Users/AddAndFind", testAddAndFindUser},
+		{"Users/FindByNames", testFindUsersByNames},
+		{"Users/Unique", testUniqueUsers},
+		{"Users/AddWithRole", testAddUserWithRole},
+		{"Users/Update", testUpdateUser},
+		{"Users/TOTP", testUserTOTP},
+		{"Users/Identities", testUserIdentities},
+		{"APIKeys/AddAndFind", testAddAndFindAPIKey},
+		{"APIKeys/ListRevokeTouch", testListRevokeTouchAPIKeys},
+		{"Audit/AddAndList", testAuditEvents},
+		{"Outbox/WrittenWithTheChange", testOutboxEvents},
+		{"Outbox/WrittenByTheBulkImports", testOutboxBulkEvents},
+		{"Outbox/PublishAndPrune", testOutboxPublishAndPrune},
+		{"Outbox/QueuesTheWebhookDeliveries", testOutboxWebhookDeliveries},
+		{"Webhooks/AddFindDelete", testWebhooks},
+		{"Webhooks/Deliveries", testWebhookDeliveries},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.run(t, newRepos(t))
+		})
+	}
+}
+
+// -------- helpers
+
+func addMovie(t *testing.T, repos Repositories, title string) *domain.Movie {
+	t.Helper()
+
+	movie, err := repos.Movies.AddMovie(context.Background(), &domain.Movie{Title: title, Director: "Director", Year: 2000})
+	if err != nil {
+		t.Fatalf("add movie %q: %v", title, err)
+	}
+	return movie
+}
+
+func addReview(t *testing.T, repos Repositories, movieId uuid.UUID, rating int32) domain.Review {
+	t.Helper()
+
+	review, err := repos.Reviews.AddReview(context.Background(), &domain.Review{Username: "reviewer", Rating: rating, MovieID: movieId})
+	if err != nil {
+		t.Fatalf("add review: %v", err)
+	}
+	return review
+}
+
+func addUser(t *testing.T, repos Repositories, username, email string) domain.User {
+	t.Helper()
+
+	user, err := repos.Users.AddUser(context.Background(), domain.CreateUserRequest{Username: username, Password: "correct horse battery", Email: email})
+	if err != nil {
+		t.Fatalf("add user %q: %v", username, err)
+	}
+	return user
+}
+
+func movieIds(movies []*domain.Movie) []uuid.UUID {
+	ids := make([]uuid.UUID, len(movies))
+	for idx, movie := range movies {
+		ids[idx] = movie.ID
+	}
+	return ids
+}
+
+func reviewIds(reviews []domain.Review) []uuid.UUID {
+	ids := make([]uuid.UUID, len(reviews))
+	for idx, review := range reviews {
+		ids[idx] = review.ID
+	}
+	return ids
+}
+
+func expectIds(t *testing.T, what string, got, want []uuid.UUID) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("%s: expected %d ids, got %d (%v)", what, len(want), len(got), got)
+	}
+	for idx := range want {
+		if got[idx] != want[idx] {
+			t.Fatalf("%s: expected %v, got %v", what, want, got)
+		}
+	}
+}
+
+// fixed ids, ordered (the keyset and "order by id" tests need to know the order)
+func orderedIds(count int) []uuid.UUID {
+	ids := make([]uuid.UUID, count)
+	for idx := range ids {
+		ids[idx] = uuid.MustParse("00000000-0000-4000-8000-000000000000")
+		ids[idx][15] = byte(idx + 1)
+	}
+	return ids
+}
@@ -0,0 +1,273 @@
+package repotest
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+func testAddReview(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	movie := addMovie(t, repos, "Alien")
+
+	comment := "in space no one can hear you scream"
+	givenId := uuid.New()
+	review, err := repos.Reviews.AddReview(ctx, &domain.Review{ID: givenId, Username: "ripley", Rating: 9, Comment: &comment, MovieID: movie.ID})
+	if err != nil {
+		t.Fatalf("add review: %v", err)
+	}
+	if review.ID == uuid.Nil || review.ID == givenId {
+		t.Fatalf("expected a new id, got %s", review.ID)
+	}
+	if review.Username != "ripley" || review.Rating != 9 || review.MovieID != movie.ID || review.Comment == nil || *review.Comment != comment {
+		t.Fatalf("unexpected inserted review: %+v", review)
+	}
+
+	withoutComment := addReview(t, repos, movie.ID, 1)
+	if withoutComment.Comment != nil {
+		t.Fatalf("expected no comment, got %q", *withoutComment.Comment)
+	}
+
+	reviews, err := repos.Reviews.GetAllReviews(ctx)
+	if err != nil {
+		t.Fatalf("get all reviews: %v", err)
+	}
+	if len(reviews) != 2 {
+		t.Fatalf("expected 2 reviews, got %d", len(reviews))
+	}
+
+	invalid := []struct {
+		name   string
+		review domain.Review
+	}{
+		{"unknown movie", domain.Review{Username: "ripley", Rating: 5, MovieID: uuid.New()}},
+		{"rating 0", domain.Review{Username: "ripley", Rating: 0, MovieID: movie.ID}},
+		{"rating 11", domain.Review{Username: "ripley", Rating: 11, MovieID: movie.ID}},
+	}
+	for _, test := range invalid {
+		if _, err := repos.Reviews.AddReview(ctx, &test.review); err == nil {
+			t.Fatalf("%s: expected an error", test.name)
+		}
+	}
+}
+
+func testGetReviewsByMovie(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	movie := addMovie(t, repos, "Alien")
+	other := addMovie(t, repos, "Brazil")
+
+	var want []uuid.UUID
+	for rating := int32(1); rating <= 3; rating++ {
+		want = append(want, addReview(t, repos, movie.ID, rating).ID)
+	}
+	addReview(t, repos, other.ID, 5)
+	// ordered by id
+	slices.SortFunc(want, func(a, b uuid.UUID) int { return slices.Compare(a[:], b[:]) })
+
+	pages := []struct {
+		page domain.Page
+		want []uuid.UUID
+	}{
+		{domain.Page{}, want},
+		{domain.Page{Limit: 2}, want[:2]},
+		{domain.Page{Limit: 2, Offset: 2}, want[2:]},
+		{domain.Page{Offset: 5}, nil},
+	}
+	for _, p := range pages {
+		reviews, err := repos.Reviews.GetAllReviewsByMovieId(ctx, movie.ID, p.page)
+		if err != nil {
+			t.Fatalf("get reviews %+v: %v", p.page, err)
+		}
+		expectIds(t, "reviews by movie", reviewIds(reviews), p.want)
+	}
+
+	reviews, err := repos.Reviews.GetAllReviewsByMovieId(ctx, uuid.New(), domain.Page{})
+	if err != nil {
+		t.Fatalf("get reviews of an unknown movie: %v", err)
+	}
+	if len(reviews) != 0 {
+		t.Fatalf("expected no reviews, got %d", len(reviews))
+	}
+}
+
+func testGetReviewsByKeys(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	alien := addMovie(t, repos, "Alien")
+	brazil := addMovie(t, repos, "Brazil")
+	other := addMovie(t, repos, "Casablanca")
+
+	// the reviews of a movie by ripley then dallas, ordered by id
+	byMovie := map[uuid.UUID][]uuid.UUID{}
+	byUser := map[string][]uuid.UUID{}
+	for _, movie := range []*domain.Movie{alien, brazil, other} {
+		for _, username := range []string{"ripley", "dallas", "ripley"} {
+			review, err := repos.Reviews.AddReview(ctx, &domain.Review{Username: username, Rating: 5, MovieID: movie.ID})
+			if err != nil {
+				t.Fatalf("add review: %v", err)
+			}
+			byMovie[movie.ID] = append(byMovie[movie.ID], review.ID)
+			byUser[username] = append(byUser[username], review.ID)
+		}
+	}
+	byId := func(a, b uuid.UUID) int { return slices.Compare(a[:], b[:]) }
+	for _, ids := range byMovie {
+		slices.SortFunc(ids, byId)
+	}
+	for _, ids := range byUser {
+		slices.SortFunc(ids, byId)
+	}
+
+	// grouped by movie (by id), the page applies to every movie
+	wanted := []uuid.UUID{brazil.ID, alien.ID, uuid.New()}
+	first, second := alien.ID, brazil.ID
+	if byId(first, second) > 0 {
+		first, second = second, first
+	}
+	moviePages := []struct {
+		page domain.Page
+		want []uuid.UUID
+	}{
+		{domain.Page{}, slices.Concat(byMovie[first], byMovie[second])},
+		{domain.Page{Limit: 2}, slices.Concat(byMovie[first][:2], byMovie[second][:2])},
+		{domain.Page{Limit: 2, Offset: 2}, slices.Concat(byMovie[first][2:], byMovie[second][2:])},
+		{domain.Page{Offset: 5}, nil},
+	}
+	for _, p := range moviePages {
+		reviews, err := repos.Reviews.GetReviewsByMovieIds(ctx, wanted, p.page)
+		if err != nil {
+			t.Fatalf("get reviews by movie ids %+v: %v", p.page, err)
+		}
+		expectIds(t, "reviews by movie ids", reviewIds(reviews), p.want)
+	}
+
+	// grouped by username (dallas first)
+	userPages := []struct {
+		page domain.Page
+		want []uuid.UUID
+	}{
+		{domain.Page{}, slices.Concat(byUser["dallas"], byUser["ripley"])},
+		{domain.Page{Limit: 1}, []uuid.UUID{byUser["dallas"][0], byUser["ripley"][0]}},
+		{domain.Page{Limit: 2, Offset: 4}, byUser["ripley"][4:6]},
+	}
+	for _, p := range userPages {
+		reviews, err := repos.Reviews.GetReviewsByUsernames(ctx, []string{"ripley", "dallas", "ash"}, p.page)
+		if err != nil {
+			t.Fatalf("get reviews by usernames %+v: %v", p.page, err)
+		}
+		expectIds(t, "reviews by usernames", reviewIds(reviews), p.want)
+	}
+
+	reviews, err := repos.Reviews.GetReviewsByMovieIds(ctx, nil, domain.Page{})
+	if err != nil || len(reviews) != 0 {
+		t.Fatalf("no movie ids: expected no reviews, got %d (%v)", len(reviews), err)
+	}
+	reviews, err = repos.Reviews.GetReviewsByUsernames(ctx, nil, domain.Page{})
+	if err != nil || len(reviews) != 0 {
+		t.Fatalf("no usernames: expected no reviews, got %d (%v)", len(reviews), err)
+	}
+}
+
+func testAddReviewsAndGetAfter(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	movie := addMovie(t, repos, "Alien")
+	ids := orderedIds(4)
+
+	reviews := []domain.Review{
+		{ID: ids[2], Username: "a", Rating: 5, MovieID: movie.ID},
+		{ID: ids[0], Username: "b", Rating: 6, MovieID: movie.ID},
+		{ID: ids[3], Username: "c", Rating: 7, MovieID: movie.ID},
+	}
+	inserted, err := repos.Reviews.AddReviews(ctx, reviews)
+	if err != nil {
+		t.Fatalf("add reviews: %v", err)
+	}
+	if inserted != 3 {
+		t.Fatalf("expected 3 inserted, got %d", inserted)
+	}
+
+	// one bad review: the whole batch is refused
+	_, err = repos.Reviews.AddReviews(ctx, []domain.Review{
+		{ID: ids[1], Username: "d", Rating: 8, MovieID: movie.ID},
+		{ID: uuid.New(), Username: "e", Rating: 8, MovieID: uuid.New()},
+	})
+	if err == nil {
+		t.Fatal("expected an error for a review of an unknown movie")
+	}
+
+	// the existing ids are skipped
+	inserted, err = repos.Reviews.AddReviews(ctx, []domain.Review{
+		{ID: ids[0], Username: "changed", Rating: 1, MovieID: movie.ID},
+		{ID: ids[1], Username: "d", Rating: 8, MovieID: movie.ID},
+	})
+	if err != nil {
+		t.Fatalf("add reviews again: %v", err)
+	}
+	if inserted != 1 {
+		t.Fatalf("expected 1 inserted, got %d", inserted)
+	}
+
+	existing, err := repos.Reviews.GetExistingReviewIds(ctx, []uuid.UUID{ids[3], uuid.New(), ids[1]})
+	if err != nil {
+		t.Fatalf("get existing review ids: %v", err)
+	}
+	expectIds(t, "existing review ids", existing, []uuid.UUID{ids[1], ids[3]})
+
+	first, err := repos.Reviews.GetReviewsAfter(ctx, uuid.Nil, 3)
+	if err != nil {
+		t.Fatalf("get reviews after: %v", err)
+	}
+	expectIds(t, "first page", reviewIds(first), ids[:3])
+	if first[0].Username != "b" {
+		t.Fatalf("existing review should be untouched, got %+v", first[0])
+	}
+
+	rest, err := repos.Reviews.GetReviewsAfter(ctx, ids[2], 3)
+	if err != nil {
+		t.Fatalf("get reviews after: %v", err)
+	}
+	expectIds(t, "second page", reviewIds(rest), ids[3:])
+}
+
+func testConcurrentReviews(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	movie := addMovie(t, repos, "Alien")
+
+	const writers, perWriter = 8, 10
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*perWriter*3)
+	for range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range perWriter {
+				_, err := repos.Reviews.AddReview(ctx, &domain.Review{Username: "crowd", Rating: 6, MovieID: movie.ID})
+				errs <- err
+				errs <- repos.Movies.AddMovieViews(ctx, movie.ID, 1)
+				// and readers in the middle
+				if _, err := repos.Movies.GetMovieWithReviews(ctx, movie.ID); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent write: %v", err)
+		}
+	}
+
+	withReviews, err := repos.Movies.GetMovieWithReviews(ctx, movie.ID)
+	if err != nil {
+		t.Fatalf("get movie with reviews: %v", err)
+	}
+	if withReviews.ReviewsCount != writers*perWriter || withReviews.Views != writers*perWriter {
+		t.Fatalf("expected %d reviews and views, got %d reviews and %d views", writers*perWriter, withReviews.ReviewsCount, withReviews.Views)
+	}
+}
@@ -0,0 +1,257 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/auth"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+func testAddAndFindUser(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := addUser(t, repos, "ripley", "ripley@nostromo.space")
+
+	if user.ID == uuid.Nil || user.Username != "ripley" || user.Email != "ripley@nostromo.space" || user.Role != domain.Regular {
+		t.Fatalf("unexpected inserted user: %+v", user)
+	}
+	// stored hashed
+	match, err := auth.ComparePassword("correct horse battery", user.PasswordHash)
+	if err != nil || !match {
+		t.Fatalf("password hash doesn't match the password (%v)", err)
+	}
+	if user.TOTPEnabled || user.TOTPSecret != "" {
+		t.Fatalf("new user should not have 2FA: %+v", user)
+	}
+
+	finders := map[string]func() (domain.User, error){
+		"by name":  func() (domain.User, error) { return repos.Users.FindUserByName(ctx, "ripley") },
+		"by email": func() (domain.User, error) { return repos.Users.FindUserByEmail(ctx, "ripley@nostromo.space") },
+		"by id":    func() (domain.User, error) { return repos.Users.FindUserById(ctx, user.ID) },
+	}
+	for name, find := range finders {
+		found, err := find()
+		if err != nil {
+			t.Fatalf("find user %s: %v", name, err)
+		}
+		if found.ID != user.ID || found.Username != user.Username || found.PasswordHash != user.PasswordHash {
+			t.Fatalf("find user %s: expected %+v, got %+v", name, user, found)
+		}
+	}
+
+	// without email: not found by ""
+	addUser(t, repos, "dallas", "")
+	notFound := map[string]func() (domain.User, error){
+		"by name":     func() (domain.User, error) { return repos.Users.FindUserByName(ctx, "ash") },
+		"by email":    func() (domain.User, error) { return repos.Users.FindUserByEmail(ctx, "ash@nostromo.space") },
+		"empty email": func() (domain.User, error) { return repos.Users.FindUserByEmail(ctx, "") },
+		"by id":       func() (domain.User, error) { return repos.Users.FindUserById(ctx, uuid.New()) },
+	}
+	for name, find := range notFound {
+		if _, err := find(); !errors.Is(err, domain.ErrUserNotFound) {
+			t.Fatalf("find unknown user %s: expected ErrUserNotFound, got %v", name, err)
+		}
+	}
+}
+
+func testFindUsersByNames(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	ripley := addUser(t, repos, "ripley", "ripley@nostromo.space")
+	dallas := addUser(t, repos, "dallas", "")
+	addUser(t, repos, "ash", "")
+
+	// ordered by username, unknown names ignored
+	users, err := repos.Users.FindUsersByNames(ctx, []string{"ripley", "kane", "dallas"})
+	if err != nil {
+		t.Fatalf("find users by names: %v", err)
+	}
+	if len(users) != 2 || users[0].ID != dallas.ID || users[1].ID != ripley.ID || users[1].Email != ripley.Email {
+		t.Fatalf("expected dallas and ripley, got %+v", users)
+	}
+
+	users, err = repos.Users.FindUsersByNames(ctx, nil)
+	if err != nil || len(users) != 0 {
+		t.Fatalf("no names: expected no users, got %d (%v)", len(users), err)
+	}
+}
+
+func testUniqueUsers(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	addUser(t, repos, "ripley", "ripley@nostromo.space")
+
+	duplicates := map[string]domain.CreateUserRequest{
+		"username": {Username: "ripley", Password: "correct horse battery", Email: "other@nostromo.space"},
+		"email":    {Username: "ellen", Password: "correct horse battery", Email: "ripley@nostromo.space"},
+	}
+	for name, request := range duplicates {
+		if _, err := repos.Users.AddUser(ctx, request); err == nil {
+			t.Fatalf("duplicate %s: expected an error", name)
+		}
+	}
+
+	// several users can have no email
+	addUser(t, repos, "dallas", "")
+	addUser(t, repos, "kane", "")
+}
+
+func testAddUserWithRole(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	addUser(t, repos, "ripley", "")
+
+	admin, err := repos.Users.AddUserWithRole(ctx, domain.CreateUserRequest{Username: "dallas", Password: "correct horse battery"}, domain.Admin)
+	if err != nil || admin.Role != domain.Admin {
+		t.Fatalf("add admin = %+v, %v", admin, err)
+	}
+	if found, err := repos.Users.FindUserById(ctx, admin.ID); err != nil || found.Role != domain.Admin {
+		t.Fatalf("stored admin = %+v, %v", found, err)
+	}
+
+	// nothing is left behind when the insert fails
+	_, err = repos.Users.AddUserWithRole(ctx, domain.CreateUserRequest{Username: "ripley", Password: "correct horse battery"}, domain.Admin)
+	if err == nil {
+		t.Fatal("duplicate username: expected an error")
+	}
+	if found, err := repos.Users.FindUserByName(ctx, "ripley"); err != nil || found.Role != domain.Regular {
+		t.Fatalf("existing user after a failed add = %+v, %v, want it untouched", found, err)
+	}
+}
+
+func testUpdateUser(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := addUser(t, repos, "ripley", "")
+
+	if err := repos.Users.UpdatePasswordHash(ctx, user.ID, "new-hash"); err != nil {
+		t.Fatalf("update password hash: %v", err)
+	}
+	if err := repos.Users.UpdateUserRole(ctx, user.ID, domain.Admin); err != nil {
+		t.Fatalf("update role: %v", err)
+	}
+
+	found, err := repos.Users.FindUserById(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("find user: %v", err)
+	}
+	if found.PasswordHash != "new-hash" || found.Role != domain.Admin {
+		t.Fatalf("user not updated: %+v", found)
+	}
+
+	// no rows updated is not an error
+	if err := repos.Users.UpdateUserRole(ctx, uuid.New(), domain.Admin); err != nil {
+		t.Fatalf("update unknown user: %v", err)
+	}
+}
+
+func testUserTOTP(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := addUser(t, repos, "ripley", "")
+
+	findUser := func() domain.User {
+		t.Helper()
+		found, err := repos.Users.FindUserById(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("find user: %v", err)
+		}
+		return found
+	}
+	useCode := func(codeHash string, want bool) {
+		t.Helper()
+		used, err := repos.Users.UseRecoveryCode(ctx, user.ID, codeHash)
+		if err != nil {
+			t.Fatalf("use recovery code: %v", err)
+		}
+		if used != want {
+			t.Fatalf("use recovery code %q: expected %v, got %v", codeHash, want, used)
+		}
+	}
+
+	if err := repos.Users.SetTOTPSecret(ctx, user.ID, "SECRET"); err != nil {
+		t.Fatalf("set totp secret: %v", err)
+	}
+	if found := findUser(); found.TOTPSecret != "SECRET" || found.TOTPEnabled {
+		t.Fatalf("expected a pending enrollment, got %+v", found)
+	}
+
+	if err := repos.Users.EnableTOTP(ctx, user.ID, []string{"code-1", "code-2"}); err != nil {
+		t.Fatalf("enable totp: %v", err)
+	}
+	if found := findUser(); !found.TOTPEnabled {
+		t.Fatal("expected 2FA enabled")
+	}
+
+	// single use
+	useCode("code-1", true)
+	useCode("code-1", false)
+	useCode("unknown", false)
+
+	// enabling again replaces the codes
+	if err := repos.Users.EnableTOTP(ctx, user.ID, []string{"code-3"}); err != nil {
+		t.Fatalf("enable totp again: %v", err)
+	}
+	useCode("code-2", false)
+	useCode("code-3", true)
+
+	if err := repos.Users.EnableTOTP(ctx, user.ID, []string{"code-4"}); err != nil {
+		t.Fatalf("enable totp again: %v", err)
+	}
+	if err := repos.Users.DisableTOTP(ctx, user.ID); err != nil {
+		t.Fatalf("disable totp: %v", err)
+	}
+	if found := findUser(); found.TOTPEnabled || found.TOTPSecret != "" {
+		t.Fatalf("expected 2FA disabled, got %+v", found)
+	}
+	useCode("code-4", false)
+}
+
+func testUserIdentities(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	const issuer = "https://accounts.example.com"
+
+	user, err := repos.Users.AddUserWithIdentity(ctx,
+		domain.CreateUserRequest{Username: "ripley", Password: "correct horse battery", Email: "ripley@nostromo.space"},
+		domain.UserIdentity{Provider: issuer, Subject: "sub-1", Email: "ripley@nostromo.space"},
+	)
+	if err != nil {
+		t.Fatalf("add user with identity: %v", err)
+	}
+
+	found, err := repos.Users.FindUserByIdentity(ctx, issuer, "sub-1")
+	if err != nil {
+		t.Fatalf("find user by identity: %v", err)
+	}
+	if found.ID != user.ID {
+		t.Fatalf("expected user %s, got %s", user.ID, found.ID)
+	}
+
+	// the identity is taken: no user is created either
+	_, err = repos.Users.AddUserWithIdentity(ctx,
+		domain.CreateUserRequest{Username: "impostor", Password: "correct horse battery"},
+		domain.UserIdentity{Provider: issuer, Subject: "sub-1"},
+	)
+	if err == nil {
+		t.Fatal("expected an error for an identity already linked")
+	}
+	if _, err := repos.Users.FindUserByName(ctx, "impostor"); !errors.Is(err, domain.ErrUserNotFound) {
+		t.Fatalf("the user should not have been created, got %v", err)
+	}
+
+	// a second provider account for the same user
+	if err := repos.Users.LinkIdentity(ctx, domain.UserIdentity{Provider: issuer, Subject: "sub-2", UserID: user.ID}); err != nil {
+		t.Fatalf("link identity: %v", err)
+	}
+	if found, err := repos.Users.FindUserByIdentity(ctx, issuer, "sub-2"); err != nil || found.ID != user.ID {
+		t.Fatalf("find linked identity: %v (%s)", err, found.ID)
+	}
+	if err := repos.Users.LinkIdentity(ctx, domain.UserIdentity{Provider: issuer, Subject: "sub-2", UserID: user.ID}); err == nil {
+		t.Fatal("expected an error linking the same identity twice")
+	}
+	if err := repos.Users.LinkIdentity(ctx, domain.UserIdentity{Provider: issuer, Subject: "sub-3", UserID: uuid.New()}); err == nil {
+		t.Fatal("expected an error linking an identity to an unknown user")
+	}
+
+	// same subject at another provider is another account
+	if _, err := repos.Users.FindUserByIdentity(ctx, "https://other.example.com", "sub-1"); !errors.Is(err, domain.ErrUserNotFound) {
+		t.Fatalf("expected ErrUserNotFound, got %v", err)
+	}
+}
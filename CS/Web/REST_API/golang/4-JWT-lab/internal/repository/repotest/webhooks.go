@@ -0,0 +1,198 @@
+package repotest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+func testWebhooks(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	admin := addUser(t, repos, "ripley", "")
+
+	reviews, err := repos.Webhooks.AddWebhook(ctx, domain.Webhook{URL: "https://example.com/reviews", Events: []string{domain.EventReviewCreated}, Secret: "secret-1", CreatedBy: &admin.ID})
+	if err != nil {
+		t.Fatalf("add webhook: %v", err)
+	}
+	if reviews.ID == uuid.Nil || reviews.Secret != "secret-1" || reviews.CreatedBy == nil || *reviews.CreatedBy != admin.ID || reviews.CreatedAt.IsZero() {
+		t.Fatalf("added webhook = %+v", reviews)
+	}
+	everything, err := repos.Webhooks.AddWebhook(ctx, domain.Webhook{URL: "https://example.com/all", Events: domain.WebhookEvents, Secret: "secret-2"})
+	if err != nil {
+		t.Fatalf("add webhook without creator: %v", err)
+	}
+	// created_by REFERENCES users
+	unknown := uuid.New()
+	if _, err := repos.Webhooks.AddWebhook(ctx, domain.Webhook{URL: "https://example.com", Events: []string{domain.EventMovieCreated}, Secret: "s", CreatedBy: &unknown}); err == nil {
+		t.Fatal("expected an error for an unknown creator")
+	}
+
+	got, err := repos.Webhooks.GetWebhookById(ctx, reviews.ID)
+	if err != nil || got.URL != reviews.URL || len(got.Events) != 1 || got.Events[0] != domain.EventReviewCreated {
+		t.Fatalf("get webhook = %+v (%v)", got, err)
+	}
+	if _, err := repos.Webhooks.GetWebhookById(ctx, uuid.New()); !errors.Is(err, domain.ErrWebhookNotFound) {
+		t.Fatalf("get unknown webhook: expected ErrWebhookNotFound, got %v", err)
+	}
+
+	listed, err := repos.Webhooks.ListWebhooks(ctx)
+	if err != nil || len(listed) != 2 || listed[0].ID != everything.ID {
+		t.Fatalf("list webhooks (newest first) = %+v (%v)", listed, err)
+	}
+
+	subscribed, err := repos.Webhooks.FindWebhooksByEvent(ctx, domain.EventReviewCreated)
+	if err != nil || len(subscribed) != 2 || subscribed[0].ID != reviews.ID {
+		t.Fatalf("review.created webhooks (oldest first) = %+v (%v)", subscribed, err)
+	}
+	subscribed, err = repos.Webhooks.FindWebhooksByEvent(ctx, domain.EventMovieDeleted)
+	if err != nil || len(subscribed) != 1 || subscribed[0].ID != everything.ID || subscribed[0].Secret != "secret-2" {
+		t.Fatalf("movie.deleted webhooks = %+v (%v)", subscribed, err)
+	}
+	// exact match only
+	if subscribed, _ := repos.Webhooks.FindWebhooksByEvent(ctx, "review"); len(subscribed) != 0 {
+		t.Fatalf("\"review\" webhooks = %+v", subscribed)
+	}
+
+	if err := repos.Webhooks.DeleteWebhook(ctx, reviews.ID); err != nil {
+		t.Fatalf("delete webhook: %v", err)
+	}
+	if err := repos.Webhooks.DeleteWebhook(ctx, reviews.ID); !errors.Is(err, domain.ErrWebhookNotFound) {
+		t.Fatalf("delete webhook again: expected ErrWebhookNotFound, got %v", err)
+	}
+	if listed, _ := repos.Webhooks.ListWebhooks(ctx); len(listed) != 1 {
+		t.Fatalf("webhooks after the delete = %+v", listed)
+	}
+}
+
+func testWebhookDeliveries(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	start := time.Now().UTC().Truncate(time.Second)
+
+	webhook, err := repos.Webhooks.AddWebhook(ctx, domain.Webhook{URL: "https://example.com/hook", Events: domain.WebhookEvents, Secret: "secret"})
+	if err != nil {
+		t.Fatalf("add webhook: %v", err)
+	}
+
+	payload := json.RawMessage(`{"type":"review.created"}`)
+	deliveries := make([]domain.WebhookDelivery, 3)
+	for idx := range deliveries {
+		deliveries[idx] = domain.WebhookDelivery{
+			ID:            uuid.New(),
+			WebhookID:     webhook.ID,
+			EventID:       uuid.New(),
+			EventType:     domain.EventReviewCreated,
+			Payload:       payload,
+			NextAttemptAt: start.Add(time.Duration(idx-2) * time.Minute),
+		}
+	}
+	// the last one is not due yet
+	deliveries[2].NextAttemptAt = start.Add(time.Hour)
+	if err := repos.Webhooks.AddDeliveries(ctx, deliveries); err != nil {
+		t.Fatalf("add deliveries: %v", err)
+	}
+	// deliveries.webhook_id REFERENCES webhooks, and it's all or nothing
+	orphan := domain.WebhookDelivery{ID: uuid.New(), WebhookID: uuid.New(), EventID: uuid.New(), EventType: domain.EventMovieCreated, Payload: payload, NextAttemptAt: start}
+	valid := domain.WebhookDelivery{ID: uuid.New(), WebhookID: webhook.ID, EventID: uuid.New(), EventType: domain.EventMovieCreated, Payload: payload, NextAttemptAt: start}
+	if err := repos.Webhooks.AddDeliveries(ctx, []domain.WebhookDelivery{valid, orphan}); err == nil {
+		t.Fatal("expected an error for a delivery of an unknown webhook")
+	}
+	if _, err := repos.Webhooks.GetDeliveryById(ctx, valid.ID); !errors.Is(err, domain.ErrDeliveryNotFound) {
+		t.Fatalf("the batch was partly added: %v", err)
+	}
+
+	got, err := repos.Webhooks.GetDeliveryById(ctx, deliveries[0].ID)
+	if err != nil || got.Status != domain.DeliveryPending || got.Attempts != 0 || got.LastAttemptAt != nil || got.EventID != deliveries[0].EventID {
+		t.Fatalf("get delivery = %+v (%v)", got, err)
+	}
+	var decoded map[string]string
+	if err := json.Unmarshal(got.Payload, &decoded); err != nil || decoded["type"] != domain.EventReviewCreated {
+		t.Fatalf("payload = %s (%v)", got.Payload, err)
+	}
+
+	// the due ones, oldest first, leased
+	lease := start.Add(30 * time.Second)
+	claimed, err := repos.Webhooks.ClaimDueDeliveries(ctx, start, lease, 10)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	claimedIds := make([]uuid.UUID, len(claimed))
+	for idx, delivery := range claimed {
+		claimedIds[idx] = delivery.ID
+		if !delivery.NextAttemptAt.Equal(lease) {
+			t.Fatalf("claimed delivery not leased: %+v", delivery)
+		}
+	}
+	if len(claimedIds) != 2 || !((claimedIds[0] == deliveries[0].ID && claimedIds[1] == deliveries[1].ID) || (claimedIds[0] == deliveries[1].ID && claimedIds[1] == deliveries[0].ID)) {
+		t.Fatalf("claimed = %v, want the first two deliveries", claimedIds)
+	}
+	// leased: nobody else gets them before the lease ends
+	if again, _ := repos.Webhooks.ClaimDueDeliveries(ctx, start, lease, 10); len(again) != 0 {
+		t.Fatalf("claimed twice: %+v", again)
+	}
+	if expired, _ := repos.Webhooks.ClaimDueDeliveries(ctx, lease, lease.Add(time.Minute), 1); len(expired) != 1 {
+		t.Fatalf("expired lease, limit 1: claimed %+v", expired)
+	}
+
+	// one succeeded, one is dead
+	attemptAt := start.Add(time.Second)
+	succeeded := claimed[0]
+	succeeded.Status = domain.DeliverySucceeded
+	succeeded.Attempts = 1
+	succeeded.LastAttemptAt = &attemptAt
+	succeeded.ResponseStatus = 204
+	dead := claimed[1]
+	dead.Status = domain.DeliveryDead
+	dead.Attempts = 5
+	dead.LastAttemptAt = &attemptAt
+	dead.ResponseStatus = 500
+	dead.LastError = "500 Internal Server Error"
+	for _, delivery := range []domain.WebhookDelivery{succeeded, dead} {
+		if err := repos.Webhooks.UpdateDelivery(ctx, delivery); err != nil {
+			t.Fatalf("update delivery: %v", err)
+		}
+	}
+	got, err = repos.Webhooks.GetDeliveryById(ctx, dead.ID)
+	if err != nil || got.Status != domain.DeliveryDead || got.Attempts != 5 || got.ResponseStatus != 500 || got.LastError != dead.LastError || got.LastAttemptAt == nil || !got.LastAttemptAt.Equal(attemptAt) {
+		t.Fatalf("dead delivery = %+v (%v)", got, err)
+	}
+	// only the pending ones are claimed
+	if claimed, _ := repos.Webhooks.ClaimDueDeliveries(ctx, start.Add(24*time.Hour), start.Add(25*time.Hour), 10); len(claimed) != 1 || claimed[0].ID != deliveries[2].ID {
+		t.Fatalf("claimed after the updates = %+v", claimed)
+	}
+	// status is pending, succeeded or dead
+	dead.Status = "lost"
+	if err := repos.Webhooks.UpdateDelivery(ctx, dead); err == nil {
+		t.Fatal("expected an error for an unknown status")
+	}
+
+	// the history: newest first, paginated
+	history, err := repos.Webhooks.ListDeliveries(ctx, webhook.ID, domain.Page{})
+	if err != nil || len(history) != 3 {
+		t.Fatalf("history = %+v (%v)", history, err)
+	}
+	for idx := 1; idx < len(history); idx++ {
+		if history[idx].CreatedAt.After(history[idx-1].CreatedAt) {
+			t.Fatalf("history is not newest first: %+v", history)
+		}
+	}
+	page, err := repos.Webhooks.ListDeliveries(ctx, webhook.ID, domain.Page{Limit: 2, Offset: 1})
+	if err != nil || len(page) != 2 || page[0].ID != history[1].ID || page[1].ID != history[2].ID {
+		t.Fatalf("history page = %+v (%v)", page, err)
+	}
+	if other, _ := repos.Webhooks.ListDeliveries(ctx, uuid.New(), domain.Page{}); len(other) != 0 {
+		t.Fatalf("history of an unknown webhook = %+v", other)
+	}
+
+	// the deliveries go with their webhook
+	if err := repos.Webhooks.DeleteWebhook(ctx, webhook.ID); err != nil {
+		t.Fatalf("delete webhook: %v", err)
+	}
+	if _, err := repos.Webhooks.GetDeliveryById(ctx, deliveries[0].ID); !errors.Is(err, domain.ErrDeliveryNotFound) {
+		t.Fatalf("delivery of a deleted webhook: expected ErrDeliveryNotFound, got %v", err)
+	}
+}
@@ -10,5 +10,14 @@ import (
 type ReviewRepository interface {
 	AddReview(ctx context.Context, review *domain.Review) (domain.Review, error)
 	GetAllReviews(ctx context.Context) ([]domain.Review, error)
-	GetAllReviewsByMovieId(ctx context.Context, movieId uuid.UUID) ([]domain.Review, error)
+	GetAllReviewsByMovieId(ctx context.Context, movieId uuid.UUID, page domain.Page) ([]domain.Review, error)
+	// the reviews of many movies (users) in one query, ordered by movie (user)
+	// then id. the page applies to each movie (user): its first page of reviews
+	GetReviewsByMovieIds(ctx context.Context, movieIds []uuid.UUID, page domain.Page) ([]domain.Review, error)
+	GetReviewsByUsernames(ctx context.Context, usernames []string, page domain.Page) ([]domain.Review, error)
+	// same as MovieRepository.AddMovies
+	AddReviews(ctx context.Context, reviews []domain.Review) (int, error)
+	GetReviewsAfter(ctx context.Context, after uuid.UUID, limit int) ([]domain.Review, error)
+	// same as MovieRepository.GetExistingMovieIds
+	GetExistingReviewIds(ctx context.Context, ids []uuid.UUID) ([]uuid.UUID, error)
 }
@@ -0,0 +1,142 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/database"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+const apiKeyColumns = "id, user_id, name, prefix, scopes, created_at, expires_at, last_used_at, revoked_at"
+
+const addApiKey = `INSERT INTO api_keys (id, user_id, name, prefix, key_hash, scopes, created_at, expires_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING ` + apiKeyColumns
+
+const findApiKeyByHash = `SELECT k.id, k.user_id, k.name, k.prefix, k.scopes, k.created_at, k.expires_at, k.last_used_at, k.revoked_at,
+  u.role AS owner_role
+FROM api_keys k
+  JOIN users u ON u.id = k.user_id
+WHERE k.key_hash = ?`
+
+const listApiKeys = `SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY created_at DESC`
+
+const revokeApiKey = `UPDATE api_keys SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`
+
+const touchApiKey = `UPDATE api_keys SET last_used_at = ? WHERE id = ?`
+
+type SQLiteAPIKeyRepository struct {
+	db database.DBTX
+}
+
+func NewSQLiteAPIKeyRepository(db database.DBTX) *SQLiteAPIKeyRepository {
+	return &SQLiteAPIKeyRepository{
+		db: db,
+	}
+}
+
+func (r *SQLiteAPIKeyRepository) AddAPIKey(ctx context.Context, key domain.APIKey, keyHash string) (domain.APIKey, error) {
+	scopes, err := scopesToJSON(key.Scopes)
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+
+	// created_at from here rather than CURRENT_TIMESTAMP: it only has seconds
+	row := r.db.QueryRowContext(ctx, addApiKey, uuid.New(), key.UserID, key.Name, key.Prefix, keyHash, scopes, now(), timePtrToNull(key.ExpiresAt))
+	return scanAPIKey(row)
+}
+
+func (r *SQLiteAPIKeyRepository) FindAPIKeyByHash(ctx context.Context, keyHash string) (domain.APIKey, error) {
+	var ownerRole sql.NullString
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, findApiKeyByHash, keyHash), &ownerRole)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.APIKey{}, domain.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+
+	if ownerRole.Valid {
+		key.OwnerRole = domain.Role(ownerRole.String)
+	}
+
+	return key, nil
+}
+
+func (r *SQLiteAPIKeyRepository) ListAPIKeys(ctx context.Context) ([]domain.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, listApiKeys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []domain.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (r *SQLiteAPIKeyRepository) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, revokeApiKey, now(), id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	// unknown or already revoked
+	if rows == 0 {
+		return domain.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+func (r *SQLiteAPIKeyRepository) TouchAPIKey(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, touchApiKey, usedAt.UTC(), id)
+	return err
+}
+
+// -------- helpers (mappers)
+
+// scans the apiKeyColumns, then extra (the columns after them)
+func scanAPIKey(row scanner, extra ...any) (domain.APIKey, error) {
+	var key domain.APIKey
+	var scopes string
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+	dest := []any{&key.ID, &key.UserID, &key.Name, &key.Prefix, &scopes, &key.CreatedAt, &expiresAt, &lastUsedAt, &revokedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return domain.APIKey{}, err
+	}
+
+	if err := json.Unmarshal([]byte(scopes), &key.Scopes); err != nil {
+		return domain.APIKey{}, err
+	}
+	key.ExpiresAt = nullTimeToPtr(expiresAt)
+	key.LastUsedAt = nullTimeToPtr(lastUsedAt)
+	key.RevokedAt = nullTimeToPtr(revokedAt)
+
+	return key, nil
+}
+
+// a JSON array, never null
+func scopesToJSON(scopes []domain.Permission) (string, error) {
+	if scopes == nil {
+		scopes = []domain.Permission{}
+	}
+
+	data, err := json.Marshal(scopes)
+	return string(data), err
+}
@@ -0,0 +1,124 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/database"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+const addAuditEvent = `INSERT INTO audit_events (id, occurred_at, actor_id, actor_name, api_key_id, action, target_type, target_id, ip, user_agent, outcome, detail)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// same filters as sql/queries/audit_events.sql: a NULL parameter is "no filter"
+const listAuditEvents = `SELECT id, occurred_at, actor_id, actor_name, api_key_id, action, target_type, target_id, ip, user_agent, outcome, detail
+FROM audit_events
+WHERE (?1 IS NULL OR actor_id = ?1)
+  AND (?2 IS NULL OR action = ?2)
+  AND (?3 IS NULL OR outcome = ?3)
+  AND (?4 IS NULL OR target_type = ?4)
+  AND (?5 IS NULL OR target_id = ?5)
+  AND (?6 IS NULL OR occurred_at >= ?6)
+  AND (?7 IS NULL OR occurred_at < ?7)
+ORDER BY occurred_at DESC, id DESC
+LIMIT ?8 OFFSET ?9`
+
+type SQLiteAuditRepository struct {
+	db database.DBTX
+}
+
+func NewSQLiteAuditRepository(db database.DBTX) *SQLiteAuditRepository {
+	return &SQLiteAuditRepository{
+		db: db,
+	}
+}
+
+func (r *SQLiteAuditRepository) AddAuditEvent(ctx context.Context, event domain.AuditEvent) error {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+
+	_, err := r.db.ExecContext(ctx, addAuditEvent,
+		event.ID,
+		event.OccurredAt.UTC(),
+		uuidPtrToNull(event.ActorID),
+		toNullString(event.ActorName),
+		uuidPtrToNull(event.APIKeyID),
+		event.Action,
+		toNullString(event.TargetType),
+		toNullString(event.TargetID),
+		toNullString(event.IP),
+		toNullString(event.UserAgent),
+		string(event.Outcome),
+		toNullString(event.Detail),
+	)
+	return err
+}
+
+func (r *SQLiteAuditRepository) ListAuditEvents(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEvent, error) {
+	rows, err := r.db.QueryContext(ctx, listAuditEvents,
+		uuidPtrToNull(filter.ActorID),
+		toNullString(filter.Action),
+		toNullString(string(filter.Outcome)),
+		toNullString(filter.TargetType),
+		toNullString(filter.TargetID),
+		timePtrToNull(filter.Since),
+		timePtrToNull(filter.Until),
+		filter.Limit,
+		filter.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []domain.AuditEvent{}
+	for rows.Next() {
+		event, err := scanAuditEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// -------- helpers (mappers)
+func scanAuditEvent(row scanner) (domain.AuditEvent, error) {
+	var event domain.AuditEvent
+	var actorId, apiKeyId uuid.NullUUID
+	var actorName, targetType, targetId, ip, userAgent, detail sql.NullString
+	var outcome string
+	err := row.Scan(
+		&event.ID,
+		&event.OccurredAt,
+		&actorId,
+		&actorName,
+		&apiKeyId,
+		&event.Action,
+		&targetType,
+		&targetId,
+		&ip,
+		&userAgent,
+		&outcome,
+		&detail,
+	)
+	if err != nil {
+		return domain.AuditEvent{}, err
+	}
+
+	event.ActorID = nullUUIDToPtr(actorId)
+	event.ActorName = actorName.String
+	event.APIKeyID = nullUUIDToPtr(apiKeyId)
+	event.TargetType = targetType.String
+	event.TargetID = targetId.String
+	event.IP = ip.String
+	event.UserAgent = userAgent.String
+	event.Outcome = domain.AuditOutcome(outcome)
+	event.Detail = detail.String
+
+	return event, nil
+}
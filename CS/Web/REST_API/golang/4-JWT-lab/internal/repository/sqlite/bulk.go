@@ -0,0 +1,75 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/database"
+)
+
+// insertRows is a multi-row INSERT ... VALUES (...), (...) ON CONFLICT DO NOTHING,
+// like the Postgres one. one statement: all the rows or none. returns the ids
+// of the rows actually inserted.
+// SQLite takes at most 32766 parameters, keep len(rows) * len(columns) below that.
+func insertRows(ctx context.Context, db database.DBTX, table string, columns []string, rows [][]any) ([]uuid.UUID, error) {
+	if len(rows) == 0 {
+		return []uuid.UUID{}, nil
+	}
+
+	var query strings.Builder
+	fmt.Fprintf(&query, "INSERT INTO %s (%s) VALUES ", table, strings.Join(columns, ", "))
+
+	args := make([]any, 0, len(rows)*len(columns))
+	for rowIdx, row := range rows {
+		if rowIdx > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString("(")
+		for colIdx, value := range row {
+			if colIdx > 0 {
+				query.WriteString(", ")
+			}
+			args = append(args, value)
+			query.WriteString("?")
+		}
+		query.WriteString(")")
+	}
+	// re-importing an export is a no-op
+	query.WriteString(" ON CONFLICT (id) DO NOTHING RETURNING id")
+
+	result, err := db.QueryContext(ctx, query.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+
+	inserted := []uuid.UUID{}
+	for result.Next() {
+		var id uuid.UUID
+		if err := result.Scan(&id); err != nil {
+			return nil, err
+		}
+		inserted = append(inserted, id)
+	}
+	return inserted, result.Err()
+}
+
+// insertedOnly keeps the rows whose id insertRows returned, in their input
+// order (the order of their events). a duplicated id: its first row.
+func insertedOnly[T any](rows []T, inserted []uuid.UUID, id func(T) uuid.UUID) []T {
+	pending := make(map[uuid.UUID]bool, len(inserted))
+	for _, insertedId := range inserted {
+		pending[insertedId] = true
+	}
+
+	kept := make([]T, 0, len(inserted))
+	for _, row := range rows {
+		if pending[id(row)] {
+			delete(pending, id(row))
+			kept = append(kept, row)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,256 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/database"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+const movieColumns = "id, title, director, year, views"
+
+// lower() only folds ASCII here, like Postgres with COLLATE "C"
+const getMovies = `SELECT ` + movieColumns + ` FROM movies ORDER BY lower(title), id LIMIT ? OFFSET ?`
+
+const getMovieById = `SELECT ` + movieColumns + ` FROM movies WHERE id = ?`
+
+const addMovie = `INSERT INTO movies (id, title, director, year) VALUES (?, ?, ?, ?) RETURNING ` + movieColumns
+
+const updateMovieTitleById = `UPDATE movies SET title = ? WHERE id = ? RETURNING ` + movieColumns
+
+const deleteMovieById = `DELETE FROM movies WHERE id = ?`
+
+const getMovieWithReviews = `SELECT m.id, m.title, m.director, m.year, m.views,
+  COALESCE(AVG(r.rating), 0.0) AS avg_rating,
+  COUNT(r.id) AS reviews_count
+FROM movies m
+  LEFT JOIN reviews r ON m.id = r.movie_id
+WHERE m.id = ?
+GROUP BY m.id`
+
+// the ids as a JSON array: SQLite has no array parameters
+const getMoviesWithReviewsByIds = `SELECT m.id, m.title, m.director, m.year, m.views,
+  COALESCE(AVG(r.rating), 0.0) AS avg_rating,
+  COUNT(r.id) AS reviews_count
+FROM movies m
+  LEFT JOIN reviews r ON m.id = r.movie_id
+WHERE m.id IN (SELECT value FROM json_each(?))
+GROUP BY m.id
+ORDER BY m.id`
+
+const addMovieViews = `UPDATE movies SET views = views + ? WHERE id = ?`
+
+const getPopularMovies = `SELECT ` + movieColumns + ` FROM movies ORDER BY views DESC, id LIMIT ? OFFSET ?`
+
+const getMoviesAfter = `SELECT ` + movieColumns + ` FROM movies WHERE id > ? ORDER BY id LIMIT ?`
+
+const getExistingMovieIds = `SELECT id FROM movies WHERE id IN (SELECT value FROM json_each(?)) ORDER BY id`
+
+type SQLiteMovieRepository struct {
+	db database.DBTX
+}
+
+func NewSQLiteMovieRepository(db database.DBTX) *SQLiteMovieRepository {
+	return &SQLiteMovieRepository{
+		db: db,
+	}
+}
+
+func (r *SQLiteMovieRepository) GetAllMovies(ctx context.Context, page domain.Page) ([]*domain.Movie, error) {
+	return r.queryMovies(ctx, getMovies, pageLimit(page), page.Offset)
+}
+
+func (r *SQLiteMovieRepository) GetMovieById(ctx context.Context, id uuid.UUID) (*domain.Movie, error) {
+	movie, err := scanMovie(r.db.QueryRowContext(ctx, getMovieById, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrMovieNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return movie, nil
+}
+
+func (r *SQLiteMovieRepository) AddMovie(ctx context.Context, movie *domain.Movie) (*domain.Movie, error) {
+	var insertedMovie *domain.Movie
+	// the movie and its event commit together
+	err := withTx(ctx, r.db, func(tx database.DBTX) error {
+		var err error
+		insertedMovie, err = scanMovie(tx.QueryRowContext(ctx, addMovie, uuid.New(), movie.Title, movie.Director, movie.Year))
+		if err != nil {
+			return err
+		}
+
+		return addOutboxEvent(ctx, tx, domain.NewMovieCreatedEvent(insertedMovie))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return insertedMovie, nil
+}
+
+func (r *SQLiteMovieRepository) UpdateMovieTitleById(ctx context.Context, id uuid.UUID, title string) error {
+	return withTx(ctx, r.db, func(tx database.DBTX) error {
+		updatedMovie, err := scanMovie(tx.QueryRowContext(ctx, updateMovieTitleById, title, id))
+		// updating an unknown movie is a no-op: no event
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		return addOutboxEvent(ctx, tx, domain.NewMovieUpdatedEvent(updatedMovie))
+	})
+}
+
+func (r *SQLiteMovieRepository) DeleteMovieById(ctx context.Context, id uuid.UUID) error {
+	return withTx(ctx, r.db, func(tx database.DBTX) error {
+		result, err := tx.ExecContext(ctx, deleteMovieById, id)
+		if err != nil {
+			return err
+		}
+		// deleting an unknown movie is a no-op: no event
+		if deleted, err := result.RowsAffected(); err != nil || deleted == 0 {
+			return err
+		}
+
+		return addOutboxEvent(ctx, tx, domain.NewMovieDeletedEvent(id))
+	})
+}
+
+func (r *SQLiteMovieRepository) GetMovieWithReviews(ctx context.Context, id uuid.UUID) (*domain.Movie, error) {
+	movie, err := scanMovieWithReviews(r.db.QueryRowContext(ctx, getMovieWithReviews, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrMovieNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return movie, nil
+}
+
+func (r *SQLiteMovieRepository) GetMoviesWithReviewsByIds(ctx context.Context, ids []uuid.UUID) ([]*domain.Movie, error) {
+	jsonIds, err := jsonArray(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, getMoviesWithReviewsByIds, jsonIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	moviesList := []*domain.Movie{}
+	for rows.Next() {
+		movie, err := scanMovieWithReviews(rows)
+		if err != nil {
+			return nil, err
+		}
+		moviesList = append(moviesList, movie)
+	}
+
+	return moviesList, rows.Err()
+}
+
+func (r *SQLiteMovieRepository) AddMovieViews(ctx context.Context, id uuid.UUID, views int64) error {
+	_, err := r.db.ExecContext(ctx, addMovieViews, views, id)
+	return err
+}
+
+func (r *SQLiteMovieRepository) GetPopularMovies(ctx context.Context, page domain.Page) ([]*domain.Movie, error) {
+	return r.queryMovies(ctx, getPopularMovies, pageLimit(page), page.Offset)
+}
+
+func (r *SQLiteMovieRepository) AddMovies(ctx context.Context, movies []*domain.Movie) (int, error) {
+	rows := make([][]any, len(movies))
+	for idx, movie := range movies {
+		rows[idx] = []any{movie.ID, movie.Title, movie.Director, movie.Year}
+	}
+
+	inserted := 0
+	err := withTx(ctx, r.db, func(tx database.DBTX) error {
+		ids, err := insertRows(ctx, tx, "movies", []string{"id", "title", "director", "year"}, rows)
+		if err != nil {
+			return err
+		}
+		inserted = len(ids)
+
+		// like AddMovie: an event per movie actually inserted, in the transaction
+		for _, movie := range insertedOnly(movies, ids, func(movie *domain.Movie) uuid.UUID { return movie.ID }) {
+			if err := addOutboxEvent(ctx, tx, domain.NewMovieCreatedEvent(movie)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return inserted, err
+}
+
+func (r *SQLiteMovieRepository) GetExistingMovieIds(ctx context.Context, ids []uuid.UUID) ([]uuid.UUID, error) {
+	return queryIds(ctx, r.db, getExistingMovieIds, ids)
+}
+
+func (r *SQLiteMovieRepository) GetMoviesAfter(ctx context.Context, after uuid.UUID, limit int) ([]*domain.Movie, error) {
+	return r.queryMovies(ctx, getMoviesAfter, after, limit)
+}
+
+// -------- helpers
+func (r *SQLiteMovieRepository) queryMovies(ctx context.Context, query string, args ...any) ([]*domain.Movie, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	moviesList := []*domain.Movie{}
+	for rows.Next() {
+		movie, err := scanMovie(rows)
+		if err != nil {
+			return nil, err
+		}
+		moviesList = append(moviesList, movie)
+	}
+
+	return moviesList, rows.Err()
+}
+
+// *sql.Row or *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanMovie(row scanner) (*domain.Movie, error) {
+	var movie domain.Movie
+	err := row.Scan(&movie.ID, &movie.Title, &movie.Director, &movie.Year, &movie.Views)
+	if err != nil {
+		return nil, err
+	}
+
+	return &movie, nil
+}
+
+func scanMovieWithReviews(row scanner) (*domain.Movie, error) {
+	var movie domain.Movie
+	err := row.Scan(
+		&movie.ID,
+		&movie.Title,
+		&movie.Director,
+		&movie.Year,
+		&movie.Views,
+		&movie.AverageRating,
+		&movie.ReviewsCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &movie, nil
+}
@@ -0,0 +1,128 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/database"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+const addOutboxEventQuery = `INSERT INTO outbox (id, event_type, aggregate_type, aggregate_id, payload, occurred_at)
+VALUES (?, ?, ?, ?, ?, ?)`
+
+const getUnpublishedOutboxEvents = `SELECT sequence, id, event_type, aggregate_type, aggregate_id, payload, occurred_at, attempts, last_error
+FROM outbox
+WHERE published_at IS NULL
+ORDER BY sequence
+LIMIT ?`
+
+const markOutboxEventPublished = `UPDATE outbox SET published_at = ? WHERE id = ?`
+
+const markOutboxEventFailed = `UPDATE outbox SET attempts = attempts + 1, last_error = ? WHERE id = ?`
+
+const deletePublishedOutboxEvents = `DELETE FROM outbox WHERE published_at < ?`
+
+const deleteUnpublishedOutboxEvents = `DELETE FROM outbox WHERE published_at IS NULL AND occurred_at < ?`
+
+type SQLiteOutboxRepository struct {
+	db database.DBTX
+}
+
+func NewSQLiteOutboxRepository(db database.DBTX) *SQLiteOutboxRepository {
+	return &SQLiteOutboxRepository{
+		db: db,
+	}
+}
+
+func (r *SQLiteOutboxRepository) GetUnpublishedEvents(ctx context.Context, limit int) ([]domain.OutboxEvent, error) {
+	rows, err := r.db.QueryContext(ctx, getUnpublishedOutboxEvents, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []domain.OutboxEvent{}
+	for rows.Next() {
+		var event domain.OutboxEvent
+		var payload string
+		var lastError sql.NullString
+		if err := rows.Scan(
+			&event.Sequence,
+			&event.ID,
+			&event.Type,
+			&event.AggregateType,
+			&event.AggregateID,
+			&payload,
+			&event.OccurredAt,
+			&event.Attempts,
+			&lastError,
+		); err != nil {
+			return nil, err
+		}
+		event.Data = []byte(payload)
+		event.LastError = lastError.String
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func (r *SQLiteOutboxRepository) MarkEventPublished(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, markOutboxEventPublished, now(), id)
+	return err
+}
+
+func (r *SQLiteOutboxRepository) MarkEventFailed(ctx context.Context, id uuid.UUID, reason string) error {
+	_, err := r.db.ExecContext(ctx, markOutboxEventFailed, reason, id)
+	return err
+}
+
+func (r *SQLiteOutboxRepository) DeletePublishedEvents(ctx context.Context, before time.Time) (int, error) {
+	return r.deleteEvents(ctx, deletePublishedOutboxEvents, before)
+}
+
+func (r *SQLiteOutboxRepository) DeleteUnpublishedEvents(ctx context.Context, before time.Time) (int, error) {
+	return r.deleteEvents(ctx, deleteUnpublishedOutboxEvents, before)
+}
+
+func (r *SQLiteOutboxRepository) deleteEvents(ctx context.Context, query string, before time.Time) (int, error) {
+	result, err := r.db.ExecContext(ctx, query, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := result.RowsAffected()
+	return int(deleted), err
+}
+
+// a SQLite file has one writer process: the relay is alone
+func (r *SQLiteOutboxRepository) TryLockRelay(ctx context.Context) (func(), bool, error) {
+	return func() {}, true, nil
+}
+
+// the caller's transaction: the event is part of the change, and so are its
+// webhook deliveries
+func addOutboxEvent(ctx context.Context, tx database.DBTX, event domain.OutboxEvent) error {
+	_, err := tx.ExecContext(ctx, addOutboxEventQuery,
+		event.ID,
+		event.Type,
+		event.AggregateType,
+		event.AggregateID,
+		string(event.Data),
+		event.OccurredAt.UTC(),
+	)
+	if err != nil {
+		return err
+	}
+
+	// on the caller's transaction: AddDeliveries doesn't open another one
+	webhookRepo := NewSQLiteWebhookRepository(tx)
+	webhooks, err := webhookRepo.FindWebhooksByEvent(ctx, event.Type)
+	if err != nil {
+		return err
+	}
+	return webhookRepo.AddDeliveries(ctx, domain.WebhookDeliveries(event, webhooks))
+}
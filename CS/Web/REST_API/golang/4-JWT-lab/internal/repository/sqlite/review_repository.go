@@ -0,0 +1,163 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/database"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+const reviewColumns = "id, user_name, rating, comment, movie_id"
+
+const getAllReviews = `SELECT ` + reviewColumns + ` FROM reviews`
+
+const addReview = `INSERT INTO reviews (id, user_name, rating, comment, movie_id) VALUES (?, ?, ?, ?, ?) RETURNING ` + reviewColumns
+
+const getAllReviewsByMovieId = `SELECT ` + reviewColumns + ` FROM reviews WHERE movie_id = ? ORDER BY id LIMIT ? OFFSET ?`
+
+// the first page of reviews of every movie (user) of a JSON array
+const getReviewsByMovieIds = `SELECT ` + reviewColumns + ` FROM (
+  SELECT ` + reviewColumns + `, ROW_NUMBER() OVER (PARTITION BY movie_id ORDER BY id) AS position
+  FROM reviews WHERE movie_id IN (SELECT value FROM json_each(?))
+) WHERE position > ? AND (? < 0 OR position <= ? + ?)
+ORDER BY movie_id, id`
+
+const getReviewsByUsernames = `SELECT ` + reviewColumns + ` FROM (
+  SELECT ` + reviewColumns + `, ROW_NUMBER() OVER (PARTITION BY user_name ORDER BY id) AS position
+  FROM reviews WHERE user_name IN (SELECT value FROM json_each(?))
+) WHERE position > ? AND (? < 0 OR position <= ? + ?)
+ORDER BY user_name, id`
+
+const getReviewsAfter = `SELECT ` + reviewColumns + ` FROM reviews WHERE id > ? ORDER BY id LIMIT ?`
+
+const getExistingReviewIds = `SELECT id FROM reviews WHERE id IN (SELECT value FROM json_each(?)) ORDER BY id`
+
+type SQLiteReviewRepository struct {
+	db database.DBTX
+}
+
+func NewSQLiteReviewRepository(db database.DBTX) *SQLiteReviewRepository {
+	return &SQLiteReviewRepository{
+		db: db,
+	}
+}
+
+func (r *SQLiteReviewRepository) GetAllReviews(ctx context.Context) ([]domain.Review, error) {
+	return r.queryReviews(ctx, getAllReviews)
+}
+
+func (r *SQLiteReviewRepository) AddReview(ctx context.Context, review *domain.Review) (domain.Review, error) {
+	var insertedReview domain.Review
+	err := withTx(ctx, r.db, func(tx database.DBTX) error {
+		var err error
+		row := tx.QueryRowContext(ctx, addReview, uuid.New(), review.Username, review.Rating, commentToNull(review.Comment), review.MovieID)
+		insertedReview, err = scanReview(row)
+		if err != nil {
+			return err
+		}
+
+		return addOutboxEvent(ctx, tx, domain.NewReviewCreatedEvent(insertedReview))
+	})
+	if err != nil {
+		return domain.Review{}, err
+	}
+
+	return insertedReview, nil
+}
+
+func (r *SQLiteReviewRepository) GetAllReviewsByMovieId(ctx context.Context, movieId uuid.UUID, page domain.Page) ([]domain.Review, error) {
+	return r.queryReviews(ctx, getAllReviewsByMovieId, movieId, pageLimit(page), page.Offset)
+}
+
+func (r *SQLiteReviewRepository) GetReviewsByMovieIds(ctx context.Context, movieIds []uuid.UUID, page domain.Page) ([]domain.Review, error) {
+	return r.queryReviewsBy(ctx, getReviewsByMovieIds, movieIds, page)
+}
+
+func (r *SQLiteReviewRepository) GetReviewsByUsernames(ctx context.Context, usernames []string, page domain.Page) ([]domain.Review, error) {
+	return r.queryReviewsBy(ctx, getReviewsByUsernames, usernames, page)
+}
+
+func (r *SQLiteReviewRepository) AddReviews(ctx context.Context, reviews []domain.Review) (int, error) {
+	rows := make([][]any, len(reviews))
+	for idx, review := range reviews {
+		rows[idx] = []any{review.ID, review.Username, review.Rating, commentToNull(review.Comment), review.MovieID}
+	}
+
+	inserted := 0
+	err := withTx(ctx, r.db, func(tx database.DBTX) error {
+		ids, err := insertRows(ctx, tx, "reviews", []string{"id", "user_name", "rating", "comment", "movie_id"}, rows)
+		if err != nil {
+			return err
+		}
+		inserted = len(ids)
+
+		for _, review := range insertedOnly(reviews, ids, func(review domain.Review) uuid.UUID { return review.ID }) {
+			if err := addOutboxEvent(ctx, tx, domain.NewReviewCreatedEvent(review)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return inserted, err
+}
+
+func (r *SQLiteReviewRepository) GetReviewsAfter(ctx context.Context, after uuid.UUID, limit int) ([]domain.Review, error) {
+	return r.queryReviews(ctx, getReviewsAfter, after, limit)
+}
+
+func (r *SQLiteReviewRepository) GetExistingReviewIds(ctx context.Context, ids []uuid.UUID) ([]uuid.UUID, error) {
+	return queryIds(ctx, r.db, getExistingReviewIds, ids)
+}
+
+// -------- helpers
+func (r *SQLiteReviewRepository) queryReviews(ctx context.Context, query string, args ...any) ([]domain.Review, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviewsList := []domain.Review{}
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviewsList = append(reviewsList, review)
+	}
+
+	return reviewsList, rows.Err()
+}
+
+func (r *SQLiteReviewRepository) queryReviewsBy(ctx context.Context, query string, keys any, page domain.Page) ([]domain.Review, error) {
+	jsonKeys, err := jsonArray(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := pageLimit(page)
+	return r.queryReviews(ctx, query, jsonKeys, page.Offset, limit, page.Offset, limit)
+}
+
+func scanReview(row scanner) (domain.Review, error) {
+	var review domain.Review
+	var comment sql.NullString
+	err := row.Scan(&review.ID, &review.Username, &review.Rating, &comment, &review.MovieID)
+	if err != nil {
+		return domain.Review{}, err
+	}
+
+	if comment.Valid {
+		review.Comment = &comment.String
+	}
+	return review, nil
+}
+
+func commentToNull(comment *string) sql.NullString {
+	if comment == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *comment, Valid: true}
+}
@@ -0,0 +1,120 @@
+/*
+ * Concrete implementation for SQLite storage (modernc.org/sqlite, no cgo)
+ *
+ * same queries as sql/queries, written by hand for SQLite: uuids are TEXT,
+ * booleans 0/1, the api key scopes a JSON array (see db/sqlite/migrations).
+ * the timestamps are always written in UTC, as text they then sort by time.
+ */
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/database"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+// withTx runs fn in a transaction when the repository was built on a *sql.DB.
+// if db is already a *sql.Tx (caller owns the transaction), fn just runs on it.
+func withTx(ctx context.Context, db database.DBTX, fn func(tx database.DBTX) error) error {
+	sqlDB, ok := db.(*sql.DB)
+	if !ok {
+		return fn(db)
+	}
+
+	tx, err := sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// no-op once committed
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// the parameter of the `IN (SELECT value FROM json_each(?))` filters:
+// SQLite has no arrays (the uuids are TEXT, they marshal as strings)
+func jsonArray(values any) (string, error) {
+	data, err := json.Marshal(values)
+	return string(data), err
+}
+
+// LIMIT -1 is "no limit" for SQLite
+func pageLimit(page domain.Page) int {
+	if page.Limit <= 0 {
+		return -1
+	}
+	return page.Limit
+}
+
+func now() time.Time {
+	return time.Now().UTC()
+}
+
+// -------- helpers (mappers)
+
+// empty string → NULL, so the "IS NULL" filters work
+func toNullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func uuidPtrToNull(id *uuid.UUID) uuid.NullUUID {
+	if id == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: *id, Valid: true}
+}
+
+func nullUUIDToPtr(id uuid.NullUUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	return &id.UUID
+}
+
+func timePtrToNull(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+func nullTimeToPtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// runs a query filtering on the ids (json_each), returns the id column
+func queryIds(ctx context.Context, db database.DBTX, query string, ids []uuid.UUID) ([]uuid.UUID, error) {
+	jsonIds, err := jsonArray(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, jsonIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		found = append(found, id)
+	}
+
+	return found, rows.Err()
+}
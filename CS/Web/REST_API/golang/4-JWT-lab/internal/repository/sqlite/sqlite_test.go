@@ -0,0 +1,38 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/grainme/movie-api/internal/repository/repotest"
+	"github.com/grainme/movie-api/internal/storage"
+)
+
+func TestConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repositories {
+		// a new database for every test
+		s, err := storage.Open("sqlite://:memory:")
+		if err != nil {
+			t.Fatalf("open sqlite: %v", err)
+		}
+		t.Cleanup(func() { s.Close() })
+
+		m, err := s.NewMigrate("../../../db/sqlite/migrations")
+		if err != nil {
+			t.Fatalf("migrate: %v", err)
+		}
+		if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+			t.Fatalf("migrate up: %v", err)
+		}
+
+		return repotest.Repositories{
+			Movies:   s.Movies,
+			Reviews:  s.Reviews,
+			Users:    s.Users,
+			APIKeys:  s.APIKeys,
+			Audit:    s.Audit,
+			Outbox:   s.Outbox,
+			Webhooks: s.Webhooks,
+		}
+	})
+}
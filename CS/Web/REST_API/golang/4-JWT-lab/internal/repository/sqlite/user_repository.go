@@ -0,0 +1,262 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/auth"
+	"github.com/grainme/movie-api/internal/database"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+const userColumns = "id, username, password_hash, role, created_at, updated_at, email, totp_secret, totp_enabled"
+
+const addUser = `INSERT INTO users (id, username, password_hash, email, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?) RETURNING ` + userColumns
+
+const findUserBy = `SELECT ` + userColumns + ` FROM users WHERE %s = ?`
+
+const findUsersByNames = `SELECT ` + userColumns + ` FROM users WHERE username IN (SELECT value FROM json_each(?)) ORDER BY username`
+
+const findUserByIdentity = `SELECT u.id, u.username, u.password_hash, u.role, u.created_at, u.updated_at, u.email, u.totp_secret, u.totp_enabled
+FROM users u
+  JOIN user_identities i ON i.user_id = u.id
+WHERE i.provider = ? AND i.subject = ?`
+
+const addUserIdentity = `INSERT INTO user_identities (provider, subject, user_id, email, created_at) VALUES (?, ?, ?, ?, ?)`
+
+const updateUserPasswordHash = `UPDATE users SET password_hash = ?, updated_at = ? WHERE id = ?`
+
+const updateUserRole = `UPDATE users SET role = ?, updated_at = ? WHERE id = ?`
+
+const setUserTotpSecret = `UPDATE users SET totp_secret = ?, totp_enabled = 0, updated_at = ? WHERE id = ?`
+
+const enableUserTotp = `UPDATE users SET totp_enabled = 1, updated_at = ? WHERE id = ?`
+
+const disableUserTotp = `UPDATE users SET totp_secret = NULL, totp_enabled = 0, updated_at = ? WHERE id = ?`
+
+const addRecoveryCode = `INSERT INTO user_recovery_codes (id, user_id, code_hash) VALUES (?, ?, ?)`
+
+const deleteRecoveryCodesByUserId = `DELETE FROM user_recovery_codes WHERE user_id = ?`
+
+const useRecoveryCode = `UPDATE user_recovery_codes SET used_at = ? WHERE user_id = ? AND code_hash = ? AND used_at IS NULL`
+
+type SQLiteUserRepository struct {
+	db database.DBTX
+}
+
+func NewSQLiteUserRepository(db database.DBTX) *SQLiteUserRepository {
+	return &SQLiteUserRepository{
+		db: db,
+	}
+}
+
+func (r *SQLiteUserRepository) AddUser(ctx context.Context, user domain.CreateUserRequest) (domain.User, error) {
+	return addUserRow(ctx, r.db, user)
+}
+
+func (r *SQLiteUserRepository) AddUserWithRole(ctx context.Context, user domain.CreateUserRequest, role domain.Role) (domain.User, error) {
+	var createdUser domain.User
+	err := withTx(ctx, r.db, func(tx database.DBTX) error {
+		var err error
+		createdUser, err = addUserRow(ctx, tx, user)
+		if err != nil {
+			return err
+		}
+
+		createdUser.Role = role
+		_, err = tx.ExecContext(ctx, updateUserRole, string(role), now(), createdUser.ID)
+		return err
+	})
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	return createdUser, nil
+}
+
+func (r *SQLiteUserRepository) AddUserWithIdentity(ctx context.Context, user domain.CreateUserRequest, identity domain.UserIdentity) (domain.User, error) {
+	var createdUser domain.User
+	err := withTx(ctx, r.db, func(tx database.DBTX) error {
+		var err error
+		createdUser, err = addUserRow(ctx, tx, user)
+		if err != nil {
+			return err
+		}
+
+		identity.UserID = createdUser.ID
+		return addIdentity(ctx, tx, identity)
+	})
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	return createdUser, nil
+}
+
+func (r *SQLiteUserRepository) LinkIdentity(ctx context.Context, identity domain.UserIdentity) error {
+	return addIdentity(ctx, r.db, identity)
+}
+
+func (r *SQLiteUserRepository) FindUserByIdentity(ctx context.Context, provider, subject string) (domain.User, error) {
+	return findUser(r.db.QueryRowContext(ctx, findUserByIdentity, provider, subject))
+}
+
+func (r *SQLiteUserRepository) FindUserByName(ctx context.Context, username string) (domain.User, error) {
+	return findUser(r.db.QueryRowContext(ctx, fmt.Sprintf(findUserBy, "username"), username))
+}
+
+func (r *SQLiteUserRepository) FindUsersByNames(ctx context.Context, usernames []string) ([]domain.User, error) {
+	jsonNames, err := jsonArray(usernames)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, findUsersByNames, jsonNames)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []domain.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+func (r *SQLiteUserRepository) FindUserByEmail(ctx context.Context, email string) (domain.User, error) {
+	return findUser(r.db.QueryRowContext(ctx, fmt.Sprintf(findUserBy, "email"), email))
+}
+
+func (r *SQLiteUserRepository) FindUserById(ctx context.Context, id uuid.UUID) (domain.User, error) {
+	return findUser(r.db.QueryRowContext(ctx, fmt.Sprintf(findUserBy, "id"), id))
+}
+
+// the caller is responsible for hashing (see auth.HashPassword)
+func (r *SQLiteUserRepository) UpdatePasswordHash(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	_, err := r.db.ExecContext(ctx, updateUserPasswordHash, passwordHash, now(), id)
+	return err
+}
+
+func (r *SQLiteUserRepository) UpdateUserRole(ctx context.Context, id uuid.UUID, role domain.Role) error {
+	_, err := r.db.ExecContext(ctx, updateUserRole, string(role), now(), id)
+	return err
+}
+
+func (r *SQLiteUserRepository) SetTOTPSecret(ctx context.Context, id uuid.UUID, secret string) error {
+	_, err := r.db.ExecContext(ctx, setUserTotpSecret, secret, now(), id)
+	return err
+}
+
+// enabling 2FA and issuing the recovery codes must happen together:
+// 2FA on without codes (or old codes still valid) is a lockout/backdoor
+func (r *SQLiteUserRepository) EnableTOTP(ctx context.Context, id uuid.UUID, recoveryCodeHashes []string) error {
+	return withTx(ctx, r.db, func(tx database.DBTX) error {
+		if _, err := tx.ExecContext(ctx, enableUserTotp, now(), id); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, deleteRecoveryCodesByUserId, id); err != nil {
+			return err
+		}
+
+		for _, codeHash := range recoveryCodeHashes {
+			if _, err := tx.ExecContext(ctx, addRecoveryCode, uuid.New(), id, codeHash); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *SQLiteUserRepository) DisableTOTP(ctx context.Context, id uuid.UUID) error {
+	return withTx(ctx, r.db, func(tx database.DBTX) error {
+		if _, err := tx.ExecContext(ctx, disableUserTotp, now(), id); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(ctx, deleteRecoveryCodesByUserId, id)
+		return err
+	})
+}
+
+func (r *SQLiteUserRepository) UseRecoveryCode(ctx context.Context, id uuid.UUID, codeHash string) (bool, error) {
+	// the UPDATE only matches an unused code, so two concurrent logins
+	// with the same code can't both succeed
+	result, err := r.db.ExecContext(ctx, useRecoveryCode, now(), id, codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+// -------- helpers
+func addUserRow(ctx context.Context, db database.DBTX, user domain.CreateUserRequest) (domain.User, error) {
+	hashedPassword, err := auth.HashPassword(user.Password)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	createdAt := now()
+	row := db.QueryRowContext(ctx, addUser, uuid.New(), user.Username, hashedPassword, toNullString(user.Email), createdAt, createdAt)
+	return scanUser(row)
+}
+
+func addIdentity(ctx context.Context, db database.DBTX, identity domain.UserIdentity) error {
+	_, err := db.ExecContext(ctx, addUserIdentity, identity.Provider, identity.Subject, identity.UserID, toNullString(identity.Email), now())
+	return err
+}
+
+func findUser(row *sql.Row) (domain.User, error) {
+	user, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.User{}, domain.ErrUserNotFound
+	}
+	return user, err
+}
+
+// -------- helpers (mappers)
+func scanUser(row scanner) (domain.User, error) {
+	var user domain.User
+	var role, email, totpSecret sql.NullString
+	var createdAt, updatedAt sql.NullTime
+	err := row.Scan(
+		&user.ID,
+		&user.Username,
+		&user.PasswordHash,
+		&role,
+		&createdAt,
+		&updatedAt,
+		&email,
+		&totpSecret,
+		&user.TOTPEnabled,
+	)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	if !role.Valid {
+		return domain.User{}, errors.New("invalid user role")
+	}
+	user.Role = domain.Role(role.String)
+	user.Email = email.String
+	user.TOTPSecret = totpSecret.String
+	user.CreatedAt = createdAt.Time
+	user.UpdatedAt = updatedAt.Time
+
+	return user, nil
+}
@@ -0,0 +1,249 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/database"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+const webhookColumns = "id, url, events, secret, created_by, created_at"
+
+const deliveryColumns = "id, webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_attempt_at, response_status, last_error, created_at"
+
+const addWebhook = `INSERT INTO webhooks (id, url, events, secret, created_by, created_at)
+VALUES (?, ?, ?, ?, ?, ?) RETURNING ` + webhookColumns
+
+const listWebhooks = `SELECT ` + webhookColumns + ` FROM webhooks ORDER BY created_at DESC, id`
+
+const getWebhookById = `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = ?`
+
+// the events as a JSON array: SQLite has no arrays
+const findWebhooksByEvent = `SELECT ` + webhookColumns + `
+FROM webhooks
+WHERE EXISTS (SELECT 1 FROM json_each(webhooks.events) WHERE value = ?)
+ORDER BY created_at, id`
+
+const deleteWebhook = `DELETE FROM webhooks WHERE id = ?`
+
+const addWebhookDelivery = `INSERT INTO webhook_deliveries (id, webhook_id, event_id, event_type, payload, next_attempt_at, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+// no FOR UPDATE SKIP LOCKED: a SQLite database has one writer at a time
+const claimDueWebhookDeliveries = `UPDATE webhook_deliveries SET next_attempt_at = ?1
+WHERE id IN (
+  SELECT id FROM webhook_deliveries
+  WHERE status = 'pending' AND next_attempt_at <= ?2
+  ORDER BY next_attempt_at
+  LIMIT ?3
+) RETURNING ` + deliveryColumns
+
+const updateWebhookDelivery = `UPDATE webhook_deliveries
+SET status = ?, attempts = ?, next_attempt_at = ?, last_attempt_at = ?, response_status = ?, last_error = ?
+WHERE id = ?`
+
+const listWebhookDeliveries = `SELECT ` + deliveryColumns + `
+FROM webhook_deliveries
+WHERE webhook_id = ?
+ORDER BY created_at DESC, id DESC
+LIMIT ? OFFSET ?`
+
+const getWebhookDeliveryById = `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE id = ?`
+
+type SQLiteWebhookRepository struct {
+	db database.DBTX
+}
+
+func NewSQLiteWebhookRepository(db database.DBTX) *SQLiteWebhookRepository {
+	return &SQLiteWebhookRepository{
+		db: db,
+	}
+}
+
+func (r *SQLiteWebhookRepository) AddWebhook(ctx context.Context, webhook domain.Webhook) (domain.Webhook, error) {
+	events := webhook.Events
+	if events == nil {
+		events = []string{}
+	}
+	eventsJSON, err := jsonArray(events)
+	if err != nil {
+		return domain.Webhook{}, err
+	}
+
+	// created_at from here rather than CURRENT_TIMESTAMP: it only has seconds
+	row := r.db.QueryRowContext(ctx, addWebhook, uuid.New(), webhook.URL, eventsJSON, webhook.Secret, uuidPtrToNull(webhook.CreatedBy), now())
+	return scanWebhook(row)
+}
+
+func (r *SQLiteWebhookRepository) ListWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	return r.queryWebhooks(ctx, listWebhooks)
+}
+
+func (r *SQLiteWebhookRepository) GetWebhookById(ctx context.Context, id uuid.UUID) (domain.Webhook, error) {
+	webhook, err := scanWebhook(r.db.QueryRowContext(ctx, getWebhookById, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Webhook{}, domain.ErrWebhookNotFound
+	}
+	return webhook, err
+}
+
+func (r *SQLiteWebhookRepository) FindWebhooksByEvent(ctx context.Context, eventType string) ([]domain.Webhook, error) {
+	return r.queryWebhooks(ctx, findWebhooksByEvent, eventType)
+}
+
+func (r *SQLiteWebhookRepository) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, deleteWebhook, id)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return domain.ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (r *SQLiteWebhookRepository) AddDeliveries(ctx context.Context, deliveries []domain.WebhookDelivery) error {
+	createdAt := now()
+	return withTx(ctx, r.db, func(tx database.DBTX) error {
+		for _, delivery := range deliveries {
+			_, err := tx.ExecContext(ctx, addWebhookDelivery,
+				delivery.ID,
+				delivery.WebhookID,
+				delivery.EventID,
+				delivery.EventType,
+				string(delivery.Payload),
+				delivery.NextAttemptAt.UTC(),
+				createdAt,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *SQLiteWebhookRepository) ClaimDueDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	return r.queryDeliveries(ctx, claimDueWebhookDeliveries, leaseUntil.UTC(), now.UTC(), limit)
+}
+
+func (r *SQLiteWebhookRepository) UpdateDelivery(ctx context.Context, delivery domain.WebhookDelivery) error {
+	_, err := r.db.ExecContext(ctx, updateWebhookDelivery,
+		string(delivery.Status),
+		delivery.Attempts,
+		delivery.NextAttemptAt.UTC(),
+		timePtrToNull(delivery.LastAttemptAt),
+		delivery.ResponseStatus,
+		toNullString(delivery.LastError),
+		delivery.ID,
+	)
+	return err
+}
+
+func (r *SQLiteWebhookRepository) ListDeliveries(ctx context.Context, webhookId uuid.UUID, page domain.Page) ([]domain.WebhookDelivery, error) {
+	return r.queryDeliveries(ctx, listWebhookDeliveries, webhookId, pageLimit(page), page.Offset)
+}
+
+func (r *SQLiteWebhookRepository) GetDeliveryById(ctx context.Context, id uuid.UUID) (domain.WebhookDelivery, error) {
+	delivery, err := scanDelivery(r.db.QueryRowContext(ctx, getWebhookDeliveryById, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.WebhookDelivery{}, domain.ErrDeliveryNotFound
+	}
+	return delivery, err
+}
+
+// -------- helpers
+func (r *SQLiteWebhookRepository) queryWebhooks(ctx context.Context, query string, args ...any) ([]domain.Webhook, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []domain.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, rows.Err()
+}
+
+func (r *SQLiteWebhookRepository) queryDeliveries(ctx context.Context, query string, args ...any) ([]domain.WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []domain.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func scanWebhook(row scanner) (domain.Webhook, error) {
+	var webhook domain.Webhook
+	var events string
+	var createdBy uuid.NullUUID
+	if err := row.Scan(&webhook.ID, &webhook.URL, &events, &webhook.Secret, &createdBy, &webhook.CreatedAt); err != nil {
+		return domain.Webhook{}, err
+	}
+
+	if err := json.Unmarshal([]byte(events), &webhook.Events); err != nil {
+		return domain.Webhook{}, err
+	}
+	webhook.CreatedBy = nullUUIDToPtr(createdBy)
+
+	return webhook, nil
+}
+
+func scanDelivery(row scanner) (domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+	var payload, status string
+	var lastAttemptAt sql.NullTime
+	var lastError sql.NullString
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.WebhookID,
+		&delivery.EventID,
+		&delivery.EventType,
+		&payload,
+		&status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&lastAttemptAt,
+		&delivery.ResponseStatus,
+		&lastError,
+		&delivery.CreatedAt,
+	)
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+
+	delivery.Payload = json.RawMessage(payload)
+	delivery.Status = domain.DeliveryStatus(status)
+	delivery.LastAttemptAt = nullTimeToPtr(lastAttemptAt)
+	delivery.LastError = lastError.String
+
+	return delivery, nil
+}
@@ -3,10 +3,33 @@ package repository
 import (
 	"context"
 
+	"github.com/google/uuid"
 	"github.com/grainme/movie-api/internal/domain"
 )
 
 type UserRepository interface {
 	AddUser(ctx context.Context, user domain.CreateUserRequest) (domain.User, error)
+	// AddUser with another role than regular, atomically (never a regular user left behind)
+	AddUserWithRole(ctx context.Context, user domain.CreateUserRequest, role domain.Role) (domain.User, error)
 	FindUserByName(ctx context.Context, username string) (domain.User, error)
+	// ordered by username, the unknown ones are skipped
+	FindUsersByNames(ctx context.Context, usernames []string) ([]domain.User, error)
+	FindUserByEmail(ctx context.Context, email string) (domain.User, error)
+	FindUserById(ctx context.Context, id uuid.UUID) (domain.User, error)
+	UpdatePasswordHash(ctx context.Context, id uuid.UUID, passwordHash string) error
+	UpdateUserRole(ctx context.Context, id uuid.UUID, role domain.Role) error
+
+	// external (OIDC) accounts
+	FindUserByIdentity(ctx context.Context, provider, subject string) (domain.User, error)
+	// creates the user and links the identity atomically (identity.UserID is ignored)
+	AddUserWithIdentity(ctx context.Context, user domain.CreateUserRequest, identity domain.UserIdentity) (domain.User, error)
+	LinkIdentity(ctx context.Context, identity domain.UserIdentity) error
+
+	// two-factor: SetTOTPSecret starts enrollment (still disabled),
+	// EnableTOTP confirms it and replaces the recovery codes (hashes only)
+	SetTOTPSecret(ctx context.Context, id uuid.UUID, secret string) error
+	EnableTOTP(ctx context.Context, id uuid.UUID, recoveryCodeHashes []string) error
+	DisableTOTP(ctx context.Context, id uuid.UUID) error
+	// marks the code as used, returns false if it doesn't exist or was already used
+	UseRecoveryCode(ctx context.Context, id uuid.UUID, codeHash string) (bool, error)
 }
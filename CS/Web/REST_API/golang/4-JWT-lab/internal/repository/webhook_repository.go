@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+type WebhookRepository interface {
+	// the webhooks come back with their Secret (the dispatcher signs with it).
+	// the id is generated
+	AddWebhook(ctx context.Context, webhook domain.Webhook) (domain.Webhook, error)
+	// newest first
+	ListWebhooks(ctx context.Context) ([]domain.Webhook, error)
+	GetWebhookById(ctx context.Context, id uuid.UUID) (domain.Webhook, error)
+	// the webhooks subscribed to eventType, oldest first
+	FindWebhooksByEvent(ctx context.Context, eventType string) ([]domain.Webhook, error)
+	// its deliveries go with it
+	DeleteWebhook(ctx context.Context, id uuid.UUID) error
+
+	// new pending deliveries (the caller picks their ids), due at their
+	// NextAttemptAt. all or nothing. the redeliveries: the deliveries of the
+	// events are queued by the movie and review repositories, with the
+	// outbox row (see OutboxRepository)
+	AddDeliveries(ctx context.Context, deliveries []domain.WebhookDelivery) error
+	// the pending deliveries due at now, leased until leaseUntil (their
+	// NextAttemptAt): the other dispatchers skip them meanwhile
+	ClaimDueDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]domain.WebhookDelivery, error)
+	// records an attempt: Status, Attempts, NextAttemptAt, LastAttemptAt,
+	// ResponseStatus and LastError
+	UpdateDelivery(ctx context.Context, delivery domain.WebhookDelivery) error
+	// newest first
+	ListDeliveries(ctx context.Context, webhookId uuid.UUID, page domain.Page) ([]domain.WebhookDelivery, error)
+	GetDeliveryById(ctx context.Context, id uuid.UUID) (domain.WebhookDelivery, error)
+}
@@ -0,0 +1,107 @@
+package server_test
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestConditionalRequests(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		token := s.adminToken("root")
+		movie := s.createMovie(token, "Heat")
+		moviePath := "/movies/" + movie.ID.String()
+
+		// the first read goes to the DB, the second one to the cache: same ETag
+		first := s.get(moviePath, "")
+		etag := first.header.Get("ETag")
+		if first.status != http.StatusOK || etag == "" || first.header.Get("Cache-Control") != "public, no-cache" {
+			t.Fatalf("GET %s = %d, headers %v", moviePath, first.status, first.header)
+		}
+		if cached := s.get(moviePath, ""); cached.header.Get("ETag") != etag {
+			t.Fatalf("ETag from the cache = %s, want %s", cached.header.Get("ETag"), etag)
+		}
+
+		// still current: 304 without the body, weak or in a list
+		for _, ifNoneMatch := range []string{etag, "W/" + etag, `"other", ` + etag, "*"} {
+			notModified := s.get(moviePath, ifNoneMatch)
+			if notModified.status != http.StatusNotModified || len(notModified.body) != 0 || notModified.header.Get("ETag") != etag {
+				t.Fatalf("If-None-Match %s: %d %q, headers %v", ifNoneMatch, notModified.status, notModified.body, notModified.header)
+			}
+		}
+
+		// changed: the old ETag gets the new payload
+		s.expect(s.do("PUT", moviePath, token, map[string]string{"title": "Heat (1995)"}), http.StatusNoContent, nil)
+		changed := s.get(moviePath, etag)
+		if changed.status != http.StatusOK || changed.header.Get("ETag") == etag {
+			t.Fatalf("after an update: %d, ETag %s", changed.status, changed.header.Get("ETag"))
+		}
+
+		// the lists too
+		list := s.get("/movies", "")
+		if s.get("/movies", list.header.Get("ETag")).status != http.StatusNotModified {
+			t.Fatalf("the movie list wasn't 304")
+		}
+		s.createMovie(token, "Thief")
+		if s.get("/movies", list.header.Get("ETag")).status != http.StatusOK {
+			t.Fatalf("the movie list was 304 after a new movie")
+		}
+		if trending := s.get("/movies/trending", ""); trending.header.Get("Cache-Control") != "public, max-age=30" {
+			t.Fatalf("trending Cache-Control = %q", trending.header.Get("Cache-Control"))
+		}
+
+		// the errors aren't cacheable
+		if missing := s.get("/movies/"+uuid.NewString(), ""); missing.status != http.StatusNotFound || missing.header.Get("ETag") != "" {
+			t.Fatalf("unknown movie: %d, headers %v", missing.status, missing.header)
+		}
+	})
+}
+
+func TestCacheControlOverride(t *testing.T) {
+	deps := backends["memory"](t)
+	deps.CacheControl = map[string]string{"/movies/{id}": "public, max-age=60", "/movies": ""}
+	s := newTestServer(t, deps)
+
+	movie := s.createMovie(s.adminToken("root"), "Ronin")
+	if got := s.get("/movies/"+movie.ID.String(), "").header.Get("Cache-Control"); got != "public, max-age=60" {
+		t.Fatalf("movie Cache-Control = %q", got)
+	}
+	list := s.get("/movies", "")
+	if list.header.Get("Cache-Control") != "" || list.header.Get("ETag") == "" {
+		t.Fatalf("movie list headers = %v", list.header)
+	}
+}
+
+// -------- helpers
+type conditionalResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// get sends If-None-Match (when not empty)
+func (s *testServer) get(path, ifNoneMatch string) conditionalResponse {
+	s.t.Helper()
+
+	req, err := http.NewRequest("GET", s.url+path, nil)
+	if err != nil {
+		s.t.Fatalf("new request: %v", err)
+	}
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		s.t.Fatalf("GET %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		s.t.Fatalf("read GET %s: %v", path, err)
+	}
+	return conditionalResponse{status: resp.StatusCode, header: resp.Header, body: body}
+}
@@ -0,0 +1,136 @@
+package server_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/grainme/movie-api/internal/apitest"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+func TestUserJourney(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		// register → login
+		s.expect(s.do("POST", "/auth/register", "", domain.CreateUserRequest{Username: "alice", Password: apitest.Password}), http.StatusCreated, nil)
+
+		var session domain.UserResponse
+		s.expect(s.do("POST", "/auth/login", "", domain.CreateUserRequest{Username: "alice", Password: apitest.Password}), http.StatusOK, &session)
+		if session.AccessToken == "" || session.RefreshToken == "" {
+			t.Fatalf("login: missing tokens in %+v", session)
+		}
+
+		// create a movie: authenticated only
+		movie := domain.Movie{Title: "Heat", Director: "Michael Mann", Year: 1995}
+		s.expect(s.do("POST", "/movies", "", movie), http.StatusUnauthorized, nil)
+
+		var created domain.Movie
+		s.expect(s.do("POST", "/movies", session.AccessToken, movie), http.StatusCreated, &created)
+		if created.Title != "Heat" || created.Director != "Michael Mann" {
+			t.Fatalf("created movie = %+v", created)
+		}
+
+		// review it
+		comment := "the diner scene"
+		review := domain.CreateReviewRequest{Rating: 4, Comment: &comment, MovieID: created.ID}
+		var createdReview domain.Review
+		s.expect(s.do("POST", "/reviews", "", review), http.StatusUnauthorized, nil)
+		s.expect(s.do("POST", "/reviews", session.AccessToken, review), http.StatusCreated, &createdReview)
+		if createdReview.MovieID != created.ID || createdReview.Rating != 4 || createdReview.Username != "alice" {
+			t.Fatalf("created review = %+v", createdReview)
+		}
+
+		// the author is the caller, not the body
+		spoofed := domain.Review{Username: "bob", Rating: 5, MovieID: created.ID}
+		var spoofedReview domain.Review
+		s.expect(s.do("POST", "/reviews", session.AccessToken, spoofed), http.StatusCreated, &spoofedReview)
+		if spoofedReview.Username != "alice" {
+			t.Fatalf("review posted as %q, want alice", spoofedReview.Username)
+		}
+
+		var reviews []domain.Review
+		s.expect(s.do("GET", "/reviews/"+created.ID.String(), "", nil), http.StatusOK, &reviews)
+		if len(reviews) != 2 {
+			t.Fatalf("movie reviews = %+v", reviews)
+		}
+
+		// refresh: new tokens, the old refresh token is spent
+		var refreshed domain.UserResponse
+		s.expect(s.do("POST", "/auth/refresh", "", refreshBody(session.RefreshToken)), http.StatusOK, &refreshed)
+		if refreshed.AccessToken == "" || refreshed.RefreshToken == session.RefreshToken {
+			t.Fatalf("refresh = %+v", refreshed)
+		}
+		s.expect(s.do("POST", "/auth/refresh", "", refreshBody(session.RefreshToken)), http.StatusBadRequest, nil)
+
+		// logout: the refresh token is gone
+		s.expect(s.do("POST", "/auth/logout", "", refreshBody(refreshed.RefreshToken)), http.StatusOK, nil)
+		s.expect(s.do("POST", "/auth/refresh", "", refreshBody(refreshed.RefreshToken)), http.StatusBadRequest, nil)
+
+		// a regular user can't delete (the access token lives on until it expires)
+		s.expect(s.do("DELETE", "/movies/"+created.ID.String(), refreshed.AccessToken, nil), http.StatusForbidden, nil)
+		s.expect(s.do("GET", "/movies/"+created.ID.String(), "", nil), http.StatusOK, nil)
+	})
+}
+
+func TestAdminDelete(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		s.expect(s.do("POST", "/auth/register", "", domain.CreateUserRequest{Username: "root", Password: apitest.Password}), http.StatusCreated, nil)
+		user, err := s.deps.Users.FindUserByName(context.Background(), "root")
+		if err != nil {
+			t.Fatalf("find user: %v", err)
+		}
+		// no route for it: operators use moviectl user role
+		if err := s.deps.Users.UpdateUserRole(context.Background(), user.ID, domain.Admin); err != nil {
+			t.Fatalf("update role: %v", err)
+		}
+
+		var session domain.UserResponse
+		s.expect(s.do("POST", "/auth/login", "", domain.CreateUserRequest{Username: "root", Password: apitest.Password}), http.StatusOK, &session)
+
+		var created domain.Movie
+		s.expect(s.do("POST", "/movies", session.AccessToken, domain.Movie{Title: "Ronin", Director: "John Frankenheimer", Year: 1998}), http.StatusCreated, &created)
+
+		s.expect(s.do("DELETE", "/movies/"+created.ID.String(), session.AccessToken, nil), http.StatusNoContent, nil)
+		s.expect(s.do("GET", "/movies/"+created.ID.String(), "", nil), http.StatusNotFound, nil)
+
+		// and it's in the audit log
+		var events []domain.AuditEvent
+		s.expect(s.do("GET", "/admin/audit?action="+domain.AuditMovieDelete, session.AccessToken, nil), http.StatusOK, &events)
+		if len(events) != 1 || events[0].TargetID != created.ID.String() {
+			t.Fatalf("audit events = %+v", events)
+		}
+
+		// so are the refusals, with who asked
+		regular := domain.CreateUserRequest{Username: "bob", Password: apitest.Password}
+		s.expect(s.do("POST", "/auth/register", "", regular), http.StatusCreated, nil)
+		var regularSession domain.UserResponse
+		s.expect(s.do("POST", "/auth/login", "", regular), http.StatusOK, &regularSession)
+		s.expect(s.do("DELETE", "/movies/"+created.ID.String(), regularSession.AccessToken, nil), http.StatusForbidden, nil)
+
+		var denied []domain.AuditEvent
+		s.expect(s.do("GET", "/admin/audit?action="+domain.AuditAuthorize, session.AccessToken, nil), http.StatusOK, &denied)
+		if len(denied) != 1 || denied[0].Outcome != domain.AuditDenied || denied[0].ActorID == nil || *denied[0].ActorID != regularSession.ID {
+			t.Fatalf("denied events = %+v", denied)
+		}
+		if want := "DELETE /movies/" + created.ID.String() + ": admin only"; denied[0].Detail != want {
+			t.Fatalf("denied detail = %q, want %q", denied[0].Detail, want)
+		}
+	})
+}
+
+func TestInvalidTokens(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		s.expect(s.do("POST", "/movies", "not-a-jwt", domain.Movie{Title: "Thief"}), http.StatusUnauthorized, nil)
+		s.expect(s.do("GET", "/admin/audit", "", nil), http.StatusUnauthorized, nil)
+		s.expect(s.do("POST", "/auth/login", "", domain.CreateUserRequest{Username: "nobody", Password: apitest.Password}), http.StatusBadRequest, nil)
+
+		// over the policy's max length: rejected before hashing it
+		s.expect(s.do("POST", "/auth/register", "", domain.CreateUserRequest{Username: "dave", Password: apitest.Password}), http.StatusCreated, nil)
+		s.expect(s.do("POST", "/auth/login", "", domain.CreateUserRequest{Username: "dave", Password: strings.Repeat(apitest.Password, 10)}), http.StatusBadRequest, nil)
+	})
+}
+
+func refreshBody(token string) map[string]string {
+	return map[string]string{"refresh_token": token}
+}
@@ -0,0 +1,175 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/grainme/movie-api/internal/apitest"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+func TestGraphQL(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		s.expect(s.do("POST", "/auth/register", "", domain.CreateUserRequest{Username: "alice", Password: apitest.Password}), http.StatusCreated, nil)
+		var session domain.UserResponse
+		s.expect(s.do("POST", "/auth/login", "", domain.CreateUserRequest{Username: "alice", Password: apitest.Password}), http.StatusOK, &session)
+
+		const createMovie = `mutation($title: String!) { createMovie(input: {title: $title, year: 1995}) { id title year } }`
+
+		// mutations need the same credentials as POST /movies
+		errs := s.graphQL("", createMovie, map[string]any{"title": "Heat"}, nil)
+		expectGraphQLCode(t, errs, "UNAUTHENTICATED")
+		s.expect(s.do("POST", "/graphql", "not-a-jwt", graphQLBody(createMovie, map[string]any{"title": "Heat"})), http.StatusUnauthorized, nil)
+
+		ids := map[string]string{}
+		for _, title := range []string{"Heat", "Ronin"} {
+			var created struct {
+				CreateMovie struct{ ID, Title string }
+			}
+			if errs := s.graphQL(session.AccessToken, createMovie, map[string]any{"title": title}, &created); len(errs) > 0 {
+				t.Fatalf("create movie %s: %+v", title, errs)
+			}
+			ids[title] = created.CreateMovie.ID
+		}
+
+		// reviews need reviews:write, like POST /reviews, and their author is the caller
+		s.expect(s.do("POST", "/auth/register", "", domain.CreateUserRequest{Username: "bob", Password: apitest.Password}), http.StatusCreated, nil)
+		var bob domain.UserResponse
+		s.expect(s.do("POST", "/auth/login", "", domain.CreateUserRequest{Username: "bob", Password: apitest.Password}), http.StatusOK, &bob)
+
+		const createReview = `mutation($movie: ID!, $rating: Int!) {
+			createReview(input: {movieId: $movie, rating: $rating}) { id rating author { username } }
+		}`
+		errs = s.graphQL("", createReview, map[string]any{"movie": ids["Heat"], "rating": 8}, nil)
+		expectGraphQLCode(t, errs, "UNAUTHENTICATED")
+		// not the client's to say
+		spoofed := `mutation($movie: ID!) { createReview(input: {movieId: $movie, username: "bob", rating: 8}) { id } }`
+		if errs := s.graphQL(session.AccessToken, spoofed, map[string]any{"movie": ids["Heat"]}, nil); len(errs) == 0 {
+			t.Fatal("a review with a username was accepted")
+		}
+		for _, review := range []struct {
+			movie, user, token string
+			rating             int
+		}{{"Heat", "alice", session.AccessToken, 8}, {"Heat", "bob", bob.AccessToken, 6}, {"Ronin", "alice", session.AccessToken, 9}} {
+			var created struct {
+				CreateReview struct{ Author struct{ Username string } }
+			}
+			if errs := s.graphQL(review.token, createReview, map[string]any{"movie": ids[review.movie], "rating": review.rating}, &created); len(errs) > 0 {
+				t.Fatalf("create review: %+v", errs)
+			}
+			if created.CreateReview.Author.Username != review.user {
+				t.Fatalf("review author = %q, want %s", created.CreateReview.Author.Username, review.user)
+			}
+		}
+
+		// the whole graph in one request
+		var listed struct {
+			Movies []struct {
+				Title         string
+				AverageRating float64
+				ReviewsCount  int
+				Reviews       []struct {
+					Rating int
+					Author *struct{ Username string }
+				}
+			}
+		}
+		const movies = `{ movies { title averageRating reviewsCount reviews(limit: 5) { rating author { username } } } }`
+		if errs := s.graphQL("", movies, nil, &listed); len(errs) > 0 {
+			t.Fatalf("movies: %+v", errs)
+		}
+		if len(listed.Movies) != 2 || listed.Movies[0].Title != "Heat" || listed.Movies[1].Title != "Ronin" {
+			t.Fatalf("movies = %+v", listed.Movies)
+		}
+		heat := listed.Movies[0]
+		if heat.AverageRating != 7 || heat.ReviewsCount != 2 || len(heat.Reviews) != 2 {
+			t.Fatalf("Heat = %+v", heat)
+		}
+		authors := map[string]bool{}
+		for _, review := range heat.Reviews {
+			if review.Author != nil {
+				authors[review.Author.Username] = true
+			}
+		}
+		if len(authors) != 2 || !authors["alice"] || !authors["bob"] {
+			t.Fatalf("expected alice and bob as the authors, got %+v", heat.Reviews)
+		}
+
+		var me struct {
+			Me struct {
+				Username string
+				Reviews  []struct{ Movie struct{ Title string } }
+			}
+		}
+		if errs := s.graphQL(session.AccessToken, `{ me { username reviews { movie { title } } } }`, nil, &me); len(errs) > 0 {
+			t.Fatalf("me: %+v", errs)
+		}
+		if me.Me.Username != "alice" || len(me.Me.Reviews) != 2 {
+			t.Fatalf("me = %+v", me.Me)
+		}
+		expectGraphQLCode(t, s.graphQL("", `{ me { username } }`, nil, nil), "UNAUTHENTICATED")
+
+		// a regular user can't delete, like DELETE /movies/{id}
+		expectGraphQLCode(t, s.graphQL(session.AccessToken, `mutation($id: ID!) { deleteMovie(id: $id) }`, map[string]any{"id": ids["Ronin"]}, nil), "FORBIDDEN")
+		expectGraphQLCode(t, s.graphQL("", `{ movies(limit: 500) { title } }`, nil, nil), "BAD_USER_INPUT")
+
+		var missing struct{ Movie *struct{ Title string } }
+		if errs := s.graphQL("", `{ movie(id: "00000000-0000-0000-0000-000000000001") { title } }`, nil, &missing); len(errs) > 0 || missing.Movie != nil {
+			t.Fatalf("unknown movie = %+v, %+v", missing.Movie, errs)
+		}
+	})
+}
+
+// the same check as REST (auth.Claims.Authorize): with REQUIRE_ADMIN_MFA, an
+// admin password alone doesn't delete anything, on either side
+func TestGraphQLAdminMFA(t *testing.T) {
+	t.Setenv("REQUIRE_ADMIN_MFA", "true")
+
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		token := s.adminToken("root")
+		movie := s.createMovie(token, "Ronin")
+
+		errs := s.graphQL(token, `mutation($id: ID!) { deleteMovie(id: $id) }`, map[string]any{"id": movie.ID}, nil)
+		expectGraphQLCode(t, errs, "FORBIDDEN")
+		if errs[0].Message != "two-factor authentication required" {
+			t.Fatalf("reason = %q", errs[0].Message)
+		}
+		s.expect(s.do("DELETE", "/movies/"+movie.ID.String(), token, nil), http.StatusForbidden, nil)
+	})
+}
+
+// -------- helpers
+
+type graphQLError struct {
+	Message    string
+	Extensions struct{ Code string }
+}
+
+func graphQLBody(query string, variables map[string]any) map[string]any {
+	return map[string]any{"query": query, "variables": variables}
+}
+
+// graphQL runs query, decodes its data into dest (when not nil) and returns its errors
+func (s *testServer) graphQL(token, query string, variables map[string]any, dest any) []graphQLError {
+	s.t.Helper()
+
+	var answer struct {
+		Data   json.RawMessage
+		Errors []graphQLError
+	}
+	s.expect(s.do("POST", "/graphql", token, graphQLBody(query, variables)), http.StatusOK, &answer)
+	if dest != nil {
+		if err := json.Unmarshal(answer.Data, dest); err != nil {
+			s.t.Fatalf("decode %s: %v", answer.Data, err)
+		}
+	}
+	return answer.Errors
+}
+
+func expectGraphQLCode(t *testing.T, errs []graphQLError, code string) {
+	t.Helper()
+	if len(errs) != 1 || errs[0].Extensions.Code != code {
+		t.Fatalf("errors = %+v, want one %s", errs, code)
+	}
+}
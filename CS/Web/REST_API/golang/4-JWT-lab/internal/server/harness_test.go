@@ -0,0 +1,178 @@
+package server_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grainme/movie-api/internal/apitest"
+	"github.com/grainme/movie-api/internal/cache"
+	"github.com/grainme/movie-api/internal/mailer"
+	"github.com/grainme/movie-api/internal/openapi"
+	"github.com/grainme/movie-api/internal/repository/memory"
+	"github.com/grainme/movie-api/internal/server"
+	"github.com/grainme/movie-api/internal/storage"
+)
+
+func TestMain(m *testing.M) {
+	apitest.Main(m)
+}
+
+// a backend is the repositories half of server.Deps (the cache is always in memory)
+type backend func(t *testing.T) server.Deps
+
+var backends = map[string]backend{
+	"memory": func(t *testing.T) server.Deps {
+		store := memory.NewStore()
+		return server.Deps{
+			Movies:   memory.NewMemoryMovieRepository(store),
+			Reviews:  memory.NewMemoryReviewRepository(store),
+			Users:    memory.NewMemoryUserRepository(store),
+			APIKeys:  memory.NewMemoryAPIKeyRepository(store),
+			Audit:    memory.NewMemoryAuditRepository(store),
+			Webhooks: memory.NewMemoryWebhookRepository(store),
+		}
+	},
+	"sqlite": func(t *testing.T) server.Deps {
+		store, err := storage.Open("sqlite://:memory:")
+		if err != nil {
+			t.Fatalf("open sqlite: %v", err)
+		}
+		m, err := store.NewMigrate("../../db/sqlite/migrations")
+		if err != nil {
+			t.Fatalf("migrate: %v", err)
+		}
+		if err := m.Up(); err != nil {
+			t.Fatalf("migrate up: %v", err)
+		}
+		// closes the database too
+		t.Cleanup(func() { m.Close() })
+
+		return server.Deps{
+			Movies:   store.Movies,
+			Reviews:  store.Reviews,
+			Users:    store.Users,
+			APIKeys:  store.APIKeys,
+			Audit:    store.Audit,
+			Webhooks: store.Webhooks,
+		}
+	},
+}
+
+// testServer is the whole API behind a real HTTP server
+type testServer struct {
+	t      *testing.T
+	url    string
+	deps   server.Deps
+	router http.Handler
+}
+
+// newTestServer starts the router on deps; the cache and the mailer are
+// filled in when missing
+func newTestServer(t *testing.T, deps server.Deps) *testServer {
+	t.Helper()
+
+	if deps.MovieCache == nil {
+		deps.MovieCache = cache.NewMemoryMovieCache()
+	}
+	if deps.Lists == nil {
+		deps.Lists = cache.NewMemoryListCache()
+	}
+	if deps.Trending == nil {
+		deps.Trending = cache.NewMemoryTrendingStore()
+	}
+	if deps.Sessions == nil {
+		deps.Sessions = cache.NewMemorySessionStore()
+	}
+	if deps.ReviewFeed == nil {
+		deps.ReviewFeed = cache.NewMemoryReviewFeed()
+	}
+	if deps.Mailer == nil {
+		deps.Mailer = mailer.NewLogMailer()
+	}
+
+	// every request of every test is checked against the OpenAPI document
+	validator, err := openapi.NewValidator(func(r *http.Request, err error) {
+		t.Errorf("%s %s doesn't match the OpenAPI spec: %v", r.Method, r.URL, err)
+	})
+	if err != nil {
+		t.Fatalf("openapi validator: %v", err)
+	}
+	deps.SpecValidator = validator
+
+	router := server.NewRouter(deps)
+	ts := httptest.NewServer(router)
+	t.Cleanup(ts.Close)
+
+	return &testServer{t: t, url: ts.URL, deps: deps, router: router}
+}
+
+// forEachBackend runs the scenario once per backend, on a fresh server each time
+func forEachBackend(t *testing.T, scenario func(t *testing.T, s *testServer)) {
+	for name, newDeps := range backends {
+		t.Run(name, func(t *testing.T) {
+			scenario(t, newTestServer(t, newDeps(t)))
+		})
+	}
+}
+
+// -------- helpers
+type response struct {
+	status int
+	body   []byte
+}
+
+// do sends body as JSON (when not nil), with the access token (when not empty)
+func (s *testServer) do(method, path, token string, body any) response {
+	s.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatalf("marshal %s %s: %v", method, path, err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, s.url+path, reader)
+	if err != nil {
+		s.t.Fatalf("new request %s %s: %v", method, path, err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		s.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		s.t.Fatalf("read %s %s: %v", method, path, err)
+	}
+
+	return response{status: resp.StatusCode, body: data}
+}
+
+// expect checks the status and decodes the body into dest (when not nil)
+func (s *testServer) expect(resp response, status int, dest any) {
+	s.t.Helper()
+
+	if resp.status != status {
+		s.t.Fatalf("status = %d, want %d (body: %s)", resp.status, status, resp.body)
+	}
+	if dest != nil {
+		if err := json.Unmarshal(resp.body, dest); err != nil {
+			s.t.Fatalf("decode %s: %v", resp.body, err)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/apitest"
+	"github.com/grainme/movie-api/internal/auth"
+	"github.com/grainme/movie-api/internal/cache"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+const wrongCode = "not-a-code"
+
+func TestMFALockout(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		user, session, recoveryCodes := s.enableTOTP("carol")
+
+		// concurrent guesses on one challenge: each one is counted before
+		// its check, so only MaxMFAChallengeAttempts of them reach the code
+		challenge := s.mfaChallenge("carol")
+		var wg sync.WaitGroup
+		for range 4 * cache.MaxMFAChallengeAttempts {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if status := s.postMFA(challenge, wrongCode); status != http.StatusUnauthorized {
+					t.Errorf("concurrent guess: status = %d, want %d", status, http.StatusUnauthorized)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if attempts := s.mfaAttempts(user.ID); attempts != cache.MaxMFAChallengeAttempts {
+			t.Fatalf("user attempts = %d, want %d", attempts, cache.MaxMFAChallengeAttempts)
+		}
+		// the challenge is burned, even with a good code
+		s.expect(s.do("POST", "/auth/login/mfa", "", domain.MFALoginRequest{MFAToken: challenge, Code: recoveryCodes[0]}), http.StatusUnauthorized, nil)
+
+		// a good code resets the count
+		s.expect(s.do("POST", "/auth/login/mfa", "", domain.MFALoginRequest{MFAToken: s.mfaChallenge("carol"), Code: recoveryCodes[0]}), http.StatusOK, nil)
+		if attempts := s.mfaAttempts(user.ID); attempts != 0 {
+			t.Fatalf("user attempts after a good code = %d, want 0", attempts)
+		}
+
+		// new challenges don't give new guesses: the limit is per user
+		for guesses := 0; guesses < cache.MaxMFAAttempts; {
+			challenge := s.mfaChallenge("carol")
+			for range cache.MaxMFAChallengeAttempts {
+				if status := s.postMFA(challenge, wrongCode); status != http.StatusUnauthorized {
+					t.Fatalf("guess %d: status = %d, want %d", guesses, status, http.StatusUnauthorized)
+				}
+				guesses++
+			}
+		}
+
+		s.expect(s.do("POST", "/auth/login", "", domain.CreateUserRequest{Username: "carol", Password: apitest.Password}), http.StatusTooManyRequests, nil)
+		// disabling 2FA goes through the same limit
+		s.expect(s.do("POST", "/auth/2fa/disable", session.AccessToken, domain.TOTPCodeRequest{Code: recoveryCodes[1]}), http.StatusTooManyRequests, nil)
+	})
+}
+
+// enableTOTP registers username and turns 2FA on, returns the session of
+// the password login and the recovery codes
+func (s *testServer) enableTOTP(username string) (domain.User, domain.UserResponse, []string) {
+	s.t.Helper()
+
+	credentials := domain.CreateUserRequest{Username: username, Password: apitest.Password}
+	s.expect(s.do("POST", "/auth/register", "", credentials), http.StatusCreated, nil)
+
+	var session domain.UserResponse
+	s.expect(s.do("POST", "/auth/login", "", credentials), http.StatusOK, &session)
+
+	var enrollment domain.TOTPEnrollment
+	s.expect(s.do("POST", "/auth/2fa/enroll", session.AccessToken, nil), http.StatusOK, &enrollment)
+
+	code, err := auth.TOTPCode(enrollment.Secret, time.Now())
+	if err != nil {
+		s.t.Fatalf("totp code: %v", err)
+	}
+	var recovery domain.RecoveryCodesResponse
+	s.expect(s.do("POST", "/auth/2fa/confirm", session.AccessToken, domain.TOTPCodeRequest{Code: code}), http.StatusOK, &recovery)
+
+	user, err := s.deps.Users.FindUserByName(context.Background(), username)
+	if err != nil {
+		s.t.Fatalf("find user: %v", err)
+	}
+
+	return user, session, recovery.RecoveryCodes
+}
+
+// mfaChallenge logs in with the password, returns the MFA token
+func (s *testServer) mfaChallenge(username string) string {
+	s.t.Helper()
+
+	var session domain.UserResponse
+	s.expect(s.do("POST", "/auth/login", "", domain.CreateUserRequest{Username: username, Password: apitest.Password}), http.StatusOK, &session)
+	if !session.MFARequired || session.MFAToken == "" {
+		s.t.Fatalf("login: no mfa challenge in %+v", session)
+	}
+
+	return session.MFAToken
+}
+
+// postMFA is safe to call from other goroutines (no t.Fatal), it returns
+// the status or 0 when the request failed
+func (s *testServer) postMFA(mfaToken, code string) int {
+	data, _ := json.Marshal(domain.MFALoginRequest{MFAToken: mfaToken, Code: code})
+	resp, err := http.Post(s.url+"/auth/login/mfa", "application/json", bytes.NewReader(data))
+	if err != nil {
+		return 0
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode
+}
+
+func (s *testServer) mfaAttempts(userId uuid.UUID) int64 {
+	s.t.Helper()
+
+	attempts, err := s.deps.Sessions.GetMFAAttempts(context.Background(), userId)
+	if err != nil {
+		s.t.Fatalf("mfa attempts: %v", err)
+	}
+	return attempts
+}
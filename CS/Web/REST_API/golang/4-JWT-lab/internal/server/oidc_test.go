@@ -0,0 +1,201 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/grainme/movie-api/internal/apitest"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/oidc"
+	"github.com/grainme/movie-api/internal/oidc/oidctest"
+)
+
+func TestOIDCAccountLinking(t *testing.T) {
+	for name, newDeps := range backends {
+		t.Run(name, func(t *testing.T) {
+			fp := oidctest.NewProvider(t)
+			provider, err := oidc.Discover(context.Background(), oidc.Config{
+				Issuer:       fp.Issuer(),
+				ClientID:     fp.ClientID,
+				ClientSecret: fp.ClientSecret,
+				RedirectURL:  "http://localhost:3000/auth/oidc/callback",
+			}, nil)
+			if err != nil {
+				t.Fatalf("discover: %v", err)
+			}
+			deps := newDeps(t)
+			deps.OIDC = provider
+			s := newTestServer(t, deps)
+
+			// someone registered first with the email of the provider account:
+			// nothing proves it's theirs, the provider login must not get in
+			credentials := domain.CreateUserRequest{Username: "alice", Password: apitest.Password, Email: "alice@example.com"}
+			s.expect(s.do("POST", "/auth/register", "", credentials), http.StatusCreated, nil)
+			s.expect(s.oidcFlow("/auth/oidc/login", ""), http.StatusConflict, nil)
+
+			// the owner links it from their session, then it logs them in
+			var session domain.UserResponse
+			s.expect(s.do("POST", "/auth/login", "", credentials), http.StatusOK, &session)
+			var linked domain.UserResponse
+			s.expect(s.oidcFlow("/auth/oidc/link", session.AccessToken), http.StatusOK, &linked)
+			if linked.ID != session.ID {
+				t.Fatalf("linked to %s, want %s", linked.ID, session.ID)
+			}
+			var viaProvider domain.UserResponse
+			s.expect(s.oidcFlow("/auth/oidc/login", ""), http.StatusOK, &viaProvider)
+			if viaProvider.ID != session.ID || viaProvider.AccessToken == "" {
+				t.Fatalf("provider login = %+v, want user %s", viaProvider, session.ID)
+			}
+
+			// already someone else's
+			bob := domain.CreateUserRequest{Username: "bob", Password: apitest.Password}
+			s.expect(s.do("POST", "/auth/register", "", bob), http.StatusCreated, nil)
+			var bobSession domain.UserResponse
+			s.expect(s.do("POST", "/auth/login", "", bob), http.StatusOK, &bobSession)
+			s.expect(s.oidcFlow("/auth/oidc/link", bobSession.AccessToken), http.StatusConflict, nil)
+			s.expect(s.oidcFlow("/auth/oidc/link", ""), http.StatusUnauthorized, nil)
+
+			// an email nobody has: a new account
+			fp.SetUser("user-456", "carol@example.com", true)
+			var created domain.UserResponse
+			s.expect(s.oidcFlow("/auth/oidc/login", ""), http.StatusOK, &created)
+			if created.ID == session.ID || created.ID == bobSession.ID {
+				t.Fatalf("provider login of a new user got an existing account: %+v", created)
+			}
+			if _, err := s.deps.Users.FindUserByEmail(context.Background(), "carol@example.com"); err != nil {
+				t.Fatalf("new account email: %v", err)
+			}
+
+			// bob starts a link and gets dave to finish it at the provider:
+			// dave's browser doesn't have bob's binding, nothing is linked
+			fp.SetUser("user-789", "dave@example.com", true)
+			authURL, bobBinding, failed := s.oidcStart("/auth/oidc/link", bobSession.AccessToken)
+			if failed != nil {
+				t.Fatalf("start link: status %d", failed.status)
+			}
+			s.expect(s.oidcCallback(s.oidcConsent(authURL), nil), http.StatusBadRequest, nil)
+			_, daveBinding, _ := s.oidcStart("/auth/oidc/login", "")
+			authURL, _, _ = s.oidcStart("/auth/oidc/link", bobSession.AccessToken)
+			s.expect(s.oidcCallback(s.oidcConsent(authURL), daveBinding), http.StatusBadRequest, nil)
+			// the binding only goes with its own state
+			s.expect(s.oidcCallback(s.oidcConsent(authURL), bobBinding), http.StatusBadRequest, nil)
+			var dave domain.UserResponse
+			s.expect(s.oidcFlow("/auth/oidc/login", ""), http.StatusOK, &dave)
+			if dave.ID == bobSession.ID {
+				t.Fatal("dave's provider login got into bob's account")
+			}
+		})
+	}
+}
+
+// oidcFlow plays the browser: start (login, or link with the access token
+// when not empty), auto-consent at the provider, then our callback with the
+// binding cookie the start set
+func (s *testServer) oidcFlow(start, token string) response {
+	s.t.Helper()
+
+	authURL, binding, failed := s.oidcStart(start, token)
+	if failed != nil {
+		return *failed
+	}
+	return s.oidcCallback(s.oidcConsent(authURL), binding)
+}
+
+// GET /auth/oidc/login redirects to the provider, POST /auth/oidc/link
+// answers with its URL. both set the binding cookie
+func (s *testServer) oidcStart(start, token string) (string, *http.Cookie, *response) {
+	s.t.Helper()
+
+	method, status := "GET", http.StatusFound
+	if start == "/auth/oidc/link" {
+		method, status = "POST", http.StatusOK
+	}
+	resp := s.browse(method, s.url+start, token, nil)
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != status {
+		return "", nil, &response{status: resp.StatusCode, body: body}
+	}
+
+	authURL := resp.Header.Get("Location")
+	if method == "POST" {
+		var link struct {
+			AuthorizationURL string `json:"authorization_url"`
+		}
+		if err := json.Unmarshal(body, &link); err != nil {
+			s.t.Fatalf("link response %s: %v", body, err)
+		}
+		authURL = link.AuthorizationURL
+	}
+
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == "__Host-oidc_binding" {
+			if !cookie.Secure || !cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode || cookie.Path != "/" {
+				s.t.Fatalf("binding cookie = %+v", cookie)
+			}
+			return authURL, cookie, nil
+		}
+	}
+	s.t.Fatalf("%s %s set no binding cookie", method, start)
+	return "", nil, nil
+}
+
+// the provider logs the user in and sends the browser back: the callback query
+func (s *testServer) oidcConsent(authURL string) string {
+	s.t.Helper()
+
+	atProvider := s.browse("GET", authURL, "", nil)
+	if atProvider.StatusCode != http.StatusFound {
+		s.t.Fatalf("provider authorize: status %d", atProvider.StatusCode)
+	}
+	callback, err := url.Parse(atProvider.Header.Get("Location"))
+	if err != nil {
+		s.t.Fatalf("callback location: %v", err)
+	}
+	return callback.RawQuery
+}
+
+// binding nil: a browser without the cookie
+func (s *testServer) oidcCallback(query string, binding *http.Cookie) response {
+	s.t.Helper()
+
+	resp := s.browse("GET", s.url+"/auth/oidc/callback?"+query, "", binding)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		s.t.Fatalf("read callback: %v", err)
+	}
+	return response{status: resp.StatusCode, body: body}
+}
+
+// browse sends the request without following the redirects, with the
+// access token and the cookie when given
+func (s *testServer) browse(method, target, token string, cookie *http.Cookie) *http.Response {
+	s.t.Helper()
+
+	req, err := http.NewRequest(method, target, nil)
+	if err != nil {
+		s.t.Fatalf("new request %s: %v", target, err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if cookie != nil {
+		req.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+	}
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		s.t.Fatalf("%s %s: %v", method, target, err)
+	}
+	s.t.Cleanup(func() { resp.Body.Close() })
+
+	return resp
+}
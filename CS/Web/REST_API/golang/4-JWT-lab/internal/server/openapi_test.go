@@ -0,0 +1,67 @@
+package server_test
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/grainme/movie-api/internal/openapi"
+)
+
+// only routed when an OIDC provider is configured
+var optionalRoutes = []string{"GET /auth/oidc/login", "GET /auth/oidc/callback", "POST /auth/oidc/link"}
+
+// the document and the router must list the same routes, both ways
+func TestSpecCoversRouter(t *testing.T) {
+	doc, err := openapi.Load()
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+
+	var specRoutes []string
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			specRoutes = append(specRoutes, method+" "+path)
+		}
+	}
+
+	s := newTestServer(t, backends["memory"](t))
+	var routerRoutes []string
+	err = chi.Walk(s.router.(chi.Routes), func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		routerRoutes = append(routerRoutes, method+" "+strings.TrimSuffix(route, "/"))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+
+	for _, route := range routerRoutes {
+		if !slices.Contains(specRoutes, route) {
+			t.Errorf("%s is routed but not in the spec", route)
+		}
+	}
+	for _, route := range specRoutes {
+		if !slices.Contains(routerRoutes, route) && !slices.Contains(optionalRoutes, route) {
+			t.Errorf("%s is in the spec but not routed", route)
+		}
+	}
+}
+
+func TestServeSpec(t *testing.T) {
+	s := newTestServer(t, backends["memory"](t))
+
+	var spec struct {
+		OpenAPI string `json:"openapi"`
+	}
+	s.expect(s.do("GET", "/openapi.json", "", nil), http.StatusOK, &spec)
+	if spec.OpenAPI != "3.1.0" {
+		t.Fatalf("openapi = %q", spec.OpenAPI)
+	}
+
+	docs := s.do("GET", "/docs", "", nil)
+	if docs.status != http.StatusOK || !strings.Contains(string(docs.body), "/openapi.json") {
+		t.Fatalf("docs = %d %s", docs.status, docs.body)
+	}
+}
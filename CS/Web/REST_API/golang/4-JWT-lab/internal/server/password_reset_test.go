@@ -0,0 +1,130 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/grainme/movie-api/internal/apitest"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/mailer"
+)
+
+func TestPasswordReset(t *testing.T) {
+	for name, newDeps := range backends {
+		t.Run(name, func(t *testing.T) {
+			outbox := &recordingMailer{}
+			deps := newDeps(t)
+			deps.Mailer = outbox
+			s := newTestServer(t, deps)
+
+			credentials := domain.CreateUserRequest{Username: "alice", Password: apitest.Password, Email: "alice@example.com"}
+			s.expect(s.do("POST", "/auth/register", "", credentials), http.StatusCreated, nil)
+
+			var session domain.UserResponse
+			s.expect(s.do("POST", "/auth/login", "", credentials), http.StatusOK, &session)
+
+			// unknown emails get the same answer, and no email
+			resp := s.do("POST", "/auth/password/forgot", "", domain.ForgotPasswordRequest{Email: "nobody@example.com"})
+			s.expect(resp, http.StatusAccepted, nil)
+			if len(resp.body) != 0 {
+				t.Fatalf("forgot body = %q, want none", resp.body)
+			}
+			if outbox.count() != 0 {
+				t.Fatalf("emails sent for an unknown address: %d", outbox.count())
+			}
+
+			s.expect(s.do("POST", "/auth/password/forgot", "", domain.ForgotPasswordRequest{Email: "alice@example.com"}), http.StatusAccepted, nil)
+			token := outbox.resetToken(t)
+
+			// a weak password doesn't burn the token
+			s.expect(s.do("POST", "/auth/password/reset", "", domain.ResetPasswordRequest{Token: token, Password: "short"}), http.StatusBadRequest, nil)
+
+			newPassword := "another horse battery"
+			s.expect(s.do("POST", "/auth/password/reset", "", domain.ResetPasswordRequest{Token: token, Password: newPassword}), http.StatusNoContent, nil)
+
+			// single use
+			s.expect(s.do("POST", "/auth/password/reset", "", domain.ResetPasswordRequest{Token: token, Password: newPassword}), http.StatusBadRequest, nil)
+
+			// the sessions opened with the old password are gone
+			s.expect(s.do("POST", "/auth/refresh", "", refreshBody(session.RefreshToken)), http.StatusBadRequest, nil)
+
+			s.expect(s.do("POST", "/auth/login", "", credentials), http.StatusBadRequest, nil)
+			credentials.Password = newPassword
+			s.expect(s.do("POST", "/auth/login", "", credentials), http.StatusOK, nil)
+		})
+	}
+}
+
+// a failure after the user lookup (token store, mailer) would only happen
+// for registered emails: it must not show in the response
+func TestForgotPasswordHidesFailures(t *testing.T) {
+	for name, newDeps := range backends {
+		t.Run(name, func(t *testing.T) {
+			deps := newDeps(t)
+			deps.Mailer = &recordingMailer{err: errors.New("smtp is down")}
+			s := newTestServer(t, deps)
+
+			s.expect(s.do("POST", "/auth/register", "", domain.CreateUserRequest{Username: "bob", Password: apitest.Password, Email: "bob@example.com"}), http.StatusCreated, nil)
+
+			for _, email := range []string{"bob@example.com", "nobody@example.com"} {
+				resp := s.do("POST", "/auth/password/forgot", "", domain.ForgotPasswordRequest{Email: email})
+				s.expect(resp, http.StatusAccepted, nil)
+				if len(resp.body) != 0 {
+					t.Fatalf("forgot %s: body = %q, want none", email, resp.body)
+				}
+			}
+		})
+	}
+}
+
+// recordingMailer keeps the emails instead of sending them
+type recordingMailer struct {
+	mu       sync.Mutex
+	messages []mailer.Message
+	err      error
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.err != nil {
+		return m.err
+	}
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+func (m *recordingMailer) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.messages)
+}
+
+var resetLink = regexp.MustCompile(`https?://\S+`)
+
+// resetToken reads the token out of the link of the last email
+func (m *recordingMailer) resetToken(t *testing.T) string {
+	t.Helper()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.messages) == 0 {
+		t.Fatal("no email sent")
+	}
+	link, err := url.Parse(resetLink.FindString(m.messages[len(m.messages)-1].Body))
+	if err != nil {
+		t.Fatalf("parse reset link: %v", err)
+	}
+	token := link.Query().Get("token")
+	if token == "" {
+		t.Fatalf("no token in %s", link)
+	}
+	return token
+}
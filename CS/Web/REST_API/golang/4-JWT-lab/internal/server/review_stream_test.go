@@ -0,0 +1,205 @@
+package server_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+func TestReviewStream(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		token := s.adminToken("root")
+		movie := s.createMovie(token, "Heat")
+		streamPath := "/movies/" + movie.ID.String() + "/reviews/stream"
+
+		sse := s.openSSE(streamPath, "")
+		first := s.postReview(token, movie.ID, 7)
+		event := sse.next()
+		if event.Review.ID != first.ID || event.ID == "" {
+			t.Fatalf("streamed %+v, want review %s", event, first.ID)
+		}
+		sse.close()
+
+		// missed while disconnected: replayed after Last-Event-ID, then live
+		second := s.postReview(token, movie.ID, 8)
+		resumed := s.openSSE(streamPath, event.ID)
+		defer resumed.close()
+		third := s.postReview(token, movie.ID, 9)
+		for _, want := range []domain.Review{second, third} {
+			if got := resumed.next(); got.Review.ID != want.ID {
+				t.Fatalf("after resume, streamed review %s, want %s", got.Review.ID, want.ID)
+			}
+		}
+
+		s.expect(s.do("GET", streamPath+"?last_event_id=not-an-id", "", nil), http.StatusBadRequest, nil)
+		s.expect(s.do("GET", "/movies/"+uuid.NewString()+"/reviews/stream", "", nil), http.StatusNotFound, nil)
+	})
+}
+
+func TestReviewWebSocket(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		token := s.adminToken("root")
+		movie := s.createMovie(token, "Ronin")
+		wsPath := "/movies/" + movie.ID.String() + "/reviews/ws"
+
+		conn := s.dialWebSocket(wsPath)
+		first := s.postReview(token, movie.ID, 6)
+		event := readFeedReview(t, conn)
+		if event.Review.ID != first.ID {
+			t.Fatalf("ws got %+v, want review %s", event, first.ID)
+		}
+		conn.Close()
+
+		second := s.postReview(token, movie.ID, 9)
+		resumed := s.dialWebSocket(wsPath + "?last_event_id=" + event.ID)
+		defer resumed.Close()
+		if got := readFeedReview(t, resumed); got.Review.ID != second.ID {
+			t.Fatalf("ws after resume got review %s, want %s", got.Review.ID, second.ID)
+		}
+
+		// the errors are plain HTTP answers, before the upgrade
+		s.expect(s.do("GET", "/movies/"+uuid.NewString()+"/reviews/ws", "", nil), http.StatusNotFound, nil)
+		s.expect(s.do("GET", wsPath+"?last_event_id=42", "", nil), http.StatusBadRequest, nil)
+	})
+}
+
+func TestReviewStreamsEndOnShutdown(t *testing.T) {
+	shutdown := make(chan struct{})
+	deps := backends["memory"](t)
+	deps.Shutdown = shutdown
+	s := newTestServer(t, deps)
+
+	movie := s.createMovie(s.adminToken("root"), "Thief")
+	sse := s.openSSE("/movies/"+movie.ID.String()+"/reviews/stream", "")
+	defer sse.close()
+	conn := s.dialWebSocket("/movies/" + movie.ID.String() + "/reviews/ws")
+	defer conn.Close()
+
+	close(shutdown)
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Fatalf("ws read after shutdown: err = %v, want a going away close", err)
+	}
+	// the body ends (before the deadline, or the read fails)
+	timer := time.AfterFunc(5*time.Second, func() { sse.resp.Body.Close() })
+	defer timer.Stop()
+	for sse.scanner.Scan() {
+	}
+	if err := sse.scanner.Err(); err != nil {
+		t.Fatalf("sse after shutdown: %v", err)
+	}
+}
+
+// -------- helpers
+
+func (s *testServer) createMovie(token, title string) domain.Movie {
+	s.t.Helper()
+
+	var movie domain.Movie
+	s.expect(s.do("POST", "/movies", token, domain.Movie{Title: title}), http.StatusCreated, &movie)
+	return movie
+}
+
+func (s *testServer) postReview(token string, movieId uuid.UUID, rating int32) domain.Review {
+	s.t.Helper()
+
+	var review domain.Review
+	s.expect(s.do("POST", "/reviews", token, domain.CreateReviewRequest{Rating: rating, MovieID: movieId}), http.StatusCreated, &review)
+	return review
+}
+
+// sseStream reads the review events of a text/event-stream
+type sseStream struct {
+	t       *testing.T
+	resp    *http.Response
+	scanner *bufio.Scanner
+}
+
+// openSSE returns once subscribed (the headers are sent after the subscription)
+func (s *testServer) openSSE(path, lastEventId string) *sseStream {
+	s.t.Helper()
+
+	req, err := http.NewRequest("GET", s.url+path, nil)
+	if err != nil {
+		s.t.Fatalf("new request: %v", err)
+	}
+	if lastEventId != "" {
+		req.Header.Set("Last-Event-ID", lastEventId)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		s.t.Fatalf("GET %s: %v", path, err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		resp.Body.Close()
+		s.t.Fatalf("GET %s = %d %s", path, resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	return &sseStream{t: s.t, resp: resp, scanner: bufio.NewScanner(resp.Body)}
+}
+
+// next skips to the next review event
+func (sse *sseStream) next() domain.FeedReview {
+	sse.t.Helper()
+
+	// the handler writes before the deadline or the test hangs
+	timer := time.AfterFunc(5*time.Second, func() { sse.resp.Body.Close() })
+	defer timer.Stop()
+
+	var event domain.FeedReview
+	var isReview bool
+	for sse.scanner.Scan() {
+		field, value, _ := strings.Cut(sse.scanner.Text(), ": ")
+		switch field {
+		case "id":
+			event.ID = value
+		case "event":
+			isReview = value == "review"
+		case "data":
+			if err := json.Unmarshal([]byte(value), &event.Review); err != nil {
+				sse.t.Fatalf("decode %s: %v", value, err)
+			}
+		case "":
+			if isReview {
+				return event
+			}
+		}
+	}
+
+	sse.t.Fatalf("stream ended: %v", sse.scanner.Err())
+	return event
+}
+
+func (sse *sseStream) close() {
+	sse.resp.Body.Close()
+}
+
+func (s *testServer) dialWebSocket(path string) *websocket.Conn {
+	s.t.Helper()
+
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.url, "http")+path, nil)
+	if err != nil {
+		s.t.Fatalf("dial %s: %v", path, err)
+	}
+	resp.Body.Close()
+	return conn
+}
+
+func readFeedReview(t *testing.T, conn *websocket.Conn) domain.FeedReview {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var event domain.FeedReview
+	if err := conn.ReadJSON(&event); err != nil {
+		t.Fatalf("ws read: %v", err)
+	}
+	return event
+}
@@ -0,0 +1,219 @@
+// Package server wires the services and handlers into the HTTP router.
+// cmd/api builds it on Postgres/SQLite and Redis, the tests on the in-memory
+// repositories and cache: same routes, same middlewares.
+// the gRPC server (internal/grpcserver) and the GraphQL endpoint
+// (internal/graph) run on the same Services.
+package server
+
+import (
+	"maps"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/grainme/movie-api/internal/cache"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/graph"
+	handlers "github.com/grainme/movie-api/internal/handler"
+	"github.com/grainme/movie-api/internal/mailer"
+	"github.com/grainme/movie-api/internal/middleware"
+	"github.com/grainme/movie-api/internal/oidc"
+	"github.com/grainme/movie-api/internal/openapi"
+	"github.com/grainme/movie-api/internal/repository"
+	"github.com/grainme/movie-api/internal/service"
+)
+
+// Deps are the backends the API runs on
+type Deps struct {
+	Movies  repository.MovieRepository
+	Reviews repository.ReviewRepository
+	Users   repository.UserRepository
+	APIKeys repository.APIKeyRepository
+	Audit   repository.AuditRepository
+	// the subscriptions and their deliveries (sent by a service.WebhookDispatcher)
+	Webhooks repository.WebhookRepository
+
+	MovieCache cache.MovieCache
+	Lists      cache.ListCache
+	Trending   cache.TrendingStore
+	Sessions   cache.SessionStore
+	ReviewFeed cache.ReviewFeed
+
+	Mailer mailer.Mailer
+	// optional: nil disables the OIDC login routes
+	OIDC *oidc.Provider
+	// optional: closed when the server shuts down, ends the review streams
+	// (SSE, WebSocket) so the server isn't kept waiting for them
+	Shutdown <-chan struct{}
+	// optional: the Cache-Control of the read routes, by route pattern, over
+	// DefaultCacheControl ("" sends none)
+	CacheControl map[string]string
+	// optional: no request logs when false (the tests)
+	RequestLogs bool
+	// optional: checks the traffic against the OpenAPI document (the tests)
+	SpecValidator *openapi.Validator
+}
+
+// DefaultCacheControl is the Cache-Control of the read routes (they all get
+// an ETag, see middleware.Conditional). no-cache: clients and CDNs keep the
+// payload but check it's still current every time, a 304 when it is. the
+// lists change with every write, and a movie served from a CDN wouldn't count
+// its view. the rankings only move a little between two view flushes
+var DefaultCacheControl = map[string]string{
+	"/movies":              "public, no-cache",
+	"/movies/popular":      "public, max-age=30",
+	"/movies/trending":     "public, max-age=30",
+	"/movies/{id}":         "public, no-cache",
+	"/movies/{id}/reviews": "public, no-cache",
+	"/reviews":             "public, no-cache",
+	"/reviews/{id}":        "public, no-cache",
+}
+
+// Services is the business logic, whatever the transport
+type Services struct {
+	Movies  *service.MovieService
+	Reviews *service.ReviewService
+	Users   *service.UserService
+	APIKeys *service.APIKeyService
+	Bulk    *service.BulkService
+	Audit   *service.AuditLogger
+	// queues the deliveries of the movie and review events
+	Webhooks *service.WebhookService
+	// nil when deps.OIDC is
+	OIDC *service.OIDCService
+}
+
+func NewServices(deps Deps) *Services {
+	auditLogger := service.NewAuditLogger(deps.Audit)
+	userService := service.NewUserService(deps.Users, deps.Sessions, deps.Mailer, auditLogger)
+	webhookService := service.NewWebhookService(deps.Webhooks, auditLogger)
+
+	services := &Services{
+		Movies:   service.NewMovieService(deps.Movies, deps.MovieCache, deps.Lists, deps.Trending, auditLogger),
+		Reviews:  service.NewReviewService(deps.Reviews, deps.MovieCache, deps.Lists, deps.Trending, deps.ReviewFeed),
+		Users:    userService,
+		APIKeys:  service.NewAPIKeyService(deps.APIKeys, auditLogger),
+		Bulk:     service.NewBulkService(deps.Movies, deps.Reviews, deps.Lists, auditLogger),
+		Audit:    auditLogger,
+		Webhooks: webhookService,
+	}
+	// OIDC login is optional: only enabled when a provider is configured
+	if deps.OIDC != nil {
+		services.OIDC = service.NewOIDCService(deps.OIDC, deps.Users, userService, deps.Sessions)
+	}
+
+	return services
+}
+
+func NewRouter(deps Deps) http.Handler {
+	services := NewServices(deps)
+	apiKeyService := services.APIKeys
+
+	movieHandler := handlers.NewMovieHandler(services.Movies)
+	reviewHandler := handlers.NewReviewHandler(services.Reviews, services.Users)
+	reviewStreamHandler := handlers.NewReviewStreamHandler(services.Movies, services.Reviews, deps.Shutdown)
+	userHandler := handlers.NewUserHandler(services.Users)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	auditHandler := handlers.NewAuditHandler(services.Audit)
+	bulkHandler := handlers.NewBulkHandler(services.Bulk)
+	webhookHandler := handlers.NewWebhookHandler(services.Webhooks)
+	graphHandler := graph.NewHandler(services.Movies, services.Reviews, services.Users, services.Audit)
+
+	var oidcHandler *handlers.OIDCHandler
+	if services.OIDC != nil {
+		oidcHandler = handlers.NewOIDCHandler(services.OIDC)
+	}
+
+	cacheControl := maps.Clone(DefaultCacheControl)
+	maps.Copy(cacheControl, deps.CacheControl)
+	// ETag, If-None-Match and Cache-Control
+	cacheable := func(pattern string) func(http.Handler) http.Handler {
+		return middleware.Conditional(cacheControl[pattern])
+	}
+
+	r := chi.NewRouter()
+	if deps.RequestLogs {
+		r.Use(chimw.Logger)
+	}
+	if deps.SpecValidator != nil {
+		r.Use(deps.SpecValidator.Middleware)
+	}
+	// client ip + user agent for the audit log
+	r.Use(middleware.RequestMeta)
+
+	// the API description
+	r.Get("/openapi.json", openapi.ServeSpec)
+	r.Get("/docs", openapi.ServeDocs)
+
+	// movie routes
+	r.With(cacheable("/movies")).Get("/movies", movieHandler.GetAllMovies)
+	r.With(cacheable("/movies/popular")).Get("/movies/popular", movieHandler.GetPopularMovies)
+	r.With(cacheable("/movies/trending")).Get("/movies/trending", movieHandler.GetTrendingMovies)
+	r.With(cacheable("/movies/{id}")).Get("/movies/{id}", movieHandler.GetMovieById)
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Authenticate(apiKeyService))
+		r.With(middleware.RequirePermission(services.Audit, domain.PermMoviesWrite)).Post("/movies", movieHandler.AddMovie)
+		r.With(middleware.RequirePermission(services.Audit, domain.PermMoviesWrite)).Put("/movies/{id}", movieHandler.UpdateMovieTitleById)
+		r.With(middleware.Authorize(services.Audit), middleware.RequirePermission(services.Audit, domain.PermMoviesDelete)).Delete("/movies/{id}", movieHandler.DeleteById)
+	})
+	r.With(cacheable("/movies/{id}/reviews")).Get("/movies/{id}/reviews", movieHandler.GetMovieWithReviews)
+	// the new reviews, live
+	r.Get("/movies/{id}/reviews/stream", reviewStreamHandler.StreamReviews)
+	r.Get("/movies/{id}/reviews/ws", reviewStreamHandler.WatchReviews)
+
+	// reviews routes
+	r.With(cacheable("/reviews")).Get("/reviews", reviewHandler.GetAllReviews)
+	r.With(middleware.Authenticate(apiKeyService), middleware.RequirePermission(services.Audit, domain.PermReviewsWrite)).Post("/reviews", reviewHandler.AddReview)
+	r.With(cacheable("/reviews/{id}")).Get("/reviews/{id}", reviewHandler.GetAllReviewsByMovieId)
+
+	// GraphQL: the queries are public, the mutations check the claims
+	r.With(middleware.OptionalAuthenticate(apiKeyService)).Post("/graphql", graphHandler.ServeHTTP)
+
+	// auth routes
+	r.Post("/auth/login", userHandler.Login)
+	r.Post("/auth/login/mfa", userHandler.LoginMFA)
+	r.Post("/auth/register", userHandler.Register)
+	r.Post("/auth/refresh", userHandler.RefreshToken)
+	r.Post("/auth/logout", userHandler.Logout)
+	r.Post("/auth/password/forgot", userHandler.ForgotPassword)
+	r.Post("/auth/password/reset", userHandler.ResetPassword)
+	if oidcHandler != nil {
+		r.Get("/auth/oidc/login", oidcHandler.Login)
+		r.Get("/auth/oidc/callback", oidcHandler.Callback)
+	}
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Authenticate(nil))
+		r.Post("/auth/2fa/enroll", userHandler.EnrollTOTP)
+		r.Post("/auth/2fa/confirm", userHandler.ConfirmTOTP)
+		r.Post("/auth/2fa/disable", userHandler.DisableTOTP)
+		if oidcHandler != nil {
+			r.Post("/auth/oidc/link", oidcHandler.Link)
+		}
+	})
+
+	// admin routes
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(middleware.Authenticate(apiKeyService))
+		r.Use(middleware.Authorize(services.Audit))
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RejectAPIKeys(services.Audit))
+			r.Post("/api-keys", apiKeyHandler.CreateAPIKey)
+			r.Get("/api-keys", apiKeyHandler.ListAPIKeys)
+			r.Delete("/api-keys/{id}", apiKeyHandler.RevokeAPIKey)
+			r.Get("/audit", auditHandler.ListEvents)
+			r.Post("/webhooks", webhookHandler.CreateWebhook)
+			r.Get("/webhooks", webhookHandler.ListWebhooks)
+			r.Delete("/webhooks/{id}", webhookHandler.DeleteWebhook)
+			r.Get("/webhooks/{id}/deliveries", webhookHandler.ListDeliveries)
+			r.Post("/webhooks/{id}/deliveries/{deliveryId}/redeliver", webhookHandler.Redeliver)
+		})
+
+		// bulk import/export, API keys welcome (scripts)
+		r.With(middleware.RequirePermission(services.Audit, domain.PermMoviesWrite)).Post("/import/movies", bulkHandler.ImportMovies)
+		r.With(middleware.RequirePermission(services.Audit, domain.PermReviewsWrite)).Post("/import/reviews", bulkHandler.ImportReviews)
+		r.With(middleware.RequirePermission(services.Audit, domain.PermCatalogExport)).Get("/export/movies", bulkHandler.ExportMovies)
+		r.With(middleware.RequirePermission(services.Audit, domain.PermCatalogExport)).Get("/export/reviews", bulkHandler.ExportReviews)
+	})
+
+	return r
+}
@@ -0,0 +1,229 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/apitest"
+	"github.com/grainme/movie-api/internal/auth"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/service"
+)
+
+func TestWebhooks(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		token := s.adminToken("root")
+		receiver := newWebhookReceiver(t)
+
+		// a tiny backoff: the retries are due right away
+		policy := service.WebhookRetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+		dispatcher := service.NewWebhookDispatcher(s.deps.Webhooks, nil, time.Hour, policy)
+		dispatch := func(want int) {
+			t.Helper()
+			time.Sleep(10 * time.Millisecond)
+			succeeded, err := dispatcher.Dispatch(context.Background())
+			if err != nil || succeeded != want {
+				t.Fatalf("dispatch = %d, %v, want %d", succeeded, err, want)
+			}
+		}
+
+		// only absolute http(s) URLs
+		s.expect(s.do("POST", "/admin/webhooks", token, domain.CreateWebhookRequest{URL: "ftp://example.com", Events: []string{domain.EventMovieCreated}}), http.StatusBadRequest, nil)
+
+		var webhook domain.CreatedWebhook
+		s.expect(s.do("POST", "/admin/webhooks", token, domain.CreateWebhookRequest{URL: receiver.url, Events: []string{domain.EventReviewCreated, domain.EventMovieCreated}}), http.StatusCreated, &webhook)
+		if webhook.Secret == "" || len(webhook.Events) != 2 {
+			t.Fatalf("created webhook = %+v", webhook)
+		}
+		receiver.secret = webhook.Secret
+		deliveriesPath := "/admin/webhooks/" + webhook.ID.String() + "/deliveries"
+
+		// the secret is only shown once
+		var webhooks []map[string]any
+		s.expect(s.do("GET", "/admin/webhooks", token, nil), http.StatusOK, &webhooks)
+		if len(webhooks) != 1 || webhooks[0]["secret"] != nil {
+			t.Fatalf("webhooks = %+v", webhooks)
+		}
+
+		// a movie and its review: one signed delivery each
+		var movie domain.Movie
+		s.expect(s.do("POST", "/movies", token, domain.Movie{Title: "Heat", Director: "Michael Mann", Year: 1995}), http.StatusCreated, &movie)
+		var review domain.Review
+		s.expect(s.do("POST", "/reviews", token, domain.CreateReviewRequest{Rating: 5, MovieID: movie.ID}), http.StatusCreated, &review)
+		// not subscribed
+		s.expect(s.do("PUT", "/movies/"+movie.ID.String(), token, map[string]string{"title": "Heat (1995)"}), http.StatusNoContent, nil)
+
+		// sent concurrently: in any order
+		dispatch(2)
+		received := map[string]webhookEvent{}
+		for _, event := range receiver.take(2) {
+			received[event.Type] = event
+		}
+		var receivedMovie domain.Movie
+		if err := json.Unmarshal(received[domain.EventMovieCreated].Data, &receivedMovie); err != nil || receivedMovie.ID != movie.ID {
+			t.Fatalf("movie.created data = %s (%v)", received[domain.EventMovieCreated].Data, err)
+		}
+		var receivedReview domain.Review
+		if err := json.Unmarshal(received[domain.EventReviewCreated].Data, &receivedReview); err != nil || receivedReview.ID != review.ID {
+			t.Fatalf("review.created data = %s (%v)", received[domain.EventReviewCreated].Data, err)
+		}
+
+		// the receiver goes down: retried, then dead after MaxAttempts
+		receiver.setStatus(http.StatusInternalServerError)
+		s.expect(s.do("POST", "/reviews", token, domain.CreateReviewRequest{Rating: 3, MovieID: movie.ID}), http.StatusCreated, nil)
+
+		dispatch(0)
+		var deliveries []domain.WebhookDelivery
+		s.expect(s.do("GET", deliveriesPath, token, nil), http.StatusOK, &deliveries)
+		if len(deliveries) != 3 || deliveries[0].Status != domain.DeliveryPending || deliveries[0].Attempts != 1 || deliveries[0].ResponseStatus != http.StatusInternalServerError {
+			t.Fatalf("after a failed attempt, deliveries = %+v", deliveries)
+		}
+
+		dispatch(0)
+		s.expect(s.do("GET", deliveriesPath+"?limit=1", token, nil), http.StatusOK, &deliveries)
+		dead := deliveries[0]
+		if len(deliveries) != 1 || dead.Status != domain.DeliveryDead || dead.Attempts != 2 || dead.LastError == "" {
+			t.Fatalf("after the last attempt, deliveries = %+v", deliveries)
+		}
+		failed := receiver.take(2)
+
+		// dead: not retried anymore
+		dispatch(0)
+
+		// back up: the redelivery carries the same event
+		receiver.setStatus(http.StatusNoContent)
+		var redelivery domain.WebhookDelivery
+		s.expect(s.do("POST", deliveriesPath+"/"+dead.ID.String()+"/redeliver", token, nil), http.StatusAccepted, &redelivery)
+		if redelivery.ID == dead.ID || redelivery.EventID != dead.EventID || redelivery.Status != domain.DeliveryPending {
+			t.Fatalf("redelivery = %+v of %+v", redelivery, dead)
+		}
+
+		dispatch(1)
+		redelivered := receiver.take(1)
+		if redelivered[0].ID != failed[0].ID || redelivered[0].ID != dead.EventID {
+			t.Fatalf("redelivered event %s, want %s", redelivered[0].ID, dead.EventID)
+		}
+
+		s.expect(s.do("GET", deliveriesPath+"?limit=1", token, nil), http.StatusOK, &deliveries)
+		if deliveries[0].ID != redelivery.ID || deliveries[0].Status != domain.DeliverySucceeded || deliveries[0].ResponseStatus != http.StatusNoContent {
+			t.Fatalf("after the redelivery, deliveries = %+v", deliveries)
+		}
+
+		// and it's in the audit log
+		var events []domain.AuditEvent
+		s.expect(s.do("GET", "/admin/audit?action="+domain.AuditWebhookRedeliver, token, nil), http.StatusOK, &events)
+		if len(events) != 1 || events[0].TargetID != dead.ID.String() {
+			t.Fatalf("audit events = %+v", events)
+		}
+
+		// unknown ids
+		unknown := uuid.NewString()
+		s.expect(s.do("POST", deliveriesPath+"/"+unknown+"/redeliver", token, nil), http.StatusNotFound, nil)
+		s.expect(s.do("GET", "/admin/webhooks/"+unknown+"/deliveries", token, nil), http.StatusNotFound, nil)
+
+		// deleted with its deliveries
+		s.expect(s.do("DELETE", "/admin/webhooks/"+webhook.ID.String(), token, nil), http.StatusNoContent, nil)
+		s.expect(s.do("DELETE", "/admin/webhooks/"+webhook.ID.String(), token, nil), http.StatusNotFound, nil)
+		s.expect(s.do("GET", deliveriesPath, token, nil), http.StatusNotFound, nil)
+	})
+}
+
+// adminToken registers an admin and logs them in
+func (s *testServer) adminToken(username string) string {
+	s.t.Helper()
+
+	s.expect(s.do("POST", "/auth/register", "", domain.CreateUserRequest{Username: username, Password: apitest.Password}), http.StatusCreated, nil)
+	user, err := s.deps.Users.FindUserByName(context.Background(), username)
+	if err != nil {
+		s.t.Fatalf("find user: %v", err)
+	}
+	if err := s.deps.Users.UpdateUserRole(context.Background(), user.ID, domain.Admin); err != nil {
+		s.t.Fatalf("update role: %v", err)
+	}
+
+	var session domain.UserResponse
+	s.expect(s.do("POST", "/auth/login", "", domain.CreateUserRequest{Username: username, Password: apitest.Password}), http.StatusOK, &session)
+	return session.AccessToken
+}
+
+// -------- the partner's side
+
+// the body of a delivery
+type webhookEvent struct {
+	ID   uuid.UUID       `json:"id"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// webhookReceiver checks the signature of every delivery and keeps the events
+type webhookReceiver struct {
+	t      *testing.T
+	url    string
+	secret string
+
+	mu     sync.Mutex
+	status int
+	events []webhookEvent
+}
+
+func newWebhookReceiver(t *testing.T) *webhookReceiver {
+	receiver := &webhookReceiver{t: t, status: http.StatusOK}
+	ts := httptest.NewServer(http.HandlerFunc(receiver.serveHTTP))
+	t.Cleanup(ts.Close)
+	receiver.url = ts.URL + "/hooks"
+	return receiver
+}
+
+func (rc *webhookReceiver) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		rc.t.Errorf("read delivery: %v", err)
+		return
+	}
+
+	timestamp, err := strconv.ParseInt(r.Header.Get("X-Webhook-Timestamp"), 10, 64)
+	if err != nil || !auth.VerifyWebhookSignature(rc.secret, timestamp, body, r.Header.Get("X-Webhook-Signature")) {
+		rc.t.Errorf("bad signature %q (timestamp %q)", r.Header.Get("X-Webhook-Signature"), r.Header.Get("X-Webhook-Timestamp"))
+	}
+
+	var event webhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		rc.t.Errorf("decode delivery %s: %v", body, err)
+	}
+	if r.Header.Get("X-Webhook-Event") != event.Type || r.Header.Get("X-Webhook-Event-Id") != event.ID.String() {
+		rc.t.Errorf("headers %v don't match the event %s %s", r.Header, event.Type, event.ID)
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.events = append(rc.events, event)
+	w.WriteHeader(rc.status)
+}
+
+func (rc *webhookReceiver) setStatus(status int) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.status = status
+}
+
+// take returns the n events received since the last take
+func (rc *webhookReceiver) take(n int) []webhookEvent {
+	rc.t.Helper()
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if len(rc.events) != n {
+		rc.t.Fatalf("received %d events, want %d", len(rc.events), n)
+	}
+	events := rc.events
+	rc.events = nil
+	return events
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/auth"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/repository"
+)
+
+// last_used_at is informative, no need to write it on every request
+const apiKeyTouchInterval = time.Minute
+
+type APIKeyService struct {
+	apiKeyRepo repository.APIKeyRepository
+	audit      *AuditLogger
+}
+
+func NewAPIKeyService(repo repository.APIKeyRepository, audit *AuditLogger) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo: repo,
+		audit:      audit,
+	}
+}
+
+// ownerRole: role of the admin creating the key, a key can't get more than its owner
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, ownerId uuid.UUID, ownerRole domain.Role, request domain.CreateAPIKeyRequest) (domain.CreatedAPIKey, error) {
+	if request.Name == "" || len(request.Name) > 100 {
+		return domain.CreatedAPIKey{}, errors.New("name is required (max 100 chars)")
+	}
+	if len(request.Scopes) == 0 || request.ExpiresInDays < 0 {
+		return domain.CreatedAPIKey{}, domain.ErrInvalidScope
+	}
+	for _, scope := range request.Scopes {
+		if !ownerRole.Can(scope) {
+			return domain.CreatedAPIKey{}, domain.ErrInvalidScope
+		}
+	}
+
+	rawKey, prefix, err := auth.GenerateAPIKey()
+	if err != nil {
+		return domain.CreatedAPIKey{}, err
+	}
+
+	key := domain.APIKey{
+		UserID: ownerId,
+		Name:   request.Name,
+		Prefix: prefix,
+		Scopes: slices.Compact(slices.Sorted(slices.Values(request.Scopes))),
+	}
+	if request.ExpiresInDays > 0 {
+		expiresAt := time.Now().Add(time.Duration(request.ExpiresInDays) * 24 * time.Hour)
+		key.ExpiresAt = &expiresAt
+	}
+
+	// like passwords: only the hash is stored
+	createdKey, err := s.apiKeyRepo.AddAPIKey(ctx, key, auth.HashToken(rawKey))
+	if err != nil {
+		return domain.CreatedAPIKey{}, err
+	}
+
+	s.audit.Log(ctx, domain.AuditEvent{
+		Action:     domain.AuditAPIKeyCreate,
+		TargetType: "api_key",
+		TargetID:   createdKey.ID.String(),
+		Outcome:    domain.AuditSuccess,
+		Detail:     fmt.Sprintf("name=%q scopes=%v", createdKey.Name, createdKey.Scopes),
+	})
+
+	return domain.CreatedAPIKey{
+		APIKey: createdKey,
+		Key:    rawKey,
+	}, nil
+}
+
+func (s *APIKeyService) ListAPIKeys(ctx context.Context) ([]domain.APIKey, error) {
+	return s.apiKeyRepo.ListAPIKeys(ctx)
+}
+
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	err := s.apiKeyRepo.RevokeAPIKey(ctx, id)
+	s.audit.Log(ctx, domain.AuditEvent{
+		Action:     domain.AuditAPIKeyRevoke,
+		TargetType: "api_key",
+		TargetID:   id.String(),
+		Outcome:    auditOutcome(err),
+		Detail:     auditDetail(err),
+	})
+
+	return err
+}
+
+// ResolveAPIKey turns an X-API-Key header into a principal (used by middleware.Authenticate).
+// the principal is the key's owner, restricted to the key scopes.
+func (s *APIKeyService) ResolveAPIKey(ctx context.Context, rawKey string) (*auth.Claims, error) {
+	if !auth.LooksLikeAPIKey(rawKey) {
+		return nil, domain.ErrInvalidAPIKey
+	}
+
+	key, err := s.apiKeyRepo.FindAPIKeyByHash(ctx, auth.HashToken(rawKey))
+	if errors.Is(err, domain.ErrAPIKeyNotFound) {
+		return nil, domain.ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && now.After(*key.ExpiresAt)) {
+		return nil, domain.ErrInvalidAPIKey
+	}
+
+	// the owner could have been demoted since: the key loses what the owner lost
+	scopes := []domain.Permission{}
+	for _, scope := range key.Scopes {
+		if key.OwnerRole.Can(scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > apiKeyTouchInterval {
+		// same idea as the view counter: don't make the request wait for it
+		go func() {
+			if err := s.apiKeyRepo.TouchAPIKey(context.Background(), key.ID, now); err != nil {
+				log.Printf("failed to update last_used_at for api key %s: %v", key.ID, err)
+			}
+		}()
+	}
+
+	claims := &auth.Claims{
+		Role:     string(key.OwnerRole),
+		APIKeyID: key.ID.String(),
+		Scopes:   scopes,
+	}
+	claims.Subject = key.UserID.String()
+
+	return claims, nil
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/auth"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/repository"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 200
+)
+
+// AuditLogger records security relevant actions (logins, 2FA changes, deletes,
+// admin operations) in the append-only audit_events table.
+type AuditLogger struct {
+	auditRepo repository.AuditRepository
+}
+
+func NewAuditLogger(repo repository.AuditRepository) *AuditLogger {
+	return &AuditLogger{
+		auditRepo: repo,
+	}
+}
+
+// Log completes the event with what the context knows (authenticated actor,
+// IP, user agent) and writes it.
+// an audit failure never fails the action itself, it only ends up in the logs.
+// a nil logger does nothing.
+func (l *AuditLogger) Log(ctx context.Context, event domain.AuditEvent) {
+	if l == nil {
+		return
+	}
+
+	event.OccurredAt = time.Now()
+
+	if claims, ok := ctx.Value("user").(*auth.Claims); ok && event.ActorID == nil {
+		if actorId, err := uuid.Parse(claims.Subject); err == nil {
+			event.ActorID = &actorId
+		}
+		if keyId, err := uuid.Parse(claims.APIKeyID); err == nil {
+			event.APIKeyID = &keyId
+		}
+	}
+
+	if meta, ok := ctx.Value("request_meta").(domain.RequestMeta); ok {
+		event.IP = meta.IP
+		event.UserAgent = meta.UserAgent
+	}
+
+	// the request could be cancelled right after the action, the event must still be written
+	if err := l.auditRepo.AddAuditEvent(context.WithoutCancel(ctx), event); err != nil {
+		log.Printf("could not write audit event %s (%s): %v", event.Action, event.Outcome, err)
+	}
+}
+
+// `GET /admin/audit`
+func (l *AuditLogger) ListEvents(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEvent, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditPageSize
+	}
+	if filter.Limit > maxAuditPageSize {
+		filter.Limit = maxAuditPageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	events, err := l.auditRepo.ListAuditEvents(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// reading the audit log is itself audited
+	l.Log(ctx, domain.AuditEvent{
+		Action:  domain.AuditRead,
+		Outcome: domain.AuditSuccess,
+	})
+
+	return events, nil
+}
+
+func auditOutcome(err error) domain.AuditOutcome {
+	if err != nil {
+		return domain.AuditFailure
+	}
+	return domain.AuditSuccess
+}
+
+func auditDetail(err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return ""
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/bulk"
+	"github.com/grainme/movie-api/internal/cache"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/repository"
+)
+
+// rows per INSERT (5 columns → 2500 parameters, postgres takes 65535)
+const importBatchSize = 500
+
+// rows per SELECT when exporting
+const exportBatchSize = 1000
+
+// BulkService imports and exports the catalog (movies, reviews) as CSV or
+// NDJSON streams: nothing is loaded in memory beyond one batch (and the ids
+// of the imported rows, see importRecords).
+type BulkService struct {
+	movieRepo  repository.MovieRepository
+	reviewRepo repository.ReviewRepository
+	lists      cache.ListCache
+	audit      *AuditLogger
+}
+
+func NewBulkService(movieRepo repository.MovieRepository, reviewRepo repository.ReviewRepository, lists cache.ListCache, audit *AuditLogger) *BulkService {
+	return &BulkService{
+		movieRepo:  movieRepo,
+		reviewRepo: reviewRepo,
+		lists:      lists,
+		audit:      audit,
+	}
+}
+
+// ImportMovies validates every row and inserts the valid ones by batches.
+// dryRun: only validation, nothing is written. the movies without an id get a
+// new one, the ids that already exist are skipped.
+func (s *BulkService) ImportMovies(ctx context.Context, reader *bulk.Reader[domain.Movie], dryRun bool) (domain.ImportReport, error) {
+	report, err := importRecords(reader, dryRun,
+		func(movie *domain.Movie) uuid.UUID { return movie.ID },
+		func(movie *domain.Movie) (error, error) {
+			if movie.ID == uuid.Nil {
+				movie.ID = uuid.New()
+			}
+			return validateMovie(movie), nil
+		},
+		func(movies []domain.Movie) (int, error) {
+			batch := make([]*domain.Movie, len(movies))
+			for idx := range movies {
+				batch[idx] = &movies[idx]
+			}
+			return s.movieRepo.AddMovies(ctx, batch)
+		},
+		func(ids []uuid.UUID) ([]uuid.UUID, error) {
+			return s.movieRepo.GetExistingMovieIds(ctx, ids)
+		},
+	)
+	if dryRun {
+		return report, err
+	}
+
+	if report.Inserted > 0 {
+		invalidateLists(ctx, s.lists, cache.MoviesTag)
+	}
+	s.auditImport(ctx, domain.AuditMoviesImport, "movie", report, err)
+
+	return report, err
+}
+
+// ImportReviews: same as ImportMovies, the movie of every review must exist
+func (s *BulkService) ImportReviews(ctx context.Context, reader *bulk.Reader[domain.Review], dryRun bool) (domain.ImportReport, error) {
+	knownMovies := map[uuid.UUID]bool{}
+	touchedMovies := map[uuid.UUID]bool{}
+
+	report, err := importRecords(reader, dryRun,
+		func(review *domain.Review) uuid.UUID { return review.ID },
+		func(review *domain.Review) (error, error) {
+			if review.ID == uuid.Nil {
+				review.ID = uuid.New()
+			}
+			if problem := validateReview(review); problem != nil {
+				return problem, nil
+			}
+
+			exists, ok := knownMovies[review.MovieID]
+			if !ok {
+				_, err := s.movieRepo.GetMovieById(ctx, review.MovieID)
+				if err != nil && !errors.Is(err, domain.ErrMovieNotFound) {
+					return nil, err
+				}
+				exists = err == nil
+				knownMovies[review.MovieID] = exists
+			}
+			if !exists {
+				return fmt.Errorf("movie %s not found", review.MovieID), nil
+			}
+
+			touchedMovies[review.MovieID] = true
+			return nil, nil
+		},
+		func(reviews []domain.Review) (int, error) {
+			return s.reviewRepo.AddReviews(ctx, reviews)
+		},
+		func(ids []uuid.UUID) ([]uuid.UUID, error) {
+			return s.reviewRepo.GetExistingReviewIds(ctx, ids)
+		},
+	)
+	if dryRun {
+		return report, err
+	}
+
+	if report.Inserted > 0 {
+		tags := []string{cache.ReviewsTag}
+		for movieId := range touchedMovies {
+			tags = append(tags, cache.MovieTag(movieId))
+		}
+		invalidateLists(ctx, s.lists, tags...)
+	}
+	s.auditImport(ctx, domain.AuditReviewsImport, "review", report, err)
+
+	return report, err
+}
+
+// what the DB would reject anyway, with a readable message
+func validateReview(review *domain.Review) error {
+	if review.Username == "" {
+		return errors.New("user_name is required")
+	}
+	if review.Rating < 1 || review.Rating > 10 {
+		return errors.New("rating must be between 1 and 10")
+	}
+	return nil
+}
+
+// importRecords reads every record, checks it and inserts the valid ones by
+// batches of importBatchSize.
+// check returns a problem (the row is rejected and reported) or an error
+// (the import stops). a bad input (header, encoding) is a domain.ErrInvalidImport.
+// an id already used earlier in the file is a problem; the ids that exist in
+// the DB are skipped by insert, on a dry run existing says which ones would be
+// (the dry run and the import report the same counts).
+// the batches inserted before an error stay inserted, the report says how many.
+func importRecords[T any](reader *bulk.Reader[T], dryRun bool, id func(record *T) uuid.UUID, check func(record *T) (problem error, err error), insert func(records []T) (int, error), existing func(ids []uuid.UUID) ([]uuid.UUID, error)) (domain.ImportReport, error) {
+	report := domain.ImportReport{DryRun: dryRun, Errors: []domain.ImportError{}}
+	batch := make([]T, 0, importBatchSize)
+	// id → line of the valid rows so far (24 bytes a row, the only thing
+	// kept beyond a batch)
+	seen := map[uuid.UUID]int{}
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		defer func() { batch = batch[:0] }()
+
+		if dryRun {
+			ids := make([]uuid.UUID, len(batch))
+			for idx := range batch {
+				ids[idx] = id(&batch[idx])
+			}
+			taken, err := existing(ids)
+			if err != nil {
+				return err
+			}
+			report.Skipped += len(taken)
+			return nil
+		}
+
+		inserted, err := insert(batch)
+		if err != nil {
+			return err
+		}
+		report.Inserted += inserted
+		report.Skipped += len(batch) - inserted
+		return nil
+	}
+
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+
+		var rowErr *bulk.RowError
+		if errors.As(err, &rowErr) {
+			report.Rows++
+			report.AddError(rowErr.Line, rowErr.Err)
+			continue
+		}
+		if err != nil {
+			return report, fmt.Errorf("%w: %v", domain.ErrInvalidImport, err)
+		}
+
+		report.Rows++
+		problem, err := check(&record)
+		if err != nil {
+			return report, err
+		}
+		if problem != nil {
+			report.AddError(reader.Line(), problem)
+			continue
+		}
+		recordId := id(&record)
+		if line, ok := seen[recordId]; ok {
+			report.AddError(reader.Line(), fmt.Errorf("id %s already used on line %d", recordId, line))
+			continue
+		}
+		seen[recordId] = reader.Line()
+
+		report.Valid++
+		batch = append(batch, record)
+		if len(batch) == importBatchSize {
+			if err := flush(); err != nil {
+				return report, fmt.Errorf("line %d: %w", reader.Line(), err)
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+func (s *BulkService) auditImport(ctx context.Context, action string, targetType string, report domain.ImportReport, err error) {
+	detail := fmt.Sprintf("rows=%d inserted=%d skipped=%d invalid=%d", report.Rows, report.Inserted, report.Skipped, report.Invalid)
+	if err != nil {
+		detail += " error=" + err.Error()
+	}
+
+	s.audit.Log(ctx, domain.AuditEvent{
+		Action:     action,
+		TargetType: targetType,
+		Outcome:    auditOutcome(err),
+		Detail:     detail,
+	})
+}
+
+// ExportMovies writes every movie (ordered by id) and returns how many
+func (s *BulkService) ExportMovies(ctx context.Context, writer *bulk.Writer[domain.Movie]) (int, error) {
+	return exportRecords(writer,
+		func(after uuid.UUID) ([]domain.Movie, error) {
+			movies, err := s.movieRepo.GetMoviesAfter(ctx, after, exportBatchSize)
+			if err != nil {
+				return nil, err
+			}
+
+			page := make([]domain.Movie, len(movies))
+			for idx, movie := range movies {
+				page[idx] = *movie
+			}
+			return page, nil
+		},
+		func(movie domain.Movie) uuid.UUID { return movie.ID },
+	)
+}
+
+// ExportReviews writes every review (ordered by id) and returns how many
+func (s *BulkService) ExportReviews(ctx context.Context, writer *bulk.Writer[domain.Review]) (int, error) {
+	return exportRecords(writer,
+		func(after uuid.UUID) ([]domain.Review, error) {
+			return s.reviewRepo.GetReviewsAfter(ctx, after, exportBatchSize)
+		},
+		func(review domain.Review) uuid.UUID { return review.ID },
+	)
+}
+
+// keyset pagination: each page starts after the last id of the previous one,
+// no OFFSET to skip (that gets slower and slower on a big table)
+func exportRecords[T any](writer *bulk.Writer[T], fetch func(after uuid.UUID) ([]T, error), id func(T) uuid.UUID) (int, error) {
+	count := 0
+	after := uuid.Nil
+
+	for {
+		page, err := fetch(after)
+		if err != nil {
+			return count, err
+		}
+
+		for _, record := range page {
+			if err := writer.Write(record); err != nil {
+				return count, err
+			}
+			count++
+		}
+
+		if len(page) < exportBatchSize {
+			break
+		}
+		after = id(page[len(page)-1])
+	}
+
+	return count, writer.Flush()
+}
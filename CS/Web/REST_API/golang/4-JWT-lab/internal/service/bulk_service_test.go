@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/bulk"
+	"github.com/grainme/movie-api/internal/cache"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/repository/memory"
+)
+
+func TestImportMovies(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	movieRepo := memory.NewMemoryMovieRepository(store)
+	bulkService := NewBulkService(movieRepo, memory.NewMemoryReviewRepository(store), cache.NewMemoryListCache(), nil)
+
+	existing, err := movieRepo.AddMovie(ctx, &domain.Movie{Title: "Heat"})
+	if err != nil {
+		t.Fatalf("add movie: %v", err)
+	}
+	duplicate := uuid.New()
+	input := "id,title,year\n" +
+		existing.ID.String() + ",Heat again,1995\n" + // line 2: already in the DB, skipped
+		duplicate.String() + ",Thief,1981\n" + // line 3
+		duplicate.String() + ",Thief again,1981\n" + // line 4: already used on line 3
+		",Ronin,1998\n" + // line 5: gets an id
+		",,2004\n" // line 6: no title
+
+	run := func(dryRun bool) domain.ImportReport {
+		t.Helper()
+		report, err := bulkService.ImportMovies(ctx, bulk.NewMovieReader(strings.NewReader(input), bulk.CSV), dryRun)
+		if err != nil {
+			t.Fatalf("import (dry run: %t): %v", dryRun, err)
+		}
+		return report
+	}
+
+	// the dry run sees the same conflicts as the import
+	dry := run(true)
+	if dry.Rows != 5 || dry.Valid != 3 || dry.Invalid != 2 || dry.Skipped != 1 || dry.Inserted != 0 || !dry.DryRun {
+		t.Fatalf("dry run report = %+v", dry)
+	}
+	wantErrors := []domain.ImportError{
+		{Line: 4, Error: fmt.Sprintf("id %s already used on line 3", duplicate)},
+		{Line: 6},
+	}
+	if len(dry.Errors) != len(wantErrors) || dry.Errors[0] != wantErrors[0] || dry.Errors[1].Line != wantErrors[1].Line {
+		t.Fatalf("dry run errors = %+v, want %+v", dry.Errors, wantErrors)
+	}
+	if movies, _ := movieRepo.GetMoviesAfter(ctx, uuid.Nil, 10); len(movies) != 1 {
+		t.Fatalf("%d movies after a dry run, want 1", len(movies))
+	}
+
+	report := run(false)
+	if report.Rows != dry.Rows || report.Valid != dry.Valid || report.Invalid != dry.Invalid || report.Skipped != dry.Skipped || report.Inserted != 2 {
+		t.Fatalf("import report = %+v, dry run said %+v", report, dry)
+	}
+	if movie, err := movieRepo.GetMovieById(ctx, existing.ID); err != nil || movie.Title != "Heat" {
+		t.Fatalf("existing movie = %+v, %v, want it untouched", movie, err)
+	}
+	if movie, err := movieRepo.GetMovieById(ctx, duplicate); err != nil || movie.Title != "Thief" {
+		t.Fatalf("duplicated id = %+v, %v, want the first row", movie, err)
+	}
+
+	// importing the same file again: everything valid is skipped but the row
+	// without an id, which gets a new one every time
+	again := run(false)
+	if again.Skipped != 2 || again.Inserted != 1 {
+		t.Fatalf("second import = %+v, want 2 skipped, 1 inserted", again)
+	}
+}
+
+func TestImportReviews(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	movieRepo := memory.NewMemoryMovieRepository(store)
+	reviewRepo := memory.NewMemoryReviewRepository(store)
+	bulkService := NewBulkService(movieRepo, reviewRepo, cache.NewMemoryListCache(), nil)
+
+	movie, err := movieRepo.AddMovie(ctx, &domain.Movie{Title: "Heat"})
+	if err != nil {
+		t.Fatalf("add movie: %v", err)
+	}
+	reviewId := uuid.New()
+	input := fmt.Sprintf(`{"id":"%[1]s","movie_id":"%[2]s","user_name":"alice","rating":8}
+{"id":"%[1]s","movie_id":"%[2]s","user_name":"bob","rating":7}
+{"movie_id":"%[3]s","user_name":"carol","rating":5}
+{"movie_id":"%[2]s","user_name":"dave","rating":11}
+`, reviewId, movie.ID, uuid.New())
+
+	run := func(dryRun bool) domain.ImportReport {
+		t.Helper()
+		report, err := bulkService.ImportReviews(ctx, bulk.NewReviewReader(strings.NewReader(input), bulk.NDJSON), dryRun)
+		if err != nil {
+			t.Fatalf("import (dry run: %t): %v", dryRun, err)
+		}
+		return report
+	}
+
+	dry := run(true)
+	if dry.Valid != 1 || dry.Invalid != 3 || dry.Skipped != 0 {
+		t.Fatalf("dry run report = %+v", dry)
+	}
+	for idx, line := range []int{2, 3, 4} {
+		if dry.Errors[idx].Line != line {
+			t.Fatalf("dry run errors = %+v, want lines 2, 3 and 4", dry.Errors)
+		}
+	}
+
+	if report := run(false); report.Inserted != 1 || report.Invalid != 3 {
+		t.Fatalf("import report = %+v", report)
+	}
+	// now the id exists: both runs skip it
+	if dry := run(true); dry.Skipped != 1 {
+		t.Fatalf("dry run after the import = %+v, want 1 skipped", dry)
+	}
+	if report := run(false); report.Skipped != 1 || report.Inserted != 0 {
+		t.Fatalf("second import = %+v, want 1 skipped", report)
+	}
+	reviews, err := reviewRepo.GetReviewsAfter(ctx, uuid.Nil, 10)
+	if err != nil || len(reviews) != 1 || reviews[0].Username != "alice" {
+		t.Fatalf("reviews = %+v, %v, want alice's only", reviews, err)
+	}
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/grainme/movie-api/internal/cache"
+)
+
+// cachedList returns the cached value of key, or loads it and caches it.
+// the cache is best effort: when it fails we just load from the DB.
+func cachedList[T any](ctx context.Context, lists cache.ListCache, key string, tags []string, load func() (T, error)) (T, error) {
+	data, versionedKey, err := lists.GetList(ctx, key, tags)
+	if err != nil {
+		log.Printf("failed to get %s from cache: %v", key, err)
+	}
+
+	if data != nil {
+		var value T
+		decodeErr := json.Unmarshal(data, &value)
+		if decodeErr == nil {
+			return value, nil
+		}
+		log.Printf("could not decode cached %s: %v", key, decodeErr)
+	}
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+
+	// no versioned key: the tag versions could not be read, don't guess them
+	if versionedKey != "" {
+		data, err := json.Marshal(value)
+		if err == nil {
+			err = lists.SetList(ctx, versionedKey, data)
+		}
+		if err != nil {
+			log.Printf("could not cache %s: %v", key, err)
+		}
+	}
+
+	return value, nil
+}
+
+func invalidateLists(ctx context.Context, lists cache.ListCache, tags ...string) {
+	if err := lists.InvalidateTags(ctx, tags...); err != nil {
+		// Log the error but don't crash. The lists will expire on their own.
+		log.Printf("failed to invalidate cached lists %v: %v", tags, err)
+	}
+}
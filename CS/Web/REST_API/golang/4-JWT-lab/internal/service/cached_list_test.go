@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/grainme/movie-api/internal/domain"
+)
+
+// stubLists answers every GetList with data, versionedKey and err
+type stubLists struct {
+	data         []byte
+	versionedKey string
+	err          error
+	sets         map[string]string
+}
+
+func (l *stubLists) GetList(ctx context.Context, key string, tags []string) ([]byte, string, error) {
+	return l.data, l.versionedKey, l.err
+}
+
+func (l *stubLists) SetList(ctx context.Context, versionedKey string, data []byte) error {
+	l.sets[versionedKey] = string(data)
+	return nil
+}
+
+func (l *stubLists) InvalidateTags(ctx context.Context, tags ...string) error {
+	return nil
+}
+
+func TestCachedList(t *testing.T) {
+	ctx := context.Background()
+	loads := 0
+	load := func() ([]string, error) {
+		loads++
+		return []string{"fresh"}, nil
+	}
+
+	tests := []struct {
+		name      string
+		lists     *stubLists
+		want      string
+		wantLoads int
+		wantSets  map[string]string
+	}{
+		{"hit", &stubLists{data: []byte(`["cached"]`), versionedKey: "list:x@1"}, "cached", 0, map[string]string{}},
+		{"miss", &stubLists{versionedKey: "list:x@1"}, "fresh", 1, map[string]string{"list:x@1": `["fresh"]`}},
+		// a broken entry is replaced
+		{"undecodable", &stubLists{data: []byte("{"), versionedKey: "list:x@1"}, "fresh", 1, map[string]string{"list:x@1": `["fresh"]`}},
+		// the tag versions could not be read: served from the DB, not cached
+		// (under a guessed version, it could outlive an invalidation)
+		{"versions unknown", &stubLists{err: errors.New("redis is down")}, "fresh", 1, map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loads = 0
+			tt.lists.sets = map[string]string{}
+
+			value, err := cachedList(ctx, tt.lists, "list:x", []string{"x"}, load)
+			if err != nil || len(value) != 1 || value[0] != tt.want {
+				t.Fatalf("cachedList = %q, %v, want [%s]", value, err, tt.want)
+			}
+			if loads != tt.wantLoads {
+				t.Fatalf("loads = %d, want %d", loads, tt.wantLoads)
+			}
+			if len(tt.lists.sets) != len(tt.wantSets) {
+				t.Fatalf("sets = %v, want %v", tt.lists.sets, tt.wantSets)
+			}
+			for key, data := range tt.wantSets {
+				if tt.lists.sets[key] != data {
+					t.Fatalf("sets = %v, want %v", tt.lists.sets, tt.wantSets)
+				}
+			}
+		})
+	}
+}
+
+func TestListsInvalidatedOnWrites(t *testing.T) {
+	ctx := context.Background()
+	movies, reviews, repo, _ := newTestServices(t)
+	page := domain.Page{Limit: 20}
+
+	listTitles := func() []string {
+		t.Helper()
+		list, err := movies.GetAllMovies(ctx, page)
+		if err != nil {
+			t.Fatalf("get all movies: %v", err)
+		}
+		titles := make([]string, len(list))
+		for idx, movie := range list {
+			titles[idx] = movie.Title
+		}
+		return titles
+	}
+	expectLoads := func(want int32) {
+		t.Helper()
+		if loads := repo.lists.Swap(0); loads != want {
+			t.Fatalf("DB list reads = %d, want %d", loads, want)
+		}
+	}
+
+	created, err := movies.AddMovie(ctx, &domain.Movie{Title: "Heat"})
+	if err != nil {
+		t.Fatalf("add movie: %v", err)
+	}
+	listTitles()
+	listTitles()
+	expectLoads(1)
+
+	ronin, err := movies.AddMovie(ctx, &domain.Movie{Title: "Ronin"})
+	if err != nil {
+		t.Fatalf("add movie: %v", err)
+	}
+	if titles := listTitles(); len(titles) != 2 {
+		t.Fatalf("list after add = %q", titles)
+	}
+	expectLoads(1)
+
+	if err := movies.UpdateMovieTitleById(ctx, created.ID, "Heat (1995)"); err != nil {
+		t.Fatalf("update title: %v", err)
+	}
+	listTitles()
+	expectLoads(1)
+
+	// a review changes the movie's aggregate, cached under its tag
+	aggregate, err := movies.GetMovieWithReviews(ctx, created.ID)
+	if err != nil || aggregate.ReviewsCount != 0 {
+		t.Fatalf("aggregate = %+v, %v", aggregate, err)
+	}
+	movies.GetMovieWithReviews(ctx, created.ID)
+	expectLoads(1)
+
+	if _, err := reviews.AddReview(ctx, &domain.Review{Username: "alice", Rating: 8, MovieID: created.ID}); err != nil {
+		t.Fatalf("add review: %v", err)
+	}
+	aggregate, err = movies.GetMovieWithReviews(ctx, created.ID)
+	if err != nil || aggregate.ReviewsCount != 1 || aggregate.AverageRating != 8 {
+		t.Fatalf("aggregate after a review = %+v, %v", aggregate, err)
+	}
+	// but not the movie list
+	listTitles()
+	expectLoads(1)
+
+	if err := movies.DeleteMovieById(ctx, ronin.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if titles := listTitles(); len(titles) != 1 || titles[0] != "Heat (1995)" {
+		t.Fatalf("list after delete = %q", titles)
+	}
+	expectLoads(1)
+}
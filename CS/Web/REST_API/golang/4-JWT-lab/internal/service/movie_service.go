@@ -2,72 +2,157 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/grainme/movie-api/internal/cache"
 	"github.com/grainme/movie-api/internal/domain"
 	"github.com/grainme/movie-api/internal/repository"
-	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
 )
 
 type MovieService struct {
-	movieRepo repository.MovieRepository
-	rdb       *redis.Client
+	movieRepo  repository.MovieRepository
+	movieCache cache.MovieCache
+	lists      cache.ListCache
+	trending   cache.TrendingStore
+	audit      *AuditLogger
+	// coalesces concurrent cache misses (cache stampede)
+	movieLoads singleflight.Group
 }
 
-func NewMovieService(repo repository.MovieRepository, rdb *redis.Client) *MovieService {
+func NewMovieService(repo repository.MovieRepository, movieCache cache.MovieCache, lists cache.ListCache, trending cache.TrendingStore, audit *AuditLogger) *MovieService {
 	return &MovieService{
-		movieRepo: repo,
-		rdb:       rdb,
+		movieRepo:  repo,
+		movieCache: movieCache,
+		lists:      lists,
+		trending:   trending,
+		audit:      audit,
 	}
 }
 
-func (s *MovieService) GetAllMovies(ctx context.Context) []*domain.Movie {
-	// should we have any other logic (validation...)?
-	movies := s.movieRepo.GetAllMovies(ctx)
-	return movies
+// every page is cached under the "movies" tag: any write bumps it
+func (s *MovieService) GetAllMovies(ctx context.Context, page domain.Page) ([]*domain.Movie, error) {
+	return cachedList(ctx, s.lists, cache.MoviesListKey(page), []string{cache.MoviesTag}, func() ([]*domain.Movie, error) {
+		return s.movieRepo.GetAllMovies(ctx, page)
+	})
 }
 
+// GetMovieById is a view: it counts for the popular and trending movies
 func (s *MovieService) GetMovieById(ctx context.Context, id uuid.UUID) (*domain.Movie, error) {
-	movie, err := cache.GetMovieById(ctx, s.rdb, id)
+	movie, err := s.getMovie(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.countView(ctx, id)
+	return movie, nil
+}
+
+// cache first, then the DB
+func (s *MovieService) getMovie(ctx context.Context, id uuid.UUID) (*domain.Movie, error) {
+	entry, err := s.movieCache.GetMovie(ctx, id)
 	if err != nil {
 		log.Printf("failed to get movie from cache: %v", err)
 	}
 
-	// cache hit
-	if movie != nil {
-		// view counter (write-behind strategy)
-		go func() {
-			count, err := cache.IncrementViewCount(context.Background(), s.rdb, id)
-			if err != nil {
-				log.Printf("failed to increment view count for movie %s: %v", id, err)
-				return
-			}
+	// cache hit (unless XFetch picked this request to refresh a hot entry early)
+	if entry != nil && !entry.ShouldRefresh(time.Now()) {
+		// negative entry: we already know this id doesn't exist
+		if entry.Movie == nil {
+			return nil, domain.ErrMovieNotFound
+		}
+
+		return entry.Movie, nil
+	}
+
+	if entry == nil {
+		log.Printf("CACHE MISS: %s\n", cache.MovieKey(id))
+	}
+
+	// all the concurrent misses for this id wait for the same DB query.
+	// not bound to this request: if its client goes away the others still need the result
+	result, err, _ := s.movieLoads.Do(id.String(), func() (any, error) {
+		return s.loadMovie(context.WithoutCancel(ctx), id)
+	})
+	if err != nil {
+		return nil, err
+	}
 
-			if count%100 == 0 {
-				// Every 100 views, sync to DB
-				// We don't have views track on the DB. but I got the idea of write-behind
-				// s.repo.UpdateViewCount(context.Background(), id, count)
-				log.Printf("syncing view count to DB: %d", count)
-			}
-		}()
+	movie, _ := result.(*domain.Movie)
+	if movie == nil {
+		return nil, domain.ErrMovieNotFound
+	}
 
-		return movie, nil
+	// the callers share the result, each one gets its own copy
+	movieCopy := *movie
+	return &movieCopy, nil
+}
+
+// view counter (write-behind strategy): counted in the cache on every read,
+// the ViewFlusher moves the counts to the DB in the background
+func (s *MovieService) countView(ctx context.Context, id uuid.UUID) {
+	if _, err := s.movieCache.IncrementViewCount(ctx, id); err != nil {
+		// a lost view is not worth failing the request
+		log.Printf("failed to increment view count for movie %s: %v", id, err)
+	}
+	if err := s.trending.RecordActivity(ctx, id, trendingViewScore); err != nil {
+		log.Printf("failed to record trending view for movie %s: %v", id, err)
 	}
+}
 
-	log.Printf("CACHE MISS: %s\n", cache.MovieKey(id))
-	movie, err = s.movieRepo.GetMovieById(ctx, id)
+// most active movies first over the window. the movies deleted since are
+// skipped, a page can be a bit short
+func (s *MovieService) GetTrendingMovies(ctx context.Context, window time.Duration, page domain.Page) ([]domain.TrendingMovie, error) {
+	entries, err := s.trending.TopMovies(ctx, window, page)
 	if err != nil {
 		return nil, err
 	}
 
-	// save in cache
-	if err := cache.SetMovie(ctx, s.rdb, id, *movie); err != nil {
+	movies := make([]domain.TrendingMovie, 0, len(entries))
+	for _, entry := range entries {
+		// hydrated from the movie cache (the top movies are the hot ones),
+		// without counting a view
+		movie, err := s.getMovie(ctx, entry.ID)
+		if errors.Is(err, domain.ErrMovieNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		movies = append(movies, domain.TrendingMovie{Movie: *movie, Score: entry.Score})
+	}
+
+	return movies, nil
+}
+
+// ranked by the flushed view counts: the views of the last few seconds
+// don't show up before the next flush
+func (s *MovieService) GetPopularMovies(ctx context.Context, page domain.Page) ([]*domain.Movie, error) {
+	return cachedList(ctx, s.lists, cache.PopularMoviesListKey(page), []string{cache.MoviesTag, cache.ViewsTag}, func() ([]*domain.Movie, error) {
+		return s.movieRepo.GetPopularMovies(ctx, page)
+	})
+}
+
+// reads the movie from the DB and caches it, "not found" included (nil movie)
+func (s *MovieService) loadMovie(ctx context.Context, id uuid.UUID) (*domain.Movie, error) {
+	start := time.Now()
+	movie, err := s.movieRepo.GetMovieById(ctx, id)
+	if err != nil && !errors.Is(err, domain.ErrMovieNotFound) {
+		return nil, err
+	}
+
+	// the load time drives the early refresh: slow queries are refreshed earlier
+	entry := cache.NewCachedMovie(movie, time.Since(start))
+	if err := s.movieCache.SetMovie(ctx, id, entry); err != nil {
 		log.Printf("could not cache movie: %v\n", err)
 	}
 
-	return movie, err
+	return movie, nil
 }
 
 func (s *MovieService) AddMovie(ctx context.Context, movie *domain.Movie) (*domain.Movie, error) {
@@ -75,12 +160,25 @@ func (s *MovieService) AddMovie(ctx context.Context, movie *domain.Movie) (*doma
 		return nil, domain.ErrInvalidMovie
 	}
 
-	if movie.Title == "" || len(movie.Title) > 40 {
-		return nil, domain.ErrInvalidMovie
+	if err := validateMovie(movie); err != nil {
+		return nil, err
 	}
 
 	movie, err := s.movieRepo.AddMovie(ctx, movie)
-	return movie, err
+	if err != nil {
+		return nil, err
+	}
+
+	invalidateLists(ctx, s.lists, cache.MoviesTag)
+	return movie, nil
+}
+
+// the rules for every new movie, from the API or from an import
+func validateMovie(movie *domain.Movie) error {
+	if movie.Title == "" || len(movie.Title) > 40 {
+		return fmt.Errorf("%w: title must be 1 to 40 characters", domain.ErrInvalidMovie)
+	}
+	return nil
 }
 
 func (s *MovieService) UpdateMovieTitleById(ctx context.Context, id uuid.UUID, title string) error {
@@ -93,21 +191,41 @@ func (s *MovieService) UpdateMovieTitleById(ctx context.Context, id uuid.UUID, t
 		return err
 	}
 
-	err = cache.DelMovie(ctx, s.rdb, id)
-	return err
+	invalidateLists(ctx, s.lists, cache.MoviesTag, cache.MovieTag(id))
+	return s.movieCache.DelMovie(ctx, id)
 }
 
 func (s *MovieService) DeleteMovieById(ctx context.Context, id uuid.UUID) error {
 	err := s.movieRepo.DeleteMovieById(ctx, id)
+	s.audit.Log(ctx, domain.AuditEvent{
+		Action:     domain.AuditMovieDelete,
+		TargetType: "movie",
+		TargetID:   id.String(),
+		Outcome:    auditOutcome(err),
+		Detail:     auditDetail(err),
+	})
 	if err != nil {
 		return err
 	}
 
-	err = cache.DelMovie(ctx, s.rdb, id)
-	return err
+	// and everything listing it or aggregating its reviews
+	invalidateLists(ctx, s.lists, cache.MoviesTag, cache.MovieTag(id), cache.ReviewsTag)
+	return s.movieCache.DelMovie(ctx, id)
 }
 
+// the aggregate (average rating, reviews count) is cached under the movie tag,
+// AddReview bumps it
 func (s *MovieService) GetMovieWithReviews(ctx context.Context, id uuid.UUID) (*domain.Movie, error) {
-	movie, err := s.movieRepo.GetMovieWithReviews(ctx, id)
-	return movie, err
+	return cachedList(ctx, s.lists, cache.MovieWithReviewsKey(id), []string{cache.MovieTag(id)}, func() (*domain.Movie, error) {
+		return s.movieRepo.GetMovieWithReviews(ctx, id)
+	})
+}
+
+// the aggregates of several movies in one query (the GraphQL loaders), not cached:
+// the loaders only live for one request. unknown ids are left out
+func (s *MovieService) GetMoviesWithReviewsByIds(ctx context.Context, ids []uuid.UUID) ([]*domain.Movie, error) {
+	if len(ids) == 0 {
+		return []*domain.Movie{}, nil
+	}
+	return s.movieRepo.GetMoviesWithReviewsByIds(ctx, ids)
 }
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/cache"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/repository"
+	"github.com/grainme/movie-api/internal/repository/memory"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// counts the reads that reach the DB: what the movie cache is there to avoid
+type countingMovies struct {
+	repository.MovieRepository
+	loads atomic.Int32
+	lists atomic.Int32
+	// when set, the reads wait for it to be closed (a slow DB)
+	gate chan struct{}
+}
+
+func (r *countingMovies) GetAllMovies(ctx context.Context, page domain.Page) ([]*domain.Movie, error) {
+	r.lists.Add(1)
+	return r.MovieRepository.GetAllMovies(ctx, page)
+}
+
+func (r *countingMovies) GetMovieWithReviews(ctx context.Context, id uuid.UUID) (*domain.Movie, error) {
+	r.lists.Add(1)
+	return r.MovieRepository.GetMovieWithReviews(ctx, id)
+}
+
+func (r *countingMovies) GetMovieById(ctx context.Context, id uuid.UUID) (*domain.Movie, error) {
+	r.loads.Add(1)
+	if r.gate != nil {
+		<-r.gate
+	}
+	return r.MovieRepository.GetMovieById(ctx, id)
+}
+
+func newTestMovieService(t *testing.T) (*MovieService, *countingMovies, *cache.MemoryMovieCache) {
+	movies, _, repo, movieCache := newTestServices(t)
+	return movies, repo, movieCache
+}
+
+// the movie and review services on one memory store, sharing their caches
+func newTestServices(t *testing.T) (*MovieService, *ReviewService, *countingMovies, *cache.MemoryMovieCache) {
+	t.Helper()
+
+	store := memory.NewStore()
+	movies := &countingMovies{MovieRepository: memory.NewMemoryMovieRepository(store)}
+	movieCache := cache.NewMemoryMovieCache()
+	lists := cache.NewMemoryListCache()
+	trending := cache.NewMemoryTrendingStore()
+	audit := NewAuditLogger(memory.NewMemoryAuditRepository(store))
+
+	movieService := NewMovieService(movies, movieCache, lists, trending, audit)
+	reviewService := NewReviewService(memory.NewMemoryReviewRepository(store), movieCache, lists, trending, cache.NewMemoryReviewFeed())
+	return movieService, reviewService, movies, movieCache
+}
+
+func TestMovieCacheAside(t *testing.T) {
+	ctx := context.Background()
+	movies, repo, movieCache := newTestMovieService(t)
+
+	created, err := movies.AddMovie(ctx, &domain.Movie{Title: "Heat"})
+	if err != nil {
+		t.Fatalf("add movie: %v", err)
+	}
+
+	for range 3 {
+		movie, err := movies.GetMovieById(ctx, created.ID)
+		if err != nil || movie.Title != "Heat" {
+			t.Fatalf("get movie = %+v, %v", movie, err)
+		}
+	}
+	if loads := repo.loads.Load(); loads != 1 {
+		t.Fatalf("DB reads = %d, want 1 (then the cache)", loads)
+	}
+
+	// a write drops the cached copy
+	if err := movies.UpdateMovieTitleById(ctx, created.ID, "Heat (1995)"); err != nil {
+		t.Fatalf("update title: %v", err)
+	}
+	if entry, _ := movieCache.GetMovie(ctx, created.ID); entry != nil {
+		t.Fatalf("cached after the update: %+v", entry.Movie)
+	}
+	movie, err := movies.GetMovieById(ctx, created.ID)
+	if err != nil || movie.Title != "Heat (1995)" {
+		t.Fatalf("get after update = %+v, %v", movie, err)
+	}
+
+	// the callers get copies: changing one doesn't change the cache
+	movie.Title = "changed by a caller"
+	if again, _ := movies.GetMovieById(ctx, created.ID); again.Title != "Heat (1995)" {
+		t.Fatalf("cached movie changed by a caller: %q", again.Title)
+	}
+
+	if err := movies.DeleteMovieById(ctx, created.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := movies.GetMovieById(ctx, created.ID); !errors.Is(err, domain.ErrMovieNotFound) {
+		t.Fatalf("get after delete = %v, want %v", err, domain.ErrMovieNotFound)
+	}
+}
+
+func TestMovieViewsAreCounted(t *testing.T) {
+	ctx := context.Background()
+	movies, _, movieCache := newTestMovieService(t)
+
+	created, err := movies.AddMovie(ctx, &domain.Movie{Title: "Thief"})
+	if err != nil {
+		t.Fatalf("add movie: %v", err)
+	}
+	for range 3 {
+		if _, err := movies.GetMovieById(ctx, created.ID); err != nil {
+			t.Fatalf("get movie: %v", err)
+		}
+	}
+
+	counts, err := movieCache.DrainViewCounts(ctx, 10)
+	if err != nil || counts[created.ID] != 3 {
+		t.Fatalf("pending views = %v, %v, want 3 for %s", counts, err, created.ID)
+	}
+	trending, err := movies.GetTrendingMovies(ctx, cache.MaxTrendingWindow, domain.Page{Limit: 10})
+	if err != nil || len(trending) != 1 || trending[0].ID != created.ID {
+		t.Fatalf("trending = %+v, %v", trending, err)
+	}
+}
+
+func TestMovieMissesAreCoalesced(t *testing.T) {
+	ctx := context.Background()
+	movies, repo, _ := newTestMovieService(t)
+
+	created, err := movies.AddMovie(ctx, &domain.Movie{Title: "Ronin"})
+	if err != nil {
+		t.Fatalf("add movie: %v", err)
+	}
+
+	// a cold key and a slow DB: every request misses while the first load runs
+	repo.gate = make(chan struct{})
+	const readers = 20
+	var wg sync.WaitGroup
+	for range readers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			movie, err := movies.GetMovieById(ctx, created.ID)
+			if err != nil || movie.Title != "Ronin" {
+				t.Errorf("get movie = %+v, %v", movie, err)
+			}
+		}()
+	}
+	// give them the time to miss and join the load
+	time.Sleep(100 * time.Millisecond)
+	close(repo.gate)
+	wg.Wait()
+
+	if loads := repo.loads.Load(); loads != 1 {
+		t.Fatalf("DB reads = %d for %d concurrent misses, want 1", loads, readers)
+	}
+}
+
+func TestUnknownMovieIsCached(t *testing.T) {
+	ctx := context.Background()
+	movies, repo, movieCache := newTestMovieService(t)
+
+	unknown := uuid.New()
+	for range 3 {
+		if _, err := movies.GetMovieById(ctx, unknown); !errors.Is(err, domain.ErrMovieNotFound) {
+			t.Fatalf("get unknown movie = %v, want %v", err, domain.ErrMovieNotFound)
+		}
+	}
+	if loads := repo.loads.Load(); loads != 1 {
+		t.Fatalf("DB reads = %d, want 1 (then the negative entry)", loads)
+	}
+
+	// remembered for a short time only
+	entry, err := movieCache.GetMovie(ctx, unknown)
+	if err != nil || entry == nil || entry.Movie != nil {
+		t.Fatalf("negative entry = %+v, %v", entry, err)
+	}
+	if ttl := time.Until(entry.ExpiresAt); ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("negative entry expires in %s, want under a minute", ttl)
+	}
+}
+
+func TestHotMovieIsRefreshedEarly(t *testing.T) {
+	ctx := context.Background()
+	movies, repo, movieCache := newTestMovieService(t)
+
+	created, err := movies.AddMovie(ctx, &domain.Movie{Title: "Collateral"})
+	if err != nil {
+		t.Fatalf("add movie: %v", err)
+	}
+
+	// a second before the expiry of a movie that took an hour to load:
+	// XFetch refreshes it now (unless -ln(rand()) < 1/3600, ~0.03%)
+	stale := *created
+	stale.Title = "stale"
+	err = movieCache.SetMovie(ctx, created.ID, cache.CachedMovie{Movie: &stale, Delta: time.Hour, ExpiresAt: time.Now().Add(time.Second)})
+	if err != nil {
+		t.Fatalf("set movie: %v", err)
+	}
+
+	movie, err := movies.GetMovieById(ctx, created.ID)
+	if err != nil || movie.Title != "Collateral" {
+		t.Fatalf("get movie = %+v, %v, want the refreshed one", movie, err)
+	}
+	if loads := repo.loads.Load(); loads != 1 {
+		t.Fatalf("DB reads = %d, want 1", loads)
+	}
+	entry, _ := movieCache.GetMovie(ctx, created.ID)
+	if entry == nil || entry.Movie.Title != "Collateral" || time.Until(entry.ExpiresAt) < time.Minute {
+		t.Fatalf("cache after the refresh = %+v", entry)
+	}
+}
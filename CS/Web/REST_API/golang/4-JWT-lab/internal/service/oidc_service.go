@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/auth"
+	"github.com/grainme/movie-api/internal/cache"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/oidc"
+	"github.com/grainme/movie-api/internal/repository"
+)
+
+// OIDCService logs users in through an external OpenID Connect provider.
+// the provider only proves who the user is, after that they get our own
+// access/refresh tokens like a local login.
+type OIDCService struct {
+	provider    *oidc.Provider
+	userRepo    repository.UserRepository
+	userService *UserService
+	sessions    cache.SessionStore
+}
+
+func NewOIDCService(provider *oidc.Provider, repo repository.UserRepository, userService *UserService, sessions cache.SessionStore) *OIDCService {
+	return &OIDCService{
+		provider:    provider,
+		userRepo:    repo,
+		userService: userService,
+		sessions:    sessions,
+	}
+}
+
+// `GET /auth/oidc/login`: returns the provider URL to redirect the browser to,
+// and the binding to set in that browser (see begin)
+func (s *OIDCService) BeginLogin(ctx context.Context) (string, string, error) {
+	return s.begin(ctx, nil)
+}
+
+// `POST /auth/oidc/link`: the same, for a logged-in user. the callback links
+// the provider account to them instead of looking one up
+func (s *OIDCService) BeginLink(ctx context.Context, userId uuid.UUID) (string, string, error) {
+	return s.begin(ctx, &userId)
+}
+
+// the state alone would let anyone finish a flow they didn't start: an
+// attacker starts a link, sends the provider URL to the victim, and the
+// victim's provider account ends up on the attacker's account. the browser
+// that starts the flow keeps the binding (a cookie), we keep its hash with
+// the state and the callback must come with it
+func (s *OIDCService) begin(ctx context.Context, linkUserId *uuid.UUID) (string, string, error) {
+	state, err := oidc.NewRandomValue()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := oidc.NewRandomValue()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := oidc.NewPKCEVerifier()
+	if err != nil {
+		return "", "", err
+	}
+	binding, err := oidc.NewRandomValue()
+	if err != nil {
+		return "", "", err
+	}
+
+	err = s.sessions.SetOIDCState(ctx, state, cache.OIDCState{
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		BindingHash:  auth.HashToken(binding),
+		LinkUserID:   linkUserId,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	return s.provider.AuthCodeURL(state, nonce, oidc.PKCEChallenge(verifier)), binding, nil
+}
+
+// `GET /auth/oidc/callback?state=...&code=...`, binding is the cookie of the
+// browser ("" when it has none)
+func (s *OIDCService) CompleteLogin(ctx context.Context, state, code, binding string) (domain.UserResponse, error) {
+	user, err := s.authenticate(ctx, state, code, binding)
+	if err != nil {
+		s.userService.auditAuth(ctx, domain.AuditLoginOIDC, nil, "", err)
+		return domain.UserResponse{}, err
+	}
+
+	s.userService.auditAuth(ctx, domain.AuditLoginOIDC, &user.ID, user.Username, nil)
+
+	// local 2FA still applies, the provider login replaces the password step only
+	if user.TOTPEnabled {
+		return s.userService.startMFAChallenge(ctx, user)
+	}
+
+	return s.userService.issueTokens(ctx, cache.UserCache{
+		UserId:   user.ID,
+		Username: user.Username,
+		Role:     user.Role,
+	})
+}
+
+// state (and its binding) → code exchange → ID token → local user
+func (s *OIDCService) authenticate(ctx context.Context, state, code, binding string) (domain.User, error) {
+	pending, err := s.sessions.ConsumeOIDCState(ctx, state)
+	if err != nil {
+		return domain.User{}, err
+	}
+	if pending == nil {
+		return domain.User{}, domain.ErrInvalidOIDCState
+	}
+	// another browser than the one that started it (consumed anyway: the
+	// state is single use)
+	if subtle.ConstantTimeCompare([]byte(auth.HashToken(binding)), []byte(pending.BindingHash)) != 1 {
+		return domain.User{}, domain.ErrInvalidOIDCState
+	}
+
+	rawIDToken, err := s.provider.Exchange(ctx, code, pending.CodeVerifier)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	idToken, err := s.provider.VerifyIDToken(ctx, rawIDToken, pending.Nonce)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	if pending.LinkUserID != nil {
+		return s.linkIdentity(ctx, *pending.LinkUserID, idToken)
+	}
+	return s.findOrCreateUser(ctx, idToken)
+}
+
+// the explicit link: the user proved they own the local account (their
+// session) and the provider account (this login)
+func (s *OIDCService) linkIdentity(ctx context.Context, userId uuid.UUID, idToken *oidc.IDToken) (domain.User, error) {
+	provider := s.provider.Issuer()
+
+	linked, err := s.userRepo.FindUserByIdentity(ctx, provider, idToken.Subject)
+	if err == nil {
+		if linked.ID != userId {
+			return domain.User{}, domain.ErrOIDCIdentityTaken
+		}
+		return linked, nil
+	}
+	if !errors.Is(err, domain.ErrUserNotFound) {
+		return domain.User{}, err
+	}
+
+	user, err := s.userRepo.FindUserById(ctx, userId)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	err = s.userRepo.LinkIdentity(ctx, domain.UserIdentity{
+		UserID:   userId,
+		Provider: provider,
+		Subject:  idToken.Subject,
+		Email:    idToken.Email,
+	})
+	s.userService.auditAuth(ctx, domain.AuditIdentityLink, &user.ID, user.Username, err)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	return user, nil
+}
+
+// account lookup:
+//  1. identity already linked → that user
+//  2. a local user has the (verified) email → refused, see below
+//  3. otherwise → new user (random password, they can set one with "forgot password")
+//
+// no automatic linking on the email: the local emails are never verified
+// (register takes them as given), so whoever registered first with the
+// victim's email would get their provider logins (pre-account takeover).
+// the owner links the provider from their session instead (BeginLink).
+func (s *OIDCService) findOrCreateUser(ctx context.Context, idToken *oidc.IDToken) (domain.User, error) {
+	provider := s.provider.Issuer()
+
+	user, err := s.userRepo.FindUserByIdentity(ctx, provider, idToken.Subject)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, domain.ErrUserNotFound) {
+		return domain.User{}, err
+	}
+
+	identity := domain.UserIdentity{
+		Provider: provider,
+		Subject:  idToken.Subject,
+		Email:    idToken.Email,
+	}
+
+	// an unverified email is not stored (it could be anyone's), a verified
+	// one can't be if a local account has it (the emails are unique)
+	if idToken.Email != "" && idToken.EmailVerified {
+		_, err := s.userRepo.FindUserByEmail(ctx, idToken.Email)
+		if err == nil {
+			return domain.User{}, domain.ErrOIDCEmailTaken
+		}
+		if !errors.Is(err, domain.ErrUserNotFound) {
+			return domain.User{}, err
+		}
+	}
+
+	username, err := s.availableUsername(ctx, idToken)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	// nobody knows this password, the account can only log in through the provider
+	password, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	request := domain.CreateUserRequest{
+		Username: username,
+		Password: password,
+	}
+	if idToken.EmailVerified {
+		request.Email = idToken.Email
+	}
+
+	return s.userRepo.AddUserWithIdentity(ctx, request, identity)
+}
+
+// preferred_username (or the email local part), with a suffix if it's taken
+func (s *OIDCService) availableUsername(ctx context.Context, idToken *oidc.IDToken) (string, error) {
+	base := idToken.PreferredUsername
+	if base == "" && idToken.Email != "" {
+		base, _, _ = strings.Cut(idToken.Email, "@")
+	}
+	if base == "" {
+		base = "user"
+	}
+
+	candidate := base
+	for attempt := 0; attempt < 5; attempt++ {
+		_, err := s.userRepo.FindUserByName(ctx, candidate)
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		suffix, err := oidc.NewRandomValue()
+		if err != nil {
+			return "", err
+		}
+		candidate = fmt.Sprintf("%s-%s", base, strings.ToLower(suffix[:6]))
+	}
+
+	return "", errors.New("could not find an available username")
+}
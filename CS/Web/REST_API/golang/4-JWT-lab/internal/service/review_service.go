@@ -8,23 +8,30 @@ import (
 	"github.com/grainme/movie-api/internal/cache"
 	"github.com/grainme/movie-api/internal/domain"
 	"github.com/grainme/movie-api/internal/repository"
-	"github.com/redis/go-redis/v9"
 )
 
 type ReviewService struct {
 	reviewRepo repository.ReviewRepository
-	rdb        *redis.Client
+	movieCache cache.MovieCache
+	lists      cache.ListCache
+	trending   cache.TrendingStore
+	feed       cache.ReviewFeed
 }
 
-func NewReviewService(repo repository.ReviewRepository, rdb *redis.Client) *ReviewService {
+func NewReviewService(repo repository.ReviewRepository, movieCache cache.MovieCache, lists cache.ListCache, trending cache.TrendingStore, feed cache.ReviewFeed) *ReviewService {
 	return &ReviewService{
 		reviewRepo: repo,
-		rdb:        rdb,
+		movieCache: movieCache,
+		lists:      lists,
+		trending:   trending,
+		feed:       feed,
 	}
 }
 
 func (s *ReviewService) GetAllReviews(ctx context.Context) ([]domain.Review, error) {
-	reviews, err := s.reviewRepo.GetAllReviews(ctx)
+	reviews, err := cachedList(ctx, s.lists, cache.ReviewsListKey(), []string{cache.ReviewsTag}, func() ([]domain.Review, error) {
+		return s.reviewRepo.GetAllReviews(ctx)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -32,8 +39,10 @@ func (s *ReviewService) GetAllReviews(ctx context.Context) ([]domain.Review, err
 	return reviews, nil
 }
 
-func (s *ReviewService) GetAllReviewsByMovieId(ctx context.Context, movieId uuid.UUID) ([]domain.Review, error) {
-	reviews, err := s.reviewRepo.GetAllReviewsByMovieId(ctx, movieId)
+func (s *ReviewService) GetAllReviewsByMovieId(ctx context.Context, movieId uuid.UUID, page domain.Page) ([]domain.Review, error) {
+	reviews, err := cachedList(ctx, s.lists, cache.MovieReviewsListKey(movieId, page), []string{cache.MovieTag(movieId)}, func() ([]domain.Review, error) {
+		return s.reviewRepo.GetAllReviewsByMovieId(ctx, movieId, page)
+	})
 	if err != nil {
 		return []domain.Review{}, err
 	}
@@ -41,6 +50,21 @@ func (s *ReviewService) GetAllReviewsByMovieId(ctx context.Context, movieId uuid
 	return reviews, nil
 }
 
+// the page of reviews of every movie (user) in one query, for the GraphQL loaders
+func (s *ReviewService) GetReviewsByMovieIds(ctx context.Context, movieIds []uuid.UUID, page domain.Page) ([]domain.Review, error) {
+	if len(movieIds) == 0 {
+		return []domain.Review{}, nil
+	}
+	return s.reviewRepo.GetReviewsByMovieIds(ctx, movieIds, page)
+}
+
+func (s *ReviewService) GetReviewsByUsernames(ctx context.Context, usernames []string, page domain.Page) ([]domain.Review, error) {
+	if len(usernames) == 0 {
+		return []domain.Review{}, nil
+	}
+	return s.reviewRepo.GetReviewsByUsernames(ctx, usernames, page)
+}
+
 func (s *ReviewService) AddReview(ctx context.Context, review *domain.Review) (domain.Review, error) {
 	insertedReview, err := s.reviewRepo.AddReview(ctx, review)
 	if err != nil {
@@ -48,11 +72,29 @@ func (s *ReviewService) AddReview(ctx context.Context, review *domain.Review) (d
 	}
 
 	// invalidate the movie cache since its data (avg_rating) has changed
-	err = cache.DelMovie(ctx, s.rdb, review.MovieID)
+	err = s.movieCache.DelMovie(ctx, review.MovieID)
 	if err != nil {
 		// Log the error but don't crash. The cache will expire on its own.
 		log.Printf("failed to invalidate movie cache for movie %s: %v", review.MovieID, err)
 	}
 
+	// and so have its reviews list, its aggregate and the global reviews list
+	invalidateLists(ctx, s.lists, cache.MovieTag(review.MovieID), cache.ReviewsTag)
+
+	if err := s.trending.RecordActivity(ctx, review.MovieID, trendingReviewScore); err != nil {
+		log.Printf("failed to record trending review for movie %s: %v", review.MovieID, err)
+	}
+
+	// the review is saved, the watchers missing it is not worth failing the request
+	if err := s.feed.PublishReview(ctx, insertedReview); err != nil {
+		log.Printf("failed to publish review %s of movie %s: %v", insertedReview.ID, review.MovieID, err)
+	}
+
 	return insertedReview, nil
 }
+
+// WatchMovieReviews: the reviews of the movie posted after lastEventId (""
+// for none: from now on), until ctx is done
+func (s *ReviewService) WatchMovieReviews(ctx context.Context, movieId uuid.UUID, lastEventId string) (<-chan domain.FeedReview, error) {
+	return s.feed.SubscribeReviews(ctx, movieId, lastEventId)
+}
@@ -0,0 +1,17 @@
+package service
+
+import "time"
+
+// what an event adds to a movie's trending score: a review is worth more
+// than a view, it takes some effort
+const (
+	trendingViewScore   = 1
+	trendingReviewScore = 10
+)
+
+// the trending windows a client can ask for
+var TrendingWindows = map[string]time.Duration{
+	"1h":  time.Hour,
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+}
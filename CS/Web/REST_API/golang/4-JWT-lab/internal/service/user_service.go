@@ -3,31 +3,46 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
+	"net/url"
+	"os"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/grainme/movie-api/internal/auth"
 	"github.com/grainme/movie-api/internal/cache"
 	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/mailer"
 	"github.com/grainme/movie-api/internal/repository"
-	"github.com/redis/go-redis/v9"
 )
 
 type UserService struct {
 	userRepo repository.UserRepository
-	rdb      *redis.Client
+	sessions cache.SessionStore
+	mailer   mailer.Mailer
+	audit    *AuditLogger
 }
 
-func NewUserService(repo repository.UserRepository, rdb *redis.Client) *UserService {
+func NewUserService(repo repository.UserRepository, sessions cache.SessionStore, mailer mailer.Mailer, audit *AuditLogger) *UserService {
 	return &UserService{
 		userRepo: repo,
-		rdb:      rdb,
+		sessions: sessions,
+		mailer:   mailer,
+		audit:    audit,
 	}
 }
 
 func (s *UserService) Login(ctx context.Context, username, password string) (domain.UserResponse, error) {
+	if auth.PasswordTooLong(password) {
+		err := errors.New("invalid credentials")
+		s.auditAuth(ctx, domain.AuditLogin, nil, username, err)
+		return domain.UserResponse{}, err
+	}
+
 	user, err := s.userRepo.FindUserByName(ctx, username)
 	if err != nil {
+		s.auditAuth(ctx, domain.AuditLogin, nil, username, err)
 		return domain.UserResponse{}, err
 	}
 
@@ -37,35 +52,56 @@ func (s *UserService) Login(ctx context.Context, username, password string) (dom
 	}
 
 	if !match {
-		return domain.UserResponse{}, errors.New("invalid credentials")
+		err := errors.New("invalid credentials")
+		s.auditAuth(ctx, domain.AuditLogin, &user.ID, user.Username, err)
+		return domain.UserResponse{}, err
 	}
 
-	accessToken, err := auth.GenerateAccessToken(user.ID, user.Role)
-	if err != nil {
-		return domain.UserResponse{}, err
+	// the hash was created with older argon2 params (we raised the cost since):
+	// this is the only moment we have the plain password to upgrade it.
+	// failing here should not block the login, we'll try again next time.
+	if auth.NeedsRehash(user.PasswordHash) {
+		if err := s.rehashPassword(ctx, user.ID, password); err != nil {
+			log.Printf("could not rehash password for user %s: %v", user.ID, err)
+		}
 	}
 
-	refreshToken := auth.GenerateRefreshToken()
+	// password is only the first step, no tokens until the second factor is checked
+	if user.TOTPEnabled {
+		s.audit.Log(ctx, domain.AuditEvent{
+			Action:    domain.AuditLogin,
+			ActorID:   &user.ID,
+			ActorName: user.Username,
+			Outcome:   domain.AuditSuccess,
+			Detail:    "second factor required",
+		})
+		return s.startMFAChallenge(ctx, user)
+	}
+
+	s.auditAuth(ctx, domain.AuditLogin, &user.ID, user.Username, nil)
+
 	// Caching the user infos needed to generate another access token
-	err = cache.SetUserByRefreshToken(ctx, s.rdb, refreshToken, cache.UserCache{
+	return s.issueTokens(ctx, cache.UserCache{
 		UserId:   user.ID,
 		Username: user.Username,
 		Role:     user.Role,
 	})
-	if err != nil {
-		log.Printf("could not cache user: %v\n", err)
-	}
+}
 
-	return domain.UserResponse{
-		ID:           user.ID,
-		Username:     user.Username,
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken.String(),
-	}, nil
+func (s *UserService) GetUserById(ctx context.Context, userId uuid.UUID) (domain.User, error) {
+	return s.userRepo.FindUserById(ctx, userId)
+}
+
+// unknown names are left out
+func (s *UserService) FindUsersByNames(ctx context.Context, usernames []string) ([]domain.User, error) {
+	if len(usernames) == 0 {
+		return []domain.User{}, nil
+	}
+	return s.userRepo.FindUsersByNames(ctx, usernames)
 }
 
 func (s *UserService) Logout(ctx context.Context, refreshToken uuid.UUID) error {
-	err := cache.DelUserByRefreshTokenId(ctx, s.rdb, refreshToken)
+	err := s.sessions.DelUserByRefreshToken(ctx, refreshToken)
 	if err != nil {
 		return err
 	}
@@ -74,17 +110,81 @@ func (s *UserService) Logout(ctx context.Context, refreshToken uuid.UUID) error
 }
 
 func (s *UserService) Register(ctx context.Context, userRequestArgs domain.CreateUserRequest) (domain.User, error) {
-	user, err := s.userRepo.AddUser(ctx, userRequestArgs)
+	return s.register(ctx, userRequestArgs, domain.Regular)
+}
+
+// CreateUser is Register with a role, for the operators (moviectl user create).
+// the user is created with its role in one transaction: a failure never
+// leaves a regular user behind
+func (s *UserService) CreateUser(ctx context.Context, userRequestArgs domain.CreateUserRequest, role domain.Role) (domain.User, error) {
+	user, err := s.register(ctx, userRequestArgs, role)
+	if err != nil || role == domain.Regular {
+		return user, err
+	}
+
+	s.audit.Log(ctx, domain.AuditEvent{
+		Action:     domain.AuditUserRoleChange,
+		TargetType: "user",
+		TargetID:   user.ID.String(),
+		Outcome:    domain.AuditSuccess,
+		Detail:     fmt.Sprintf("role=%s", role),
+	})
+	return user, nil
+}
+
+func (s *UserService) register(ctx context.Context, userRequestArgs domain.CreateUserRequest, role domain.Role) (domain.User, error) {
+	if err := auth.ValidatePasswordStrength(userRequestArgs.Password, userRequestArgs.Username); err != nil {
+		s.auditAuth(ctx, domain.AuditRegister, nil, userRequestArgs.Username, err)
+		return domain.User{}, err
+	}
+
+	var user domain.User
+	var err error
+	if role == domain.Regular {
+		user, err = s.userRepo.AddUser(ctx, userRequestArgs)
+	} else {
+		user, err = s.userRepo.AddUserWithRole(ctx, userRequestArgs, role)
+	}
 	if err != nil {
+		s.auditAuth(ctx, domain.AuditRegister, nil, userRequestArgs.Username, err)
 		return domain.User{}, err
 	}
 
+	s.auditAuth(ctx, domain.AuditRegister, &user.ID, user.Username, nil)
 	return user, nil
 }
 
+// PurgeSessions logs out every user (empty username) or one user: their
+// refresh tokens stop working, the access tokens still live until they expire
+func (s *UserService) PurgeSessions(ctx context.Context, username string) (int, error) {
+	var userId *uuid.UUID
+	if username != "" {
+		user, err := s.userRepo.FindUserByName(ctx, username)
+		if err != nil {
+			return 0, err
+		}
+		userId = &user.ID
+	}
+
+	deleted, err := s.sessions.PurgeSessions(ctx, userId)
+
+	event := domain.AuditEvent{
+		Action:  domain.AuditSessionsPurge,
+		Outcome: auditOutcome(err),
+		Detail:  fmt.Sprintf("deleted=%d", deleted),
+	}
+	if userId != nil {
+		event.TargetType = "user"
+		event.TargetID = userId.String()
+	}
+	s.audit.Log(ctx, event)
+
+	return deleted, err
+}
+
 // the client sends this request: `POST /auth/refresh`
 func (s *UserService) RefreshToken(ctx context.Context, refreshTokenId uuid.UUID) (domain.UserResponse, error) {
-	user, err := cache.GetUserByRefreshToken(ctx, s.rdb, refreshTokenId)
+	user, err := s.sessions.GetUserByRefreshToken(ctx, refreshTokenId)
 	if err != nil {
 		return domain.UserResponse{}, err
 	}
@@ -92,17 +192,18 @@ func (s *UserService) RefreshToken(ctx context.Context, refreshTokenId uuid.UUID
 		return domain.UserResponse{}, errors.New("refresh token not found")
 	}
 
-	accessToken, err := auth.GenerateAccessToken(user.UserId, user.Role)
+	accessToken, err := generateAccessToken(*user)
 	if err != nil {
 		return domain.UserResponse{}, err
 	}
 
 	refreshToken := auth.GenerateRefreshToken()
 
-	cache.SetUserByRefreshToken(ctx, s.rdb, refreshToken, cache.UserCache{
+	s.sessions.SetUserByRefreshToken(ctx, refreshToken, cache.UserCache{
 		UserId:   user.UserId,
 		Username: user.Username,
 		Role:     user.Role,
+		MFA:      user.MFA,
 	})
 
 	response := domain.UserResponse{
@@ -112,10 +213,386 @@ func (s *UserService) RefreshToken(ctx context.Context, refreshTokenId uuid.UUID
 		RefreshToken: refreshToken.String(),
 	}
 
-	err = cache.DelUserByRefreshTokenId(ctx, s.rdb, refreshTokenId)
+	err = s.sessions.DelUserByRefreshToken(ctx, refreshTokenId)
 	if err != nil {
 		log.Printf("failed to delete user from cache: %v", err)
 	}
 
 	return response, nil
 }
+
+func (s *UserService) rehashPassword(ctx context.Context, userId uuid.UUID, password string) error {
+	hashedPassword, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	return s.userRepo.UpdatePasswordHash(ctx, userId, hashedPassword)
+}
+
+// access + refresh tokens for a user that is fully authenticated
+func (s *UserService) issueTokens(ctx context.Context, user cache.UserCache) (domain.UserResponse, error) {
+	accessToken, err := generateAccessToken(user)
+	if err != nil {
+		return domain.UserResponse{}, err
+	}
+
+	refreshToken := auth.GenerateRefreshToken()
+	err = s.sessions.SetUserByRefreshToken(ctx, refreshToken, user)
+	if err != nil {
+		log.Printf("could not cache user: %v\n", err)
+	}
+
+	return domain.UserResponse{
+		ID:           user.UserId,
+		Username:     user.Username,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken.String(),
+	}, nil
+}
+
+// auth events: the actor is the account being logged into / changed,
+// not the (often anonymous) caller
+func (s *UserService) auditAuth(ctx context.Context, action string, userId *uuid.UUID, username string, err error) {
+	event := domain.AuditEvent{
+		Action:    action,
+		ActorID:   userId,
+		ActorName: username,
+		Outcome:   auditOutcome(err),
+		Detail:    auditDetail(err),
+	}
+	if userId != nil {
+		event.TargetType = "user"
+		event.TargetID = userId.String()
+	}
+
+	s.audit.Log(ctx, event)
+}
+
+func generateAccessToken(user cache.UserCache) (string, error) {
+	if user.MFA {
+		return auth.GenerateMFAAccessToken(user.UserId, user.Role)
+	}
+
+	return auth.GenerateAccessToken(user.UserId, user.Role)
+}
+
+// ---------- two-factor authentication (TOTP)
+
+func (s *UserService) startMFAChallenge(ctx context.Context, user domain.User) (domain.UserResponse, error) {
+	// locked out: no new challenge (and so no new guesses) until it expires
+	attempts, err := s.sessions.GetMFAAttempts(ctx, user.ID)
+	if err != nil {
+		return domain.UserResponse{}, err
+	}
+	if attempts >= cache.MaxMFAAttempts {
+		s.auditAuth(ctx, domain.AuditLoginMFA, &user.ID, user.Username, domain.ErrMFALocked)
+		return domain.UserResponse{}, domain.ErrMFALocked
+	}
+
+	mfaToken, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return domain.UserResponse{}, err
+	}
+
+	err = s.sessions.SetMFAChallenge(ctx, auth.HashToken(mfaToken), cache.UserCache{
+		UserId:   user.ID,
+		Username: user.Username,
+		Role:     user.Role,
+	})
+	if err != nil {
+		// unlike the refresh token, without the challenge the user can't log in at all
+		return domain.UserResponse{}, err
+	}
+
+	return domain.UserResponse{
+		ID:          user.ID,
+		Username:    user.Username,
+		MFARequired: true,
+		MFAToken:    mfaToken,
+	}, nil
+}
+
+// `POST /auth/login/mfa`: second step of the login
+func (s *UserService) VerifyMFA(ctx context.Context, mfaToken, code string) (domain.UserResponse, error) {
+	tokenHash := auth.HashToken(mfaToken)
+
+	pending, err := s.sessions.GetMFAChallenge(ctx, tokenHash)
+	if err != nil {
+		return domain.UserResponse{}, err
+	}
+	if pending == nil {
+		return domain.UserResponse{}, domain.ErrInvalidMFAToken
+	}
+
+	// counted before the check, not after a failure: concurrent guesses on
+	// the same challenge would all pass a "failures so far" check
+	attempts, err := s.sessions.IncrementMFAChallengeAttempts(ctx, tokenHash)
+	if err != nil {
+		return domain.UserResponse{}, err
+	}
+	if attempts > cache.MaxMFAChallengeAttempts {
+		s.dropMFAChallenge(ctx, tokenHash)
+		return domain.UserResponse{}, domain.ErrInvalidMFAToken
+	}
+
+	// read the user again: 2FA could have been disabled/reset since the password step
+	user, err := s.userRepo.FindUserById(ctx, pending.UserId)
+	if err != nil {
+		return domain.UserResponse{}, err
+	}
+
+	ok, err := s.verifySecondFactor(ctx, user, code)
+	if errors.Is(err, domain.ErrMFALocked) {
+		s.dropMFAChallenge(ctx, tokenHash)
+		s.auditAuth(ctx, domain.AuditLoginMFA, &user.ID, user.Username, err)
+		return domain.UserResponse{}, err
+	}
+	if err != nil {
+		return domain.UserResponse{}, err
+	}
+	if !ok {
+		if attempts >= cache.MaxMFAChallengeAttempts {
+			// too many guesses: back to the password step
+			s.dropMFAChallenge(ctx, tokenHash)
+		}
+		s.auditAuth(ctx, domain.AuditLoginMFA, &user.ID, user.Username, domain.ErrInvalidMFACode)
+		return domain.UserResponse{}, domain.ErrInvalidMFACode
+	}
+
+	s.auditAuth(ctx, domain.AuditLoginMFA, &user.ID, user.Username, nil)
+
+	// the challenge is single-use
+	s.dropMFAChallenge(ctx, tokenHash)
+
+	pending.MFA = true
+	return s.issueTokens(ctx, *pending)
+}
+
+func (s *UserService) dropMFAChallenge(ctx context.Context, tokenHash string) {
+	if err := s.sessions.DelMFAChallenge(ctx, tokenHash); err != nil {
+		log.Printf("could not delete mfa challenge: %v", err)
+	}
+}
+
+// checkSecondFactor with the per-user limit (cache.MaxMFAAttempts): the
+// attempt is counted first, a good code resets the count
+func (s *UserService) verifySecondFactor(ctx context.Context, user domain.User, code string) (bool, error) {
+	attempts, err := s.sessions.IncrementMFAAttempts(ctx, user.ID)
+	if err != nil {
+		return false, err
+	}
+	if attempts > cache.MaxMFAAttempts {
+		return false, domain.ErrMFALocked
+	}
+
+	ok, err := s.checkSecondFactor(ctx, user, code)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	if err := s.sessions.ResetMFAAttempts(ctx, user.ID); err != nil {
+		log.Printf("could not reset mfa attempts of user %s: %v", user.ID, err)
+	}
+	return true, nil
+}
+
+// a code is either a 6 digits TOTP code or one of the recovery codes
+func (s *UserService) checkSecondFactor(ctx context.Context, user domain.User, code string) (bool, error) {
+	if !user.TOTPEnabled {
+		return false, domain.ErrMFANotEnrolled
+	}
+
+	if step, ok := auth.ValidateTOTP(user.TOTPSecret, code, time.Now()); ok {
+		return s.sessions.MarkTOTPStepUsed(ctx, user.ID, step)
+	}
+
+	return s.userRepo.UseRecoveryCode(ctx, user.ID, auth.HashToken(auth.NormalizeRecoveryCode(code)))
+}
+
+// `POST /auth/2fa/enroll`: step 1, the user scans the QR code (otpauth URI)
+// nothing changes for the login until the enrollment is confirmed with a code
+func (s *UserService) EnrollTOTP(ctx context.Context, userId uuid.UUID) (domain.TOTPEnrollment, error) {
+	user, err := s.userRepo.FindUserById(ctx, userId)
+	if err != nil {
+		return domain.TOTPEnrollment{}, err
+	}
+	if user.TOTPEnabled {
+		return domain.TOTPEnrollment{}, domain.ErrMFAAlreadyEnabled
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return domain.TOTPEnrollment{}, err
+	}
+
+	if err := s.userRepo.SetTOTPSecret(ctx, userId, secret); err != nil {
+		return domain.TOTPEnrollment{}, err
+	}
+
+	return domain.TOTPEnrollment{
+		Secret:     secret,
+		OTPAuthURI: auth.TOTPAuthURI(totpIssuer(), user.Username, secret),
+	}, nil
+}
+
+// `POST /auth/2fa/confirm`: step 2, proves the app is set up, turns 2FA on.
+// the recovery codes are only returned here, once.
+func (s *UserService) ConfirmTOTP(ctx context.Context, userId uuid.UUID, code string) ([]string, error) {
+	user, err := s.userRepo.FindUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, domain.ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, domain.ErrMFANotEnrolled
+	}
+
+	step, ok := auth.ValidateTOTP(user.TOTPSecret, code, time.Now())
+	if !ok {
+		s.auditAuth(ctx, domain.AuditTOTPEnable, &user.ID, user.Username, domain.ErrInvalidMFACode)
+		return nil, domain.ErrInvalidMFACode
+	}
+	if _, err := s.sessions.MarkTOTPStepUsed(ctx, user.ID, step); err != nil {
+		log.Printf("could not mark totp code as used: %v", err)
+	}
+
+	codes, err := auth.GenerateRecoveryCodes(10)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([]string, len(codes))
+	for idx, c := range codes {
+		hashes[idx] = auth.HashToken(auth.NormalizeRecoveryCode(c))
+	}
+
+	if err := s.userRepo.EnableTOTP(ctx, userId, hashes); err != nil {
+		return nil, err
+	}
+
+	s.auditAuth(ctx, domain.AuditTOTPEnable, &user.ID, user.Username, nil)
+	return codes, nil
+}
+
+// `POST /auth/2fa/disable`: a stolen access token alone should not be enough
+// to turn 2FA off, so we ask for a current code too
+func (s *UserService) DisableTOTP(ctx context.Context, userId uuid.UUID, code string) error {
+	user, err := s.userRepo.FindUserById(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	ok, err := s.verifySecondFactor(ctx, user, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		s.auditAuth(ctx, domain.AuditTOTPDisable, &user.ID, user.Username, domain.ErrInvalidMFACode)
+		return domain.ErrInvalidMFACode
+	}
+
+	if err := s.userRepo.DisableTOTP(ctx, userId); err != nil {
+		return err
+	}
+
+	s.auditAuth(ctx, domain.AuditTOTPDisable, &user.ID, user.Username, nil)
+	return nil
+}
+
+// the name shown in the authenticator app
+func totpIssuer() string {
+	issuer := os.Getenv("TOTP_ISSUER")
+	if issuer == "" {
+		// default value
+		issuer = "movie-api"
+	}
+
+	return issuer
+}
+
+// `POST /auth/password/forgot`
+// we never tell the caller whether the email exists (account enumeration),
+// unknown emails are a silent no-op.
+func (s *UserService) ForgotPassword(ctx context.Context, email string) error {
+	if email == "" {
+		return nil
+	}
+
+	user, err := s.userRepo.FindUserByEmail(ctx, email)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	// only the hash goes to Redis, the raw token only exists in the email
+	err = s.sessions.SetPasswordResetToken(ctx, auth.HashToken(token), user.ID)
+	if err != nil {
+		return err
+	}
+
+	s.auditAuth(ctx, domain.AuditPasswordResetStart, &user.ID, user.Username, nil)
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nUse the link below to choose a new password (valid for 30 minutes):\n%s\n\nIf you did not ask for this, ignore this email.",
+			user.Username, resetPasswordLink(token),
+		),
+	})
+}
+
+// `POST /auth/password/reset`
+func (s *UserService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if token == "" {
+		return domain.ErrInvalidResetToken
+	}
+	// checked before consuming: a weak password should not burn the token
+	if err := auth.ValidatePasswordStrength(newPassword, ""); err != nil {
+		return err
+	}
+
+	// consuming first: a token is burned even if the rest fails
+	userId, err := s.sessions.ConsumePasswordResetToken(ctx, auth.HashToken(token))
+	if err != nil {
+		return err
+	}
+	if userId == uuid.Nil {
+		s.auditAuth(ctx, domain.AuditPasswordReset, nil, "", domain.ErrInvalidResetToken)
+		return domain.ErrInvalidResetToken
+	}
+
+	if err := s.rehashPassword(ctx, userId, newPassword); err != nil {
+		return err
+	}
+
+	// whoever had the old password may still hold a session: the refresh
+	// tokens go, the access tokens still live until they expire
+	if _, err := s.sessions.PurgeSessions(ctx, &userId); err != nil {
+		log.Printf("could not revoke sessions of user %s: %v", userId, err)
+	}
+
+	s.auditAuth(ctx, domain.AuditPasswordReset, &userId, "", nil)
+	return nil
+}
+
+// PASSWORD_RESET_URL is the frontend page that reads the token and calls /auth/password/reset
+func resetPasswordLink(token string) string {
+	base := os.Getenv("PASSWORD_RESET_URL")
+	if base == "" {
+		// default value
+		base = "http://localhost:3000/reset-password"
+	}
+
+	return base + "?token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/cache"
+	"github.com/grainme/movie-api/internal/repository"
+)
+
+// how many movies are drained from the cache at a time
+const viewFlushBatch = 500
+
+// how long the last flush (on shutdown) can take
+const finalFlushTimeout = 10 * time.Second
+
+// ViewFlusher periodically moves the view counts from the cache to the DB
+// (write-behind): one UPDATE per movie and per flush instead of one per view.
+type ViewFlusher struct {
+	movieRepo  repository.MovieRepository
+	movieCache cache.MovieCache
+	lists      cache.ListCache
+	interval   time.Duration
+}
+
+func NewViewFlusher(repo repository.MovieRepository, movieCache cache.MovieCache, lists cache.ListCache, interval time.Duration) *ViewFlusher {
+	return &ViewFlusher{
+		movieRepo:  repo,
+		movieCache: movieCache,
+		lists:      lists,
+		interval:   interval,
+	}
+}
+
+// Run flushes every interval until ctx is cancelled, then flushes one last
+// time so the views counted since the last tick are not lost.
+func (f *ViewFlusher) Run(ctx context.Context) {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), finalFlushTimeout)
+			if _, err := f.Flush(flushCtx); err != nil {
+				log.Printf("last view count flush failed: %v", err)
+			}
+			cancel()
+			return
+		case <-ticker.C:
+			if _, err := f.Flush(ctx); err != nil {
+				log.Printf("view count flush failed: %v", err)
+			}
+		}
+	}
+}
+
+// Flush writes every pending view count to the DB and returns how many movies
+// were updated. the counts that could not be written go back to the cache.
+func (f *ViewFlusher) Flush(ctx context.Context) (int, error) {
+	flushed := 0
+	defer func() {
+		if flushed > 0 {
+			invalidateLists(ctx, f.lists, cache.ViewsTag)
+		}
+	}()
+
+	for {
+		counts, err := f.movieCache.DrainViewCounts(ctx, viewFlushBatch)
+		if err != nil {
+			return flushed, err
+		}
+
+		failed := map[uuid.UUID]int64{}
+		var flushErr error
+		for id, views := range counts {
+			if err := f.movieRepo.AddMovieViews(ctx, id, views); err != nil {
+				failed[id] = views
+				flushErr = err
+				continue
+			}
+			flushed++
+		}
+
+		if len(failed) > 0 {
+			// stop here: the DB is probably down, the next tick tries again
+			if err := f.movieCache.RestoreViewCounts(ctx, failed); err != nil {
+				log.Printf("lost the view counts of %d movies: %v", len(failed), err)
+			}
+			return flushed, fmt.Errorf("could not flush the views of %d movies: %w", len(failed), flushErr)
+		}
+
+		if len(counts) < viewFlushBatch {
+			return flushed, nil
+		}
+	}
+}
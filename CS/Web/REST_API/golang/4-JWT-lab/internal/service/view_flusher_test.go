@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/cache"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/repository"
+	"github.com/grainme/movie-api/internal/repository/memory"
+)
+
+// failingViews refuses the view counts while down is set
+type failingViews struct {
+	repository.MovieRepository
+	down bool
+}
+
+func (r *failingViews) AddMovieViews(ctx context.Context, id uuid.UUID, views int64) error {
+	if r.down {
+		return errors.New("db is down")
+	}
+	return r.MovieRepository.AddMovieViews(ctx, id, views)
+}
+
+func TestViewFlusher(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	repo := &failingViews{MovieRepository: memory.NewMemoryMovieRepository(store)}
+	movieCache := cache.NewMemoryMovieCache()
+	lists := cache.NewMemoryListCache()
+	movies := NewMovieService(repo, movieCache, lists, cache.NewMemoryTrendingStore(), nil)
+	flusher := NewViewFlusher(repo, movieCache, lists, time.Minute)
+
+	heat, _ := movies.AddMovie(ctx, &domain.Movie{Title: "Heat"})
+	ronin, _ := movies.AddMovie(ctx, &domain.Movie{Title: "Ronin"})
+	views := func(id uuid.UUID) int64 {
+		t.Helper()
+		movie, err := repo.GetMovieById(ctx, id)
+		if err != nil {
+			t.Fatalf("get movie: %v", err)
+		}
+		return movie.Views
+	}
+	view := func(id uuid.UUID, times int) {
+		t.Helper()
+		for range times {
+			if _, err := movies.GetMovieById(ctx, id); err != nil {
+				t.Fatalf("get movie: %v", err)
+			}
+		}
+	}
+
+	// the popular list is cached before the flush
+	if popular, _ := movies.GetPopularMovies(ctx, domain.Page{Limit: 10}); len(popular) != 2 {
+		t.Fatalf("popular = %d movies, want 2", len(popular))
+	}
+
+	view(heat.ID, 1)
+	view(ronin.ID, 3)
+	if flushed, err := flusher.Flush(ctx); err != nil || flushed != 2 {
+		t.Fatalf("flush = %d, %v, want 2", flushed, err)
+	}
+	if views(heat.ID) != 1 || views(ronin.ID) != 3 {
+		t.Fatalf("views = %d and %d, want 1 and 3", views(heat.ID), views(ronin.ID))
+	}
+	// and the ranking is recomputed
+	popular, err := movies.GetPopularMovies(ctx, domain.Page{Limit: 10})
+	if err != nil || len(popular) != 2 || popular[0].ID != ronin.ID {
+		t.Fatalf("popular after the flush = %+v, %v, want Ronin first", popular, err)
+	}
+	if flushed, err := flusher.Flush(ctx); err != nil || flushed != 0 {
+		t.Fatalf("flush of nothing = %d, %v", flushed, err)
+	}
+
+	// the DB is down: the counts go back to the cache, nothing is lost
+	view(heat.ID, 5)
+	repo.down = true
+	if _, err := flusher.Flush(ctx); err == nil {
+		t.Fatal("flush with the DB down succeeded")
+	}
+	view(heat.ID, 1)
+	repo.down = false
+	if flushed, err := flusher.Flush(ctx); err != nil || flushed != 1 {
+		t.Fatalf("flush after the outage = %d, %v, want 1", flushed, err)
+	}
+	if got := views(heat.ID); got != 7 {
+		t.Fatalf("views after the outage = %d, want 7", got)
+	}
+}
+
+func TestViewFlusherBatches(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	repo := memory.NewMemoryMovieRepository(store)
+	movieCache := cache.NewMemoryMovieCache()
+	flusher := NewViewFlusher(repo, movieCache, cache.NewMemoryListCache(), time.Minute)
+
+	// more than a batch: one Flush drains them all
+	for range viewFlushBatch + 1 {
+		movie, err := repo.AddMovie(ctx, &domain.Movie{Title: "Movie"})
+		if err != nil {
+			t.Fatalf("add movie: %v", err)
+		}
+		movieCache.IncrementViewCount(ctx, movie.ID)
+	}
+
+	if flushed, err := flusher.Flush(ctx); err != nil || flushed != viewFlushBatch+1 {
+		t.Fatalf("flush = %d, %v, want %d", flushed, err, viewFlushBatch+1)
+	}
+}
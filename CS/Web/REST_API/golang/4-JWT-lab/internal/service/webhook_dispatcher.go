@@ -0,0 +1,239 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/auth"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/repository"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// how many deliveries are claimed at a time
+	webhookDispatchBatch = 20
+	// how many are sent at once: a slow partner doesn't hold up the others
+	webhookConcurrency = 8
+	// how long a partner has to answer
+	webhookTimeout = 10 * time.Second
+	// a claimed delivery is retried after that if its dispatcher died
+	webhookLease = time.Minute
+)
+
+// WebhookRetryPolicy: attempt n failed, the next one is BaseDelay * 2^(n-1)
+// later (at most MaxDelay). after MaxAttempts failures the delivery is dead
+// (dead-lettered): it stays in the history until an admin redelivers it.
+type WebhookRetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// ~3 hours of retries
+var DefaultWebhookRetryPolicy = WebhookRetryPolicy{
+	MaxAttempts: 10,
+	BaseDelay:   30 * time.Second,
+	MaxDelay:    time.Hour,
+}
+
+func (p WebhookRetryPolicy) backoff(attempts int) time.Duration {
+	delay := p.BaseDelay
+	for range attempts - 1 {
+		if delay >= p.MaxDelay {
+			break
+		}
+		delay *= 2
+	}
+	return min(delay, p.MaxDelay)
+}
+
+// WebhookDispatcher periodically sends the due deliveries (the queue is the
+// webhook_deliveries table: the retries survive a restart). several replicas
+// can run one, a delivery is leased by the one sending it.
+type WebhookDispatcher struct {
+	webhookRepo repository.WebhookRepository
+	client      *http.Client
+	interval    time.Duration
+	policy      WebhookRetryPolicy
+}
+
+// client nil: a default client (webhookTimeout, no redirects)
+func NewWebhookDispatcher(repo repository.WebhookRepository, client *http.Client, interval time.Duration, policy WebhookRetryPolicy) *WebhookDispatcher {
+	if client == nil {
+		client = &http.Client{
+			Timeout: webhookTimeout,
+			// a redirect is an answer: the partner fixes its URL
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+
+	return &WebhookDispatcher{
+		webhookRepo: repo,
+		client:      client,
+		interval:    interval,
+		policy:      policy,
+	}
+}
+
+// Run dispatches every interval until ctx is cancelled. the deliveries in
+// flight then are retried after their lease.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := d.Dispatch(ctx); err != nil {
+				log.Printf("webhook dispatch failed: %v", err)
+			}
+		}
+	}
+}
+
+// Dispatch sends every due delivery once and returns how many succeeded
+func (d *WebhookDispatcher) Dispatch(ctx context.Context) (int, error) {
+	succeeded := 0
+	// the webhooks of this round (their url and secret)
+	webhooks := map[uuid.UUID]*domain.Webhook{}
+
+	for {
+		now := time.Now().UTC()
+		deliveries, err := d.webhookRepo.ClaimDueDeliveries(ctx, now, now.Add(webhookLease), webhookDispatchBatch)
+		if err != nil {
+			return succeeded, err
+		}
+
+		// the webhooks of the whole batch before any send: a failed read
+		// returns with nothing in flight (the claimed deliveries are retried
+		// after their lease)
+		targets := make([]*domain.Webhook, len(deliveries))
+		for idx, delivery := range deliveries {
+			targets[idx], err = d.webhook(ctx, webhooks, delivery.WebhookID)
+			if err != nil {
+				return succeeded, err
+			}
+		}
+
+		var mu sync.Mutex
+		group, groupCtx := errgroup.WithContext(ctx)
+		group.SetLimit(webhookConcurrency)
+		for idx, delivery := range deliveries {
+			webhook := targets[idx]
+			// deleted since: its deliveries are gone too
+			if webhook == nil {
+				continue
+			}
+
+			group.Go(func() error {
+				delivery = d.attempt(groupCtx, webhook, delivery)
+				if err := d.webhookRepo.UpdateDelivery(groupCtx, delivery); err != nil {
+					return fmt.Errorf("could not record the attempt of delivery %s: %w", delivery.ID, err)
+				}
+
+				if delivery.Status == domain.DeliverySucceeded {
+					mu.Lock()
+					succeeded++
+					mu.Unlock()
+				}
+				return nil
+			})
+		}
+		if err := group.Wait(); err != nil {
+			return succeeded, err
+		}
+
+		if len(deliveries) < webhookDispatchBatch {
+			return succeeded, nil
+		}
+	}
+}
+
+// the webhooks are only read once per round
+func (d *WebhookDispatcher) webhook(ctx context.Context, webhooks map[uuid.UUID]*domain.Webhook, id uuid.UUID) (*domain.Webhook, error) {
+	if webhook, ok := webhooks[id]; ok {
+		return webhook, nil
+	}
+
+	webhook, err := d.webhookRepo.GetWebhookById(ctx, id)
+	if err == domain.ErrWebhookNotFound {
+		webhooks[id] = nil
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks[id] = &webhook
+	return &webhook, nil
+}
+
+// attempt POSTs the delivery and returns it updated with the outcome
+func (d *WebhookDispatcher) attempt(ctx context.Context, webhook *domain.Webhook, delivery domain.WebhookDelivery) domain.WebhookDelivery {
+	attemptAt := time.Now().UTC()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &attemptAt
+	delivery.ResponseStatus = 0
+	delivery.LastError = ""
+
+	status, err := d.send(ctx, webhook, delivery, attemptAt)
+	delivery.ResponseStatus = status
+	if err == nil {
+		delivery.Status = domain.DeliverySucceeded
+		return delivery
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= d.policy.MaxAttempts {
+		delivery.Status = domain.DeliveryDead
+		log.Printf("webhook delivery %s to %s is dead after %d attempts: %v", delivery.ID, webhook.URL, delivery.Attempts, err)
+		return delivery
+	}
+
+	delivery.Status = domain.DeliveryPending
+	delivery.NextAttemptAt = attemptAt.Add(d.policy.backoff(delivery.Attempts))
+	return delivery
+}
+
+// a 2xx is a success, anything else (or no answer) a failure
+func (d *WebhookDispatcher) send(ctx context.Context, webhook *domain.Webhook, delivery domain.WebhookDelivery, attemptAt time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := attemptAt.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "movie-api-webhooks")
+	req.Header.Set("X-Webhook-Id", delivery.ID.String())
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Event-Id", delivery.EventID.String())
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", auth.SignWebhook(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// drained (a bit) so the connection is reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/repository"
+	"github.com/grainme/movie-api/internal/repository/memory"
+)
+
+// failingWebhooks can't read the broken webhook
+type failingWebhooks struct {
+	repository.WebhookRepository
+	broken uuid.UUID
+}
+
+func (r *failingWebhooks) GetWebhookById(ctx context.Context, id uuid.UUID) (domain.Webhook, error) {
+	if id == r.broken {
+		return domain.Webhook{}, errors.New("db is down")
+	}
+	return r.WebhookRepository.GetWebhookById(ctx, id)
+}
+
+// a webhook that can't be read fails the round before anything is sent:
+// nothing keeps sending once Dispatch has returned
+func TestWebhookDispatchFailsBeforeSending(t *testing.T) {
+	ctx := context.Background()
+
+	var sent atomic.Int32
+	partner := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent.Add(1)
+	}))
+	t.Cleanup(partner.Close)
+
+	repo := &failingWebhooks{WebhookRepository: memory.NewMemoryWebhookRepository(memory.NewStore())}
+	working, _ := repo.AddWebhook(ctx, domain.Webhook{URL: partner.URL, Events: []string{domain.EventReviewCreated}, Secret: "s3cret"})
+	broken, _ := repo.AddWebhook(ctx, domain.Webhook{URL: partner.URL, Events: []string{domain.EventReviewCreated}, Secret: "s3cret"})
+	repo.broken = broken.ID
+
+	// the working one is claimed first
+	first := domain.NewWebhookDelivery(working.ID, uuid.New(), domain.EventReviewCreated, json.RawMessage(`{}`))
+	first.NextAttemptAt = time.Now().UTC().Add(-time.Minute)
+	second := domain.NewWebhookDelivery(broken.ID, uuid.New(), domain.EventReviewCreated, json.RawMessage(`{}`))
+	if err := repo.AddDeliveries(ctx, []domain.WebhookDelivery{first, second}); err != nil {
+		t.Fatalf("add deliveries: %v", err)
+	}
+
+	dispatcher := NewWebhookDispatcher(repo, nil, time.Minute, DefaultWebhookRetryPolicy)
+	if _, err := dispatcher.Dispatch(ctx); err == nil {
+		t.Fatal("dispatch with an unreadable webhook: no error")
+	}
+
+	// give a stray send the time to show up
+	time.Sleep(100 * time.Millisecond)
+	if sent.Load() != 0 {
+		t.Fatalf("%d deliveries sent by a failed dispatch", sent.Load())
+	}
+	delivery, err := repo.GetDeliveryById(ctx, first.ID)
+	if err != nil || delivery.Attempts != 0 {
+		t.Fatalf("delivery after a failed dispatch = %+v, %v", delivery, err)
+	}
+}
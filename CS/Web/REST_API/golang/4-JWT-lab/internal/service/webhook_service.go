@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/grainme/movie-api/internal/auth"
+	"github.com/grainme/movie-api/internal/domain"
+	"github.com/grainme/movie-api/internal/repository"
+)
+
+// WebhookService manages the partners' subscriptions and their deliveries.
+// the deliveries are queued by the repositories, with the outbox row of their
+// event (domain.WebhookDeliveries); the WebhookDispatcher sends them.
+type WebhookService struct {
+	webhookRepo repository.WebhookRepository
+	audit       *AuditLogger
+}
+
+func NewWebhookService(repo repository.WebhookRepository, audit *AuditLogger) *WebhookService {
+	return &WebhookService{
+		webhookRepo: repo,
+		audit:       audit,
+	}
+}
+
+func (s *WebhookService) CreateWebhook(ctx context.Context, creatorId uuid.UUID, request domain.CreateWebhookRequest) (domain.CreatedWebhook, error) {
+	if err := validateWebhookURL(request.URL); err != nil {
+		return domain.CreatedWebhook{}, err
+	}
+	if len(request.Events) == 0 {
+		return domain.CreatedWebhook{}, fmt.Errorf("%w: events is required", domain.ErrInvalidWebhook)
+	}
+	for _, event := range request.Events {
+		if !slices.Contains(domain.WebhookEvents, event) {
+			return domain.CreatedWebhook{}, fmt.Errorf("%w: unknown event %q", domain.ErrInvalidWebhook, event)
+		}
+	}
+
+	secret, err := auth.GenerateWebhookSecret()
+	if err != nil {
+		return domain.CreatedWebhook{}, err
+	}
+
+	webhook, err := s.webhookRepo.AddWebhook(ctx, domain.Webhook{
+		URL:       request.URL,
+		Events:    slices.Compact(slices.Sorted(slices.Values(request.Events))),
+		Secret:    secret,
+		CreatedBy: &creatorId,
+	})
+	if err != nil {
+		return domain.CreatedWebhook{}, err
+	}
+
+	s.audit.Log(ctx, domain.AuditEvent{
+		Action:     domain.AuditWebhookCreate,
+		TargetType: "webhook",
+		TargetID:   webhook.ID.String(),
+		Outcome:    domain.AuditSuccess,
+		Detail:     fmt.Sprintf("url=%q events=%v", webhook.URL, webhook.Events),
+	})
+
+	return domain.CreatedWebhook{
+		Webhook: webhook,
+		Secret:  secret,
+	}, nil
+}
+
+// admins only: the target is any URL they want, no SSRF filtering here
+func validateWebhookURL(rawURL string) error {
+	if len(rawURL) > 2048 {
+		return fmt.Errorf("%w: url is too long", domain.ErrInvalidWebhook)
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) URL", domain.ErrInvalidWebhook)
+	}
+	return nil
+}
+
+func (s *WebhookService) ListWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	return s.webhookRepo.ListWebhooks(ctx)
+}
+
+// its pending deliveries are dropped with it
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	err := s.webhookRepo.DeleteWebhook(ctx, id)
+	s.audit.Log(ctx, domain.AuditEvent{
+		Action:     domain.AuditWebhookDelete,
+		TargetType: "webhook",
+		TargetID:   id.String(),
+		Outcome:    auditOutcome(err),
+		Detail:     auditDetail(err),
+	})
+
+	return err
+}
+
+// the delivery history of a webhook, newest first
+func (s *WebhookService) ListDeliveries(ctx context.Context, webhookId uuid.UUID, page domain.Page) ([]domain.WebhookDelivery, error) {
+	if _, err := s.webhookRepo.GetWebhookById(ctx, webhookId); err != nil {
+		return nil, err
+	}
+
+	return s.webhookRepo.ListDeliveries(ctx, webhookId, page)
+}
+
+// Redeliver queues the event of a delivery again, as a new delivery (the old
+// one stays in the history as it was). any delivery can be redelivered, the
+// dead ones are the point.
+func (s *WebhookService) Redeliver(ctx context.Context, webhookId, deliveryId uuid.UUID) (domain.WebhookDelivery, error) {
+	delivery, err := s.webhookRepo.GetDeliveryById(ctx, deliveryId)
+	if err == nil && delivery.WebhookID != webhookId {
+		err = domain.ErrDeliveryNotFound
+	}
+
+	var redelivery domain.WebhookDelivery
+	if err == nil {
+		redelivery = domain.NewWebhookDelivery(delivery.WebhookID, delivery.EventID, delivery.EventType, delivery.Payload)
+		err = s.webhookRepo.AddDeliveries(ctx, []domain.WebhookDelivery{redelivery})
+	}
+
+	s.audit.Log(ctx, domain.AuditEvent{
+		Action:     domain.AuditWebhookRedeliver,
+		TargetType: "webhook_delivery",
+		TargetID:   deliveryId.String(),
+		Outcome:    auditOutcome(err),
+		Detail:     auditDetail(err),
+	})
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+
+	return s.webhookRepo.GetDeliveryById(ctx, redelivery.ID)
+}
@@ -0,0 +1,7 @@
+// Package moviev1 is the gRPC API (internal/grpcserver): the messages and
+// services generated from the .proto files of this directory.
+//
+// regenerate after changing them (needs buf, protoc-gen-go and protoc-gen-go-grpc)
+package moviev1
+
+//go:generate sh -c "cd ../.. && buf generate"
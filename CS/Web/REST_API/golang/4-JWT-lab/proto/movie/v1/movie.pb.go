@@ -0,0 +1,786 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v6.33.0
+// source: movie/v1/movie.proto
+
+package moviev1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// the aggregates and views are only set where the REST API returns them
+type Movie struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Director      string                 `protobuf:"bytes,3,opt,name=director,proto3" json:"director,omitempty"`
+	Year          int32                  `protobuf:"varint,4,opt,name=year,proto3" json:"year,omitempty"`
+	AverageRating float64                `protobuf:"fixed64,5,opt,name=average_rating,json=averageRating,proto3" json:"average_rating,omitempty"`
+	ReviewsCount  int64                  `protobuf:"varint,6,opt,name=reviews_count,json=reviewsCount,proto3" json:"reviews_count,omitempty"`
+	Views         int64                  `protobuf:"varint,7,opt,name=views,proto3" json:"views,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Movie) Reset() {
+	*x = Movie{}
+	mi := &file_movie_v1_movie_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Movie) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Movie) ProtoMessage() {}
+
+func (x *Movie) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_v1_movie_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Movie.ProtoReflect.Descriptor instead.
+func (*Movie) Descriptor() ([]byte, []int) {
+	return file_movie_v1_movie_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Movie) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Movie) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Movie) GetDirector() string {
+	if x != nil {
+		return x.Director
+	}
+	return ""
+}
+
+func (x *Movie) GetYear() int32 {
+	if x != nil {
+		return x.Year
+	}
+	return 0
+}
+
+func (x *Movie) GetAverageRating() float64 {
+	if x != nil {
+		return x.AverageRating
+	}
+	return 0
+}
+
+func (x *Movie) GetReviewsCount() int64 {
+	if x != nil {
+		return x.ReviewsCount
+	}
+	return 0
+}
+
+func (x *Movie) GetViews() int64 {
+	if x != nil {
+		return x.Views
+	}
+	return 0
+}
+
+// limit 0 means the whole list (or the method's default), at most 100
+type Page struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Limit         int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Page) Reset() {
+	*x = Page{}
+	mi := &file_movie_v1_movie_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Page) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Page) ProtoMessage() {}
+
+func (x *Page) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_v1_movie_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Page.ProtoReflect.Descriptor instead.
+func (*Page) Descriptor() ([]byte, []int) {
+	return file_movie_v1_movie_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Page) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *Page) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type ListMoviesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Page          *Page                  `protobuf:"bytes,1,opt,name=page,proto3" json:"page,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListMoviesRequest) Reset() {
+	*x = ListMoviesRequest{}
+	mi := &file_movie_v1_movie_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListMoviesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMoviesRequest) ProtoMessage() {}
+
+func (x *ListMoviesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_v1_movie_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMoviesRequest.ProtoReflect.Descriptor instead.
+func (*ListMoviesRequest) Descriptor() ([]byte, []int) {
+	return file_movie_v1_movie_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListMoviesRequest) GetPage() *Page {
+	if x != nil {
+		return x.Page
+	}
+	return nil
+}
+
+type ListMoviesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Movies        []*Movie               `protobuf:"bytes,1,rep,name=movies,proto3" json:"movies,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListMoviesResponse) Reset() {
+	*x = ListMoviesResponse{}
+	mi := &file_movie_v1_movie_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListMoviesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMoviesResponse) ProtoMessage() {}
+
+func (x *ListMoviesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_v1_movie_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMoviesResponse.ProtoReflect.Descriptor instead.
+func (*ListMoviesResponse) Descriptor() ([]byte, []int) {
+	return file_movie_v1_movie_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListMoviesResponse) GetMovies() []*Movie {
+	if x != nil {
+		return x.Movies
+	}
+	return nil
+}
+
+// the top 10 when the page has no limit
+type ListPopularMoviesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Page          *Page                  `protobuf:"bytes,1,opt,name=page,proto3" json:"page,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPopularMoviesRequest) Reset() {
+	*x = ListPopularMoviesRequest{}
+	mi := &file_movie_v1_movie_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPopularMoviesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPopularMoviesRequest) ProtoMessage() {}
+
+func (x *ListPopularMoviesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_v1_movie_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPopularMoviesRequest.ProtoReflect.Descriptor instead.
+func (*ListPopularMoviesRequest) Descriptor() ([]byte, []int) {
+	return file_movie_v1_movie_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListPopularMoviesRequest) GetPage() *Page {
+	if x != nil {
+		return x.Page
+	}
+	return nil
+}
+
+type ListTrendingMoviesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 1h, 24h (default) or 7d
+	Window        string `protobuf:"bytes,1,opt,name=window,proto3" json:"window,omitempty"`
+	Page          *Page  `protobuf:"bytes,2,opt,name=page,proto3" json:"page,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTrendingMoviesRequest) Reset() {
+	*x = ListTrendingMoviesRequest{}
+	mi := &file_movie_v1_movie_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTrendingMoviesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTrendingMoviesRequest) ProtoMessage() {}
+
+func (x *ListTrendingMoviesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_v1_movie_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTrendingMoviesRequest.ProtoReflect.Descriptor instead.
+func (*ListTrendingMoviesRequest) Descriptor() ([]byte, []int) {
+	return file_movie_v1_movie_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListTrendingMoviesRequest) GetWindow() string {
+	if x != nil {
+		return x.Window
+	}
+	return ""
+}
+
+func (x *ListTrendingMoviesRequest) GetPage() *Page {
+	if x != nil {
+		return x.Page
+	}
+	return nil
+}
+
+type TrendingMovie struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Movie         *Movie                 `protobuf:"bytes,1,opt,name=movie,proto3" json:"movie,omitempty"`
+	Score         float64                `protobuf:"fixed64,2,opt,name=score,proto3" json:"score,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TrendingMovie) Reset() {
+	*x = TrendingMovie{}
+	mi := &file_movie_v1_movie_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrendingMovie) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrendingMovie) ProtoMessage() {}
+
+func (x *TrendingMovie) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_v1_movie_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrendingMovie.ProtoReflect.Descriptor instead.
+func (*TrendingMovie) Descriptor() ([]byte, []int) {
+	return file_movie_v1_movie_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *TrendingMovie) GetMovie() *Movie {
+	if x != nil {
+		return x.Movie
+	}
+	return nil
+}
+
+func (x *TrendingMovie) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+type ListTrendingMoviesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Movies        []*TrendingMovie       `protobuf:"bytes,1,rep,name=movies,proto3" json:"movies,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTrendingMoviesResponse) Reset() {
+	*x = ListTrendingMoviesResponse{}
+	mi := &file_movie_v1_movie_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTrendingMoviesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTrendingMoviesResponse) ProtoMessage() {}
+
+func (x *ListTrendingMoviesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_v1_movie_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTrendingMoviesResponse.ProtoReflect.Descriptor instead.
+func (*ListTrendingMoviesResponse) Descriptor() ([]byte, []int) {
+	return file_movie_v1_movie_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListTrendingMoviesResponse) GetMovies() []*TrendingMovie {
+	if x != nil {
+		return x.Movies
+	}
+	return nil
+}
+
+type GetMovieRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMovieRequest) Reset() {
+	*x = GetMovieRequest{}
+	mi := &file_movie_v1_movie_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMovieRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMovieRequest) ProtoMessage() {}
+
+func (x *GetMovieRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_v1_movie_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMovieRequest.ProtoReflect.Descriptor instead.
+func (*GetMovieRequest) Descriptor() ([]byte, []int) {
+	return file_movie_v1_movie_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetMovieRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type CreateMovieRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 1 to 40 characters
+	Title         string `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	Director      string `protobuf:"bytes,2,opt,name=director,proto3" json:"director,omitempty"`
+	Year          int32  `protobuf:"varint,3,opt,name=year,proto3" json:"year,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateMovieRequest) Reset() {
+	*x = CreateMovieRequest{}
+	mi := &file_movie_v1_movie_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateMovieRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateMovieRequest) ProtoMessage() {}
+
+func (x *CreateMovieRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_v1_movie_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateMovieRequest.ProtoReflect.Descriptor instead.
+func (*CreateMovieRequest) Descriptor() ([]byte, []int) {
+	return file_movie_v1_movie_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *CreateMovieRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *CreateMovieRequest) GetDirector() string {
+	if x != nil {
+		return x.Director
+	}
+	return ""
+}
+
+func (x *CreateMovieRequest) GetYear() int32 {
+	if x != nil {
+		return x.Year
+	}
+	return 0
+}
+
+type UpdateMovieTitleRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateMovieTitleRequest) Reset() {
+	*x = UpdateMovieTitleRequest{}
+	mi := &file_movie_v1_movie_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateMovieTitleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateMovieTitleRequest) ProtoMessage() {}
+
+func (x *UpdateMovieTitleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_v1_movie_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateMovieTitleRequest.ProtoReflect.Descriptor instead.
+func (*UpdateMovieTitleRequest) Descriptor() ([]byte, []int) {
+	return file_movie_v1_movie_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *UpdateMovieTitleRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateMovieTitleRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+type DeleteMovieRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteMovieRequest) Reset() {
+	*x = DeleteMovieRequest{}
+	mi := &file_movie_v1_movie_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteMovieRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteMovieRequest) ProtoMessage() {}
+
+func (x *DeleteMovieRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_v1_movie_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteMovieRequest.ProtoReflect.Descriptor instead.
+func (*DeleteMovieRequest) Descriptor() ([]byte, []int) {
+	return file_movie_v1_movie_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *DeleteMovieRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+var File_movie_v1_movie_proto protoreflect.FileDescriptor
+
+const file_movie_v1_movie_proto_rawDesc = "" +
+	"\n" +
+	"\x14movie/v1/movie.proto\x12\bmovie.v1\x1a\x1bgoogle/protobuf/empty.proto\"\xbf\x01\n" +
+	"\x05Movie\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x1a\n" +
+	"\bdirector\x18\x03 \x01(\tR\bdirector\x12\x12\n" +
+	"\x04year\x18\x04 \x01(\x05R\x04year\x12%\n" +
+	"\x0eaverage_rating\x18\x05 \x01(\x01R\raverageRating\x12#\n" +
+	"\rreviews_count\x18\x06 \x01(\x03R\freviewsCount\x12\x14\n" +
+	"\x05views\x18\a \x01(\x03R\x05views\"4\n" +
+	"\x04Page\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x05R\x06offset\"7\n" +
+	"\x11ListMoviesRequest\x12\"\n" +
+	"\x04page\x18\x01 \x01(\v2\x0e.movie.v1.PageR\x04page\"=\n" +
+	"\x12ListMoviesResponse\x12'\n" +
+	"\x06movies\x18\x01 \x03(\v2\x0f.movie.v1.MovieR\x06movies\">\n" +
+	"\x18ListPopularMoviesRequest\x12\"\n" +
+	"\x04page\x18\x01 \x01(\v2\x0e.movie.v1.PageR\x04page\"W\n" +
+	"\x19ListTrendingMoviesRequest\x12\x16\n" +
+	"\x06window\x18\x01 \x01(\tR\x06window\x12\"\n" +
+	"\x04page\x18\x02 \x01(\v2\x0e.movie.v1.PageR\x04page\"L\n" +
+	"\rTrendingMovie\x12%\n" +
+	"\x05movie\x18\x01 \x01(\v2\x0f.movie.v1.MovieR\x05movie\x12\x14\n" +
+	"\x05score\x18\x02 \x01(\x01R\x05score\"M\n" +
+	"\x1aListTrendingMoviesResponse\x12/\n" +
+	"\x06movies\x18\x01 \x03(\v2\x17.movie.v1.TrendingMovieR\x06movies\"!\n" +
+	"\x0fGetMovieRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"Z\n" +
+	"\x12CreateMovieRequest\x12\x14\n" +
+	"\x05title\x18\x01 \x01(\tR\x05title\x12\x1a\n" +
+	"\bdirector\x18\x02 \x01(\tR\bdirector\x12\x12\n" +
+	"\x04year\x18\x03 \x01(\x05R\x04year\"?\n" +
+	"\x17UpdateMovieTitleRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\"$\n" +
+	"\x12DeleteMovieRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id2\xdc\x04\n" +
+	"\fMovieService\x12G\n" +
+	"\n" +
+	"ListMovies\x12\x1b.movie.v1.ListMoviesRequest\x1a\x1c.movie.v1.ListMoviesResponse\x12U\n" +
+	"\x11ListPopularMovies\x12\".movie.v1.ListPopularMoviesRequest\x1a\x1c.movie.v1.ListMoviesResponse\x12_\n" +
+	"\x12ListTrendingMovies\x12#.movie.v1.ListTrendingMoviesRequest\x1a$.movie.v1.ListTrendingMoviesResponse\x126\n" +
+	"\bGetMovie\x12\x19.movie.v1.GetMovieRequest\x1a\x0f.movie.v1.Movie\x12A\n" +
+	"\x13GetMovieWithReviews\x12\x19.movie.v1.GetMovieRequest\x1a\x0f.movie.v1.Movie\x12<\n" +
+	"\vCreateMovie\x12\x1c.movie.v1.CreateMovieRequest\x1a\x0f.movie.v1.Movie\x12M\n" +
+	"\x10UpdateMovieTitle\x12!.movie.v1.UpdateMovieTitleRequest\x1a\x16.google.protobuf.Empty\x12C\n" +
+	"\vDeleteMovie\x12\x1c.movie.v1.DeleteMovieRequest\x1a\x16.google.protobuf.EmptyB5Z3github.com/grainme/movie-api/proto/movie/v1;moviev1b\x06proto3"
+
+var (
+	file_movie_v1_movie_proto_rawDescOnce sync.Once
+	file_movie_v1_movie_proto_rawDescData []byte
+)
+
+func file_movie_v1_movie_proto_rawDescGZIP() []byte {
+	file_movie_v1_movie_proto_rawDescOnce.Do(func() {
+		file_movie_v1_movie_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_movie_v1_movie_proto_rawDesc), len(file_movie_v1_movie_proto_rawDesc)))
+	})
+	return file_movie_v1_movie_proto_rawDescData
+}
+
+var file_movie_v1_movie_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_movie_v1_movie_proto_goTypes = []any{
+	(*Movie)(nil),                      // 0: movie.v1.Movie
+	(*Page)(nil),                       // 1: movie.v1.Page
+	(*ListMoviesRequest)(nil),          // 2: movie.v1.ListMoviesRequest
+	(*ListMoviesResponse)(nil),         // 3: movie.v1.ListMoviesResponse
+	(*ListPopularMoviesRequest)(nil),   // 4: movie.v1.ListPopularMoviesRequest
+	(*ListTrendingMoviesRequest)(nil),  // 5: movie.v1.ListTrendingMoviesRequest
+	(*TrendingMovie)(nil),              // 6: movie.v1.TrendingMovie
+	(*ListTrendingMoviesResponse)(nil), // 7: movie.v1.ListTrendingMoviesResponse
+	(*GetMovieRequest)(nil),            // 8: movie.v1.GetMovieRequest
+	(*CreateMovieRequest)(nil),         // 9: movie.v1.CreateMovieRequest
+	(*UpdateMovieTitleRequest)(nil),    // 10: movie.v1.UpdateMovieTitleRequest
+	(*DeleteMovieRequest)(nil),         // 11: movie.v1.DeleteMovieRequest
+	(*emptypb.Empty)(nil),              // 12: google.protobuf.Empty
+}
+var file_movie_v1_movie_proto_depIdxs = []int32{
+	1,  // 0: movie.v1.ListMoviesRequest.page:type_name -> movie.v1.Page
+	0,  // 1: movie.v1.ListMoviesResponse.movies:type_name -> movie.v1.Movie
+	1,  // 2: movie.v1.ListPopularMoviesRequest.page:type_name -> movie.v1.Page
+	1,  // 3: movie.v1.ListTrendingMoviesRequest.page:type_name -> movie.v1.Page
+	0,  // 4: movie.v1.TrendingMovie.movie:type_name -> movie.v1.Movie
+	6,  // 5: movie.v1.ListTrendingMoviesResponse.movies:type_name -> movie.v1.TrendingMovie
+	2,  // 6: movie.v1.MovieService.ListMovies:input_type -> movie.v1.ListMoviesRequest
+	4,  // 7: movie.v1.MovieService.ListPopularMovies:input_type -> movie.v1.ListPopularMoviesRequest
+	5,  // 8: movie.v1.MovieService.ListTrendingMovies:input_type -> movie.v1.ListTrendingMoviesRequest
+	8,  // 9: movie.v1.MovieService.GetMovie:input_type -> movie.v1.GetMovieRequest
+	8,  // 10: movie.v1.MovieService.GetMovieWithReviews:input_type -> movie.v1.GetMovieRequest
+	9,  // 11: movie.v1.MovieService.CreateMovie:input_type -> movie.v1.CreateMovieRequest
+	10, // 12: movie.v1.MovieService.UpdateMovieTitle:input_type -> movie.v1.UpdateMovieTitleRequest
+	11, // 13: movie.v1.MovieService.DeleteMovie:input_type -> movie.v1.DeleteMovieRequest
+	3,  // 14: movie.v1.MovieService.ListMovies:output_type -> movie.v1.ListMoviesResponse
+	3,  // 15: movie.v1.MovieService.ListPopularMovies:output_type -> movie.v1.ListMoviesResponse
+	7,  // 16: movie.v1.MovieService.ListTrendingMovies:output_type -> movie.v1.ListTrendingMoviesResponse
+	0,  // 17: movie.v1.MovieService.GetMovie:output_type -> movie.v1.Movie
+	0,  // 18: movie.v1.MovieService.GetMovieWithReviews:output_type -> movie.v1.Movie
+	0,  // 19: movie.v1.MovieService.CreateMovie:output_type -> movie.v1.Movie
+	12, // 20: movie.v1.MovieService.UpdateMovieTitle:output_type -> google.protobuf.Empty
+	12, // 21: movie.v1.MovieService.DeleteMovie:output_type -> google.protobuf.Empty
+	14, // [14:22] is the sub-list for method output_type
+	6,  // [6:14] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_movie_v1_movie_proto_init() }
+func file_movie_v1_movie_proto_init() {
+	if File_movie_v1_movie_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_movie_v1_movie_proto_rawDesc), len(file_movie_v1_movie_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_movie_v1_movie_proto_goTypes,
+		DependencyIndexes: file_movie_v1_movie_proto_depIdxs,
+		MessageInfos:      file_movie_v1_movie_proto_msgTypes,
+	}.Build()
+	File_movie_v1_movie_proto = out.File
+	file_movie_v1_movie_proto_goTypes = nil
+	file_movie_v1_movie_proto_depIdxs = nil
+}
@@ -0,0 +1,435 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v6.33.0
+// source: movie/v1/review.proto
+
+package moviev1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Review struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Username      string                 `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	Rating        int32                  `protobuf:"varint,3,opt,name=rating,proto3" json:"rating,omitempty"`
+	Comment       *string                `protobuf:"bytes,4,opt,name=comment,proto3,oneof" json:"comment,omitempty"`
+	MovieId       string                 `protobuf:"bytes,5,opt,name=movie_id,json=movieId,proto3" json:"movie_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Review) Reset() {
+	*x = Review{}
+	mi := &file_movie_v1_review_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Review) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Review) ProtoMessage() {}
+
+func (x *Review) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_v1_review_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Review.ProtoReflect.Descriptor instead.
+func (*Review) Descriptor() ([]byte, []int) {
+	return file_movie_v1_review_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Review) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Review) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *Review) GetRating() int32 {
+	if x != nil {
+		return x.Rating
+	}
+	return 0
+}
+
+func (x *Review) GetComment() string {
+	if x != nil && x.Comment != nil {
+		return *x.Comment
+	}
+	return ""
+}
+
+func (x *Review) GetMovieId() string {
+	if x != nil {
+		return x.MovieId
+	}
+	return ""
+}
+
+type ListReviewsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListReviewsRequest) Reset() {
+	*x = ListReviewsRequest{}
+	mi := &file_movie_v1_review_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListReviewsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListReviewsRequest) ProtoMessage() {}
+
+func (x *ListReviewsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_v1_review_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListReviewsRequest.ProtoReflect.Descriptor instead.
+func (*ListReviewsRequest) Descriptor() ([]byte, []int) {
+	return file_movie_v1_review_proto_rawDescGZIP(), []int{1}
+}
+
+type ListReviewsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Reviews       []*Review              `protobuf:"bytes,1,rep,name=reviews,proto3" json:"reviews,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListReviewsResponse) Reset() {
+	*x = ListReviewsResponse{}
+	mi := &file_movie_v1_review_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListReviewsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListReviewsResponse) ProtoMessage() {}
+
+func (x *ListReviewsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_v1_review_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListReviewsResponse.ProtoReflect.Descriptor instead.
+func (*ListReviewsResponse) Descriptor() ([]byte, []int) {
+	return file_movie_v1_review_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListReviewsResponse) GetReviews() []*Review {
+	if x != nil {
+		return x.Reviews
+	}
+	return nil
+}
+
+type ListMovieReviewsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MovieId       string                 `protobuf:"bytes,1,opt,name=movie_id,json=movieId,proto3" json:"movie_id,omitempty"`
+	Page          *Page                  `protobuf:"bytes,2,opt,name=page,proto3" json:"page,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListMovieReviewsRequest) Reset() {
+	*x = ListMovieReviewsRequest{}
+	mi := &file_movie_v1_review_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListMovieReviewsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMovieReviewsRequest) ProtoMessage() {}
+
+func (x *ListMovieReviewsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_v1_review_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMovieReviewsRequest.ProtoReflect.Descriptor instead.
+func (*ListMovieReviewsRequest) Descriptor() ([]byte, []int) {
+	return file_movie_v1_review_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListMovieReviewsRequest) GetMovieId() string {
+	if x != nil {
+		return x.MovieId
+	}
+	return ""
+}
+
+func (x *ListMovieReviewsRequest) GetPage() *Page {
+	if x != nil {
+		return x.Page
+	}
+	return nil
+}
+
+type CreateReviewRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 1 to 10
+	Rating        int32   `protobuf:"varint,2,opt,name=rating,proto3" json:"rating,omitempty"`
+	Comment       *string `protobuf:"bytes,3,opt,name=comment,proto3,oneof" json:"comment,omitempty"`
+	MovieId       string  `protobuf:"bytes,4,opt,name=movie_id,json=movieId,proto3" json:"movie_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateReviewRequest) Reset() {
+	*x = CreateReviewRequest{}
+	mi := &file_movie_v1_review_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateReviewRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateReviewRequest) ProtoMessage() {}
+
+func (x *CreateReviewRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_v1_review_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateReviewRequest.ProtoReflect.Descriptor instead.
+func (*CreateReviewRequest) Descriptor() ([]byte, []int) {
+	return file_movie_v1_review_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CreateReviewRequest) GetRating() int32 {
+	if x != nil {
+		return x.Rating
+	}
+	return 0
+}
+
+func (x *CreateReviewRequest) GetComment() string {
+	if x != nil && x.Comment != nil {
+		return *x.Comment
+	}
+	return ""
+}
+
+func (x *CreateReviewRequest) GetMovieId() string {
+	if x != nil {
+		return x.MovieId
+	}
+	return ""
+}
+
+type WatchMovieReviewsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MovieId       string                 `protobuf:"bytes,1,opt,name=movie_id,json=movieId,proto3" json:"movie_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchMovieReviewsRequest) Reset() {
+	*x = WatchMovieReviewsRequest{}
+	mi := &file_movie_v1_review_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchMovieReviewsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchMovieReviewsRequest) ProtoMessage() {}
+
+func (x *WatchMovieReviewsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_v1_review_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchMovieReviewsRequest.ProtoReflect.Descriptor instead.
+func (*WatchMovieReviewsRequest) Descriptor() ([]byte, []int) {
+	return file_movie_v1_review_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *WatchMovieReviewsRequest) GetMovieId() string {
+	if x != nil {
+		return x.MovieId
+	}
+	return ""
+}
+
+var File_movie_v1_review_proto protoreflect.FileDescriptor
+
+const file_movie_v1_review_proto_rawDesc = "" +
+	"\n" +
+	"\x15movie/v1/review.proto\x12\bmovie.v1\x1a\x14movie/v1/movie.proto\"\x92\x01\n" +
+	"\x06Review\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\busername\x18\x02 \x01(\tR\busername\x12\x16\n" +
+	"\x06rating\x18\x03 \x01(\x05R\x06rating\x12\x1d\n" +
+	"\acomment\x18\x04 \x01(\tH\x00R\acomment\x88\x01\x01\x12\x19\n" +
+	"\bmovie_id\x18\x05 \x01(\tR\amovieIdB\n" +
+	"\n" +
+	"\b_comment\"\x14\n" +
+	"\x12ListReviewsRequest\"A\n" +
+	"\x13ListReviewsResponse\x12*\n" +
+	"\areviews\x18\x01 \x03(\v2\x10.movie.v1.ReviewR\areviews\"X\n" +
+	"\x17ListMovieReviewsRequest\x12\x19\n" +
+	"\bmovie_id\x18\x01 \x01(\tR\amovieId\x12\"\n" +
+	"\x04page\x18\x02 \x01(\v2\x0e.movie.v1.PageR\x04page\"\x83\x01\n" +
+	"\x13CreateReviewRequest\x12\x16\n" +
+	"\x06rating\x18\x02 \x01(\x05R\x06rating\x12\x1d\n" +
+	"\acomment\x18\x03 \x01(\tH\x00R\acomment\x88\x01\x01\x12\x19\n" +
+	"\bmovie_id\x18\x04 \x01(\tR\amovieIdB\n" +
+	"\n" +
+	"\b_commentJ\x04\b\x01\x10\x02R\busername\"5\n" +
+	"\x18WatchMovieReviewsRequest\x12\x19\n" +
+	"\bmovie_id\x18\x01 \x01(\tR\amovieId2\xbf\x02\n" +
+	"\rReviewService\x12J\n" +
+	"\vListReviews\x12\x1c.movie.v1.ListReviewsRequest\x1a\x1d.movie.v1.ListReviewsResponse\x12T\n" +
+	"\x10ListMovieReviews\x12!.movie.v1.ListMovieReviewsRequest\x1a\x1d.movie.v1.ListReviewsResponse\x12?\n" +
+	"\fCreateReview\x12\x1d.movie.v1.CreateReviewRequest\x1a\x10.movie.v1.Review\x12K\n" +
+	"\x11WatchMovieReviews\x12\".movie.v1.WatchMovieReviewsRequest\x1a\x10.movie.v1.Review0\x01B5Z3github.com/grainme/movie-api/proto/movie/v1;moviev1b\x06proto3"
+
+var (
+	file_movie_v1_review_proto_rawDescOnce sync.Once
+	file_movie_v1_review_proto_rawDescData []byte
+)
+
+func file_movie_v1_review_proto_rawDescGZIP() []byte {
+	file_movie_v1_review_proto_rawDescOnce.Do(func() {
+		file_movie_v1_review_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_movie_v1_review_proto_rawDesc), len(file_movie_v1_review_proto_rawDesc)))
+	})
+	return file_movie_v1_review_proto_rawDescData
+}
+
+var file_movie_v1_review_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_movie_v1_review_proto_goTypes = []any{
+	(*Review)(nil),                   // 0: movie.v1.Review
+	(*ListReviewsRequest)(nil),       // 1: movie.v1.ListReviewsRequest
+	(*ListReviewsResponse)(nil),      // 2: movie.v1.ListReviewsResponse
+	(*ListMovieReviewsRequest)(nil),  // 3: movie.v1.ListMovieReviewsRequest
+	(*CreateReviewRequest)(nil),      // 4: movie.v1.CreateReviewRequest
+	(*WatchMovieReviewsRequest)(nil), // 5: movie.v1.WatchMovieReviewsRequest
+	(*Page)(nil),                     // 6: movie.v1.Page
+}
+var file_movie_v1_review_proto_depIdxs = []int32{
+	0, // 0: movie.v1.ListReviewsResponse.reviews:type_name -> movie.v1.Review
+	6, // 1: movie.v1.ListMovieReviewsRequest.page:type_name -> movie.v1.Page
+	1, // 2: movie.v1.ReviewService.ListReviews:input_type -> movie.v1.ListReviewsRequest
+	3, // 3: movie.v1.ReviewService.ListMovieReviews:input_type -> movie.v1.ListMovieReviewsRequest
+	4, // 4: movie.v1.ReviewService.CreateReview:input_type -> movie.v1.CreateReviewRequest
+	5, // 5: movie.v1.ReviewService.WatchMovieReviews:input_type -> movie.v1.WatchMovieReviewsRequest
+	2, // 6: movie.v1.ReviewService.ListReviews:output_type -> movie.v1.ListReviewsResponse
+	2, // 7: movie.v1.ReviewService.ListMovieReviews:output_type -> movie.v1.ListReviewsResponse
+	0, // 8: movie.v1.ReviewService.CreateReview:output_type -> movie.v1.Review
+	0, // 9: movie.v1.ReviewService.WatchMovieReviews:output_type -> movie.v1.Review
+	6, // [6:10] is the sub-list for method output_type
+	2, // [2:6] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_movie_v1_review_proto_init() }
+func file_movie_v1_review_proto_init() {
+	if File_movie_v1_review_proto != nil {
+		return
+	}
+	file_movie_v1_movie_proto_init()
+	file_movie_v1_review_proto_msgTypes[0].OneofWrappers = []any{}
+	file_movie_v1_review_proto_msgTypes[4].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_movie_v1_review_proto_rawDesc), len(file_movie_v1_review_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_movie_v1_review_proto_goTypes,
+		DependencyIndexes: file_movie_v1_review_proto_depIdxs,
+		MessageInfos:      file_movie_v1_review_proto_msgTypes,
+	}.Build()
+	File_movie_v1_review_proto = out.File
+	file_movie_v1_review_proto_goTypes = nil
+	file_movie_v1_review_proto_depIdxs = nil
+}
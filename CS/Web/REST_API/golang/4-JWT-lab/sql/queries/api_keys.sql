@@ -0,0 +1,38 @@
+-- name: AddApiKey :one
+INSERT INTO
+  api_keys (id, user_id, name, prefix, key_hash, scopes, expires_at)
+VALUES
+  ($1, $2, $3, $4, $5, $6, $7) RETURNING *;
+
+-- name: FindApiKeyByHash :one
+SELECT
+  k.*,
+  u.role AS owner_role
+FROM
+  api_keys k
+  JOIN users u ON u.id = k.user_id
+WHERE
+  k.key_hash = $1;
+
+-- name: ListApiKeys :many
+SELECT
+  *
+FROM
+  api_keys
+ORDER BY
+  created_at DESC;
+
+-- name: RevokeApiKey :execrows
+UPDATE api_keys
+SET
+  revoked_at = NOW()
+WHERE
+  id = $1
+  AND revoked_at IS NULL;
+
+-- name: TouchApiKey :exec
+UPDATE api_keys
+SET
+  last_used_at = $2
+WHERE
+  id = $1;
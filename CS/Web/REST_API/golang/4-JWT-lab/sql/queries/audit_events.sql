@@ -0,0 +1,60 @@
+-- name: AddAuditEvent :exec
+INSERT INTO
+  audit_events (
+    id,
+    occurred_at,
+    actor_id,
+    actor_name,
+    api_key_id,
+    action,
+    target_type,
+    target_id,
+    ip,
+    user_agent,
+    outcome,
+    detail
+  )
+VALUES
+  ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12);
+
+-- name: ListAuditEvents :many
+SELECT
+  *
+FROM
+  audit_events
+WHERE
+  (
+    sqlc.narg(actor_id)::uuid IS NULL
+    OR actor_id = sqlc.narg(actor_id)
+  )
+  AND (
+    sqlc.narg(action)::text IS NULL
+    OR action = sqlc.narg(action)
+  )
+  AND (
+    sqlc.narg(outcome)::text IS NULL
+    OR outcome = sqlc.narg(outcome)
+  )
+  AND (
+    sqlc.narg(target_type)::text IS NULL
+    OR target_type = sqlc.narg(target_type)
+  )
+  AND (
+    sqlc.narg(target_id)::text IS NULL
+    OR target_id = sqlc.narg(target_id)
+  )
+  AND (
+    sqlc.narg(since)::timestamp IS NULL
+    OR occurred_at >= sqlc.narg(since)
+  )
+  AND (
+    sqlc.narg(until)::timestamp IS NULL
+    OR occurred_at < sqlc.narg(until)
+  )
+ORDER BY
+  occurred_at DESC,
+  id DESC
+LIMIT
+  sqlc.arg(page_size)::int
+OFFSET
+  sqlc.arg(page_offset)::int;
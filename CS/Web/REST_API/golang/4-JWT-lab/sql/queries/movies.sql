@@ -1,8 +1,17 @@
 -- name: GetMovies :many
+-- case-insensitive, ASCII only and bytewise ("C"): the order of the SQLite
+-- and the in-memory backends, whatever the collation of the database
 SELECT
   *
 FROM
-  movies;
+  movies
+ORDER BY
+  lower(title COLLATE "C"),
+  id
+LIMIT
+  sqlc.narg(page_size)::int
+OFFSET
+  sqlc.arg(page_offset)::int;
 
 -- name: GetMovieById :one
 SELECT
@@ -18,14 +27,15 @@ INSERT INTO
 VALUES
   ($1, $2, $3, $4) RETURNING *;
 
--- name: UpdateMovieTitleById :exec
+-- name: UpdateMovieTitleById :one
+-- the movie as updated: the data of its movie.updated event
 UPDATE movies
 SET
   title = $2
 WHERE
-  id = $1;
+  id = $1 RETURNING *;
 
--- name: DeleteMovieById :exec
+-- name: DeleteMovieById :execrows
 DELETE FROM movies
 WHERE
   id = $1;
@@ -33,7 +43,7 @@ WHERE
 -- name: GetMovieWithReviews :one
 SELECT
   m.*,
-  AVG(r.rating) as avg_rating,
+  COALESCE(AVG(r.rating), 0)::float8 as avg_rating,
   COUNT(r.*) as reviews_count
 FROM
   movies m
@@ -42,3 +52,60 @@ WHERE
   m.id = $1
 GROUP BY
   m.id;
+
+-- name: AddMovieViews :exec
+UPDATE movies
+SET
+  views = views + sqlc.arg(views)::bigint
+WHERE
+  id = sqlc.arg(id)::uuid;
+
+-- name: GetPopularMovies :many
+SELECT
+  *
+FROM
+  movies
+ORDER BY
+  views DESC,
+  id
+LIMIT
+  sqlc.narg(page_size)::int
+OFFSET
+  sqlc.arg(page_offset)::int;
+
+-- name: GetMoviesAfter :many
+SELECT
+  *
+FROM
+  movies
+WHERE
+  id > sqlc.arg(after)::uuid
+ORDER BY
+  id
+LIMIT
+  sqlc.arg(page_size)::int;
+
+-- name: GetMoviesWithReviewsByIds :many
+SELECT
+  m.*,
+  COALESCE(AVG(r.rating), 0)::float8 as avg_rating,
+  COUNT(r.*) as reviews_count
+FROM
+  movies m
+  LEFT JOIN reviews r ON m.id = r.movie_id
+WHERE
+  m.id = ANY(sqlc.arg(ids)::text[]::uuid[])
+GROUP BY
+  m.id
+ORDER BY
+  m.id;
+
+-- name: GetExistingMovieIds :many
+SELECT
+  id
+FROM
+  movies
+WHERE
+  id = ANY(sqlc.arg(ids)::text[]::uuid[])
+ORDER BY
+  id;
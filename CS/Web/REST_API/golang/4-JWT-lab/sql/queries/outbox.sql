@@ -0,0 +1,61 @@
+-- name: AddOutboxEvent :exec
+INSERT INTO
+  outbox (
+    id,
+    event_type,
+    aggregate_type,
+    aggregate_id,
+    payload,
+    occurred_at
+  )
+VALUES
+  ($1, $2, $3, $4, $5, $6);
+
+-- name: GetUnpublishedOutboxEvents :many
+-- every unpublished row, not the ones after a cursor: a transaction can commit
+-- after one with a higher sequence, its events are picked up on the next read
+SELECT
+  *
+FROM
+  outbox
+WHERE
+  published_at IS NULL
+ORDER BY
+  sequence
+LIMIT
+  sqlc.arg(page_size)::int;
+
+-- name: MarkOutboxEventPublished :exec
+UPDATE outbox
+SET
+  published_at = NOW()
+WHERE
+  id = $1;
+
+-- name: MarkOutboxEventFailed :exec
+UPDATE outbox
+SET
+  attempts = attempts + 1,
+  last_error = $2
+WHERE
+  id = $1;
+
+-- name: DeletePublishedOutboxEvents :execrows
+DELETE FROM outbox
+WHERE
+  published_at < $1;
+
+-- name: DeleteUnpublishedOutboxEvents :execrows
+DELETE FROM outbox
+WHERE
+  published_at IS NULL
+  AND occurred_at < $1;
+
+-- name: TryLockOutboxRelay :one
+-- one relay at a time: a session lock, held by one connection
+SELECT
+  pg_try_advisory_lock(sqlc.arg(lock_key)::bigint);
+
+-- name: UnlockOutboxRelay :exec
+SELECT
+  pg_advisory_unlock(sqlc.arg(lock_key)::bigint);
@@ -0,0 +1,19 @@
+-- name: AddRecoveryCode :exec
+INSERT INTO
+  user_recovery_codes (id, user_id, code_hash)
+VALUES
+  ($1, $2, $3);
+
+-- name: DeleteRecoveryCodesByUserId :exec
+DELETE FROM user_recovery_codes
+WHERE
+  user_id = $1;
+
+-- name: UseRecoveryCode :execrows
+UPDATE user_recovery_codes
+SET
+  used_at = NOW()
+WHERE
+  user_id = $1
+  AND code_hash = $2
+  AND used_at IS NULL;
@@ -16,4 +16,96 @@ SELECT
 FROM
   reviews
 WHERE
-  movie_id = $1;
+  movie_id = sqlc.arg(movie_id)::uuid
+ORDER BY
+  id
+LIMIT
+  sqlc.narg(page_size)::int
+OFFSET
+  sqlc.arg(page_offset)::int;
+
+-- name: GetReviewsAfter :many
+SELECT
+  *
+FROM
+  reviews
+WHERE
+  id > sqlc.arg(after)::uuid
+ORDER BY
+  id
+LIMIT
+  sqlc.arg(page_size)::int;
+
+-- name: GetReviewsByMovieIds :many
+SELECT
+  id,
+  user_name,
+  rating,
+  comment,
+  movie_id
+FROM
+  (
+    SELECT
+      *,
+      ROW_NUMBER() OVER (
+        PARTITION BY
+          movie_id
+        ORDER BY
+          id
+      ) AS position
+    FROM
+      reviews
+    WHERE
+      movie_id = ANY(sqlc.arg(movie_ids)::text[]::uuid[])
+  ) ranked
+WHERE
+  position > sqlc.arg(page_offset)::int
+  AND (
+    sqlc.narg(page_size)::int IS NULL
+    OR position <= sqlc.arg(page_offset)::int + sqlc.narg(page_size)::int
+  )
+ORDER BY
+  movie_id,
+  id;
+
+-- name: GetReviewsByUsernames :many
+SELECT
+  id,
+  user_name,
+  rating,
+  comment,
+  movie_id
+FROM
+  (
+    SELECT
+      *,
+      ROW_NUMBER() OVER (
+        PARTITION BY
+          user_name
+        ORDER BY
+          id
+      ) AS position
+    FROM
+      reviews
+    WHERE
+      user_name = ANY(sqlc.arg(usernames)::text[])
+  ) ranked
+WHERE
+  position > sqlc.arg(page_offset)::int
+  AND (
+    sqlc.narg(page_size)::int IS NULL
+    OR position <= sqlc.arg(page_offset)::int + sqlc.narg(page_size)::int
+  )
+ORDER BY
+  user_name,
+  id;
+
+-- name: GetExistingReviewIds :many
+SELECT
+  id
+FROM
+  reviews
+WHERE
+  id = ANY(sqlc.arg(ids)::text[]::uuid[])
+ORDER BY
+  id;
@@ -0,0 +1,15 @@
+-- name: FindUserByIdentity :one
+SELECT
+  u.*
+FROM
+  users u
+  JOIN user_identities i ON i.user_id = u.id
+WHERE
+  i.provider = $1
+  AND i.subject = $2;
+
+-- name: AddUserIdentity :exec
+INSERT INTO
+  user_identities (provider, subject, user_id, email)
+VALUES
+  ($1, $2, $3, $4);
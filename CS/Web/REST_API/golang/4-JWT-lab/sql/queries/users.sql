@@ -1,8 +1,8 @@
 -- name: AddUser :one
 INSERT INTO
-  users (id, username, password_hash)
+  users (id, username, password_hash, email)
 VALUES
-  ($1, $2, $3) RETURNING *;
+  ($1, $2, $3, $4) RETURNING *;
 
 -- name: FindUserByName :one
 SELECT
@@ -11,3 +11,71 @@ FROM
   users
 WHERE
   username = $1;
+
+-- name: FindUsersByNames :many
+SELECT
+  *
+FROM
+  users
+WHERE
+  username = ANY(sqlc.arg(usernames)::text[])
+ORDER BY
+  username;
+
+-- name: FindUserByEmail :one
+SELECT
+  *
+FROM
+  users
+WHERE
+  email = $1;
+
+-- name: UpdateUserPasswordHash :exec
+UPDATE users
+SET
+  password_hash = $2,
+  updated_at = NOW()
+WHERE
+  id = $1;
+
+-- name: FindUserById :one
+SELECT
+  *
+FROM
+  users
+WHERE
+  id = $1;
+
+-- name: SetUserTotpSecret :exec
+UPDATE users
+SET
+  totp_secret = $2,
+  totp_enabled = FALSE,
+  updated_at = NOW()
+WHERE
+  id = $1;
+
+-- name: EnableUserTotp :exec
+UPDATE users
+SET
+  totp_enabled = TRUE,
+  updated_at = NOW()
+WHERE
+  id = $1;
+
+-- name: DisableUserTotp :exec
+UPDATE users
+SET
+  totp_secret = NULL,
+  totp_enabled = FALSE,
+  updated_at = NOW()
+WHERE
+  id = $1;
+
+-- name: UpdateUserRole :exec
+UPDATE users
+SET
+  role = $2,
+  updated_at = NOW()
+WHERE
+  id = $1;
@@ -0,0 +1,109 @@
+-- name: AddWebhook :one
+INSERT INTO
+  webhooks (id, url, events, secret, created_by)
+VALUES
+  ($1, $2, $3, $4, $5) RETURNING *;
+
+-- name: ListWebhooks :many
+SELECT
+  *
+FROM
+  webhooks
+ORDER BY
+  created_at DESC,
+  id;
+
+-- name: GetWebhookById :one
+SELECT
+  *
+FROM
+  webhooks
+WHERE
+  id = $1;
+
+-- name: FindWebhooksByEvent :many
+SELECT
+  *
+FROM
+  webhooks
+WHERE
+  sqlc.arg(event_type)::text = ANY (events)
+ORDER BY
+  created_at,
+  id;
+
+-- name: DeleteWebhook :execrows
+DELETE FROM webhooks
+WHERE
+  id = $1;
+
+-- name: AddWebhookDelivery :exec
+INSERT INTO
+  webhook_deliveries (
+    id,
+    webhook_id,
+    event_id,
+    event_type,
+    payload,
+    next_attempt_at
+  )
+VALUES
+  ($1, $2, $3, $4, $5, $6);
+
+-- name: ClaimDueWebhookDeliveries :many
+-- the due deliveries are leased (next_attempt_at = lease_until): another
+-- dispatcher skips them until then, a crashed one's are retried after it
+UPDATE webhook_deliveries
+SET
+  next_attempt_at = sqlc.arg(lease_until)
+WHERE
+  id IN (
+    SELECT
+      id
+    FROM
+      webhook_deliveries
+    WHERE
+      status = 'pending'
+      AND next_attempt_at <= sqlc.arg(now)
+    ORDER BY
+      next_attempt_at
+    LIMIT
+      sqlc.arg(page_size)::int
+    FOR UPDATE
+      SKIP LOCKED
+  ) RETURNING *;
+
+-- name: UpdateWebhookDelivery :exec
+UPDATE webhook_deliveries
+SET
+  status = $2,
+  attempts = $3,
+  next_attempt_at = $4,
+  last_attempt_at = $5,
+  response_status = $6,
+  last_error = $7
+WHERE
+  id = $1;
+
+-- name: ListWebhookDeliveries :many
+SELECT
+  *
+FROM
+  webhook_deliveries
+WHERE
+  webhook_id = sqlc.arg(webhook_id)
+ORDER BY
+  created_at DESC,
+  id DESC
+LIMIT
+  sqlc.narg(page_size)::int
+OFFSET
+  sqlc.arg(page_offset)::int;
+
+-- name: GetWebhookDeliveryById :one
+SELECT
+  *
+FROM
+  webhook_deliveries
+WHERE
+  id = $1;